@@ -0,0 +1,269 @@
+package claim
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AttestationEvent reports a witness attestation recorded with
+// RecordAttestation
+type AttestationEvent struct {
+	WitnessID string
+	Domain    string
+
+	// Time is when the attestation was recorded, by the store's clock
+	Time time.Time
+}
+
+// OnAttestation registers fn to be called for every attestation recorded
+// with RecordAttestation. It is called synchronously, without the store
+// locked. The returned function unregisters fn.
+func (rs *ReputationStore) OnAttestation(fn func(AttestationEvent)) (unsubscribe func()) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.attestations == nil {
+		rs.attestations = make(map[int]func(AttestationEvent))
+	}
+	id := rs.nextListener
+	rs.nextListener++
+	rs.attestations[id] = fn
+
+	return func() {
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+		delete(rs.attestations, id)
+	}
+}
+
+// notifyAttestation calls the attestation listeners. Callers must not hold
+// rs.mu.
+func (rs *ReputationStore) notifyAttestation(event AttestationEvent) {
+	rs.mu.RLock()
+	listeners := make([]func(AttestationEvent), 0, len(rs.attestations))
+	for _, fn := range rs.attestations {
+		listeners = append(listeners, fn)
+	}
+	rs.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// AlertKind classifies an anomaly
+type AlertKind string
+
+const (
+	// AlertVolumeSpike means a witness signed far more than usual
+	AlertVolumeSpike AlertKind = "volume-spike"
+
+	// AlertDomainShift means a witness signed mostly in domains it rarely
+	// attests to
+	AlertDomainShift AlertKind = "domain-shift"
+)
+
+// Alert reports a witness whose recent signing deviates from its baseline,
+// which may mean its key is being abused
+type Alert struct {
+	WitnessID string
+	Kind      AlertKind
+
+	// Recent is the number of attestations in the recent window
+	Recent int
+
+	// Expected is the witness's baseline number of attestations per window
+	Expected float64
+
+	// Domains lists the unfamiliar domains, for a domain shift
+	Domains []string
+
+	Detail string
+}
+
+// AnomalyConfig tunes a WitnessAnomalyDetector. Zero fields take the
+// defaults noted.
+type AnomalyConfig struct {
+	// Window is the span of recent behavior judged against the baseline
+	// (default 1 hour)
+	Window time.Duration
+
+	// Baseline is how much history, before the recent window, forms a
+	// witness's baseline (default 7 days). Witnesses seen for less than
+	// one window before it are not judged.
+	Baseline time.Duration
+
+	// MinEvents is the fewest recent attestations that can raise an alert
+	// (default 10)
+	MinEvents int
+
+	// VolumeFactor is how many times its baseline rate a witness must sign
+	// at to raise a volume spike (default 5)
+	VolumeFactor float64
+
+	// UnfamiliarShare is the share of a witness's baseline below which a
+	// domain counts as unfamiliar (default 0.05)
+	UnfamiliarShare float64
+
+	// DomainShift is the share of recent attestations in unfamiliar
+	// domains that raises a domain shift (default 0.5)
+	DomainShift float64
+}
+
+// WitnessAnomalyDetector watches a reputation store's attestations and
+// flags witnesses whose recent signing volume or domains deviate from
+// their own baseline
+type WitnessAnomalyDetector struct {
+	rs          *ReputationStore
+	cfg         AnomalyConfig
+	unsubscribe func()
+
+	mu     sync.Mutex
+	events map[string][]AttestationEvent // WitnessID -> events, oldest first
+}
+
+// NewWitnessAnomalyDetector starts watching the store's attestations.
+// Close stops it.
+func NewWitnessAnomalyDetector(rs *ReputationStore, cfg AnomalyConfig) *WitnessAnomalyDetector {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Hour
+	}
+	if cfg.Baseline <= 0 {
+		cfg.Baseline = 7 * 24 * time.Hour
+	}
+	if cfg.MinEvents <= 0 {
+		cfg.MinEvents = 10
+	}
+	if cfg.VolumeFactor <= 0 {
+		cfg.VolumeFactor = 5
+	}
+	if cfg.UnfamiliarShare <= 0 {
+		cfg.UnfamiliarShare = 0.05
+	}
+	if cfg.DomainShift <= 0 {
+		cfg.DomainShift = 0.5
+	}
+
+	d := &WitnessAnomalyDetector{
+		rs:     rs,
+		cfg:    cfg,
+		events: make(map[string][]AttestationEvent),
+	}
+	d.unsubscribe = rs.OnAttestation(d.record)
+	return d
+}
+
+// Close stops watching the store
+func (d *WitnessAnomalyDetector) Close() {
+	d.unsubscribe()
+}
+
+// record adds an attestation to its witness's history, dropping events too
+// old to matter
+func (d *WitnessAnomalyDetector) record(event AttestationEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	events := append(d.events[event.WitnessID], event)
+	horizon := event.Time.Add(-d.cfg.Window - d.cfg.Baseline)
+	i := 0
+	for i < len(events) && events[i].Time.Before(horizon) {
+		i++
+	}
+	d.events[event.WitnessID] = events[i:]
+}
+
+// Anomalies returns an alert for each witness, and each kind of deviation,
+// whose last window of attestations departs from its baseline, ordered by
+// witness. Time is read from the reputation store's clock.
+func (d *WitnessAnomalyDetector) Anomalies() []Alert {
+	now := d.rs.currentTime()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	witnesses := make([]string, 0, len(d.events))
+	for id := range d.events {
+		witnesses = append(witnesses, id)
+	}
+	sort.Strings(witnesses)
+
+	var alerts []Alert
+	for _, id := range witnesses {
+		alerts = append(alerts, d.check(id, d.events[id], now)...)
+	}
+	return alerts
+}
+
+// check compares a witness's recent window with its baseline
+func (d *WitnessAnomalyDetector) check(witnessID string, events []AttestationEvent, now time.Time) []Alert {
+	recentStart := now.Add(-d.cfg.Window)
+	baselineStart := recentStart.Add(-d.cfg.Baseline)
+
+	var recent []AttestationEvent
+	baseline := make(map[string]int)
+	baselineTotal := 0
+	var first time.Time
+	for _, e := range events {
+		switch {
+		case e.Time.After(now) || e.Time.Before(baselineStart):
+			continue
+		case e.Time.After(recentStart):
+			recent = append(recent, e)
+		default:
+			baseline[e.Domain]++
+			baselineTotal++
+		}
+		if first.IsZero() {
+			first = e.Time
+		}
+	}
+
+	// A witness needs some history before anything is unusual for it
+	span := recentStart.Sub(first)
+	if baselineTotal == 0 || span < d.cfg.Window || len(recent) < d.cfg.MinEvents {
+		return nil
+	}
+	if span > d.cfg.Baseline {
+		span = d.cfg.Baseline
+	}
+	expected := float64(baselineTotal) * float64(d.cfg.Window) / float64(span)
+
+	var alerts []Alert
+	if float64(len(recent)) >= d.cfg.VolumeFactor*expected {
+		alerts = append(alerts, Alert{
+			WitnessID: witnessID,
+			Kind:      AlertVolumeSpike,
+			Recent:    len(recent),
+			Expected:  expected,
+			Detail:    fmt.Sprintf("%d attestations in %s, expected %.1f", len(recent), d.cfg.Window, expected),
+		})
+	}
+
+	unfamiliar := make(map[string]bool)
+	outside := 0
+	for _, e := range recent {
+		if float64(baseline[e.Domain]) < d.cfg.UnfamiliarShare*float64(baselineTotal) {
+			unfamiliar[e.Domain] = true
+			outside++
+		}
+	}
+	if float64(outside) >= d.cfg.DomainShift*float64(len(recent)) {
+		domains := make([]string, 0, len(unfamiliar))
+		for domain := range unfamiliar {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		alerts = append(alerts, Alert{
+			WitnessID: witnessID,
+			Kind:      AlertDomainShift,
+			Recent:    len(recent),
+			Expected:  expected,
+			Domains:   domains,
+			Detail:    fmt.Sprintf("%d of %d attestations in unfamiliar domains", outside, len(recent)),
+		})
+	}
+	return alerts
+}
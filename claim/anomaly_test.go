@@ -0,0 +1,73 @@
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWitnessAnomalyDetector(t *testing.T) {
+	rs := NewReputationStore()
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	rs.SetClock(FixedClock(start))
+
+	d := NewWitnessAnomalyDetector(rs, AnomalyConfig{})
+	defer d.Close()
+
+	// A day of normal behavior: four sports attestations an hour each
+	var now time.Time
+	for hour := 0; hour < 24; hour++ {
+		for i := 0; i < 4; i++ {
+			now = start.Add(time.Duration(hour)*time.Hour + time.Duration(i)*10*time.Minute)
+			rs.SetClock(FixedClock(now))
+			for _, id := range []string{"steady", "spiker", "shifter"} {
+				rs.RecordAttestation(id, "sports")
+			}
+		}
+	}
+
+	rs.SetClock(FixedClock(start.Add(24 * time.Hour)))
+	assert.Empty(t, d.Anomalies(), "baseline behavior is not anomalous")
+
+	// The next hour: steady carries on, spiker signs 40 claims and shifter
+	// signs a normal number of claims in a domain it has never touched
+	for i := 0; i < 40; i++ {
+		now = start.Add(24*time.Hour + time.Duration(i)*time.Minute)
+		rs.SetClock(FixedClock(now))
+		if i%10 == 0 {
+			rs.RecordAttestation("steady", "sports")
+		}
+		rs.RecordAttestation("spiker", "sports")
+		if i < 12 {
+			rs.RecordAttestation("shifter", "finance")
+		}
+	}
+	rs.RecordAttestation("newcomer", "finance")
+
+	alerts := d.Anomalies()
+	require.Len(t, alerts, 2)
+
+	assert.Equal(t, "shifter", alerts[0].WitnessID)
+	assert.Equal(t, AlertDomainShift, alerts[0].Kind)
+	assert.Equal(t, []string{"finance"}, alerts[0].Domains)
+
+	assert.Equal(t, "spiker", alerts[1].WitnessID)
+	assert.Equal(t, AlertVolumeSpike, alerts[1].Kind)
+	assert.Equal(t, 40, alerts[1].Recent)
+	assert.InDelta(t, 4, alerts[1].Expected, 0.1)
+
+	t.Run("alerts clear once the window passes", func(t *testing.T) {
+		rs.SetClock(FixedClock(now.Add(2 * time.Hour)))
+		assert.Empty(t, d.Anomalies())
+	})
+
+	t.Run("closed detector stops recording", func(t *testing.T) {
+		d.Close()
+		rs.RecordAttestation("late", "sports")
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		assert.NotContains(t, d.events, "late")
+	})
+}
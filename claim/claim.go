@@ -5,7 +5,10 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"runtime"
 	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -21,18 +24,63 @@ type Claim struct {
 	// Statement is the claim being made
 	Statement Statement
 
+	// Namespace is the application the claim belongs to, so applications
+	// sharing a store keep distinct claims for the same statement (empty
+	// for the default namespace)
+	Namespace string
+
+	// Quantity is an optional numeric object with a unit
+	Quantity *Quantity
+
+	// Objects is an optional object with several values, used in place
+	// of Statement.Object
+	Objects *ObjectList
+
+	// Fields holds optional structured parts of the claim (e.g. "price")
+	// that witnesses can attest to individually
+	Fields map[string]string
+
 	// Evidence is a list of CIDs pointing to supporting data
 	Evidence []string
 
+	// EvidenceOrdering declares whether evidence order is part of the
+	// claim's identity (default: EvidenceSet, order-insensitive)
+	EvidenceOrdering EvidenceOrdering
+
+	// ExternalSignatures are signatures third parties made over their own
+	// payloads, carried as evidence (see VerifyExternalSignature)
+	ExternalSignatures []ExternalSignature
+
+	// CIDFields declares which parts of the claim its CID covers
+	// (default: CIDFieldsFull, all of them)
+	CIDFields CIDFields
+
 	// TimeEvent is the dag-time event ID that anchors this claim in time
 	TimeEvent string
 
+	// TimestampToken is an optional RFC 3161 token over the claim's CID,
+	// giving a second, independently verifiable time anchor (see VerifyTSA)
+	TimestampToken []byte
+
 	// Witnesses contains attestations from witnesses
 	Witnesses []Attestation
 
+	// Resolution tracks escalation of a disputed claim to arbiters
+	Resolution *Resolution
+
+	// State is the claim's lifecycle state (see Transition)
+	State State
+
 	// Created is when the claim was first created
 	Created time.Time
 
+	// ExpiresAt is when the claim stops being valid (zero means never)
+	ExpiresAt time.Time
+
+	// Supersedes is the CID of the earlier revision this claim corrects
+	// (empty for an original claim)
+	Supersedes string
+
 	// Metadata contains optional additional data
 	Metadata map[string]string
 }
@@ -52,32 +100,211 @@ type Statement struct {
 	Domain string
 }
 
+// EvidenceOrdering controls how evidence contributes to a claim's CID
+type EvidenceOrdering int
+
+const (
+	// EvidenceSet treats evidence as an unordered set: it is sorted
+	// before hashing, so reordering evidence does not change the CID
+	EvidenceSet EvidenceOrdering = iota
+
+	// EvidenceSequence treats evidence as an ordered list: its order
+	// is preserved when hashing, so reordering changes the CID
+	EvidenceSequence
+)
+
+// String returns the name of the ordering
+func (o EvidenceOrdering) String() string {
+	switch o {
+	case EvidenceSet:
+		return "set"
+	case EvidenceSequence:
+		return "sequence"
+	default:
+		return fmt.Sprintf("EvidenceOrdering(%d)", int(o))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (o EvidenceOrdering) MarshalText() ([]byte, error) {
+	switch o {
+	case EvidenceSet, EvidenceSequence:
+		return []byte(o.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown evidence ordering %d", int(o))
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (o *EvidenceOrdering) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "set", "":
+		*o = EvidenceSet
+	case "sequence":
+		*o = EvidenceSequence
+	default:
+		return fmt.Errorf("unknown evidence ordering %q", string(text))
+	}
+	return nil
+}
+
+// CIDFields selects which parts of a claim contribute to its CID. The
+// mode is itself part of the CID, so verifiers recompute it the same way.
+type CIDFields int
+
+const (
+	// CIDFieldsFull covers all of the claim's content (see ComputeCID)
+	CIDFieldsFull CIDFields = iota
+
+	// CIDFieldsStatement covers only what is claimed: the statement, its
+	// namespace, quantity, object list and fields. Claims making the same
+	// statement share a CID whatever their evidence, time event, creation
+	// time, expiry or revision link.
+	CIDFieldsStatement
+
+	// CIDFieldsStatementEvidence covers what CIDFieldsStatement does plus
+	// the evidence
+	CIDFieldsStatementEvidence
+)
+
+// String returns the name of the mode
+func (f CIDFields) String() string {
+	switch f {
+	case CIDFieldsFull:
+		return "full"
+	case CIDFieldsStatement:
+		return "statement"
+	case CIDFieldsStatementEvidence:
+		return "statement+evidence"
+	default:
+		return fmt.Sprintf("CIDFields(%d)", int(f))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (f CIDFields) MarshalText() ([]byte, error) {
+	switch f {
+	case CIDFieldsFull, CIDFieldsStatement, CIDFieldsStatementEvidence:
+		return []byte(f.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown CID fields mode %d", int(f))
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (f *CIDFields) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "full", "":
+		*f = CIDFieldsFull
+	case "statement":
+		*f = CIDFieldsStatement
+	case "statement+evidence":
+		*f = CIDFieldsStatementEvidence
+	default:
+		return fmt.Errorf("unknown CID fields mode %q", string(text))
+	}
+	return nil
+}
+
 // Attestation represents a witness signature on a claim
 type Attestation struct {
 	// WitnessID is the public key or DID of the witness
 	WitnessID string
 
+	// ClaimID is the CID the witness signed. The signature covers it, so
+	// the attestation never verifies on another claim, not even a revision
+	// of this one. Empty in attestations made before it was recorded.
+	ClaimID string
+
 	// Signature is the witness's signature over the claim
 	Signature []byte
 
 	// Timestamp is when the attestation was made
 	Timestamp time.Time
+
+	// TimestampSigned reports whether the signature covers Timestamp.
+	// Attestations made before timestamps were signed leave it unset; their
+	// Timestamp can be changed by anyone, so it never orders them (see
+	// NewerThan).
+	TimestampSigned bool
+
+	// Stance is whether the witness endorses or disputes the claim
+	Stance Stance
+
+	// Fields limits the attestation to the named claim fields
+	// (empty means the whole claim)
+	Fields []string
+
+	// Context optionally records how the witness reached its conclusion.
+	// It is covered by the signature.
+	Context *AttestationContext
+
+	// ContextHash optionally binds the attestation to external context
+	// the witness saw (e.g. a hash of a webpage snapshot). It is covered
+	// by the signature.
+	ContextHash []byte
+
+	// ExpiresAt is when the attestation lapses (zero means never). Lapsed
+	// attestations no longer count toward confidence. It is covered by
+	// the signature.
+	ExpiresAt time.Time
+
+	// Nonce makes each renewal of an attestation distinct. It is covered
+	// by the signature.
+	Nonce []byte
+
+	// BeaconRound is the dag-time beacon round the attestation is bound
+	// to, proving it was made no earlier than that round (zero if
+	// unbound). It is covered by the signature.
+	BeaconRound uint64
+
+	// Prehash marks a signature made with Ed25519ph over the SHA-512
+	// digest of the signing payload rather than over the payload itself.
+	// Ed25519ph signs under its own domain separator, so a signature of
+	// one kind never verifies as the other.
+	Prehash bool
+
+	// Membership is set when a group member signed on behalf of the group
+	// named by WitnessID. The signature is the member's, and covers the
+	// group and member IDs.
+	Membership *GroupMembership
+}
+
+// AttestationContext is a witness's signed account of how it verified a
+// claim
+type AttestationContext struct {
+	// Method names the verification method (e.g. "manual-review")
+	Method string
+
+	// Evidence lists the CIDs the witness examined
+	Evidence []string
+
+	// VerifiedAt is when the witness carried out the verification
+	VerifiedAt time.Time
 }
 
 // ComputeCID computes the content-addressed identifier for a claim.
 // The CID is computed from immutable content only:
 // - Statement
-// - Evidence (sorted)
+// - Evidence (sorted, or in order under EvidenceSequence)
 // - TimeEvent
 // - Created timestamp
+// - ExpiresAt (if set)
+// - Quantity (if set, in canonical form)
+// - Supersedes (if set)
+// - Namespace (if set)
+// - Objects (if set, sorted unless ordered)
+// - Fields (if set, sorted by name)
 //
-// Witnesses/attestations are NOT included as they are added after creation.
+// Under a CIDFields mode other than CIDFieldsFull, only the parts the mode
+// selects are included. Witnesses/attestations are NOT included as they
+// are added after creation.
 func ComputeCID(claim *Claim) (string, error) {
 	if claim == nil {
 		return "", fmt.Errorf("claim cannot be nil")
 	}
 
-	data, err := serializeClaimContent(claim)
+	data, err := CanonicalBytes(claim)
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize claim: %w", err)
 	}
@@ -91,8 +318,93 @@ func ComputeCID(claim *Claim) (string, error) {
 	return c.String(), nil
 }
 
-// serializeClaimContent creates a deterministic byte representation
-func serializeClaimContent(claim *Claim) ([]byte, error) {
+// ComputeCIDs computes the CIDs of many claims in parallel, returning them
+// in input order. It fails on the first claim (by index) that cannot be
+// hashed.
+func ComputeCIDs(claims []*Claim) ([]string, error) {
+	cids := make([]string, len(claims))
+	errs := make([]error, len(claims))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(claims) {
+		workers = len(claims)
+	}
+
+	// Each worker takes a strided share of the claims, avoiding
+	// per-claim coordination
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+			for i := start; i < len(claims); i += workers {
+				cids[i], errs[i] = ComputeCID(claims[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("claim %d: %w", i, err)
+		}
+	}
+
+	return cids, nil
+}
+
+// CanonicalFormat identifies the byte layout produced by CanonicalBytes.
+// The tag is not written into the bytes, so that existing CIDs stay
+// valid; an incompatible layout will be published under a new tag.
+const CanonicalFormat = "claim-graph/canonical/v1"
+
+// CanonicalBytes returns the deterministic encoding of a claim's content
+// that its CID is the SHA2-256 raw CIDv1 of. The layout is frozen as
+// CanonicalFormat so other implementations can reproduce CIDs.
+//
+// Strings are written as a big-endian uint32 byte length followed by the
+// UTF-8 bytes. The layout is:
+//
+//	string  Statement.Subject
+//	string  Statement.Predicate
+//	string  Statement.Object
+//	string  Statement.Domain
+//	uint32  number of evidence entries
+//	string  each evidence entry, sorted bytewise unless EvidenceSequence
+//	string  TimeEvent
+//	int64   Created as big-endian Unix nanoseconds
+//
+// followed by optional tagged fields, each a tag string and a value
+// string, present only when set and in this order:
+//
+//	"cid-fields"         CIDFields mode name, when not CIDFieldsFull
+//	"evidence-ordering"  ordering name, when not EvidenceSet
+//	"expires-at"         ExpiresAt as decimal Unix nanoseconds
+//	"quantity"           Quantity in canonical "<value> <unit>" form
+//	"supersedes"         Supersedes
+//	"namespace"          Namespace
+//	"object-set"         Objects values, sorted bytewise, each written as a
+//	                     string, when not ordered
+//	"object-sequence"    Objects values in order, likewise, when ordered
+//	"field:<name>"       each field value, sorted by name
+//	"external-signature" each external signature, as its algorithm,
+//	                     signer, hex payload hash and hex signature
+//	                     written as strings, sorted bytewise
+//
+// Parts a CIDFields mode leaves out are written as if unset: no evidence
+// (including external signatures), an empty TimeEvent and a zero Created,
+// with the tagged fields omitted.
+func CanonicalBytes(claim *Claim) ([]byte, error) {
+	if claim == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+
+	if _, err := claim.CIDFields.MarshalText(); err != nil {
+		return nil, err
+	}
+	withEvidence := claim.CIDFields != CIDFieldsStatement
+	full := claim.CIDFields == CIDFieldsFull
+
 	var buf bytes.Buffer
 
 	// Write statement
@@ -109,33 +421,117 @@ func serializeClaimContent(claim *Claim) ([]byte, error) {
 		return nil, err
 	}
 
-	// Write evidence (sorted for determinism)
-	sortedEvidence := make([]string, len(claim.Evidence))
-	copy(sortedEvidence, claim.Evidence)
-	sort.Strings(sortedEvidence)
+	// Write evidence (sorted for determinism unless order is significant)
+	var evidence []string
+	if withEvidence {
+		evidence = append(evidence, claim.Evidence...)
+	}
+	if claim.EvidenceOrdering != EvidenceSequence {
+		sort.Strings(evidence)
+	}
 
-	if err := binary.Write(&buf, binary.BigEndian, uint32(len(sortedEvidence))); err != nil {
+	if err := binary.Write(&buf, binary.BigEndian, uint32(len(evidence))); err != nil {
 		return nil, err
 	}
-	for _, e := range sortedEvidence {
+	for _, e := range evidence {
 		if err := writeString(&buf, e); err != nil {
 			return nil, err
 		}
 	}
 
 	// Write time event reference
-	if err := writeString(&buf, claim.TimeEvent); err != nil {
+	var timeEvent string
+	var created int64
+	if full {
+		timeEvent = claim.TimeEvent
+		created = claim.Created.UnixNano()
+	}
+	if err := writeString(&buf, timeEvent); err != nil {
 		return nil, err
 	}
 
 	// Write created timestamp (Unix nano for precision)
-	if err := binary.Write(&buf, binary.BigEndian, claim.Created.UnixNano()); err != nil {
+	if err := binary.Write(&buf, binary.BigEndian, created); err != nil {
 		return nil, err
 	}
 
+	// Optional fields are appended as tagged values after the base layout,
+	// so claims that don't use them keep their original CIDs
+	if claim.CIDFields != CIDFieldsFull {
+		if err := writeField(&buf, "cid-fields", claim.CIDFields.String()); err != nil {
+			return nil, err
+		}
+	}
+	if withEvidence && claim.EvidenceOrdering != EvidenceSet {
+		if err := writeField(&buf, "evidence-ordering", claim.EvidenceOrdering.String()); err != nil {
+			return nil, err
+		}
+	}
+	if full && !claim.ExpiresAt.IsZero() {
+		if err := writeField(&buf, "expires-at", strconv.FormatInt(claim.ExpiresAt.UnixNano(), 10)); err != nil {
+			return nil, err
+		}
+	}
+	if claim.Quantity != nil {
+		if err := writeField(&buf, "quantity", claim.Quantity.String()); err != nil {
+			return nil, err
+		}
+	}
+	if full && claim.Supersedes != "" {
+		if err := writeField(&buf, "supersedes", claim.Supersedes); err != nil {
+			return nil, err
+		}
+	}
+	if claim.Namespace != "" {
+		if err := writeField(&buf, "namespace", claim.Namespace); err != nil {
+			return nil, err
+		}
+	}
+	if claim.Objects != nil {
+		tag, value, err := claim.Objects.canonicalField()
+		if err != nil {
+			return nil, err
+		}
+		if err := writeField(&buf, tag, value); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(claim.Fields) {
+		if err := writeField(&buf, "field:"+name, claim.Fields[name]); err != nil {
+			return nil, err
+		}
+	}
+	if withEvidence {
+		values, err := canonicalExternalSignatures(claim.ExternalSignatures)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			if err := writeField(&buf, "external-signature", v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return buf.Bytes(), nil
 }
 
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeField(buf *bytes.Buffer, tag, value string) error {
+	if err := writeString(buf, tag); err != nil {
+		return err
+	}
+	return writeString(buf, value)
+}
+
 func writeString(buf *bytes.Buffer, s string) error {
 	if err := binary.Write(buf, binary.BigEndian, uint32(len(s))); err != nil {
 		return err
@@ -164,8 +560,76 @@ func VerifyCID(claim *Claim) error {
 	return nil
 }
 
+// ClaimOption configures optional claim content in NewClaim
+type ClaimOption func(*Claim)
+
+// WithEvidenceOrdering sets how evidence order affects the claim's CID
+func WithEvidenceOrdering(ordering EvidenceOrdering) ClaimOption {
+	return func(c *Claim) {
+		c.EvidenceOrdering = ordering
+	}
+}
+
+// WithCIDFields sets which parts of the claim its CID covers
+func WithCIDFields(fields CIDFields) ClaimOption {
+	return func(c *Claim) {
+		c.CIDFields = fields
+	}
+}
+
+// WithFields sets the claim's structured fields
+func WithFields(fields map[string]string) ClaimOption {
+	return func(c *Claim) {
+		c.Fields = make(map[string]string, len(fields))
+		for k, v := range fields {
+			c.Fields[k] = v
+		}
+	}
+}
+
+// WithQuantity sets the claim's numeric object
+func WithQuantity(q Quantity) ClaimOption {
+	return func(c *Claim) {
+		c.Quantity = &q
+	}
+}
+
+// WithObjects gives the claim an object with several values (see
+// NewObjectSet and NewObjectSequence)
+func WithObjects(l ObjectList) ClaimOption {
+	return func(c *Claim) {
+		c.Objects = &ObjectList{Values: append([]string(nil), l.Values...), Ordered: l.Ordered}
+	}
+}
+
+// WithExpiry sets when the claim stops being valid
+func WithExpiry(expiresAt time.Time) ClaimOption {
+	return func(c *Claim) {
+		c.ExpiresAt = expiresAt.UTC()
+	}
+}
+
+// WithSupersedes marks the claim as a revision of an earlier claim
+func WithSupersedes(cid string) ClaimOption {
+	return func(c *Claim) {
+		c.Supersedes = cid
+	}
+}
+
+// WithNamespace places the claim in an application namespace
+func WithNamespace(namespace string) ClaimOption {
+	return func(c *Claim) {
+		c.Namespace = namespace
+	}
+}
+
+// IsExpired reports whether the claim has an expiry at or before now
+func (c *Claim) IsExpired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !c.ExpiresAt.After(now)
+}
+
 // NewClaim creates a new claim with computed CID
-func NewClaim(statement Statement, evidence []string, timeEvent string) (*Claim, error) {
+func NewClaim(statement Statement, evidence []string, timeEvent string, opts ...ClaimOption) (*Claim, error) {
 	claim := &Claim{
 		Statement: statement,
 		Evidence:  evidence,
@@ -175,6 +639,24 @@ func NewClaim(statement Statement, evidence []string, timeEvent string) (*Claim,
 		Metadata:  make(map[string]string),
 	}
 
+	for _, opt := range opts {
+		opt(claim)
+	}
+
+	if claim.Quantity != nil {
+		if err := claim.Quantity.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if claim.Objects != nil {
+		if err := claim.Objects.validate(); err != nil {
+			return nil, err
+		}
+		if claim.Statement.Object != "" {
+			return nil, fmt.Errorf("claim cannot have both an object and an object list")
+		}
+	}
+
 	id, err := ComputeCID(claim)
 	if err != nil {
 		return nil, err
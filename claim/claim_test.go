@@ -1,6 +1,12 @@
 package claim
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -8,6 +14,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+var updateGolden = flag.Bool("update", false, "update golden files")
+
 func TestNewClaim(t *testing.T) {
 	statement := Statement{
 		Subject:   "https://example.com",
@@ -115,3 +123,318 @@ func TestVerifyCID(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestEvidenceOrdering(t *testing.T) {
+	now := time.Now().UTC()
+
+	newClaim := func(ordering EvidenceOrdering, evidence ...string) *Claim {
+		return &Claim{
+			Statement:        Statement{Subject: "process", Predicate: "steps"},
+			Evidence:         evidence,
+			EvidenceOrdering: ordering,
+			Created:          now,
+		}
+	}
+
+	t.Run("set ordering ignores evidence order", func(t *testing.T) {
+		cid1, err := ComputeCID(newClaim(EvidenceSet, "a", "b", "c"))
+		require.NoError(t, err)
+		cid2, err := ComputeCID(newClaim(EvidenceSet, "c", "a", "b"))
+		require.NoError(t, err)
+
+		assert.Equal(t, cid1, cid2)
+	})
+
+	t.Run("sequence ordering preserves evidence order", func(t *testing.T) {
+		cid1, err := ComputeCID(newClaim(EvidenceSequence, "a", "b", "c"))
+		require.NoError(t, err)
+		cid2, err := ComputeCID(newClaim(EvidenceSequence, "c", "a", "b"))
+		require.NoError(t, err)
+
+		assert.NotEqual(t, cid1, cid2)
+	})
+
+	t.Run("ordering is part of identity", func(t *testing.T) {
+		setCID, err := ComputeCID(newClaim(EvidenceSet, "a", "b"))
+		require.NoError(t, err)
+		seqCID, err := ComputeCID(newClaim(EvidenceSequence, "a", "b"))
+		require.NoError(t, err)
+
+		assert.NotEqual(t, setCID, seqCID)
+	})
+
+	t.Run("NewClaim option", func(t *testing.T) {
+		c, err := NewClaim(Statement{Subject: "process"}, []string{"b", "a"}, "", WithEvidenceOrdering(EvidenceSequence))
+		require.NoError(t, err)
+
+		assert.Equal(t, EvidenceSequence, c.EvidenceOrdering)
+		assert.NoError(t, VerifyCID(c))
+
+		c.Evidence = []string{"a", "b"}
+		assert.Error(t, VerifyCID(c))
+	})
+}
+
+func TestClaimExpiry(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	c, err := NewClaim(Statement{Subject: "odds"}, nil, "", WithExpiry(expiresAt))
+	require.NoError(t, err)
+
+	assert.False(t, c.IsExpired(time.Now()))
+	assert.True(t, c.IsExpired(expiresAt.Add(time.Second)))
+
+	t.Run("expiry is part of identity", func(t *testing.T) {
+		c.ExpiresAt = expiresAt.Add(time.Hour)
+		assert.Error(t, VerifyCID(c))
+	})
+
+	t.Run("claims without expiry never expire", func(t *testing.T) {
+		c, err := NewClaim(Statement{Subject: "forever"}, nil, "")
+		require.NoError(t, err)
+		assert.False(t, c.IsExpired(time.Now().Add(100*365*24*time.Hour)))
+	})
+}
+
+func TestClaimNamespace(t *testing.T) {
+	statement := Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}
+	created := time.Now()
+
+	newClaim := func(namespace string) *Claim {
+		c := &Claim{Statement: statement, Namespace: namespace, Created: created}
+		cid, err := ComputeCID(c)
+		require.NoError(t, err)
+		c.ID = cid
+		return c
+	}
+
+	plain := newClaim("")
+	appA := newClaim("app-a")
+	appB := newClaim("app-b")
+
+	assert.NotEqual(t, plain.ID, appA.ID)
+	assert.NotEqual(t, appA.ID, appB.ID)
+
+	t.Run("default namespace keeps existing CIDs", func(t *testing.T) {
+		withoutField := &Claim{Statement: statement, Created: created}
+		cid, err := ComputeCID(withoutField)
+		require.NoError(t, err)
+		assert.Equal(t, plain.ID, cid)
+	})
+
+	t.Run("namespace is part of identity", func(t *testing.T) {
+		appA.Namespace = "app-b"
+		assert.Error(t, VerifyCID(appA))
+	})
+
+	t.Run("option", func(t *testing.T) {
+		c, err := NewClaim(statement, nil, "", WithNamespace("app-a"))
+		require.NoError(t, err)
+		assert.Equal(t, "app-a", c.Namespace)
+	})
+}
+
+func TestComputeCIDs(t *testing.T) {
+	claims := benchmarkClaims(t, 200)
+
+	cids, err := ComputeCIDs(claims)
+	require.NoError(t, err)
+	require.Len(t, cids, len(claims))
+
+	for i, c := range claims {
+		serial, err := ComputeCID(c)
+		require.NoError(t, err)
+		assert.Equal(t, serial, cids[i], "claim %d", i)
+	}
+
+	t.Run("empty input", func(t *testing.T) {
+		cids, err := ComputeCIDs(nil)
+		require.NoError(t, err)
+		assert.Empty(t, cids)
+	})
+
+	t.Run("nil claim fails", func(t *testing.T) {
+		_, err := ComputeCIDs([]*Claim{claims[0], nil})
+		assert.ErrorContains(t, err, "claim 1")
+	})
+}
+
+func BenchmarkComputeCID(b *testing.B) {
+	claims := benchmarkClaims(b, 1000)
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		for _, c := range claims {
+			if _, err := ComputeCID(c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkComputeCIDs(b *testing.B) {
+	claims := benchmarkClaims(b, 1000)
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		if _, err := ComputeCIDs(claims); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkClaims(tb testing.TB, n int) []*Claim {
+	tb.Helper()
+
+	claims := make([]*Claim, n)
+	for i := range claims {
+		c, err := NewClaim(Statement{
+			Subject:   fmt.Sprintf("https://example.com/item/%d", i),
+			Predicate: "price",
+			Object:    fmt.Sprintf("%d USD", i),
+			Domain:    "finance",
+		}, []string{fmt.Sprintf("evidence-%d", i), "shared-evidence"}, "time-event")
+		require.NoError(tb, err)
+		claims[i] = c
+	}
+	return claims
+}
+
+// canonicalVector is a pinned CanonicalBytes encoding and its CID
+type canonicalVector struct {
+	Bytes string `json:"bytes"`
+	CID   string `json:"cid"`
+}
+
+func TestCanonicalBytes(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	quantity := Quantity{Value: 2.5, Unit: "Goals"}
+
+	claims := map[string]*Claim{
+		"minimal": {Created: created},
+		"base": {
+			Statement: Statement{Subject: "https://example.com", Predicate: "contains", Object: "hello world", Domain: "web"},
+			Evidence:  []string{"bafkreib", "bafkreia"},
+			TimeEvent: "dag-time-event-1",
+			Created:   created,
+		},
+		"optional-fields": {
+			Statement:        Statement{Subject: "match-42", Predicate: "score", Domain: "sports"},
+			Evidence:         []string{"step-2", "step-1"},
+			EvidenceOrdering: EvidenceSequence,
+			Created:          created,
+			ExpiresAt:        created.Add(24 * time.Hour),
+			Quantity:         &quantity,
+			Fields:           map[string]string{"venue": "home", "half": "second"},
+		},
+	}
+
+	got := make(map[string]canonicalVector, len(claims))
+	for name, c := range claims {
+		data, err := CanonicalBytes(c)
+		require.NoError(t, err)
+		cid, err := ComputeCID(c)
+		require.NoError(t, err)
+		got[name] = canonicalVector{Bytes: hex.EncodeToString(data), CID: cid}
+	}
+
+	golden := filepath.Join("testdata", "canonical_vectors.golden.json")
+	if *updateGolden {
+		out, err := json.MarshalIndent(got, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll("testdata", 0755))
+		require.NoError(t, os.WriteFile(golden, append(out, '\n'), 0644))
+	}
+
+	data, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	var expected map[string]canonicalVector
+	require.NoError(t, json.Unmarshal(data, &expected))
+
+	// A mismatch here means the canonical format changed, which breaks
+	// every existing CID
+	assert.Equal(t, expected, got)
+
+	t.Run("layout", func(t *testing.T) {
+		data, err := CanonicalBytes(claims["minimal"])
+		require.NoError(t, err)
+
+		// Four empty statement strings, zero evidence, empty time event,
+		// then the creation time
+		expected := append(make([]byte, 4*4+4+4), 0x17, 0xa6, 0x68, 0xb7, 0x30, 0x01, 0x32, 0x06)
+		assert.Equal(t, expected, data)
+	})
+
+	t.Run("nil claim", func(t *testing.T) {
+		_, err := CanonicalBytes(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestCIDFields(t *testing.T) {
+	statement := Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}
+
+	newClaim := func(t *testing.T, fields CIDFields, evidence []string, timeEvent string) *Claim {
+		c, err := NewClaim(statement, evidence, timeEvent, WithCIDFields(fields))
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("statement only", func(t *testing.T) {
+		a := newClaim(t, CIDFieldsStatement, []string{"bafkreia"}, "event-1")
+		time.Sleep(time.Millisecond) // a later Created does not matter either
+		b := newClaim(t, CIDFieldsStatement, []string{"bafkreib", "bafkreic"}, "event-2")
+		b.ExpiresAt = time.Now().Add(time.Hour)
+		b.Supersedes = "bafkreiold"
+
+		cid, err := ComputeCID(b)
+		require.NoError(t, err)
+		assert.Equal(t, a.ID, cid)
+		assert.NoError(t, VerifyCID(a))
+
+		// The statement still defines the claim
+		other, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "3-1", Domain: "sports"}, nil, "", WithCIDFields(CIDFieldsStatement))
+		require.NoError(t, err)
+		assert.NotEqual(t, a.ID, other.ID)
+	})
+
+	t.Run("statement and evidence", func(t *testing.T) {
+		a := newClaim(t, CIDFieldsStatementEvidence, []string{"bafkreia", "bafkreib"}, "event-1")
+		b := newClaim(t, CIDFieldsStatementEvidence, []string{"bafkreib", "bafkreia"}, "event-2")
+		c := newClaim(t, CIDFieldsStatementEvidence, []string{"bafkreia"}, "event-1")
+
+		assert.Equal(t, a.ID, b.ID)
+		assert.NotEqual(t, a.ID, c.ID)
+	})
+
+	t.Run("mode is part of the CID", func(t *testing.T) {
+		full := newClaim(t, CIDFieldsFull, nil, "")
+		stmt := newClaim(t, CIDFieldsStatement, nil, "")
+		withEvidence := newClaim(t, CIDFieldsStatementEvidence, nil, "")
+		assert.Len(t, map[string]bool{full.ID: true, stmt.ID: true, withEvidence.ID: true}, 3)
+
+		// Verifiers use the declared mode, so changing it breaks the CID
+		stmt.CIDFields = CIDFieldsStatementEvidence
+		assert.Error(t, VerifyCID(stmt))
+	})
+
+	t.Run("full mode covers everything", func(t *testing.T) {
+		a := newClaim(t, CIDFieldsFull, nil, "event-1")
+		b := *a
+		b.TimeEvent = "event-2"
+		assert.Error(t, VerifyCID(&b))
+	})
+
+	t.Run("text form", func(t *testing.T) {
+		for _, f := range []CIDFields{CIDFieldsFull, CIDFieldsStatement, CIDFieldsStatementEvidence} {
+			text, err := f.MarshalText()
+			require.NoError(t, err)
+			var got CIDFields
+			require.NoError(t, got.UnmarshalText(text))
+			assert.Equal(t, f, got)
+		}
+
+		_, err := ComputeCID(&Claim{CIDFields: CIDFields(9)})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,26 @@
+package claim
+
+import "time"
+
+// Clock tells the time. A ReputationStore reads its clock when it records
+// when witnesses are seen and when it scores them, so tests and replays of
+// historical data can fix "now" and deployments can use a trusted source.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock reads the system clock (the default)
+type SystemClock struct{}
+
+// Now returns the current system time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock is a Clock that always reads the same time
+type FixedClock time.Time
+
+// Now returns the fixed time
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
@@ -0,0 +1,156 @@
+package claim
+
+import (
+	"sort"
+	"strings"
+)
+
+// Conflict is a witness contradicting itself: it endorsed two claims that
+// make the same statement about a subject with different objects, or
+// endorsed one claim and disputed another asserting the same object
+type Conflict struct {
+	WitnessID string
+
+	// Subject, Predicate and Domain are the statement both claims make,
+	// as written in ClaimA
+	Subject   string
+	Predicate string
+	Domain    string
+
+	// ClaimA and ClaimB are the contradicting claims' CIDs, in CID order
+	ClaimA string
+	ClaimB string
+
+	// ObjectA and ObjectB are the objects the two claims assert
+	ObjectA string
+	ObjectB string
+
+	// Disputed is the CID of the claim the witness disputed, when it
+	// endorsed the other one; it is empty if it endorsed both
+	Disputed string
+}
+
+// DetectWitnessConflicts finds, for each witness, the pairs of claims with
+// the same subject and predicate (compared as StatementKey does, within a
+// domain and namespace, at the same time anchor) that the witness endorsed
+// with different objects, or endorsed and disputed with the same object.
+// Only each witness's latest valid, whole-claim attestation on a claim
+// counts, and a claim and the revision that supersedes it are not a
+// conflict. Claims anchored at different TimeEvents are a series, not a
+// contradiction. Witnesses that merely disagree with each other are not
+// reported. Conflicts are sorted by witness, then by claim.
+func DetectWitnessConflicts(claims []*Claim) []Conflict {
+	type attested struct {
+		claim  *Claim
+		object string
+		stance Stance
+	}
+	// Witness -> statement without its object -> endorsed or disputed claims
+	byWitness := make(map[string]map[string][]attested)
+
+	for _, c := range claims {
+		if c == nil {
+			continue
+		}
+		if VerifyCID(c) != nil {
+			continue
+		}
+		key := conflictKey(c)
+		object := conflictObject(c)
+
+		attestations := latestAttestations(c.Witnesses)
+		for i := range attestations {
+			att := &attestations[i]
+			if att.Stance == StanceAbstain || len(att.Fields) > 0 || verifySignedAttestation(c, att) != nil {
+				continue
+			}
+			statements := byWitness[att.WitnessID]
+			if statements == nil {
+				statements = make(map[string][]attested)
+				byWitness[att.WitnessID] = statements
+			}
+			statements[key] = append(statements[key], attested{claim: c, object: object, stance: att.Stance})
+		}
+	}
+
+	var conflicts []Conflict
+	for witnessID, statements := range byWitness {
+		for _, group := range statements {
+			sort.Slice(group, func(i, j int) bool { return group[i].claim.ID < group[j].claim.ID })
+			for i := range group {
+				for j := i + 1; j < len(group); j++ {
+					a, b := group[i], group[j]
+					if a.claim.ID == b.claim.ID {
+						continue
+					}
+					if a.claim.Supersedes == b.claim.ID || b.claim.Supersedes == a.claim.ID {
+						continue
+					}
+
+					var disputed string
+					switch {
+					case a.stance == StanceEndorse && b.stance == StanceEndorse:
+						if a.object == b.object {
+							continue
+						}
+					case a.stance != b.stance:
+						if a.object != b.object {
+							continue // Disputing a rival object is consistent
+						}
+						disputed = a.claim.ID
+						if b.stance == StanceDispute {
+							disputed = b.claim.ID
+						}
+					default:
+						continue // Disputing both says nothing about the witness
+					}
+
+					conflicts = append(conflicts, Conflict{
+						WitnessID: witnessID,
+						Subject:   a.claim.Statement.Subject,
+						Predicate: a.claim.Statement.Predicate,
+						Domain:    a.claim.Statement.Domain,
+						ClaimA:    a.claim.ID,
+						ClaimB:    b.claim.ID,
+						ObjectA:   a.object,
+						ObjectB:   b.object,
+						Disputed:  disputed,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		a, b := conflicts[i], conflicts[j]
+		if a.WitnessID != b.WitnessID {
+			return a.WitnessID < b.WitnessID
+		}
+		if a.ClaimA != b.ClaimA {
+			return a.ClaimA < b.ClaimA
+		}
+		return a.ClaimB < b.ClaimB
+	})
+	return conflicts
+}
+
+// conflictKey identifies what a claim's statement is about and when,
+// leaving out its object
+func conflictKey(c *Claim) string {
+	about := Claim{Statement: c.Statement, Namespace: c.Namespace}
+	about.Statement.Object = ""
+	return StatementKey(&about) + "\x00" + c.TimeEvent
+}
+
+// conflictObject returns the object a claim asserts: its scalar object,
+// quantity or object list
+func conflictObject(c *Claim) string {
+	switch {
+	case c.Quantity != nil:
+		return c.Quantity.String()
+	case c.Objects != nil:
+		return strings.Join(c.Objects.Canonical(), ", ")
+	default:
+		return strings.TrimSpace(c.Statement.Object)
+	}
+}
@@ -0,0 +1,122 @@
+package claim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectWitnessConflicts(t *testing.T) {
+	alice := DeterministicWitness("alice")
+	bob := DeterministicWitness("bob")
+
+	newClaim := func(subject, object string, opts ...ClaimOption) *Claim {
+		c, err := NewClaim(Statement{Subject: subject, Predicate: "final-score", Object: object, Domain: "sports"}, nil, "", opts...)
+		require.NoError(t, err)
+		return c
+	}
+	anchored := func(subject, object, timeEvent string) *Claim {
+		c, err := NewClaim(Statement{Subject: subject, Predicate: "final-score", Object: object, Domain: "sports"}, nil, timeEvent)
+		require.NoError(t, err)
+		return c
+	}
+	endorse := func(c *Claim, w *Witness) {
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+	}
+
+	t.Run("witness contradicts itself", func(t *testing.T) {
+		home := newClaim("match-1", "2-1")
+		away := newClaim("match-1", "1-2")
+		endorse(home, alice)
+		endorse(away, alice)
+		endorse(home, bob)
+
+		conflicts := DetectWitnessConflicts([]*Claim{home, away})
+		require.Len(t, conflicts, 1)
+		got := conflicts[0]
+		assert.Equal(t, alice.ID, got.WitnessID)
+		assert.Equal(t, "match-1", got.Subject)
+		assert.Equal(t, "final-score", got.Predicate)
+
+		first, second := home, away
+		if away.ID < home.ID {
+			first, second = away, home
+		}
+		assert.Equal(t, first.ID, got.ClaimA)
+		assert.Equal(t, second.ID, got.ClaimB)
+		assert.Equal(t, first.Statement.Object, got.ObjectA)
+		assert.Equal(t, second.Statement.Object, got.ObjectB)
+	})
+
+	t.Run("witnesses merely disagree", func(t *testing.T) {
+		home := newClaim("match-2", "2-1")
+		away := newClaim("match-2", "1-2")
+		endorse(home, alice)
+		endorse(away, bob)
+
+		assert.Empty(t, DetectWitnessConflicts([]*Claim{home, away}))
+	})
+
+	t.Run("disputing the contradiction is consistent", func(t *testing.T) {
+		home := newClaim("match-3", "2-1")
+		away := newClaim("match-3", "1-2")
+		endorse(home, alice)
+		att, err := alice.Dispute(away)
+		require.NoError(t, err)
+		require.NoError(t, away.AddAttestation(att))
+
+		assert.Empty(t, DetectWitnessConflicts([]*Claim{home, away}))
+	})
+
+	t.Run("same object or different subject", func(t *testing.T) {
+		a := newClaim("match-4", "0-0")
+		b := newClaim(" match-4 ", "0-0")
+		c := newClaim("match-5", "3-0")
+		for _, cl := range []*Claim{a, b, c} {
+			endorse(cl, alice)
+		}
+
+		assert.Empty(t, DetectWitnessConflicts([]*Claim{a, b, c}))
+	})
+
+	t.Run("revision is not a conflict", func(t *testing.T) {
+		original := newClaim("match-6", "2-1")
+		revision := newClaim("match-6", "2-2", WithSupersedes(original.ID))
+		endorse(original, alice)
+		endorse(revision, alice)
+
+		assert.Empty(t, DetectWitnessConflicts([]*Claim{original, revision}))
+	})
+
+	t.Run("time series is not a conflict", func(t *testing.T) {
+		halfTime := anchored("match-7", "1-0", "event-45")
+		fullTime := anchored("match-7", "2-1", "event-90")
+		endorse(halfTime, alice)
+		endorse(fullTime, alice)
+
+		assert.Empty(t, DetectWitnessConflicts([]*Claim{halfTime, fullTime}))
+
+		recount := anchored("match-7", "1-1", "event-45")
+		endorse(recount, alice)
+		assert.Len(t, DetectWitnessConflicts([]*Claim{halfTime, fullTime, recount}), 1)
+	})
+
+	t.Run("endorsing and disputing the same object", func(t *testing.T) {
+		a := newClaim("match-8", "2-1")
+		b := newClaim("match-8", "2-1", WithFields(map[string]string{"source": "wire"}))
+		require.NotEqual(t, a.ID, b.ID)
+		endorse(a, alice)
+		att, err := alice.Dispute(b)
+		require.NoError(t, err)
+		require.NoError(t, b.AddAttestation(att))
+
+		conflicts := DetectWitnessConflicts([]*Claim{a, b})
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, b.ID, conflicts[0].Disputed)
+		assert.Equal(t, "2-1", conflicts[0].ObjectA)
+		assert.Equal(t, "2-1", conflicts[0].ObjectB)
+	})
+}
@@ -0,0 +1,110 @@
+package claim
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// consensusTolerance is the relative spread of witness values, measured as
+// the weighted median absolute deviation over the median, at which
+// NumericConsensus confidence falls to one half
+const consensusTolerance = 0.01
+
+// weightedValue is a numeric value vouched for with a reputation weight
+type weightedValue struct {
+	value  float64
+	weight float64
+}
+
+// NumericConsensus combines claims about the same numeric quantity, such
+// as prices reported by different witnesses, into one value. Every
+// whole-claim endorsement counts as a vote for its claim's quantity,
+// weighted by the witness's reputation as in ClaimConfidence, and the
+// value is the weighted median of the votes, so a minority of outliers
+// cannot move it far. Confidence reflects how tightly the votes cluster:
+// 1 when they agree exactly, 0.5 when the weighted median absolute
+// deviation is 1% of the value, and towards 0 as they scatter.
+//
+// Every claim must carry a quantity, all in the same unit.
+func NumericConsensus(claims []*Claim, store *ReputationStore) (value float64, confidence float64, err error) {
+	var votes []weightedValue
+	var unit *Quantity
+	now := time.Now()
+
+	for _, c := range claims {
+		if c == nil {
+			return 0, 0, fmt.Errorf("claim cannot be nil")
+		}
+		if c.Quantity == nil {
+			return 0, 0, fmt.Errorf("claim %s has no numeric object", c.ID)
+		}
+		if unit == nil {
+			unit = c.Quantity
+		} else if !unit.SameUnit(*c.Quantity) {
+			return 0, 0, fmt.Errorf("claim %s is in %s, not %s", c.ID, c.Quantity.Unit, unit.Unit)
+		}
+
+		if VerifyCID(c) != nil {
+			continue
+		}
+		for _, att := range unexpiredAttestations(latestAttestations(c.Witnesses), now) {
+			if att.Stance != StanceEndorse || len(att.Fields) > 0 {
+				continue
+			}
+			if verifySignedAttestation(c, &att) != nil {
+				continue
+			}
+
+			// Weight by reputation score, as ClaimConfidence does
+			weight := 0.5 + witnessScore(c, att.WitnessID, store)*0.5
+			votes = append(votes, weightedValue{value: c.Quantity.Value, weight: weight})
+		}
+	}
+
+	if len(votes) == 0 {
+		return 0, 0, fmt.Errorf("no endorsed numeric claims")
+	}
+
+	value = weightedMedian(votes)
+
+	deviations := make([]weightedValue, len(votes))
+	for i, v := range votes {
+		deviations[i] = weightedValue{value: math.Abs(v.value - value), weight: v.weight}
+	}
+	spread := weightedMedian(deviations)
+
+	scale := math.Max(math.Abs(value), spread)
+	if scale == 0 {
+		return value, 1, nil
+	}
+	return value, 1 / (1 + spread/scale/consensusTolerance), nil
+}
+
+// weightedMedian returns the value at which half the total weight lies on
+// either side, averaging the two middle values when the weight splits
+// evenly between them. It sorts values in place.
+func weightedMedian(values []weightedValue) float64 {
+	sort.Slice(values, func(i, j int) bool { return values[i].value < values[j].value })
+
+	var total float64
+	for _, v := range values {
+		total += v.weight
+	}
+
+	half := total / 2
+	var cumulative float64
+	for i, v := range values {
+		cumulative += v.weight
+		if cumulative > half {
+			return v.value
+		}
+		// Compare with a tolerance so an even split is not lost to
+		// floating-point rounding
+		if math.Abs(cumulative-half) < 1e-12 && i+1 < len(values) {
+			return (v.value + values[i+1].value) / 2
+		}
+	}
+	return values[len(values)-1].value
+}
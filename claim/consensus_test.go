@@ -0,0 +1,119 @@
+package claim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumericConsensus(t *testing.T) {
+	// priceClaim returns a price claim endorsed by w
+	priceClaim := func(t *testing.T, w *Witness, value float64, unit string) *Claim {
+		c, err := NewClaim(Statement{Subject: "BTC", Predicate: "price", Domain: "markets"}, nil, "",
+			WithQuantity(Quantity{Value: value, Unit: unit}))
+		require.NoError(t, err)
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		return c
+	}
+
+	// witnessWithScore returns a witness whose markets reputation is the
+	// given extreme: agreed every time, or disputed every time
+	witnessWithScore := func(rs *ReputationStore, trusted bool) *Witness {
+		w, _ := GenerateWitness()
+		for i := 0; i < 100; i++ {
+			rs.RecordAttestation(w.ID, "markets")
+			if trusted {
+				rs.RecordAgreement(w.ID, "markets")
+			} else {
+				rs.RecordDispute(w.ID, "markets")
+			}
+		}
+		return w
+	}
+
+	priceClaims := func(t *testing.T, values ...float64) []*Claim {
+		var claims []*Claim
+		for _, v := range values {
+			w, _ := GenerateWitness()
+			claims = append(claims, priceClaim(t, w, v, "USD"))
+		}
+		return claims
+	}
+
+	t.Run("clustered values", func(t *testing.T) {
+		value, confidence, err := NumericConsensus(priceClaims(t, 64000, 64010, 63990, 64005, 63995), NewReputationStore())
+		require.NoError(t, err)
+		assert.Equal(t, 64000.0, value)
+		assert.Greater(t, confidence, 0.9)
+	})
+
+	t.Run("scattered values", func(t *testing.T) {
+		value, confidence, err := NumericConsensus(priceClaims(t, 30000, 64000, 90000, 12000, 50000), NewReputationStore())
+		require.NoError(t, err)
+		assert.Equal(t, 50000.0, value)
+		assert.Less(t, confidence, 0.1)
+	})
+
+	t.Run("identical values", func(t *testing.T) {
+		value, confidence, err := NumericConsensus(priceClaims(t, 0, 0, 0), NewReputationStore())
+		require.NoError(t, err)
+		assert.Zero(t, value)
+		assert.Equal(t, 1.0, confidence)
+	})
+
+	t.Run("outlier cannot move the median", func(t *testing.T) {
+		value, _, err := NumericConsensus(priceClaims(t, 64000, 64010, 63990, 1e9), NewReputationStore())
+		require.NoError(t, err)
+		assert.Equal(t, 64005.0, value)
+	})
+
+	t.Run("median weighted by reputation", func(t *testing.T) {
+		rs := NewReputationStore()
+		claims := []*Claim{
+			priceClaim(t, witnessWithScore(rs, true), 64000, "USD"),
+			priceClaim(t, witnessWithScore(rs, true), 64010, "USD"),
+		}
+		for _, v := range []float64{70000, 71000, 72000} {
+			claims = append(claims, priceClaim(t, witnessWithScore(rs, false), v, "USD"))
+		}
+
+		// The three untrusted witnesses are a majority by count but not by weight
+		value, _, err := NumericConsensus(claims, rs)
+		require.NoError(t, err)
+		assert.Equal(t, 64010.0, value)
+	})
+
+	t.Run("disputes and forged endorsements are not votes", func(t *testing.T) {
+		claims := priceClaims(t, 64000, 64010)
+
+		disputed := priceClaims(t, 99999)[0]
+		w, _ := GenerateWitness()
+		att, err := w.Dispute(disputed)
+		require.NoError(t, err)
+		disputed.Witnesses = []Attestation{*att}
+
+		forged := priceClaims(t, 99999)[0]
+		forged.Witnesses[0].Signature = claims[0].Witnesses[0].Signature
+
+		value, _, err := NumericConsensus(append(claims, disputed, forged), NewReputationStore())
+		require.NoError(t, err)
+		assert.Equal(t, 64005.0, value)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		w, _ := GenerateWitness()
+		_, _, err := NumericConsensus([]*Claim{priceClaim(t, w, 1, "USD"), priceClaim(t, w, 1, "EUR")}, NewReputationStore())
+		assert.Error(t, err)
+
+		untyped, err := NewClaim(Statement{Subject: "BTC", Object: "64000"}, nil, "")
+		require.NoError(t, err)
+		_, _, err = NumericConsensus([]*Claim{untyped}, NewReputationStore())
+		assert.Error(t, err)
+
+		_, _, err = NumericConsensus(nil, NewReputationStore())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,130 @@
+package claim
+
+import (
+	"fmt"
+	"time"
+)
+
+// Resolution records the escalation of a disputed claim to arbitration
+// and, once the arbiters have ruled, the outcome. It is not part of the
+// claim's CID and is not signed, so it does not name the arbiters: they
+// are the witnesses a ReputationStore trusts (see SetArbiters), and only
+// their valid attestations decide the outcome.
+type Resolution struct {
+	// EscalatedAt is when the claim was escalated
+	EscalatedAt time.Time
+
+	// Resolved reports whether the arbiters have reached a verdict
+	Resolved bool
+
+	// Outcome is the arbiters' verdict (only meaningful once resolved)
+	Outcome Stance
+
+	// ResolvedAt is when the verdict was recorded
+	ResolvedAt time.Time
+}
+
+// IsDisputed reports whether any witness has validly disputed the claim
+func (c *Claim) IsDisputed() bool {
+	if VerifyCID(c) != nil {
+		return false
+	}
+	for i := range c.Witnesses {
+		att := &c.Witnesses[i]
+		if att.Stance == StanceDispute && verifySignedAttestation(c, att) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SetArbiters sets the witnesses whose verdicts settle escalated disputes
+// for confidence computed with the store, replacing any set before.
+// Arbiters are configured here rather than named in the claim, since
+// anyone who can store a claim can write its Resolution.
+func (rs *ReputationStore) SetArbiters(ids []string) error {
+	arbiters := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, err := WitnessFromID(id); err != nil {
+			return fmt.Errorf("invalid arbiter %s: %w", id, err)
+		}
+		arbiters[id] = true
+	}
+
+	rs.mu.Lock()
+	rs.arbiters = arbiters
+	rs.mu.Unlock()
+
+	rs.notify("")
+	return nil
+}
+
+// IsArbiter reports whether the store trusts a witness as an arbiter
+func (rs *ReputationStore) IsArbiter(witnessID string) bool {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.arbiters[witnessID]
+}
+
+// Escalate hands a disputed claim to arbitration. The arbiters then
+// attest to the claim as usual, and Resolve records their verdict.
+func Escalate(c *Claim) error {
+	if c == nil {
+		return fmt.Errorf("claim cannot be nil")
+	}
+	if c.Resolution != nil {
+		return fmt.Errorf("claim %s already escalated", c.ID)
+	}
+	if !c.IsDisputed() {
+		return fmt.Errorf("claim %s is not disputed", c.ID)
+	}
+
+	c.Resolution = &Resolution{EscalatedAt: time.Now().UTC()}
+	return nil
+}
+
+// Resolve tallies the valid attestations of the store's arbiters and
+// records the majority stance as the outcome. It fails if no arbiter has
+// attested or the arbiters are evenly split.
+func Resolve(c *Claim, store *ReputationStore) (*Resolution, error) {
+	if c == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+	if c.Resolution == nil {
+		return nil, fmt.Errorf("claim %s has not been escalated", c.ID)
+	}
+	if err := VerifyCID(c); err != nil {
+		return nil, err
+	}
+
+	endorse, dispute := 0, 0
+	attestations := latestAttestations(c.Witnesses)
+	for i := range attestations {
+		att := &attestations[i]
+		if !store.IsArbiter(att.WitnessID) || store.revokedMember(att) || verifySignedAttestation(c, att) != nil {
+			continue
+		}
+		switch att.Stance {
+		case StanceEndorse:
+			endorse++
+		case StanceDispute:
+			dispute++
+		}
+	}
+
+	if endorse+dispute == 0 {
+		return nil, fmt.Errorf("no arbiter has attested to claim %s", c.ID)
+	}
+	if endorse == dispute {
+		return nil, fmt.Errorf("arbiters are evenly split on claim %s", c.ID)
+	}
+
+	c.Resolution.Outcome = StanceEndorse
+	if dispute > endorse {
+		c.Resolution.Outcome = StanceDispute
+	}
+	c.Resolution.Resolved = true
+	c.Resolution.ResolvedAt = time.Now().UTC()
+
+	return c.Resolution, nil
+}
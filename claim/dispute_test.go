@@ -0,0 +1,152 @@
+package claim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisputeAttestation(t *testing.T) {
+	w, _ := GenerateWitness()
+	c, _ := NewClaim(Statement{Subject: "test"}, nil, "")
+
+	att, err := w.Dispute(c)
+	require.NoError(t, err)
+	assert.Equal(t, StanceDispute, att.Stance)
+	assert.NoError(t, VerifyAttestation(c, att))
+
+	t.Run("stance is covered by signature", func(t *testing.T) {
+		forged := *att
+		forged.Stance = StanceEndorse
+		assert.Error(t, VerifyAttestation(c, &forged))
+	})
+
+	t.Run("claim is marked disputed", func(t *testing.T) {
+		require.NoError(t, c.AddAttestation(att))
+		assert.True(t, c.IsDisputed())
+	})
+}
+
+func TestDisputeResolution(t *testing.T) {
+	rs := NewReputationStore()
+
+	newDisputedClaim := func(t *testing.T) *Claim {
+		c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			w, _ := GenerateWitness()
+			att, _ := w.Attest(c)
+			require.NoError(t, c.AddAttestation(att))
+		}
+
+		disputer, _ := GenerateWitness()
+		att, _ := disputer.Dispute(c)
+		require.NoError(t, c.AddAttestation(att))
+
+		return c
+	}
+
+	t.Run("arbiters flip confidence", func(t *testing.T) {
+		c := newDisputedClaim(t)
+		before := ClaimConfidence(c, rs)
+		assert.Greater(t, before, 0.5)
+
+		a1, _ := GenerateWitness()
+		a2, _ := GenerateWitness()
+		require.NoError(t, rs.SetArbiters([]string{a1.ID, a2.ID}))
+		require.NoError(t, Escalate(c))
+
+		for _, a := range []*Witness{a1, a2} {
+			att, err := a.Dispute(c)
+			require.NoError(t, err)
+			require.NoError(t, c.AddAttestation(att))
+		}
+
+		// Escalation alone does not override the witness tally
+		assert.False(t, c.Resolution.Resolved)
+
+		res, err := Resolve(c, rs)
+		require.NoError(t, err)
+		assert.True(t, res.Resolved)
+		assert.Equal(t, StanceDispute, res.Outcome)
+
+		after := ClaimConfidence(c, rs)
+		assert.Less(t, after, 0.5)
+		assert.Less(t, after, before)
+	})
+
+	t.Run("arbiters uphold claim", func(t *testing.T) {
+		c := newDisputedClaim(t)
+
+		a1, _ := GenerateWitness()
+		require.NoError(t, rs.SetArbiters([]string{a1.ID}))
+		require.NoError(t, Escalate(c))
+
+		att, _ := a1.Attest(c)
+		require.NoError(t, c.AddAttestation(att))
+
+		res, err := Resolve(c, rs)
+		require.NoError(t, err)
+		assert.Equal(t, StanceEndorse, res.Outcome)
+		assert.Equal(t, 1.0, ClaimConfidence(c, rs))
+	})
+
+	t.Run("undisputed claim cannot be escalated", func(t *testing.T) {
+		c, _ := NewClaim(Statement{Subject: "calm"}, nil, "")
+		assert.Error(t, Escalate(c))
+	})
+
+	t.Run("non-arbiter attestations do not decide", func(t *testing.T) {
+		c := newDisputedClaim(t)
+
+		a1, _ := GenerateWitness()
+		require.NoError(t, rs.SetArbiters([]string{a1.ID}))
+		require.NoError(t, Escalate(c))
+
+		_, err := Resolve(c, rs)
+		assert.Error(t, err)
+	})
+
+	t.Run("forged resolution does not override the tally", func(t *testing.T) {
+		c := newDisputedClaim(t)
+
+		// Anyone can write the unsigned Resolution and dispute the claim
+		// themselves; only the store's arbiters decide
+		forger, _ := GenerateWitness()
+		att, _ := forger.Dispute(c)
+		require.NoError(t, c.AddAttestation(att))
+		tally := ClaimConfidence(c, rs)
+		c.Resolution = &Resolution{Resolved: true, Outcome: StanceDispute}
+
+		e := ExplainConfidence(c, rs)
+		assert.False(t, e.Arbitrated)
+		assert.Equal(t, tally, e.Confidence)
+		assert.Greater(t, e.Confidence, 0.5)
+
+		_, err := Resolve(c, NewReputationStore())
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid arbiter ID is rejected", func(t *testing.T) {
+		assert.Error(t, NewReputationStore().SetArbiters([]string{"not-a-key"}))
+	})
+
+	t.Run("split arbiters cannot resolve", func(t *testing.T) {
+		c := newDisputedClaim(t)
+
+		a1, _ := GenerateWitness()
+		a2, _ := GenerateWitness()
+		require.NoError(t, rs.SetArbiters([]string{a1.ID, a2.ID}))
+		require.NoError(t, Escalate(c))
+
+		att1, _ := a1.Attest(c)
+		att2, _ := a2.Dispute(c)
+		require.NoError(t, c.AddAttestation(att1))
+		require.NoError(t, c.AddAttestation(att2))
+
+		_, err := Resolve(c, rs)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,75 @@
+package claim
+
+import (
+	"context"
+	"math"
+)
+
+// Evidence quality adjusts confidence by at most these fractions: a bonus
+// for verified evidence, reaching its maximum at five items, and a penalty
+// in proportion to the share of evidence that is dangling
+const (
+	evidenceBonus   = 0.2
+	danglingPenalty = 0.5
+)
+
+// EvidenceResolver checks a claim's evidence against a store
+type EvidenceResolver interface {
+	// VerifyEvidence reports whether the evidence CID resolves to content
+	// that hashes to it. It returns false for evidence that is missing or
+	// does not match, and an error only if the lookup itself failed.
+	VerifyEvidence(ctx context.Context, cid string) (bool, error)
+}
+
+// EvidenceAssessment counts how a claim's evidence resolved
+type EvidenceAssessment struct {
+	// Verified is the number of evidence CIDs that resolved and matched
+	Verified int
+
+	// Dangling is the number that were missing or did not match
+	Dangling int
+
+	// Unknown is the number that could not be checked
+	Unknown int
+}
+
+// AssessEvidence checks each of a claim's evidence CIDs
+func AssessEvidence(ctx context.Context, c *Claim, resolver EvidenceResolver) EvidenceAssessment {
+	var a EvidenceAssessment
+	for _, cid := range c.Evidence {
+		ok, err := resolver.VerifyEvidence(ctx, cid)
+		switch {
+		case err != nil:
+			a.Unknown++
+		case ok:
+			a.Verified++
+		default:
+			a.Dangling++
+		}
+	}
+	return a
+}
+
+// Factor returns the relative confidence adjustment for the assessment,
+// between -danglingPenalty and +evidenceBonus. Evidence that could not be
+// checked counts toward neither.
+func (a EvidenceAssessment) Factor() float64 {
+	checked := a.Verified + a.Dangling
+	if checked == 0 {
+		return 0
+	}
+
+	bonus := evidenceBonus * math.Min(float64(a.Verified)/5, 1)
+	penalty := danglingPenalty * float64(a.Dangling) / float64(checked)
+	return bonus - penalty
+}
+
+// ClaimConfidenceWithEvidence is ClaimConfidence scaled by the quality of
+// the claim's evidence: verified evidence raises it, dangling evidence
+// lowers it. Evidence never creates confidence on its own, so a claim
+// nobody has endorsed stays at zero.
+func ClaimConfidenceWithEvidence(ctx context.Context, c *Claim, store *ReputationStore, resolver EvidenceResolver) float64 {
+	confidence := ClaimConfidence(c, store)
+	factor := AssessEvidence(ctx, c, resolver).Factor()
+	return math.Max(0, math.Min(1, confidence*(1+factor)))
+}
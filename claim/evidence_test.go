@@ -0,0 +1,65 @@
+package claim
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapEvidence resolves evidence from a fixed table; unlisted CIDs fail
+type mapEvidence map[string]bool
+
+func (m mapEvidence) VerifyEvidence(ctx context.Context, cid string) (bool, error) {
+	ok, listed := m[cid]
+	if !listed {
+		return false, fmt.Errorf("lookup failed")
+	}
+	return ok, nil
+}
+
+func TestClaimConfidenceWithEvidence(t *testing.T) {
+	ctx := context.Background()
+	rs := NewReputationStore()
+	resolver := mapEvidence{"good1": true, "good2": true, "gone1": false, "gone2": false}
+
+	attested := func(evidence ...string) *Claim {
+		c, err := NewClaim(Statement{Subject: "test", Domain: "sports"}, evidence, "")
+		require.NoError(t, err)
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		return c
+	}
+
+	none := ClaimConfidenceWithEvidence(ctx, attested(), rs, resolver)
+	verified := ClaimConfidenceWithEvidence(ctx, attested("good1", "good2"), rs, resolver)
+	dangling := ClaimConfidenceWithEvidence(ctx, attested("gone1", "gone2"), rs, resolver)
+	mixed := ClaimConfidenceWithEvidence(ctx, attested("good1", "gone1"), rs, resolver)
+
+	assert.Equal(t, ClaimConfidence(attested(), rs), none)
+	assert.Greater(t, verified, none)
+	assert.Less(t, dangling, none)
+	assert.Greater(t, verified, mixed)
+	assert.Less(t, dangling, mixed)
+
+	t.Run("unchecked evidence is neutral", func(t *testing.T) {
+		assert.Equal(t, none, ClaimConfidenceWithEvidence(ctx, attested("unreachable"), rs, resolver))
+	})
+
+	t.Run("evidence alone gives no confidence", func(t *testing.T) {
+		c, err := NewClaim(Statement{Subject: "test", Domain: "sports"}, []string{"good1"}, "")
+		require.NoError(t, err)
+		assert.Zero(t, ClaimConfidenceWithEvidence(ctx, c, rs, resolver))
+	})
+
+	t.Run("bonus is bounded", func(t *testing.T) {
+		a := EvidenceAssessment{Verified: 100}
+		assert.InDelta(t, evidenceBonus, a.Factor(), 1e-9)
+		a = EvidenceAssessment{Dangling: 100}
+		assert.InDelta(t, -danglingPenalty, a.Factor(), 1e-9)
+	})
+}
@@ -0,0 +1,250 @@
+package claim
+
+import "math"
+
+// ConfidenceExplanation breaks a claim's confidence score into the parts it
+// is computed from, so it can be shown alongside the score. The parts
+// compose as
+//
+//	Confidence = clamp((Base + WitnessBonus) * AbstentionFactor, 0, 1)
+//
+// and Base is the sum of the witnesses' contributions.
+type ConfidenceExplanation struct {
+	// Confidence is the final score, as returned by ClaimConfidence
+	Confidence float64 `json:"confidence"`
+
+	// Arbitrated reports whether arbiters resolved the claim, in which case
+	// only their attestations count and there is no witness bonus
+	Arbitrated bool `json:"arbitrated"`
+
+	// Witnesses lists each counted witness's part in the score
+	Witnesses []WitnessContribution `json:"witnesses"`
+
+	// Base is the reputation-weighted average support of the witnesses
+	Base float64 `json:"base"`
+
+	// WitnessBonus is added for independent endorsers, net of disputers,
+	// and scaled down when endorsers share an operator or network
+	WitnessBonus float64 `json:"witness_bonus"`
+
+	// DisputePenalty is the witness bonus lost to disputers
+	DisputePenalty float64 `json:"dispute_penalty"`
+
+	// AbstentionFactor scales the score down by the weight of counted
+	// abstentions; it is 1 when abstentions are ignored
+	AbstentionFactor float64 `json:"abstention_factor"`
+
+	// Endorsers and Disputers are the independent witness counts on each side
+	Endorsers float64 `json:"endorsers"`
+	Disputers float64 `json:"disputers"`
+}
+
+// WitnessContribution is one witness's part in a ConfidenceExplanation
+type WitnessContribution struct {
+	WitnessID string `json:"witness_id"`
+	Stance    Stance `json:"stance"`
+
+	// Score is the witness's reputation in the claim's domain
+	Score float64 `json:"score"`
+
+	// Share is the witness's independence share among witnesses taking the
+	// same stance (see WitnessDiversity)
+	Share float64 `json:"share"`
+
+	// Weight is the witness's voting weight, from its score, share and
+	// remaining stake
+	Weight float64 `json:"weight"`
+
+	// Support is how strongly the witness's stance supports the claim
+	Support float64 `json:"support"`
+
+	// Contribution is the witness's part of Base. Abstentions contribute
+	// nothing and reduce the score through AbstentionFactor instead.
+	Contribution float64 `json:"contribution"`
+}
+
+// ExplainConfidence computes a claim's confidence score as ClaimConfidence
+// does, and reports how each witness and adjustment contributed to it
+func ExplainConfidence(claim *Claim, store *ReputationStore) *ConfidenceExplanation {
+	if e := explainResolved(claim, store); e != nil {
+		return e
+	}
+
+	// Field-scoped attestations only count toward their fields
+	var whole []Attestation
+	for _, att := range claim.Witnesses {
+		if len(att.Fields) == 0 {
+			whole = append(whole, att)
+		}
+	}
+
+	return explainTally(claim, whole, store)
+}
+
+// explainTally combines the given attestations into a confidence score
+func explainTally(claim *Claim, attestations []Attestation, store *ReputationStore) *ConfidenceExplanation {
+	e := &ConfidenceExplanation{Witnesses: []WitnessContribution{}, AbstentionFactor: 1}
+
+	// Revoked group members no longer speak for their group
+	var current []Attestation
+	for _, att := range attestations {
+		if !store.revokedMember(&att) {
+			current = append(current, att)
+		}
+	}
+
+	// A witness's lapsed attestation is not replaced by its older ones.
+	// Expiry is judged by the store's clock, like the scores themselves.
+	attestations = unexpiredAttestations(latestAttestations(current), store.now())
+	if len(attestations) == 0 {
+		return e
+	}
+
+	var totalWeight float64
+	var weightedSum float64
+	var abstainWeight float64
+	var endorsements int
+
+	// Witnesses sharing an operator or network count as fewer
+	// independent voices on their side
+	shares := stanceShares(attestations, store)
+	abstentions := store.abstentionPolicy()
+
+	for i, att := range attestations {
+		score := witnessScore(claim, att.WitnessID, store)
+
+		// Weight by reputation score (higher rep = more weight), scaled
+		// down for witnesses that have been slashed
+		weight := (0.5 + score*0.5) * shares[i] * store.stakes.Weight(att.WitnessID) // Range [0.5, 1.0] per independent, unslashed witness
+
+		wc := WitnessContribution{
+			WitnessID: att.WitnessID,
+			Stance:    att.Stance,
+			Score:     score,
+			Share:     shares[i],
+			Weight:    weight,
+		}
+
+		switch att.Stance {
+		case StanceDispute:
+			wc.Support = 1 - score
+			e.Disputers += shares[i]
+		case StanceAbstain:
+			if abstentions == CountAbstentions {
+				abstainWeight += weight
+				e.Witnesses = append(e.Witnesses, wc)
+			}
+			continue
+		default:
+			wc.Support = score
+			e.Endorsers += shares[i]
+			endorsements++
+		}
+
+		weightedSum += wc.Support * weight
+		totalWeight += weight
+		e.Witnesses = append(e.Witnesses, wc)
+	}
+
+	if totalWeight == 0 {
+		return e
+	}
+
+	for i := range e.Witnesses {
+		if e.Witnesses[i].Stance != StanceAbstain {
+			e.Witnesses[i].Contribution = e.Witnesses[i].Support * e.Witnesses[i].Weight / totalWeight
+		}
+	}
+
+	// Also factor in number of independent witnesses (diversity), net of
+	// disputes. Endorsers sharing an operator or network shrink the bonus
+	// by the fraction of them that are independent, so three endorsers run
+	// by one operator earn a third of what three unrelated ones do.
+	independence := 1.0
+	if endorsements > 0 {
+		independence = e.Endorsers / float64(endorsements)
+	}
+	netEndorsers := math.Max(e.Endorsers-e.Disputers, 0)
+	e.WitnessBonus = math.Min(netEndorsers/5, 0.2) * independence // Max 20% bonus for 5+ witnesses
+	e.DisputePenalty = math.Min(e.Endorsers/5, 0.2)*independence - e.WitnessBonus
+
+	e.Base = weightedSum / totalWeight
+	confidence := e.Base + e.WitnessBonus
+
+	// Abstentions dilute confidence by the share of undecided weight
+	e.AbstentionFactor = totalWeight / (totalWeight + abstainWeight)
+	confidence *= e.AbstentionFactor
+
+	e.Confidence = math.Max(0, math.Min(1, confidence))
+	return e
+}
+
+// explainResolved returns the arbiters' verdict on a resolved claim, or
+// nil if the claim is unresolved or none of the store's arbiters has ruled
+// on it. Resolved is unsigned, so it only switches to a verdict the
+// trusted arbiters signed.
+func explainResolved(claim *Claim, store *ReputationStore) *ConfidenceExplanation {
+	if claim.Resolution == nil || !claim.Resolution.Resolved {
+		return nil
+	}
+	e := explainArbiters(claim, store)
+	if len(e.Witnesses) == 0 {
+		return nil
+	}
+	return e
+}
+
+// explainArbiters computes confidence from the store's arbiters'
+// attestations alone: the reputation-weighted share of arbiters endorsing
+// the claim
+func explainArbiters(claim *Claim, store *ReputationStore) *ConfidenceExplanation {
+	e := &ConfidenceExplanation{Arbitrated: true, Witnesses: []WitnessContribution{}, AbstentionFactor: 1}
+
+	var totalWeight float64
+	var endorseWeight float64
+
+	if VerifyCID(claim) != nil {
+		return e
+	}
+	attestations := latestAttestations(claim.Witnesses)
+	for i := range attestations {
+		att := &attestations[i]
+		if !store.IsArbiter(att.WitnessID) || store.revokedMember(att) || verifySignedAttestation(claim, att) != nil {
+			continue
+		}
+
+		// Arbiters rule one way or the other; abstentions carry no verdict
+		if att.Stance == StanceAbstain {
+			continue
+		}
+
+		score := witnessScore(claim, att.WitnessID, store)
+		wc := WitnessContribution{
+			WitnessID: att.WitnessID,
+			Stance:    att.Stance,
+			Score:     score,
+			Share:     1,
+			Weight:    0.5 + score*0.5,
+		}
+		if att.Stance == StanceEndorse {
+			wc.Support = 1
+			endorseWeight += wc.Weight
+			e.Endorsers++
+		} else {
+			e.Disputers++
+		}
+		totalWeight += wc.Weight
+		e.Witnesses = append(e.Witnesses, wc)
+	}
+
+	if totalWeight == 0 {
+		return e
+	}
+
+	for i := range e.Witnesses {
+		e.Witnesses[i].Contribution = e.Witnesses[i].Support * e.Witnesses[i].Weight / totalWeight
+	}
+	e.Base = endorseWeight / totalWeight
+	e.Confidence = e.Base
+	return e
+}
@@ -0,0 +1,140 @@
+package claim
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertComposes checks that an explanation's parts add up to its score
+func assertComposes(t *testing.T, e *ConfidenceExplanation) {
+	t.Helper()
+
+	var base float64
+	for _, wc := range e.Witnesses {
+		base += wc.Contribution
+	}
+	assert.InDelta(t, e.Base, base, 1e-9)
+
+	composed := math.Max(0, math.Min(1, (e.Base+e.WitnessBonus)*e.AbstentionFactor))
+	assert.InDelta(t, e.Confidence, composed, 1e-9)
+}
+
+func TestExplainConfidence(t *testing.T) {
+	newClaim := func(t *testing.T) *Claim {
+		c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		return c
+	}
+	addStance := func(t *testing.T, c *Claim, stance Stance) *Witness {
+		w, _ := GenerateWitness()
+		att, err := w.AttestWithStance(c, stance)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		return w
+	}
+
+	t.Run("endorsements", func(t *testing.T) {
+		rs := NewReputationStore()
+		c := newClaim(t)
+		for i := 0; i < 3; i++ {
+			w := addStance(t, c, StanceEndorse)
+			rs.RecordAgreement(w.ID, "sports")
+		}
+
+		e := ExplainConfidence(c, rs)
+		assertComposes(t, e)
+		assert.InDelta(t, ClaimConfidence(c, rs), e.Confidence, 1e-6)
+		assert.Len(t, e.Witnesses, 3)
+		assert.Equal(t, 3.0, e.Endorsers)
+		assert.InDelta(t, 0.2, e.WitnessBonus, 1e-9)
+		assert.Zero(t, e.DisputePenalty)
+		assert.Equal(t, 1.0, e.AbstentionFactor)
+	})
+
+	t.Run("disputes", func(t *testing.T) {
+		rs := NewReputationStore()
+		c := newClaim(t)
+		addStance(t, c, StanceEndorse)
+		addStance(t, c, StanceDispute)
+
+		e := ExplainConfidence(c, rs)
+		assertComposes(t, e)
+		assert.InDelta(t, ClaimConfidence(c, rs), e.Confidence, 1e-6)
+		assert.Equal(t, 1.0, e.Disputers)
+		assert.InDelta(t, 0.2, e.DisputePenalty, 1e-9)
+		assert.Zero(t, e.WitnessBonus)
+	})
+
+	t.Run("counted abstentions", func(t *testing.T) {
+		rs := NewReputationStore()
+		rs.SetAbstentionPolicy(CountAbstentions)
+		c := newClaim(t)
+		addStance(t, c, StanceEndorse)
+		addStance(t, c, StanceAbstain)
+
+		e := ExplainConfidence(c, rs)
+		assertComposes(t, e)
+		assert.InDelta(t, ClaimConfidence(c, rs), e.Confidence, 1e-6)
+		assert.InDelta(t, 0.5, e.AbstentionFactor, 1e-9)
+		require.Len(t, e.Witnesses, 2)
+		for _, wc := range e.Witnesses {
+			if wc.Stance == StanceAbstain {
+				assert.Zero(t, wc.Contribution)
+			}
+		}
+	})
+
+	t.Run("arbitrated", func(t *testing.T) {
+		rs := NewReputationStore()
+		c := newClaim(t)
+		addStance(t, c, StanceEndorse)
+		addStance(t, c, StanceDispute)
+
+		a1, _ := GenerateWitness()
+		require.NoError(t, rs.SetArbiters([]string{a1.ID}))
+		require.NoError(t, Escalate(c))
+		att, _ := a1.Attest(c)
+		require.NoError(t, c.AddAttestation(att))
+		_, err := Resolve(c, rs)
+		require.NoError(t, err)
+
+		e := ExplainConfidence(c, rs)
+		assertComposes(t, e)
+		assert.True(t, e.Arbitrated)
+		assert.Equal(t, 1.0, e.Confidence)
+		assert.Zero(t, e.WitnessBonus)
+		require.Len(t, e.Witnesses, 1)
+		assert.Equal(t, a1.ID, e.Witnesses[0].WitnessID)
+	})
+
+	t.Run("expiry follows the store's clock", func(t *testing.T) {
+		rs := NewReputationStore()
+		c := newClaim(t)
+		w, _ := GenerateWitness()
+		expiresAt := time.Now().Add(time.Hour)
+		att, err := w.AttestUntil(c, expiresAt)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+
+		rs.SetClock(FixedClock(expiresAt.Add(-time.Minute)))
+		assert.Len(t, ExplainConfidence(c, rs).Witnesses, 1)
+
+		// Replaying at a time after the attestation lapsed drops it
+		rs.SetClock(FixedClock(expiresAt.Add(time.Minute)))
+		e := ExplainConfidence(c, rs)
+		assertComposes(t, e)
+		assert.Empty(t, e.Witnesses)
+		assert.Zero(t, e.Confidence)
+	})
+
+	t.Run("no attestations", func(t *testing.T) {
+		e := ExplainConfidence(newClaim(t), NewReputationStore())
+		assertComposes(t, e)
+		assert.Zero(t, e.Confidence)
+		assert.Empty(t, e.Witnesses)
+	})
+}
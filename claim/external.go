@@ -0,0 +1,136 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// SignatureAlgorithm names the scheme an external signature was made with
+type SignatureAlgorithm string
+
+const (
+	// AlgorithmEd25519 is an Ed25519 signature over the payload hash
+	AlgorithmEd25519 SignatureAlgorithm = "ed25519"
+
+	// AlgorithmSecp256k1 is a DER-encoded ECDSA signature on the secp256k1
+	// curve over the payload hash, as Ethereum and Bitcoin oracles make
+	AlgorithmSecp256k1 SignatureAlgorithm = "secp256k1"
+)
+
+// ExternalSignature is evidence signed by a third party, such as a price
+// feed oracle, that signs its own payloads. The signature is carried as
+// is, without being re-signed, so anyone can check it against the
+// oracle's key. It is part of the claim's content (see CanonicalBytes).
+type ExternalSignature struct {
+	// Signer is the hex-encoded public key of the third party: 32 bytes
+	// for Ed25519, or a 33-byte compressed or 65-byte uncompressed
+	// secp256k1 key
+	Signer string
+
+	// Algorithm is the signature scheme
+	Algorithm SignatureAlgorithm
+
+	// Signature is the third party's signature over PayloadHash
+	Signature []byte
+
+	// PayloadHash is the SHA-256 hash of the signed payload
+	PayloadHash []byte
+}
+
+// VerifyExternalSignature checks that the signer signed the payload hash
+func VerifyExternalSignature(ev *ExternalSignature) error {
+	if ev == nil {
+		return fmt.Errorf("external signature cannot be nil")
+	}
+	if len(ev.PayloadHash) != sha256.Size {
+		return fmt.Errorf("payload hash must be %d bytes", sha256.Size)
+	}
+
+	key, err := hex.DecodeString(ev.Signer)
+	if err != nil {
+		return fmt.Errorf("invalid signer: %w", err)
+	}
+
+	switch ev.Algorithm {
+	case AlgorithmEd25519:
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid public key length")
+		}
+		if !ed25519.Verify(ed25519.PublicKey(key), ev.PayloadHash, ev.Signature) {
+			return fmt.Errorf("invalid signature")
+		}
+	case AlgorithmSecp256k1:
+		pub, err := secp256k1.ParsePubKey(key)
+		if err != nil {
+			return fmt.Errorf("invalid public key: %w", err)
+		}
+		sig, err := ecdsa.ParseDERSignature(ev.Signature)
+		if err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+		if !sig.Verify(ev.PayloadHash, pub) {
+			return fmt.Errorf("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", ev.Algorithm)
+	}
+	return nil
+}
+
+// MatchesPayload reports whether payload is the one the hash was taken of
+func (ev *ExternalSignature) MatchesPayload(payload []byte) bool {
+	sum := sha256.Sum256(payload)
+	return bytes.Equal(sum[:], ev.PayloadHash)
+}
+
+// VerifyExternalSignatures checks every external signature a claim
+// carries, failing on the first that does not verify
+func VerifyExternalSignatures(c *Claim) error {
+	if c == nil {
+		return fmt.Errorf("claim cannot be nil")
+	}
+	for i := range c.ExternalSignatures {
+		if err := VerifyExternalSignature(&c.ExternalSignatures[i]); err != nil {
+			return fmt.Errorf("external signature %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// WithExternalSignature adds a third party's signature as evidence
+func WithExternalSignature(ev ExternalSignature) ClaimOption {
+	return func(c *Claim) {
+		ev.Signature = append([]byte(nil), ev.Signature...)
+		ev.PayloadHash = append([]byte(nil), ev.PayloadHash...)
+		c.ExternalSignatures = append(c.ExternalSignatures, ev)
+	}
+}
+
+// canonicalExternalSignatures returns the values external signatures are
+// hashed as (see CanonicalBytes), sorted bytewise
+func canonicalExternalSignatures(sigs []ExternalSignature) ([]string, error) {
+	values := make([]string, 0, len(sigs))
+	for _, ev := range sigs {
+		var buf bytes.Buffer
+		for _, s := range []string{
+			string(ev.Algorithm),
+			ev.Signer,
+			hex.EncodeToString(ev.PayloadHash),
+			hex.EncodeToString(ev.Signature),
+		} {
+			if err := writeString(&buf, s); err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, buf.String())
+	}
+	sort.Strings(values)
+	return values, nil
+}
@@ -0,0 +1,91 @@
+package claim
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyExternalSignature(t *testing.T) {
+	payload := []byte(`{"pair":"ETH/USD","price":"3120.55","round":8812}`)
+	hash := sha256.Sum256(payload)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	edSig := ExternalSignature{
+		Signer:      hex.EncodeToString(pub),
+		Algorithm:   AlgorithmEd25519,
+		Signature:   ed25519.Sign(priv, hash[:]),
+		PayloadHash: hash[:],
+	}
+
+	key, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+	secpSig := ExternalSignature{
+		Signer:      hex.EncodeToString(key.PubKey().SerializeCompressed()),
+		Algorithm:   AlgorithmSecp256k1,
+		Signature:   ecdsa.Sign(key, hash[:]).Serialize(),
+		PayloadHash: hash[:],
+	}
+
+	for _, ev := range []ExternalSignature{edSig, secpSig} {
+		ev := ev
+		t.Run(string(ev.Algorithm), func(t *testing.T) {
+			assert.NoError(t, VerifyExternalSignature(&ev))
+			assert.True(t, ev.MatchesPayload(payload))
+
+			tampered := ev
+			tampered.PayloadHash = append([]byte(nil), ev.PayloadHash...)
+			tampered.PayloadHash[0] ^= 0xff
+			assert.Error(t, VerifyExternalSignature(&tampered))
+			assert.False(t, tampered.MatchesPayload(payload))
+		})
+	}
+
+	t.Run("uncompressed secp256k1 key", func(t *testing.T) {
+		ev := secpSig
+		ev.Signer = hex.EncodeToString(key.PubKey().SerializeUncompressed())
+		assert.NoError(t, VerifyExternalSignature(&ev))
+	})
+
+	t.Run("wrong signer", func(t *testing.T) {
+		other, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		ev := edSig
+		ev.Signer = hex.EncodeToString(other)
+		assert.Error(t, VerifyExternalSignature(&ev))
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		ev := edSig
+		ev.Algorithm = "rsa"
+		assert.ErrorContains(t, VerifyExternalSignature(&ev), "unsupported")
+	})
+
+	t.Run("carried by a claim", func(t *testing.T) {
+		st := Statement{Subject: "ETH/USD", Predicate: "price", Object: "3120.55", Domain: "finance"}
+		plain, err := NewClaim(st, nil, "")
+		require.NoError(t, err)
+		c, err := NewClaim(st, nil, "", WithExternalSignature(edSig), WithExternalSignature(secpSig))
+		require.NoError(t, err)
+		assert.NotEqual(t, plain.ID, c.ID)
+		assert.NoError(t, VerifyExternalSignatures(c))
+
+		reordered, err := NewClaim(st, nil, "", WithExternalSignature(secpSig), WithExternalSignature(edSig))
+		require.NoError(t, err)
+		reordered.Created = c.Created
+		reordered.ID, err = ComputeCID(reordered)
+		require.NoError(t, err)
+		assert.Equal(t, c.ID, reordered.ID, "external signatures are an unordered set")
+
+		c.ExternalSignatures[1].PayloadHash[0] ^= 0xff
+		assert.Error(t, VerifyCID(c))
+		assert.Error(t, VerifyExternalSignatures(c))
+	})
+}
@@ -0,0 +1,215 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// importDiscount is the most an imported history can count for: it is
+// credited with at most this share of the volume weight a locally built
+// history of the same accuracy would have, so a witness arriving from
+// another network starts between a newcomer and an established witness
+// and earns the rest here
+const importDiscount = 0.5
+
+// EndorsedReputation is a witness's exported reputation signed by a
+// federation root, which vouches that the history is genuine. Receiving
+// networks that trust the root can seed the witness's reputation from it.
+type EndorsedReputation struct {
+	// Export is the endorsed reputation. Its scores are not signed; the
+	// receiving store recomputes them from the counts.
+	Export ExportedReputation
+
+	// Root is the hex-encoded public key of the endorsing root
+	Root string
+
+	// Timestamp is when the endorsement was signed
+	Timestamp time.Time
+
+	// Signature is the root's signature over the endorsement
+	Signature []byte
+}
+
+// EndorseReputation signs a witness's exported reputation with a
+// federation root key
+func EndorseReputation(export ExportedReputation, rootKey ed25519.PrivateKey) (*EndorsedReputation, error) {
+	if len(rootKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid root key length")
+	}
+	if err := validateExport(export); err != nil {
+		return nil, err
+	}
+
+	e := &EndorsedReputation{
+		Export:    export,
+		Root:      hex.EncodeToString(rootKey.Public().(ed25519.PublicKey)),
+		Timestamp: time.Now().UTC(),
+	}
+
+	payload, err := endorsementPayload(e)
+	if err != nil {
+		return nil, err
+	}
+	e.Signature = ed25519.Sign(rootKey, payload)
+
+	return e, nil
+}
+
+// VerifyReputationEndorsement checks that an endorsement was signed by one
+// of the given root keys and that its history is well-formed
+func VerifyReputationEndorsement(e *EndorsedReputation, rootKeys []ed25519.PublicKey) error {
+	if e == nil {
+		return fmt.Errorf("endorsement cannot be nil")
+	}
+	if err := validateExport(e.Export); err != nil {
+		return err
+	}
+
+	var root ed25519.PublicKey
+	for _, key := range rootKeys {
+		if hex.EncodeToString(key) == e.Root {
+			root = key
+			break
+		}
+	}
+	if root == nil {
+		return fmt.Errorf("endorsing key %s is not a trusted root", e.Root)
+	}
+
+	payload, err := endorsementPayload(e)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(root, payload, e.Signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+// validateExport checks that an exported history is self-consistent
+func validateExport(export ExportedReputation) error {
+	if _, err := WitnessFromID(export.WitnessID); err != nil {
+		return err
+	}
+
+	check := func(what string, total, agreed, disputed int64) error {
+		if total < 0 || agreed < 0 || disputed < 0 || agreed > total || disputed > total {
+			return fmt.Errorf("inconsistent %s claim counts", what)
+		}
+		return nil
+	}
+	if err := check("witness", export.TotalClaims, export.AgreedClaims, export.DisputedClaims); err != nil {
+		return err
+	}
+	for domain, d := range export.Domains {
+		if err := check("domain "+domain, d.TotalClaims, d.AgreedClaims, d.DisputedClaims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func endorsementPayload(e *EndorsedReputation) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, s := range []string{"claim-graph/reputation-endorsement", e.Root, e.Export.WitnessID} {
+		if err := writeString(&buf, s); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := []int64{e.Export.TotalClaims, e.Export.AgreedClaims, e.Export.DisputedClaims,
+		e.Export.FirstSeen.UnixNano(), e.Export.LastSeen.UnixNano(), e.Timestamp.UnixNano()}
+	if err := binary.Write(&buf, binary.BigEndian, counts); err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0, len(e.Export.Domains))
+	for domain := range e.Export.Domains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		d := e.Export.Domains[domain]
+		if err := writeString(&buf, domain); err != nil {
+			return nil, err
+		}
+		if err := binary.Write(&buf, binary.BigEndian, []int64{d.TotalClaims, d.AgreedClaims, d.DisputedClaims}); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// AddFederationRoot trusts a public key to endorse imported reputations
+func (rs *ReputationStore) AddFederationRoot(pubKey ed25519.PublicKey) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.roots = append(rs.roots, append(ed25519.PublicKey(nil), pubKey...))
+}
+
+// ImportReputation seeds a witness's reputation from a history endorsed by
+// a trusted federation root. The history is credited at a discount (see
+// importDiscount), and the witness's longevity starts afresh. Witnesses
+// whose history is not endorsed stay newcomers. A witness this store
+// already has a record for cannot be imported.
+func (rs *ReputationStore) ImportReputation(e *EndorsedReputation) (err error) {
+	defer func() {
+		if err == nil {
+			rs.notify(e.Export.WitnessID)
+		}
+	}()
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if err := VerifyReputationEndorsement(e, rs.roots); err != nil {
+		return fmt.Errorf("reputation not endorsed: %w", err)
+	}
+
+	export := e.Export
+	shard := rs.shard(export.WitnessID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if _, exists := shard.records[export.WitnessID]; exists {
+		return fmt.Errorf("witness %s already has a reputation record", export.WitnessID)
+	}
+
+	// Scale counts so their volume weight is at most importDiscount
+	scale := func(n, total int64, volume float64) int64 {
+		credit := importDiscount
+		if limit := importDiscount * volume; float64(total)*credit > limit {
+			credit = limit / float64(total)
+		}
+		return int64(float64(n) * credit)
+	}
+
+	now := rs.now().UTC()
+	record := &ReputationRecord{
+		WitnessID:      export.WitnessID,
+		TotalClaims:    scale(export.TotalClaims, export.TotalClaims, recordVolume),
+		AgreedClaims:   scale(export.AgreedClaims, export.TotalClaims, recordVolume),
+		DisputedClaims: scale(export.DisputedClaims, export.TotalClaims, recordVolume),
+		Domains:        make(map[string]*DomainReputation, len(export.Domains)),
+		FirstSeen:      now,
+		LastSeen:       now,
+	}
+	for domain, d := range export.Domains {
+		record.Domains[domain] = &DomainReputation{
+			Domain:         domain,
+			TotalClaims:    scale(d.TotalClaims, d.TotalClaims, domainVolume),
+			AgreedClaims:   scale(d.AgreedClaims, d.TotalClaims, domainVolume),
+			DisputedClaims: scale(d.DisputedClaims, d.TotalClaims, domainVolume),
+		}
+	}
+	shard.records[export.WitnessID] = record
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package claim
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportReputation(t *testing.T) {
+	rootPub, rootKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	// The witness built a strong record on its home network
+	w, _ := GenerateWitness()
+	home := NewReputationStore()
+	for i := 0; i < 200; i++ {
+		home.RecordAttestation(w.ID, "markets")
+		home.RecordAgreement(w.ID, "markets")
+	}
+	record, _ := home.GetRecord(w.ID)
+	homeScore := record.DomainScore("markets")
+	export := record.Export()
+
+	endorsed, err := EndorseReputation(export, rootKey)
+	require.NoError(t, err)
+	require.NoError(t, VerifyReputationEndorsement(endorsed, []ed25519.PublicKey{rootPub}))
+
+	c, err := NewClaim(Statement{Subject: "BTC", Domain: "markets"}, nil, "")
+	require.NoError(t, err)
+	att, err := w.Attest(c)
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+	newcomerConfidence := ClaimConfidence(c, NewReputationStore())
+
+	t.Run("endorsed import seeds a discounted score", func(t *testing.T) {
+		rs := NewReputationStore()
+		rs.AddFederationRoot(rootPub)
+		require.NoError(t, rs.ImportReputation(endorsed))
+
+		imported, ok := rs.GetRecord(w.ID)
+		require.True(t, ok)
+		score := imported.DomainScore("markets")
+		assert.Greater(t, score, 0.5)
+		assert.Less(t, score, homeScore)
+		assert.Greater(t, ClaimConfidence(c, rs), newcomerConfidence)
+
+		// Importing again would overwrite the local record
+		assert.Error(t, rs.ImportReputation(endorsed))
+	})
+
+	t.Run("unendorsed import treated as newcomer", func(t *testing.T) {
+		otherPub, otherKey, _ := ed25519.GenerateKey(nil)
+		selfSigned, err := EndorseReputation(export, otherKey)
+		require.NoError(t, err)
+
+		rs := NewReputationStore()
+		rs.AddFederationRoot(rootPub)
+		assert.Error(t, rs.ImportReputation(selfSigned))
+		assert.Error(t, VerifyReputationEndorsement(selfSigned, []ed25519.PublicKey{rootPub}))
+		assert.NoError(t, VerifyReputationEndorsement(selfSigned, []ed25519.PublicKey{otherPub}))
+
+		_, ok := rs.GetRecord(w.ID)
+		assert.False(t, ok)
+		assert.Equal(t, newcomerConfidence, ClaimConfidence(c, rs))
+	})
+
+	t.Run("altered history rejected", func(t *testing.T) {
+		inflated := *endorsed
+		inflated.Export.TotalClaims = 10000
+		inflated.Export.AgreedClaims = 10000
+		assert.Error(t, VerifyReputationEndorsement(&inflated, []ed25519.PublicKey{rootPub}))
+
+		_, err := EndorseReputation(ExportedReputation{WitnessID: w.ID, TotalClaims: 1, AgreedClaims: 2}, rootKey)
+		assert.Error(t, err)
+	})
+}
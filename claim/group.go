@@ -0,0 +1,327 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WitnessGroup is an organization that attests under one stable identity
+// while its members sign with their own, rotating keys. The group ID is
+// the hex-encoded group public key, so it works anywhere a witness ID
+// does: attestations made by members carry the group ID, and reputation
+// accrues to the group.
+type WitnessGroup struct {
+	// ID is the hex-encoded group public key
+	ID string
+
+	// PublicKey is the group's ed25519 public key
+	PublicKey ed25519.PublicKey
+
+	// PrivateKey certifies members (only set for the group's own admin)
+	PrivateKey ed25519.PrivateKey
+
+	mu      sync.RWMutex
+	revoked map[string]time.Time // MemberID -> revocation time
+}
+
+// GroupMembership is a group's signed statement that a member key may
+// attest on its behalf from Since onward. Members attach it to their
+// attestations, so VerifyAttestation can resolve the member to the group.
+type GroupMembership struct {
+	GroupID  string
+	MemberID string
+	Since    time.Time
+
+	// Signature is the group's signature over the membership
+	Signature []byte
+}
+
+// NewWitnessGroup creates a group with a fresh group key
+func NewWitnessGroup() (*WitnessGroup, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate keypair: %w", err)
+	}
+
+	return &WitnessGroup{
+		ID:         hex.EncodeToString(pub),
+		PublicKey:  pub,
+		PrivateKey: priv,
+		revoked:    make(map[string]time.Time),
+	}, nil
+}
+
+// WitnessGroupFromID creates a group from its ID, for verifiers that track
+// revocations but cannot certify members
+func WitnessGroupFromID(id string) (*WitnessGroup, error) {
+	w, err := WitnessFromID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WitnessGroup{
+		ID:        id,
+		PublicKey: w.PublicKey,
+		revoked:   make(map[string]time.Time),
+	}, nil
+}
+
+// AddMember certifies a member key to attest for the group from now on
+func (g *WitnessGroup) AddMember(memberID string) (*GroupMembership, error) {
+	if g.PrivateKey == nil {
+		return nil, fmt.Errorf("group has no private key")
+	}
+	if _, err := WitnessFromID(memberID); err != nil {
+		return nil, fmt.Errorf("invalid member: %w", err)
+	}
+
+	m := &GroupMembership{
+		GroupID:  g.ID,
+		MemberID: memberID,
+		Since:    time.Now().UTC(),
+	}
+
+	payload, err := membershipPayload(m)
+	if err != nil {
+		return nil, err
+	}
+	m.Signature = ed25519.Sign(g.PrivateKey, payload)
+
+	return m, nil
+}
+
+// RevokeMember stops the member's attestations counting for the group
+// from at onward. Attestations a verifier received earlier remain valid
+// (see VerifyAttestationAt).
+func (g *WitnessGroup) RevokeMember(memberID string, at time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.revoked == nil {
+		g.revoked = make(map[string]time.Time)
+	}
+	g.revoked[memberID] = at.UTC()
+}
+
+// RevokedAt returns when the member was revoked, if it has been
+func (g *WitnessGroup) RevokedAt(memberID string) (time.Time, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	at, revoked := g.revoked[memberID]
+	return at, revoked
+}
+
+// VerifyAttestation checks that the attestation was made for the group by
+// a member that has not been revoked, as well as everything
+// VerifyAttestation checks. The attestation's timestamp is signed by the
+// member itself, so a revoked key could backdate it; without a record of
+// receiving the attestation earlier, it is taken as received now.
+func (g *WitnessGroup) VerifyAttestation(claim *Claim, att *Attestation) error {
+	return g.VerifyAttestationAt(claim, att, time.Now())
+}
+
+// VerifyAttestationAt is VerifyAttestation for an attestation the verifier
+// first received at receivedAt: it still counts if receivedAt precedes
+// the member's revocation. receivedAt must come from the verifier, such
+// as the time a store first accepted the attestation, never from the
+// attestation itself.
+func (g *WitnessGroup) VerifyAttestationAt(claim *Claim, att *Attestation, receivedAt time.Time) error {
+	if att == nil {
+		return fmt.Errorf("attestation cannot be nil")
+	}
+	if att.WitnessID != g.ID || att.Membership == nil {
+		return fmt.Errorf("attestation was not made for group %s", g.ID)
+	}
+	if err := VerifyAttestation(claim, att); err != nil {
+		return err
+	}
+	return g.checkRevoked(att, receivedAt)
+}
+
+// checkRevoked fails if the attestation's member was revoked by receivedAt
+func (g *WitnessGroup) checkRevoked(att *Attestation, receivedAt time.Time) error {
+	if at, revoked := g.RevokedAt(att.Membership.MemberID); revoked && !receivedAt.Before(at) {
+		return fmt.Errorf("member %s was revoked at %s", att.Membership.MemberID, at.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// AttestAsGroup creates an endorsement of a claim on behalf of the group
+// the membership names, signed with the witness's own key
+func (w *Witness) AttestAsGroup(claim *Claim, membership *GroupMembership) (*Attestation, error) {
+	if membership == nil {
+		return nil, fmt.Errorf("membership cannot be nil")
+	}
+	if membership.MemberID != w.ID {
+		return nil, fmt.Errorf("membership belongs to member %s", membership.MemberID)
+	}
+
+	m := *membership
+	m.Signature = append([]byte(nil), membership.Signature...)
+	return w.sign(claim, &Attestation{Membership: &m})
+}
+
+// VerifyGroupMembership checks that the group signed the membership
+func VerifyGroupMembership(m *GroupMembership) error {
+	if m == nil {
+		return fmt.Errorf("membership cannot be nil")
+	}
+
+	group, err := WitnessFromID(m.GroupID)
+	if err != nil {
+		return fmt.Errorf("invalid group ID: %w", err)
+	}
+
+	payload, err := membershipPayload(m)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(group.PublicKey, payload, m.Signature) {
+		return fmt.Errorf("invalid membership signature")
+	}
+	return nil
+}
+
+// attestationKey returns the key an attestation is signed with: the
+// witness's own, or for a group attestation, the certified member's
+func attestationKey(att *Attestation) (ed25519.PublicKey, error) {
+	id := att.WitnessID
+	if m := att.Membership; m != nil {
+		if m.GroupID != att.WitnessID {
+			return nil, fmt.Errorf("membership is for group %s, not %s", m.GroupID, att.WitnessID)
+		}
+		if err := VerifyGroupMembership(m); err != nil {
+			return nil, err
+		}
+		// Group attestations are only valid in a time window, so the time
+		// they are checked against must be signed
+		if !att.TimestampSigned {
+			return nil, fmt.Errorf("group attestation has no signed timestamp")
+		}
+		if att.Timestamp.Before(m.Since) {
+			return nil, fmt.Errorf("attestation predates membership")
+		}
+		id = m.MemberID
+	}
+
+	pubBytes, err := hex.DecodeString(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid witness ID: %w", err)
+	}
+	if len(pubBytes) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key length")
+	}
+	return ed25519.PublicKey(pubBytes), nil
+}
+
+func membershipPayload(m *GroupMembership) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := writeString(&buf, "claim-graph/group-membership"); err != nil {
+		return nil, err
+	}
+	if err := writeString(&buf, m.GroupID); err != nil {
+		return nil, err
+	}
+	if err := writeString(&buf, m.MemberID); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&buf, binary.BigEndian, m.Since.UnixNano()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RegisterGroup makes the store honor a group's revocations: attestations
+// made for the group by a member after it was revoked fail the store's
+// VerifyAttestation and no longer count toward confidence. Revocations
+// made on g after it is registered take effect at once.
+func (rs *ReputationStore) RegisterGroup(g *WitnessGroup) {
+	rs.mu.Lock()
+	if rs.groups == nil {
+		rs.groups = make(map[string]*WitnessGroup)
+	}
+	rs.groups[g.ID] = g
+	rs.mu.Unlock()
+
+	rs.notify("")
+}
+
+// VerifyAttestation checks an attestation as the package-level
+// VerifyAttestation does and, if it was made for a registered group, that
+// its member had not been revoked when the store first received it. The
+// store records, by its clock, when it first verifies each group
+// attestation; that receipt time, which the member cannot choose, is what
+// revocations are checked against.
+func (rs *ReputationStore) VerifyAttestation(claim *Claim, att *Attestation) error {
+	if err := VerifyAttestation(claim, att); err != nil {
+		return err
+	}
+	if att.Membership == nil {
+		return nil
+	}
+
+	receivedAt := rs.receive(att)
+	if g := rs.group(att.WitnessID); g != nil {
+		return g.checkRevoked(att, receivedAt)
+	}
+	return nil
+}
+
+// receive records when the store first received a group attestation and
+// returns that time
+func (rs *ReputationStore) receive(att *Attestation) time.Time {
+	key := string(att.Signature)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if at, ok := rs.received[key]; ok {
+		return at
+	}
+	if rs.received == nil {
+		rs.received = make(map[string]time.Time)
+	}
+	at := rs.now().UTC()
+	rs.received[key] = at
+	return at
+}
+
+// receivedAt returns when the store first received a group attestation,
+// or the store's current time if it never has
+func (rs *ReputationStore) receivedAt(att *Attestation) time.Time {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	if at, ok := rs.received[string(att.Signature)]; ok {
+		return at
+	}
+	return rs.now()
+}
+
+// group returns a registered group, or nil
+func (rs *ReputationStore) group(id string) *WitnessGroup {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.groups[id]
+}
+
+// revokedMember reports whether an attestation was made for a registered
+// group by a member revoked by the time the store received it
+func (rs *ReputationStore) revokedMember(att *Attestation) bool {
+	if att.Membership == nil {
+		return false
+	}
+	g := rs.group(att.WitnessID)
+	if g == nil {
+		return false
+	}
+	return g.checkRevoked(att, rs.receivedAt(att)) != nil
+}
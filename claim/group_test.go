@@ -0,0 +1,200 @@
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWitnessGroup(t *testing.T) {
+	c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+
+	group, err := NewWitnessGroup()
+	require.NoError(t, err)
+	alice := DeterministicWitness("alice")
+	bob := DeterministicWitness("bob")
+
+	aliceMembership, err := group.AddMember(alice.ID)
+	require.NoError(t, err)
+	bobMembership, err := group.AddMember(bob.ID)
+	require.NoError(t, err)
+
+	t.Run("member attests as the group", func(t *testing.T) {
+		att, err := alice.AttestAsGroup(c, aliceMembership)
+		require.NoError(t, err)
+		assert.Equal(t, group.ID, att.WitnessID)
+		require.NoError(t, VerifyAttestation(c, att))
+		require.NoError(t, group.VerifyAttestation(c, att))
+
+		// Reputation accrues to the group, not the member
+		rs := NewReputationStore()
+		rs.RecordAttestation(att.WitnessID, c.Statement.Domain)
+		_, exists := rs.GetRecord(group.ID)
+		assert.True(t, exists)
+		_, exists = rs.GetRecord(alice.ID)
+		assert.False(t, exists)
+	})
+
+	t.Run("membership is bound to its member and group", func(t *testing.T) {
+		_, err := bob.AttestAsGroup(c, aliceMembership)
+		assert.Error(t, err)
+
+		att, err := alice.AttestAsGroup(c, aliceMembership)
+		require.NoError(t, err)
+
+		// Another group's ID does not match the membership
+		other, err := NewWitnessGroup()
+		require.NoError(t, err)
+		relabeled := *att
+		relabeled.WitnessID = other.ID
+		assert.Error(t, VerifyAttestation(c, &relabeled))
+
+		// Nor can the signature be passed off as the member's own
+		relabeled = *att
+		relabeled.WitnessID = alice.ID
+		relabeled.Membership = nil
+		assert.Error(t, VerifyAttestation(c, &relabeled))
+	})
+
+	t.Run("forged membership", func(t *testing.T) {
+		mallory := DeterministicWitness("mallory")
+		forged := &GroupMembership{GroupID: group.ID, MemberID: mallory.ID, Since: time.Now().UTC()}
+		att, err := mallory.AttestAsGroup(c, forged)
+		require.NoError(t, err)
+		assert.ErrorContains(t, VerifyAttestation(c, att), "membership signature")
+	})
+
+	t.Run("revoked member", func(t *testing.T) {
+		before, err := bob.AttestAsGroup(c, bobMembership)
+		require.NoError(t, err)
+		receivedBefore := time.Now()
+
+		group.RevokeMember(bob.ID, time.Now())
+		after, err := bob.AttestAsGroup(c, bobMembership)
+		require.NoError(t, err)
+
+		// Attestations received earlier still count, later ones do not
+		assert.NoError(t, group.VerifyAttestationAt(c, before, receivedBefore))
+		assert.ErrorContains(t, group.VerifyAttestation(c, after), "revoked")
+
+		// Without a record of receiving it earlier, an attestation is
+		// taken as received now
+		assert.ErrorContains(t, group.VerifyAttestation(c, before), "revoked")
+
+		// Other members are unaffected
+		att, err := alice.AttestAsGroup(c, aliceMembership)
+		require.NoError(t, err)
+		assert.NoError(t, group.VerifyAttestation(c, att))
+	})
+
+	t.Run("revocation time is signed", func(t *testing.T) {
+		carol := DeterministicWitness("carol")
+		membership, err := group.AddMember(carol.ID)
+		require.NoError(t, err)
+
+		revokedAt := time.Now()
+		carol.Clock = FixedClock(revokedAt.Add(time.Minute))
+		after, err := carol.AttestAsGroup(c, membership)
+		require.NoError(t, err)
+		group.RevokeMember(carol.ID, revokedAt)
+		assert.ErrorContains(t, group.VerifyAttestation(c, after), "revoked")
+
+		// Backdating the attestation breaks its signature
+		backdated := *after
+		backdated.Timestamp = revokedAt.Add(-time.Hour)
+		assert.Error(t, group.VerifyAttestation(c, &backdated))
+
+		// And a group attestation without a signed time never verifies
+		backdated.TimestampSigned = false
+		assert.Error(t, VerifyAttestation(c, &backdated))
+	})
+
+	t.Run("revoked member cannot backdate its own signed time", func(t *testing.T) {
+		erin := DeterministicWitness("erin")
+		membership, err := group.AddMember(erin.ID)
+		require.NoError(t, err)
+		claimed, err := NewClaim(Statement{Subject: "match-3", Predicate: "result", Object: "1-1", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+
+		// An hour after joining the member is revoked; an hour after that
+		// its compromised key signs a timestamp from before the revocation
+		revokedAt := membership.Since.Add(time.Hour)
+		now := revokedAt.Add(time.Hour)
+		group.RevokeMember(erin.ID, revokedAt)
+		rs := NewReputationStore()
+		rs.SetClock(FixedClock(now))
+		rs.RegisterGroup(group)
+
+		erin.Clock = FixedClock(revokedAt.Add(-time.Minute))
+		backdated, err := erin.AttestAsGroup(claimed, membership)
+		require.NoError(t, err)
+		require.NoError(t, VerifyAttestation(claimed, backdated), "the signature itself is valid")
+
+		assert.ErrorContains(t, group.VerifyAttestationAt(claimed, backdated, now), "revoked")
+		assert.ErrorContains(t, rs.VerifyAttestation(claimed, backdated), "revoked")
+		assert.Error(t, claimed.AddAttestationWith(backdated, AttestOptions{Reputation: rs}))
+
+		claimed.Witnesses = append(claimed.Witnesses, *backdated)
+		assert.Zero(t, ClaimConfidence(claimed, rs))
+	})
+
+	t.Run("store receipt time outlives revocation", func(t *testing.T) {
+		frank := DeterministicWitness("frank")
+		membership, err := group.AddMember(frank.ID)
+		require.NoError(t, err)
+		claimed, err := NewClaim(Statement{Subject: "match-4", Predicate: "result", Object: "3-0", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		att, err := frank.AttestAsGroup(claimed, membership)
+		require.NoError(t, err)
+
+		rs := NewReputationStore()
+		rs.RegisterGroup(group)
+		require.NoError(t, claimed.AddAttestationWith(att, AttestOptions{Reputation: rs}))
+
+		group.RevokeMember(frank.ID, time.Now().Add(time.Second))
+		rs.SetClock(FixedClock(time.Now().Add(time.Hour)))
+		assert.NoError(t, rs.VerifyAttestation(claimed, att), "received before the revocation")
+		assert.Positive(t, ClaimConfidence(claimed, rs))
+	})
+
+	t.Run("registered groups' revocations are honored", func(t *testing.T) {
+		dave := DeterministicWitness("dave")
+		membership, err := group.AddMember(dave.ID)
+		require.NoError(t, err)
+		claimed, err := NewClaim(Statement{Subject: "match-2", Predicate: "result", Object: "0-0", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		att, err := dave.AttestAsGroup(claimed, membership)
+		require.NoError(t, err)
+
+		rs := NewReputationStore()
+		require.NoError(t, claimed.AddAttestationWith(att, AttestOptions{Reputation: rs}))
+		before := ClaimConfidence(claimed, rs)
+		assert.Positive(t, before)
+
+		rs.RegisterGroup(group)
+		group.RevokeMember(dave.ID, att.Timestamp.Add(-time.Second))
+		assert.ErrorContains(t, rs.VerifyAttestation(claimed, att), "revoked")
+		assert.Zero(t, ClaimConfidence(claimed, rs), "revoked members' attestations stop counting")
+
+		fresh := *claimed
+		fresh.Witnesses = nil
+		assert.Error(t, fresh.AddAttestationWith(att, AttestOptions{Reputation: rs}))
+
+		// Stores that do not know the group still accept it
+		assert.NoError(t, VerifyAttestation(claimed, att))
+	})
+
+	t.Run("verifier without the group key", func(t *testing.T) {
+		verifier, err := WitnessGroupFromID(group.ID)
+		require.NoError(t, err)
+		_, err = verifier.AddMember(alice.ID)
+		assert.Error(t, err)
+
+		att, err := alice.AttestAsGroup(c, aliceMembership)
+		require.NoError(t, err)
+		assert.NoError(t, verifier.VerifyAttestation(c, att))
+	})
+}
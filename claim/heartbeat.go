@@ -0,0 +1,65 @@
+package claim
+
+import (
+	"fmt"
+	"time"
+)
+
+// Heartbeat claims are published by witnesses to announce they are online
+const (
+	// HeartbeatDomain is the domain of heartbeat claims, so they can be
+	// filtered out of listings
+	HeartbeatDomain = "heartbeat"
+
+	// HeartbeatPredicate is the predicate of heartbeat claims
+	HeartbeatPredicate = "alive"
+)
+
+// Heartbeat creates a claim that the witness is alive now, attested by the
+// witness itself. Witnesses publish heartbeats periodically so others can
+// tell which of them are online.
+func (w *Witness) Heartbeat() (*Claim, error) {
+	c, err := NewClaim(Statement{
+		Subject:   w.ID,
+		Predicate: HeartbeatPredicate,
+		Object:    time.Now().UTC().Format(time.RFC3339Nano),
+		Domain:    HeartbeatDomain,
+	}, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	att, err := w.Attest(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.AddAttestation(att); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// HeartbeatTime returns the witness and time of a heartbeat claim. It fails
+// unless the claim is a heartbeat endorsed by the witness it names.
+func HeartbeatTime(c *Claim) (string, time.Time, error) {
+	s := c.Statement
+	if s.Domain != HeartbeatDomain || s.Predicate != HeartbeatPredicate {
+		return "", time.Time{}, fmt.Errorf("not a heartbeat claim")
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, s.Object)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid heartbeat time: %w", err)
+	}
+
+	if err := VerifyCID(c); err != nil {
+		return "", time.Time{}, err
+	}
+	for i := range c.Witnesses {
+		att := &c.Witnesses[i]
+		if att.WitnessID == s.Subject && att.Stance == StanceEndorse && verifySignedAttestation(c, att) == nil {
+			return s.Subject, at, nil
+		}
+	}
+	return "", time.Time{}, fmt.Errorf("heartbeat is not attested by witness %s", s.Subject)
+}
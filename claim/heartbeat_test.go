@@ -0,0 +1,44 @@
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeat(t *testing.T) {
+	w := DeterministicWitness("alice")
+
+	c, err := w.Heartbeat()
+	require.NoError(t, err)
+	assert.Equal(t, w.ID, c.Statement.Subject)
+	assert.Equal(t, HeartbeatPredicate, c.Statement.Predicate)
+	require.Len(t, c.Witnesses, 1)
+	assert.NoError(t, VerifyAttestation(c, &c.Witnesses[0]))
+
+	id, at, err := HeartbeatTime(c)
+	require.NoError(t, err)
+	assert.Equal(t, w.ID, id)
+	assert.WithinDuration(t, time.Now(), at, time.Minute)
+
+	t.Run("must be attested by its subject", func(t *testing.T) {
+		forged, err := NewClaim(c.Statement, nil, "")
+		require.NoError(t, err)
+		mallory := DeterministicWitness("mallory")
+		att, err := mallory.Attest(forged)
+		require.NoError(t, err)
+		require.NoError(t, forged.AddAttestation(att))
+
+		_, _, err = HeartbeatTime(forged)
+		assert.Error(t, err)
+	})
+
+	t.Run("other claims are not heartbeats", func(t *testing.T) {
+		other, err := NewClaim(Statement{Subject: w.ID, Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		_, _, err = HeartbeatTime(other)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,116 @@
+package claim
+
+import "fmt"
+
+// State is a claim's position in its managed lifecycle. State is an
+// annotation on top of the immutable claim content and is not part of the
+// CID.
+type State int
+
+const (
+	// StateDraft is a claim still being prepared (the default)
+	StateDraft State = iota
+
+	// StatePublished is a claim open for attestation
+	StatePublished
+
+	// StateAttested is a claim with at least one endorsement
+	StateAttested
+
+	// StateDisputed is a claim under dispute
+	StateDisputed
+
+	// StateResolved is a disputed claim settled by arbiters (terminal)
+	StateResolved
+
+	// StateRevoked is a claim withdrawn by its issuer (terminal)
+	StateRevoked
+)
+
+var stateNames = map[State]string{
+	StateDraft:     "draft",
+	StatePublished: "published",
+	StateAttested:  "attested",
+	StateDisputed:  "disputed",
+	StateResolved:  "resolved",
+	StateRevoked:   "revoked",
+}
+
+// transitions lists the states each state may move to
+var transitions = map[State][]State{
+	StateDraft:     {StatePublished, StateRevoked},
+	StatePublished: {StateAttested, StateDisputed, StateRevoked},
+	StateAttested:  {StateDisputed, StateRevoked},
+	StateDisputed:  {StateResolved, StateRevoked},
+}
+
+// String returns the name of the state
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s State) MarshalText() ([]byte, error) {
+	if name, ok := stateNames[s]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown state %d", int(s))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *State) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = StateDraft
+		return nil
+	}
+	for state, name := range stateNames {
+		if name == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown state %q", string(text))
+}
+
+// IsTerminal reports whether no further transitions are allowed
+func (s State) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// CanTransition reports whether a claim may move from s to next
+func (s State) CanTransition(next State) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError is returned when a lifecycle transition is not allowed
+type TransitionError struct {
+	ClaimID string
+	From    State
+	To      State
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("claim %s cannot transition from %s to %s", e.ClaimID, e.From, e.To)
+}
+
+// Transition moves a claim to a new lifecycle state, returning a
+// *TransitionError if the move is not allowed from its current state
+func Transition(c *Claim, next State) error {
+	if c == nil {
+		return fmt.Errorf("claim cannot be nil")
+	}
+	if !c.State.CanTransition(next) {
+		return &TransitionError{ClaimID: c.ID, From: c.State, To: next}
+	}
+
+	c.State = next
+	return nil
+}
@@ -0,0 +1,75 @@
+package claim
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransition(t *testing.T) {
+	newClaim := func(t *testing.T) *Claim {
+		c, err := NewClaim(Statement{Subject: "test"}, nil, "")
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("full lifecycle", func(t *testing.T) {
+		c := newClaim(t)
+		cid := c.ID
+		assert.Equal(t, StateDraft, c.State)
+
+		for _, next := range []State{StatePublished, StateAttested, StateDisputed, StateResolved} {
+			require.NoError(t, Transition(c, next))
+			assert.Equal(t, next, c.State)
+		}
+		assert.True(t, c.State.IsTerminal())
+		assert.Equal(t, cid, c.ID)
+		assert.NoError(t, VerifyCID(c), "state is not part of the CID")
+	})
+
+	t.Run("revocation", func(t *testing.T) {
+		for _, from := range []State{StateDraft, StatePublished, StateAttested, StateDisputed} {
+			c := newClaim(t)
+			c.State = from
+			assert.NoError(t, Transition(c, StateRevoked), from.String())
+		}
+	})
+
+	t.Run("invalid transitions", func(t *testing.T) {
+		cases := []struct{ from, to State }{
+			{StateDraft, StateAttested},
+			{StateDraft, StateResolved},
+			{StatePublished, StateDraft},
+			{StateAttested, StatePublished},
+			{StateAttested, StateResolved},
+			{StateResolved, StateDisputed},
+			{StateRevoked, StatePublished},
+			{StatePublished, StatePublished},
+		}
+		for _, tc := range cases {
+			c := newClaim(t)
+			c.State = tc.from
+
+			err := Transition(c, tc.to)
+			var terr *TransitionError
+			require.True(t, errors.As(err, &terr), "%s -> %s", tc.from, tc.to)
+			assert.Equal(t, tc.from, terr.From)
+			assert.Equal(t, tc.to, terr.To)
+			assert.Equal(t, tc.from, c.State, "state unchanged on error")
+		}
+	})
+
+	t.Run("text encoding", func(t *testing.T) {
+		data, err := json.Marshal(StateDisputed)
+		require.NoError(t, err)
+		assert.Equal(t, `"disputed"`, string(data))
+
+		var s State
+		require.NoError(t, json.Unmarshal(data, &s))
+		assert.Equal(t, StateDisputed, s)
+		assert.Error(t, json.Unmarshal([]byte(`"archived"`), &s))
+	})
+}
@@ -0,0 +1,118 @@
+package claim
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// ObjectList is a claim object with several values, such as the teams in
+// a match. By default the values are a set: they are sorted when hashing,
+// so listing them in another order gives the same CID. An ordered list
+// keeps its order in the CID instead.
+type ObjectList struct {
+	// Values are the object's elements
+	Values []string
+
+	// Ordered makes the order of Values part of the claim's identity
+	Ordered bool
+}
+
+// NewObjectSet returns an unordered object list of the given values
+func NewObjectSet(values ...string) (ObjectList, error) {
+	l := ObjectList{Values: append([]string(nil), values...)}
+	if err := l.validate(); err != nil {
+		return ObjectList{}, err
+	}
+	return l, nil
+}
+
+// NewObjectSequence returns an ordered object list of the given values
+func NewObjectSequence(values ...string) (ObjectList, error) {
+	l := ObjectList{Values: append([]string(nil), values...), Ordered: true}
+	if err := l.validate(); err != nil {
+		return ObjectList{}, err
+	}
+	return l, nil
+}
+
+// Canonical returns the values as they are hashed: sorted, unless the list
+// is ordered
+func (l ObjectList) Canonical() []string {
+	values := append([]string(nil), l.Values...)
+	if !l.Ordered {
+		sort.Strings(values)
+	}
+	return values
+}
+
+// Contains reports whether value is one of the list's elements
+func (l ObjectList) Contains(value string) bool {
+	for _, v := range l.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of elements
+func (l ObjectList) Len() int {
+	return len(l.Values)
+}
+
+func (l ObjectList) validate() error {
+	if len(l.Values) == 0 {
+		return fmt.Errorf("object list cannot be empty")
+	}
+	if l.Ordered {
+		return nil
+	}
+
+	// A set lists each value once
+	values := l.Canonical()
+	for i := 1; i < len(values); i++ {
+		if values[i] == values[i-1] {
+			return fmt.Errorf("duplicate object %q", values[i])
+		}
+	}
+	return nil
+}
+
+// canonicalField returns the tag and value an object list is hashed as
+// (see CanonicalBytes)
+func (l ObjectList) canonicalField() (string, string, error) {
+	var buf bytes.Buffer
+	for _, v := range l.Canonical() {
+		if err := writeString(&buf, v); err != nil {
+			return "", "", err
+		}
+	}
+
+	tag := "object-set"
+	if l.Ordered {
+		tag = "object-sequence"
+	}
+	return tag, buf.String(), nil
+}
+
+// ObjectValues returns the claim's object values: the elements of its
+// object list, or its scalar object as the only element (none if empty)
+func (c *Claim) ObjectValues() []string {
+	if c.Objects != nil {
+		return append([]string(nil), c.Objects.Values...)
+	}
+	if c.Statement.Object == "" {
+		return nil
+	}
+	return []string{c.Statement.Object}
+}
+
+// HasObject reports whether value is the claim's scalar object or an
+// element of its object list
+func (c *Claim) HasObject(value string) bool {
+	if c.Objects != nil {
+		return c.Objects.Contains(value)
+	}
+	return c.Statement.Object == value
+}
@@ -0,0 +1,94 @@
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectList(t *testing.T) {
+	statement := Statement{Subject: "match-1", Predicate: "teams", Domain: "sports"}
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cidOf := func(l *ObjectList) string {
+		c := &Claim{Statement: statement, Objects: l, Created: created}
+		cid, err := ComputeCID(c)
+		require.NoError(t, err)
+		return cid
+	}
+
+	t.Run("set CID ignores order", func(t *testing.T) {
+		ab, err := NewObjectSet("A", "B")
+		require.NoError(t, err)
+		ba, err := NewObjectSet("B", "A")
+		require.NoError(t, err)
+
+		assert.Equal(t, cidOf(&ab), cidOf(&ba))
+		assert.Equal(t, cidOf(&ab), cidOf(&ab), "CID is deterministic")
+		assert.NotEqual(t, cidOf(nil), cidOf(&ab))
+	})
+
+	t.Run("sequence CID keeps order", func(t *testing.T) {
+		ab, err := NewObjectSequence("A", "B")
+		require.NoError(t, err)
+		ba, err := NewObjectSequence("B", "A")
+		require.NoError(t, err)
+		set, err := NewObjectSet("A", "B")
+		require.NoError(t, err)
+
+		assert.NotEqual(t, cidOf(&ab), cidOf(&ba))
+		assert.NotEqual(t, cidOf(&ab), cidOf(&set))
+	})
+
+	t.Run("elements are delimited", func(t *testing.T) {
+		joined, err := NewObjectSet("AB")
+		require.NoError(t, err)
+		split, err := NewObjectSet("A", "B")
+		require.NoError(t, err)
+		assert.NotEqual(t, cidOf(&joined), cidOf(&split))
+	})
+
+	t.Run("accessors", func(t *testing.T) {
+		l, err := NewObjectSet("B", "A")
+		require.NoError(t, err)
+		c, err := NewClaim(statement, nil, "", WithObjects(l))
+		require.NoError(t, err)
+		require.NoError(t, VerifyCID(c))
+
+		assert.Equal(t, []string{"B", "A"}, c.ObjectValues())
+		assert.Equal(t, []string{"A", "B"}, c.Objects.Canonical())
+		assert.Equal(t, 2, c.Objects.Len())
+		assert.True(t, c.HasObject("A"))
+		assert.False(t, c.HasObject("C"))
+
+		scalar, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1"}, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2-1"}, scalar.ObjectValues())
+		assert.True(t, scalar.HasObject("2-1"))
+	})
+
+	t.Run("invalid lists rejected", func(t *testing.T) {
+		_, err := NewObjectSet()
+		assert.Error(t, err)
+		_, err = NewObjectSet("A", "A")
+		assert.Error(t, err)
+
+		// Sequences may repeat values
+		_, err = NewObjectSequence("A", "A")
+		assert.NoError(t, err)
+
+		l, err := NewObjectSet("A", "B")
+		require.NoError(t, err)
+		_, err = NewClaim(Statement{Subject: "match-1", Object: "A"}, nil, "", WithObjects(l))
+		assert.Error(t, err)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		ab, _ := NewObjectSet("A", "B")
+		ac, _ := NewObjectSequence("A", "C")
+		changes := Diff(&Claim{Objects: &ab}, &Claim{Objects: &ac})
+		assert.Equal(t, []Change{{Field: "objects", Old: "{A,B}", New: "[A,C]"}}, changes)
+	})
+}
@@ -0,0 +1,110 @@
+package claim
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WitnessPool distributes attestation work across several local witnesses,
+// e.g. a service operating many oracle keys.
+type WitnessPool struct {
+	mu        sync.Mutex
+	witnesses []*Witness
+	next      int
+	load      map[string]int
+}
+
+// NewWitnessPool creates a pool from witnesses that hold private keys
+func NewWitnessPool(witnesses ...*Witness) (*WitnessPool, error) {
+	if len(witnesses) == 0 {
+		return nil, fmt.Errorf("pool requires at least one witness")
+	}
+
+	seen := make(map[string]bool)
+	for _, w := range witnesses {
+		if w == nil || !w.CanSign() {
+			return nil, fmt.Errorf("pool witnesses must have private keys")
+		}
+		if seen[w.ID] {
+			return nil, fmt.Errorf("duplicate witness %s", w.ID)
+		}
+		seen[w.ID] = true
+	}
+
+	return &WitnessPool{
+		witnesses: append([]*Witness(nil), witnesses...),
+		load:      make(map[string]int),
+	}, nil
+}
+
+// Size returns the number of witnesses in the pool
+func (p *WitnessPool) Size() int {
+	return len(p.witnesses)
+}
+
+// AttestRoundRobin attests with the next witness in rotation, spreading
+// load evenly regardless of which witnesses already attested the claim.
+func (p *WitnessPool) AttestRoundRobin(c *Claim) (*Attestation, error) {
+	p.mu.Lock()
+	w := p.witnesses[p.next]
+	p.next = (p.next + 1) % len(p.witnesses)
+	p.mu.Unlock()
+
+	return p.attest(w, c)
+}
+
+// AttestWithLeast attests with the least-loaded witness that has not yet
+// attested the claim, maximizing witness diversity on each claim.
+func (p *WitnessPool) AttestWithLeast(c *Claim) (*Attestation, error) {
+	if c == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+
+	attested := make(map[string]bool)
+	for _, att := range c.Witnesses {
+		attested[att.WitnessID] = true
+	}
+
+	p.mu.Lock()
+	var chosen *Witness
+	for _, w := range p.witnesses {
+		if attested[w.ID] {
+			continue
+		}
+		if chosen == nil || p.load[w.ID] < p.load[chosen.ID] {
+			chosen = w
+		}
+	}
+	p.mu.Unlock()
+
+	if chosen == nil {
+		return nil, fmt.Errorf("every pool witness has already attested claim %s", c.ID)
+	}
+
+	return p.attest(chosen, c)
+}
+
+// Load returns the number of attestations made by each pool witness
+func (p *WitnessPool) Load() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	load := make(map[string]int, len(p.witnesses))
+	for _, w := range p.witnesses {
+		load[w.ID] = p.load[w.ID]
+	}
+	return load
+}
+
+func (p *WitnessPool) attest(w *Witness, c *Claim) (*Attestation, error) {
+	att, err := w.Attest(c)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.load[w.ID]++
+	p.mu.Unlock()
+
+	return att, nil
+}
@@ -0,0 +1,82 @@
+package claim
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPool(t *testing.T, n int) *WitnessPool {
+	t.Helper()
+
+	witnesses := make([]*Witness, n)
+	for i := range witnesses {
+		w, err := GenerateWitness()
+		require.NoError(t, err)
+		witnesses[i] = w
+	}
+
+	pool, err := NewWitnessPool(witnesses...)
+	require.NoError(t, err)
+	return pool
+}
+
+func TestWitnessPoolRoundRobin(t *testing.T) {
+	pool := newTestPool(t, 3)
+
+	for i := 0; i < 9; i++ {
+		c, _ := NewClaim(Statement{Subject: fmt.Sprintf("claim-%d", i)}, nil, "")
+		att, err := pool.AttestRoundRobin(c)
+		require.NoError(t, err)
+		assert.NoError(t, VerifyAttestation(c, att))
+	}
+
+	for id, n := range pool.Load() {
+		assert.Equal(t, 3, n, "witness %s", id)
+	}
+}
+
+func TestWitnessPoolWithLeast(t *testing.T) {
+	pool := newTestPool(t, 3)
+	c, _ := NewClaim(Statement{Subject: "diverse"}, nil, "")
+
+	for i := 0; i < 3; i++ {
+		att, err := pool.AttestWithLeast(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att), "diversity mode must not pick a duplicate witness")
+	}
+
+	t.Run("pool exhausted for claim", func(t *testing.T) {
+		_, err := pool.AttestWithLeast(c)
+		assert.Error(t, err)
+	})
+
+	t.Run("prefers least-loaded witness", func(t *testing.T) {
+		pool := newTestPool(t, 2)
+
+		busy, _ := NewClaim(Statement{Subject: "busy"}, nil, "")
+		first, err := pool.AttestWithLeast(busy)
+		require.NoError(t, err)
+
+		other, _ := NewClaim(Statement{Subject: "other"}, nil, "")
+		second, err := pool.AttestWithLeast(other)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first.WitnessID, second.WitnessID)
+	})
+}
+
+func TestNewWitnessPoolValidation(t *testing.T) {
+	_, err := NewWitnessPool()
+	assert.Error(t, err)
+
+	w, _ := GenerateWitness()
+	_, err = NewWitnessPool(w, w)
+	assert.Error(t, err)
+
+	public, _ := WitnessFromID(w.ID)
+	_, err = NewWitnessPool(public)
+	assert.Error(t, err)
+}
@@ -0,0 +1,208 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WitnessProfile is a witness's signed self-description of who runs it
+// and where. Profiles let confidence scoring discount witnesses that share
+// an operator or network, since they are less likely to be independent.
+type WitnessProfile struct {
+	// WitnessID is the witness the profile describes
+	WitnessID string
+
+	// Operator identifies the organization running the witness
+	Operator string
+
+	// ASN is the autonomous system number the witness runs in (0 if unknown)
+	ASN uint32
+
+	// Region is the witness's geographic region (e.g. "eu-west")
+	Region string
+
+	// Timestamp is when the profile was signed
+	Timestamp time.Time
+
+	// Signature is the witness's signature over the profile
+	Signature []byte
+}
+
+// Similarity weights between two witnesses' profiles. Witnesses sharing
+// an operator are treated as one; a shared network or region only
+// partially overlaps.
+const (
+	operatorSimilarity = 1.0
+	asnSimilarity      = 0.5
+	regionSimilarity   = 0.25
+)
+
+// SignProfile creates a signed profile for this witness
+func (w *Witness) SignProfile(operator string, asn uint32, region string) (*WitnessProfile, error) {
+	if !w.CanSign() {
+		return nil, fmt.Errorf("witness has no private key")
+	}
+
+	p := &WitnessProfile{
+		WitnessID: w.ID,
+		Operator:  operator,
+		ASN:       asn,
+		Region:    region,
+		Timestamp: time.Now().UTC(),
+	}
+
+	payload, err := profilePayload(p)
+	if err != nil {
+		return nil, err
+	}
+	p.Signature, err = w.signPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// VerifyProfile checks that a profile was signed by its witness
+func VerifyProfile(p *WitnessProfile) error {
+	if p == nil {
+		return fmt.Errorf("profile cannot be nil")
+	}
+
+	w, err := WitnessFromID(p.WitnessID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := profilePayload(p)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(w.PublicKey, payload, p.Signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+func profilePayload(p *WitnessProfile) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, s := range []string{"claim-graph/witness-profile", p.WitnessID, p.Operator, p.Region} {
+		if err := writeString(&buf, s); err != nil {
+			return nil, err
+		}
+	}
+	if err := binary.Write(&buf, binary.BigEndian, p.ASN); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&buf, binary.BigEndian, p.Timestamp.UnixNano()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// similarity returns how much two profiles overlap, from 0 (independent)
+// to 1 (same operator)
+func (p *WitnessProfile) similarity(other *WitnessProfile) float64 {
+	switch {
+	case p.Operator != "" && p.Operator == other.Operator:
+		return operatorSimilarity
+	case p.ASN != 0 && p.ASN == other.ASN:
+		return asnSimilarity
+	case p.Region != "" && p.Region == other.Region:
+		return regionSimilarity
+	default:
+		return 0
+	}
+}
+
+// SetProfile records a verified witness profile, replacing any older one
+func (rs *ReputationStore) SetProfile(p *WitnessProfile) error {
+	if err := VerifyProfile(p); err != nil {
+		return err
+	}
+
+	rs.mu.Lock()
+	if existing, ok := rs.profiles[p.WitnessID]; ok && !p.Timestamp.After(existing.Timestamp) {
+		rs.mu.Unlock()
+		return fmt.Errorf("profile for witness %s is not newer than the current one", p.WitnessID)
+	}
+	copy := *p
+	rs.profiles[p.WitnessID] = &copy
+	rs.mu.Unlock()
+
+	// The operator bears on the independence of claims the witness attested
+	rs.notify(p.WitnessID)
+	return nil
+}
+
+// Profile returns the recorded profile for a witness
+func (rs *ReputationStore) Profile(witnessID string) (*WitnessProfile, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	p, ok := rs.profiles[witnessID]
+	if !ok {
+		return nil, false
+	}
+	copy := *p
+	return &copy, true
+}
+
+// Profiles returns every recorded profile, ordered by witness ID
+func (rs *ReputationStore) Profiles() []*WitnessProfile {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	profiles := make([]*WitnessProfile, 0, len(rs.profiles))
+	for _, p := range rs.profiles {
+		copy := *p
+		profiles = append(profiles, &copy)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].WitnessID < profiles[j].WitnessID })
+	return profiles
+}
+
+// independenceShares returns, for each witness, the share of a fully
+// independent vote it carries: 1 divided by its total similarity to the
+// group (itself included). Three witnesses under one operator get 1/3
+// each, so together they count as one. Witnesses without a profile are
+// treated as independent.
+func (rs *ReputationStore) independenceShares(witnessIDs []string) []float64 {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	shares := make([]float64, len(witnessIDs))
+	for i, id := range witnessIDs {
+		total := 1.0
+		p, ok := rs.profiles[id]
+		for j, other := range witnessIDs {
+			if i == j || !ok {
+				continue
+			}
+			if q, ok := rs.profiles[other]; ok {
+				total += p.similarity(q)
+			}
+		}
+		shares[i] = 1 / total
+	}
+	return shares
+}
+
+// WitnessDiversity returns the effective number of independent witnesses
+// among the given ones. It equals the witness count when no profiles
+// overlap and shrinks as witnesses share operators, networks or regions.
+func WitnessDiversity(witnessIDs []string, store *ReputationStore) float64 {
+	var effective float64
+	for _, share := range store.independenceShares(witnessIDs) {
+		effective += share
+	}
+	return effective
+}
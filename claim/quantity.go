@@ -0,0 +1,122 @@
+package claim
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Quantity is a numeric claim object with a unit, such as "2.5 goals" or
+// "100 USD". Units are compared case-insensitively, and quantities in
+// different units are never compared.
+type Quantity struct {
+	// Value is the numeric value
+	Value float64
+
+	// Unit is the unit of measure or currency code
+	Unit string
+}
+
+// NewQuantity returns a validated quantity
+func NewQuantity(value float64, unit string) (Quantity, error) {
+	q := Quantity{Value: value, Unit: strings.TrimSpace(unit)}
+	if err := q.validate(); err != nil {
+		return Quantity{}, err
+	}
+	return q, nil
+}
+
+// ParseQuantity parses a quantity written as "<value> <unit>", e.g. "100 USD"
+func ParseQuantity(s string) (Quantity, error) {
+	parts := strings.Fields(s)
+	if len(parts) != 2 {
+		return Quantity{}, fmt.Errorf("invalid quantity %q: want \"<value> <unit>\"", s)
+	}
+
+	value, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return Quantity{}, fmt.Errorf("invalid quantity value %q: %w", parts[0], err)
+	}
+
+	return NewQuantity(value, parts[1])
+}
+
+// String returns the canonical form used in the claim's CID
+func (q Quantity) String() string {
+	value := q.Value
+	if value == 0 {
+		value = 0 // Normalize negative zero
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64) + " " + canonicalUnit(q.Unit)
+}
+
+// SameUnit reports whether two quantities can be compared
+func (q Quantity) SameUnit(other Quantity) bool {
+	return canonicalUnit(q.Unit) == canonicalUnit(other.Unit)
+}
+
+// Compare returns -1, 0 or 1 as q is less than, equal to or greater than
+// other. It fails if the quantities have different units.
+func (q Quantity) Compare(other Quantity) (int, error) {
+	if !q.SameUnit(other) {
+		return 0, fmt.Errorf("cannot compare %s with %s", q.Unit, other.Unit)
+	}
+
+	switch {
+	case q.Value < other.Value:
+		return -1, nil
+	case q.Value > other.Value:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (q Quantity) validate() error {
+	if math.IsNaN(q.Value) || math.IsInf(q.Value, 0) {
+		return fmt.Errorf("quantity value must be finite")
+	}
+	if canonicalUnit(q.Unit) == "" {
+		return fmt.Errorf("quantity unit cannot be empty")
+	}
+	if strings.ContainsAny(q.Unit, " \t\n") {
+		return fmt.Errorf("quantity unit %q cannot contain whitespace", q.Unit)
+	}
+	return nil
+}
+
+func canonicalUnit(unit string) string {
+	return strings.ToLower(strings.TrimSpace(unit))
+}
+
+// QuantityRange matches quantities in a unit between Min and Max
+// (inclusive). Use math.Inf for an open-ended bound.
+type QuantityRange struct {
+	Min  float64
+	Max  float64
+	Unit string
+}
+
+// Validate checks that the range is well-formed
+func (r QuantityRange) Validate() error {
+	if canonicalUnit(r.Unit) == "" {
+		return fmt.Errorf("range unit cannot be empty")
+	}
+	if math.IsNaN(r.Min) || math.IsNaN(r.Max) {
+		return fmt.Errorf("range bounds cannot be NaN")
+	}
+	if r.Min > r.Max {
+		return fmt.Errorf("range min %v is greater than max %v", r.Min, r.Max)
+	}
+	return nil
+}
+
+// Contains reports whether q falls within the range. It fails if q is in
+// a different unit, rather than comparing incompatible values.
+func (r QuantityRange) Contains(q Quantity) (bool, error) {
+	if canonicalUnit(r.Unit) != canonicalUnit(q.Unit) {
+		return false, fmt.Errorf("cannot compare %s with range in %s", q.Unit, r.Unit)
+	}
+	return q.Value >= r.Min && q.Value <= r.Max, nil
+}
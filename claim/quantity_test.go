@@ -0,0 +1,76 @@
+package claim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuantity(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		q, err := ParseQuantity("2.5 goals")
+		require.NoError(t, err)
+		assert.Equal(t, Quantity{Value: 2.5, Unit: "goals"}, q)
+
+		for _, bad := range []string{"", "100", "abc USD", "100 US D", "NaN USD", "Inf USD"} {
+			_, err := ParseQuantity(bad)
+			assert.Error(t, err, bad)
+		}
+	})
+
+	t.Run("canonical form", func(t *testing.T) {
+		a, _ := NewQuantity(100, "USD")
+		b, _ := NewQuantity(100.0, " usd ")
+		assert.Equal(t, "100 usd", a.String())
+		assert.Equal(t, a.String(), b.String())
+	})
+
+	t.Run("cross-unit comparison rejected", func(t *testing.T) {
+		usd, _ := NewQuantity(100, "USD")
+		eur, _ := NewQuantity(90, "EUR")
+
+		_, err := usd.Compare(eur)
+		assert.Error(t, err)
+
+		_, err = QuantityRange{Min: 90, Max: 110, Unit: "USD"}.Contains(eur)
+		assert.Error(t, err)
+
+		cmp, err := usd.Compare(Quantity{Value: 90, Unit: "usd"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, cmp)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		r := QuantityRange{Min: 90, Max: 110, Unit: "USD"}
+		require.NoError(t, r.Validate())
+
+		for value, want := range map[float64]bool{89.99: false, 90: true, 100: true, 110: true, 110.01: false} {
+			ok, err := r.Contains(Quantity{Value: value, Unit: "USD"})
+			require.NoError(t, err)
+			assert.Equal(t, want, ok, value)
+		}
+
+		open := QuantityRange{Min: 100, Max: math.Inf(1), Unit: "USD"}
+		ok, _ := open.Contains(Quantity{Value: 1e9, Unit: "USD"})
+		assert.True(t, ok)
+
+		assert.Error(t, QuantityRange{Min: 110, Max: 90, Unit: "USD"}.Validate())
+		assert.Error(t, QuantityRange{Min: 90, Max: 110}.Validate())
+	})
+
+	t.Run("part of claim identity", func(t *testing.T) {
+		q, _ := NewQuantity(100, "USD")
+		c, err := NewClaim(Statement{Subject: "AAPL", Predicate: "price", Object: "100 USD", Domain: "finance"}, nil, "", WithQuantity(q))
+		require.NoError(t, err)
+		assert.NoError(t, VerifyCID(c))
+
+		tampered := *c
+		tampered.Quantity = &Quantity{Value: 101, Unit: "USD"}
+		assert.Error(t, VerifyCID(&tampered))
+
+		_, err = NewClaim(Statement{Subject: "AAPL"}, nil, "", WithQuantity(Quantity{Value: math.NaN(), Unit: "USD"}))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,111 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Receipt is a node's signed acknowledgement that it accepted and stored a
+// witness's attestation. The witness keeps it as proof of submission.
+type Receipt struct {
+	// ClaimCID is the claim the attestation was added to
+	ClaimCID string
+
+	// WitnessID is the witness whose attestation was accepted
+	WitnessID string
+
+	// AcceptedAt is when the node accepted the attestation
+	AcceptedAt time.Time
+
+	// StateHash is the updated claim's StateHash, committing the receipt
+	// to the attestations stored with the witness's
+	StateHash string
+
+	// NodeID is the hex-encoded public key of the issuing node
+	NodeID string
+
+	// Signature is the node's signature over the receipt
+	Signature []byte
+}
+
+// IssueReceipt creates a receipt, signed with the node's key, for the
+// witness's attestation on the stored claim c
+func IssueReceipt(nodeKey ed25519.PrivateKey, c *Claim, witnessID string) (*Receipt, error) {
+	if len(nodeKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid node key")
+	}
+	if c == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+	attested := false
+	for _, att := range c.Witnesses {
+		attested = attested || att.WitnessID == witnessID
+	}
+	if !attested {
+		return nil, fmt.Errorf("claim has no attestation from witness %s", witnessID)
+	}
+
+	stateHash, err := StateHash(c)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &Receipt{
+		ClaimCID:   c.ID,
+		WitnessID:  witnessID,
+		AcceptedAt: time.Now().UTC(),
+		StateHash:  stateHash,
+		NodeID:     hex.EncodeToString(nodeKey.Public().(ed25519.PublicKey)),
+	}
+
+	payload, err := receiptPayload(receipt)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Signature = ed25519.Sign(nodeKey, payload)
+
+	return receipt, nil
+}
+
+// VerifyReceipt checks that a receipt was signed by the given node key
+func VerifyReceipt(receipt *Receipt, nodePubKey ed25519.PublicKey) error {
+	if receipt == nil {
+		return fmt.Errorf("receipt cannot be nil")
+	}
+	if len(nodePubKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key length: got %d, want %d", len(nodePubKey), ed25519.PublicKeySize)
+	}
+	if receipt.NodeID != hex.EncodeToString(nodePubKey) {
+		return fmt.Errorf("receipt was issued by node %s", receipt.NodeID)
+	}
+
+	payload, err := receiptPayload(receipt)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(nodePubKey, payload, receipt.Signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+func receiptPayload(receipt *Receipt) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, s := range []string{"claim-graph/receipt", receipt.ClaimCID, receipt.WitnessID, receipt.StateHash, receipt.NodeID} {
+		if err := writeString(&buf, s); err != nil {
+			return nil, err
+		}
+	}
+	if err := binary.Write(&buf, binary.BigEndian, receipt.AcceptedAt.UnixNano()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package claim
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceipt(t *testing.T) {
+	nodePub, nodeKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	c, err := NewClaim(Statement{Subject: "match", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	w, _ := GenerateWitness()
+	att, err := w.Attest(c)
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	receipt, err := IssueReceipt(nodeKey, c, w.ID)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyReceipt(receipt, nodePub))
+
+	stateHash, err := StateHash(c)
+	require.NoError(t, err)
+	assert.Equal(t, c.ID, receipt.ClaimCID)
+	assert.Equal(t, stateHash, receipt.StateHash)
+
+	t.Run("tampered fields fail", func(t *testing.T) {
+		for name, tamper := range map[string]func(r *Receipt){
+			"claim":      func(r *Receipt) { r.ClaimCID = "other" },
+			"witness":    func(r *Receipt) { r.WitnessID = "other" },
+			"state":      func(r *Receipt) { r.StateHash = "other" },
+			"acceptedAt": func(r *Receipt) { r.AcceptedAt = r.AcceptedAt.Add(-time.Hour) },
+		} {
+			forged := *receipt
+			tamper(&forged)
+			assert.Error(t, VerifyReceipt(&forged, nodePub), name)
+		}
+	})
+
+	t.Run("wrong node key fails", func(t *testing.T) {
+		otherPub, _, _ := ed25519.GenerateKey(nil)
+		assert.Error(t, VerifyReceipt(receipt, otherPub))
+	})
+
+	t.Run("invalid node key rejected", func(t *testing.T) {
+		_, err := IssueReceipt(nil, c, w.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("witness without an attestation rejected", func(t *testing.T) {
+		other, _ := GenerateWitness()
+		_, err := IssueReceipt(nodeKey, c, other.ID)
+		assert.Error(t, err)
+	})
+}
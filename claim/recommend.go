@@ -0,0 +1,70 @@
+package claim
+
+import (
+	"sort"
+	"time"
+)
+
+// recommendActiveWindow is how recently a witness must have been seen to
+// be recommended
+const recommendActiveWindow = 30 * 24 * time.Hour
+
+// RecommendWitnesses returns up to n witnesses to ask for attestations in
+// a domain, best first. Only witnesses with a history in the domain that
+// have been seen in the last 30 days are recommended, ranked by domain
+// score. Excluded witnesses, such as the producer's own, are left out,
+// along with any witness whose signed profile shares an operator with an
+// excluded one.
+func RecommendWitnesses(domain string, store *ReputationStore, n int, exclude ...string) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	excluded := make(map[string]bool, len(exclude))
+	operators := make(map[string]bool)
+	for _, id := range exclude {
+		excluded[id] = true
+		if p, ok := store.profiles[id]; ok && p.Operator != "" {
+			operators[p.Operator] = true
+		}
+	}
+
+	type candidate struct {
+		id    string
+		score float64
+	}
+	var candidates []candidate
+	cutoff := store.now().Add(-recommendActiveWindow)
+	store.eachRecord(func(record *ReputationRecord) {
+		id := record.WitnessID
+		if excluded[id] || record.LastSeen.Before(cutoff) {
+			return
+		}
+		if d, ok := record.Domains[domain]; !ok || d.TotalClaims == 0 {
+			return
+		}
+		if p, ok := store.profiles[id]; ok && operators[p.Operator] {
+			return
+		}
+		candidates = append(candidates, candidate{id: id, score: store.view(record).DomainScore(domain)})
+	})
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].id < candidates[j].id
+	})
+
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.id
+	}
+	return ids
+}
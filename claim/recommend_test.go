@@ -0,0 +1,54 @@
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecommendWitnesses(t *testing.T) {
+	rs := NewReputationStore()
+
+	// witness records 60 claims in a domain, agreeing with consensus on
+	// the given number of them
+	witness := func(domain string, agreed int) *Witness {
+		w, _ := GenerateWitness()
+		for i := 0; i < 60; i++ {
+			rs.RecordAttestation(w.ID, domain)
+			if i < agreed {
+				rs.RecordAgreement(w.ID, domain)
+			}
+		}
+		return w
+	}
+
+	best := witness("sports", 60)
+	good := witness("sports", 45)
+	poor := witness("sports", 10)
+	witness("finance", 60) // Strong, but in another domain
+
+	stale := witness("sports", 60)
+	rs.shard(stale.ID).records[stale.ID].LastSeen = time.Now().Add(-60 * 24 * time.Hour)
+
+	assert.Equal(t, []string{best.ID, good.ID, poor.ID}, RecommendWitnesses("sports", rs, 5))
+	assert.Equal(t, []string{best.ID, good.ID}, RecommendWitnesses("sports", rs, 2))
+	assert.Empty(t, RecommendWitnesses("sports", rs, 0))
+	assert.Empty(t, RecommendWitnesses("chess", rs, 5))
+
+	t.Run("excluded witnesses", func(t *testing.T) {
+		assert.Equal(t, []string{good.ID, poor.ID}, RecommendWitnesses("sports", rs, 5, best.ID))
+
+		// A witness run by the producer's operator is not independent
+		producer, _ := GenerateWitness()
+		profile, err := producer.SignProfile("acme", 0, "")
+		require.NoError(t, err)
+		require.NoError(t, rs.SetProfile(profile))
+		profile, err = good.SignProfile("acme", 0, "")
+		require.NoError(t, err)
+		require.NoError(t, rs.SetProfile(profile))
+
+		assert.Equal(t, []string{best.ID, poor.ID}, RecommendWitnesses("sports", rs, 5, producer.ID))
+	})
+}
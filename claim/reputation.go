@@ -1,18 +1,87 @@
 package claim
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 )
 
 // ReputationStore tracks witness reputation over time
 type ReputationStore struct {
-	mu      sync.RWMutex
-	records map[string]*ReputationRecord
+	mu sync.RWMutex
+
+	// shards hold the witness records, each under its own lock (see
+	// shard.go); mu guards everything else
+	shards []*recordShard
+
+	// Reset workflow state (see reset.go)
+	admins  map[string]bool
+	pending map[string]*ResetRequest
+	archive map[string][]*ArchivedReputation
+	resets  map[string]time.Time // Newest accepted request per witness
+
+	// Signed witness profiles (see profile.go)
+	profiles map[string]*WitnessProfile
+
+	// Federation roots trusted to endorse imported reputations (see
+	// federation.go)
+	roots []ed25519.PublicKey
+
+	// abstentions sets how abstaining witnesses affect confidence
+	abstentions AbstentionPolicy
+
+	// stakes scales the weight of slashed witnesses (see stake.go)
+	stakes *StakeLedger
+
+	// groups are the witness groups whose revocations are honored (see
+	// RegisterGroup)
+	groups map[string]*WitnessGroup
+
+	// received maps a group attestation's signature to when the store
+	// first verified it, the time revocations are checked against
+	received map[string]time.Time
+
+	// arbiters are the witnesses trusted to settle disputes (see
+	// SetArbiters)
+	arbiters map[string]bool
+
+	// listeners are notified of reputation changes (see OnChange)
+	listeners    map[int]func(witnessID string)
+	thresholds   map[int]thresholdListener      // See OnThreshold
+	attestations map[int]func(AttestationEvent) // See OnAttestation
+	nextListener int
+
+	// clock is the store's time source (nil reads the system clock)
+	clock Clock
 }
 
+// AbstentionPolicy controls how attestations with StanceAbstain affect
+// confidence scores
+type AbstentionPolicy int
+
+const (
+	// IgnoreAbstentions leaves abstentions out of both the numerator and
+	// the denominator, as if the witness had not attested (the default)
+	IgnoreAbstentions AbstentionPolicy = iota
+
+	// CountAbstentions treats abstentions as expressed uncertainty:
+	// confidence is scaled by the weighted share of witnesses that took a
+	// side, so two endorsements and two equally reputable abstentions give
+	// half the confidence of the endorsements alone. Abstentions only ever
+	// lower confidence.
+	CountAbstentions
+)
+
+// Number of claims after which a witness's overall and per-domain scores
+// rest entirely on its record rather than the neutral prior
+const (
+	recordVolume = 100
+	domainVolume = 50
+)
+
 // ReputationRecord tracks a single witness's reputation
 type ReputationRecord struct {
 	// WitnessID is the witness identifier
@@ -35,6 +104,10 @@ type ReputationRecord struct {
 
 	// LastSeen is when this witness was last observed
 	LastSeen time.Time
+
+	// clock is the time source Score measures longevity against (nil
+	// reads the system clock). Records returned by a store use its clock.
+	clock Clock
 }
 
 // DomainReputation tracks reputation in a specific domain
@@ -47,48 +120,202 @@ type DomainReputation struct {
 
 // NewReputationStore creates a new reputation store
 func NewReputationStore() *ReputationStore {
-	return &ReputationStore{
-		records: make(map[string]*ReputationRecord),
+	rs := &ReputationStore{
+		shards:   newRecordShards(reputationShards),
+		admins:   make(map[string]bool),
+		pending:  make(map[string]*ResetRequest),
+		archive:  make(map[string][]*ArchivedReputation),
+		resets:   make(map[string]time.Time),
+		profiles: make(map[string]*WitnessProfile),
+	}
+	rs.stakes = newStakeLedger(rs)
+	return rs
+}
+
+// SetAbstentionPolicy sets how abstentions affect confidence scores
+// computed with this store
+func (rs *ReputationStore) SetAbstentionPolicy(policy AbstentionPolicy) {
+	rs.mu.Lock()
+	rs.abstentions = policy
+	rs.mu.Unlock()
+
+	rs.notify("")
+}
+
+// OnChange registers fn to be called after any change that can affect
+// confidence scores computed with the store, such as a witness's record
+// being updated, reset or restored, or its profile changing. fn receives
+// the witness whose reputation changed, or "" when a change affects every
+// witness. It is called synchronously, without the store locked. The
+// returned function unregisters fn.
+func (rs *ReputationStore) OnChange(fn func(witnessID string)) (unsubscribe func()) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.listeners == nil {
+		rs.listeners = make(map[int]func(string))
+	}
+	id := rs.nextListener
+	rs.nextListener++
+	rs.listeners[id] = fn
+
+	return func() {
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+		delete(rs.listeners, id)
+	}
+}
+
+// notify calls the change listeners. Callers must not hold rs.mu.
+func (rs *ReputationStore) notify(witnessID string) {
+	rs.mu.RLock()
+	listeners := make([]func(string), 0, len(rs.listeners))
+	for _, fn := range rs.listeners {
+		listeners = append(listeners, fn)
+	}
+	rs.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(witnessID)
 	}
 }
 
+// SetClock sets the time source the store records sightings and scores
+// witnesses with. Records already returned by GetRecord keep the clock they
+// were returned with.
+func (rs *ReputationStore) SetClock(clock Clock) {
+	rs.mu.Lock()
+	rs.clock = clock
+	rs.mu.Unlock()
+
+	rs.notify("")
+}
+
+// now reads the store's clock. Callers must hold rs.mu.
+func (rs *ReputationStore) now() time.Time {
+	if rs.clock == nil {
+		return time.Now()
+	}
+	return rs.clock.Now()
+}
+
+// view returns a shallow copy of a record that scores with the store's
+// clock, for reading while rs.mu is held
+func (rs *ReputationStore) view(record *ReputationRecord) *ReputationRecord {
+	view := *record
+	view.clock = rs.clock
+	return &view
+}
+
+func (rs *ReputationStore) abstentionPolicy() AbstentionPolicy {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.abstentions
+}
+
 // GetRecord returns the reputation record for a witness
 func (rs *ReputationStore) GetRecord(witnessID string) (*ReputationRecord, bool) {
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
 
-	record, exists := rs.records[witnessID]
+	shard := rs.shard(witnessID)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	record, exists := shard.records[witnessID]
 	if !exists {
 		return nil, false
 	}
 
-	// Return a copy
-	copy := *record
+	clone := record.clone()
+	clone.clock = rs.clock
+	return clone, true
+}
+
+// Records exports every witness's reputation record, ordered by witness ID
+func (rs *ReputationStore) Records() []ExportedReputation {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	var records []ExportedReputation
+	rs.eachRecord(func(record *ReputationRecord) {
+		records = append(records, rs.view(record).Export())
+	})
+	if records == nil {
+		records = []ExportedReputation{}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].WitnessID < records[j].WitnessID })
+	return records
+}
+
+// RestoreRecord loads an exported record as-is, replacing any record the
+// store has for the witness. It is meant for restoring backups of this
+// store; use ImportReputation for records from other networks.
+func (rs *ReputationStore) RestoreRecord(export ExportedReputation) error {
+	if err := validateExport(export); err != nil {
+		return err
+	}
+
+	record := &ReputationRecord{
+		WitnessID:      export.WitnessID,
+		TotalClaims:    export.TotalClaims,
+		AgreedClaims:   export.AgreedClaims,
+		DisputedClaims: export.DisputedClaims,
+		Domains:        make(map[string]*DomainReputation, len(export.Domains)),
+		FirstSeen:      export.FirstSeen,
+		LastSeen:       export.LastSeen,
+	}
+	for domain, d := range export.Domains {
+		record.Domains[domain] = &DomainReputation{
+			Domain:         domain,
+			TotalClaims:    d.TotalClaims,
+			AgreedClaims:   d.AgreedClaims,
+			DisputedClaims: d.DisputedClaims,
+		}
+	}
+
+	shard := rs.shard(export.WitnessID)
+	shard.mu.Lock()
+	shard.records[export.WitnessID] = record
+	shard.mu.Unlock()
+
+	rs.notify(export.WitnessID)
+	return nil
+}
+
+// clone returns a deep copy of the record
+func (rr *ReputationRecord) clone() *ReputationRecord {
+	copy := *rr
 	copy.Domains = make(map[string]*DomainReputation)
-	for k, v := range record.Domains {
+	for k, v := range rr.Domains {
 		domainCopy := *v
 		copy.Domains[k] = &domainCopy
 	}
-	return &copy, true
+	return &copy
 }
 
 // RecordAttestation records that a witness attested to a claim
 func (rs *ReputationStore) RecordAttestation(witnessID string, domain string) {
-	rs.mu.Lock()
-	defer rs.mu.Unlock()
+	now := rs.currentTime().UTC()
+	defer rs.notify(witnessID)
+	defer rs.notifyAttestation(AttestationEvent{WitnessID: witnessID, Domain: domain, Time: now})
 
-	record, exists := rs.records[witnessID]
+	shard := rs.shard(witnessID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	record, exists := shard.records[witnessID]
 	if !exists {
 		record = &ReputationRecord{
 			WitnessID: witnessID,
 			Domains:   make(map[string]*DomainReputation),
-			FirstSeen: time.Now().UTC(),
+			FirstSeen: now,
 		}
-		rs.records[witnessID] = record
+		shard.records[witnessID] = record
 	}
 
 	record.TotalClaims++
-	record.LastSeen = time.Now().UTC()
+	record.LastSeen = now
 
 	if domain != "" {
 		domainRep, exists := record.Domains[domain]
@@ -102,10 +329,12 @@ func (rs *ReputationStore) RecordAttestation(witnessID string, domain string) {
 
 // RecordAgreement records that a witness agreed with consensus
 func (rs *ReputationStore) RecordAgreement(witnessID string, domain string) {
-	rs.mu.Lock()
-	defer rs.mu.Unlock()
+	defer rs.notify(witnessID)
+	shard := rs.shard(witnessID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	record, exists := rs.records[witnessID]
+	record, exists := shard.records[witnessID]
 	if !exists {
 		return
 	}
@@ -121,10 +350,12 @@ func (rs *ReputationStore) RecordAgreement(witnessID string, domain string) {
 
 // RecordDispute records that a witness was disputed
 func (rs *ReputationStore) RecordDispute(witnessID string, domain string) {
-	rs.mu.Lock()
-	defer rs.mu.Unlock()
+	defer rs.notify(witnessID)
+	shard := rs.shard(witnessID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	record, exists := rs.records[witnessID]
+	record, exists := shard.records[witnessID]
 	if !exists {
 		return
 	}
@@ -153,11 +384,11 @@ func (rr *ReputationRecord) Score() float64 {
 	penalty := disputeRatio * 0.5
 
 	// Longevity bonus (witnesses active longer get slight boost)
-	age := time.Since(rr.FirstSeen)
+	age := rr.now().Sub(rr.FirstSeen)
 	longevityBonus := math.Min(age.Hours()/(24*365), 0.1) // Max 10% bonus after 1 year
 
 	// Volume confidence (more claims = more confident in score)
-	volumeWeight := math.Min(float64(rr.TotalClaims)/100, 1.0)
+	volumeWeight := math.Min(float64(rr.TotalClaims)/recordVolume, 1.0)
 
 	// Combine factors
 	rawScore := accuracy - penalty + longevityBonus
@@ -169,6 +400,14 @@ func (rr *ReputationRecord) Score() float64 {
 	return math.Max(0, math.Min(1, score))
 }
 
+// now reads the record's clock
+func (rr *ReputationRecord) now() time.Time {
+	if rr.clock == nil {
+		return time.Now()
+	}
+	return rr.clock.Now()
+}
+
 // DomainScore computes the reputation score for a specific domain
 func (rr *ReputationRecord) DomainScore(domain string) float64 {
 	domainRep, exists := rr.Domains[domain]
@@ -180,7 +419,7 @@ func (rr *ReputationRecord) DomainScore(domain string) float64 {
 	disputeRatio := float64(domainRep.DisputedClaims) / float64(domainRep.TotalClaims)
 	penalty := disputeRatio * 0.5
 
-	volumeWeight := math.Min(float64(domainRep.TotalClaims)/50, 1.0)
+	volumeWeight := math.Min(float64(domainRep.TotalClaims)/domainVolume, 1.0)
 
 	rawScore := accuracy - penalty
 	score := rawScore*volumeWeight + rr.Score()*(1-volumeWeight)
@@ -188,39 +427,73 @@ func (rr *ReputationRecord) DomainScore(domain string) float64 {
 	return math.Max(0, math.Min(1, score))
 }
 
-// ClaimConfidence computes the confidence score for a claim based on its attestations
+// ClaimConfidence computes the confidence score for a claim based on its attestations.
+// Each witness's reputation score is read as the probability that it is right,
+// so an endorsement supports the claim with that score and a dispute with its
+// complement. Witnesses whose signed profiles share an operator, network or
+// region count as fewer independent voices (see WitnessDiversity). Once
+// arbiters have resolved a dispute, their verdict replaces the raw witness
+// tally. ExplainConfidence breaks the score down.
 func ClaimConfidence(claim *Claim, store *ReputationStore) float64 {
-	if len(claim.Witnesses) == 0 {
-		return 0
-	}
+	return ExplainConfidence(claim, store).Confidence
+}
 
-	var totalWeight float64
-	var weightedSum float64
+// FieldConfidence computes the confidence for each of a claim's structured
+// fields, counting whole-claim attestations and those scoped to the field.
+func FieldConfidence(claim *Claim, store *ReputationStore) map[string]float64 {
+	result := make(map[string]float64, len(claim.Fields))
 
-	for _, att := range claim.Witnesses {
-		record, exists := store.GetRecord(att.WitnessID)
-		var score float64
-		if exists {
-			score = record.DomainScore(claim.Statement.Domain)
-		} else {
-			score = 0.5 // Neutral for unknown witnesses
+	for name := range claim.Fields {
+		if e := explainResolved(claim, store); e != nil {
+			result[name] = e.Confidence
+			continue
 		}
 
-		// Weight by reputation score (higher rep = more weight)
-		weight := 0.5 + score*0.5 // Range [0.5, 1.0]
-		weightedSum += score * weight
-		totalWeight += weight
+		var covering []Attestation
+		for _, att := range claim.Witnesses {
+			if att.Covers(name) {
+				covering = append(covering, att)
+			}
+		}
+		result[name] = tallyConfidence(claim, covering, store)
 	}
 
-	if totalWeight == 0 {
-		return 0
+	return result
+}
+
+// tallyConfidence combines the given attestations into a confidence score
+func tallyConfidence(claim *Claim, attestations []Attestation, store *ReputationStore) float64 {
+	return explainTally(claim, attestations, store).Confidence
+}
+
+// stanceShares returns each attestation's independence share, computed
+// among the witnesses taking the same stance
+func stanceShares(attestations []Attestation, store *ReputationStore) []float64 {
+	groups := make(map[Stance][]int)
+	for i, att := range attestations {
+		groups[att.Stance] = append(groups[att.Stance], i)
 	}
 
-	// Also factor in number of witnesses (diversity)
-	witnessBonus := math.Min(float64(len(claim.Witnesses))/5, 0.2) // Max 20% bonus for 5+ witnesses
+	shares := make([]float64, len(attestations))
+	for _, group := range groups {
+		ids := make([]string, len(group))
+		for k, i := range group {
+			ids[k] = attestations[i].WitnessID
+		}
+		for k, share := range store.independenceShares(ids) {
+			shares[group[k]] = share
+		}
+	}
+	return shares
+}
 
-	confidence := (weightedSum / totalWeight) + witnessBonus
-	return math.Max(0, math.Min(1, confidence))
+// witnessScore returns a witness's reputation in the claim's domain
+func witnessScore(claim *Claim, witnessID string, store *ReputationStore) float64 {
+	record, exists := store.GetRecord(witnessID)
+	if !exists {
+		return 0.5 // Neutral for unknown witnesses
+	}
+	return record.DomainScore(claim.Statement.Domain)
 }
 
 // ExportRecord exports a reputation record for portability
@@ -0,0 +1,255 @@
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldConfidence(t *testing.T) {
+	rs := NewReputationStore()
+
+	c, err := NewClaim(Statement{Subject: "AAPL", Predicate: "quote", Domain: "finance"}, nil, "",
+		WithFields(map[string]string{"price": "189.20", "volume": "1000"}))
+	require.NoError(t, err)
+
+	w, _ := GenerateWitness()
+	att, err := w.AttestFields(c, "price")
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	fields := FieldConfidence(c, rs)
+	assert.Greater(t, fields["price"], 0.0)
+	assert.Zero(t, fields["volume"])
+
+	// A field-scoped attestation does not vouch for the whole claim
+	assert.Zero(t, ClaimConfidence(c, rs))
+
+	t.Run("whole-claim attestation covers every field", func(t *testing.T) {
+		w2, _ := GenerateWitness()
+		att, err := w2.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+
+		fields := FieldConfidence(c, rs)
+		assert.Greater(t, fields["volume"], 0.0)
+		assert.Greater(t, ClaimConfidence(c, rs), 0.0)
+	})
+}
+
+func TestWitnessDiversity(t *testing.T) {
+	// attestedBy returns a claim endorsed by witnesses with the given
+	// operator and region, each on its own network
+	attestedBy := func(t *testing.T, rs *ReputationStore, profiles [][2]string) *Claim {
+		c, err := NewClaim(Statement{Subject: "match", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+
+		for i, p := range profiles {
+			w, _ := GenerateWitness()
+			profile, err := w.SignProfile(p[0], uint32(64500+i), p[1])
+			require.NoError(t, err)
+			require.NoError(t, rs.SetProfile(profile))
+
+			att, _ := w.Attest(c)
+			require.NoError(t, c.AddAttestation(att))
+		}
+		return c
+	}
+
+	rs := NewReputationStore()
+	clustered := attestedBy(t, rs, [][2]string{{"acme", "eu-west"}, {"acme", "eu-west"}, {"acme", "eu-west"}})
+	independent := attestedBy(t, rs, [][2]string{{"acme", "eu-west"}, {"globex", "us-east"}, {"initech", "ap-south"}})
+	sameRegion := attestedBy(t, rs, [][2]string{{"op-a", "eu-west"}, {"op-b", "eu-west"}, {"op-c", "eu-west"}})
+
+	ids := func(c *Claim) []string {
+		var ids []string
+		for _, att := range c.Witnesses {
+			ids = append(ids, att.WitnessID)
+		}
+		return ids
+	}
+
+	assert.InDelta(t, 1.0, WitnessDiversity(ids(clustered), rs), 1e-9)
+	assert.InDelta(t, 3.0, WitnessDiversity(ids(independent), rs), 1e-9)
+	regional := WitnessDiversity(ids(sameRegion), rs)
+	assert.Greater(t, regional, 1.0)
+	assert.Less(t, regional, 3.0)
+
+	assert.Less(t, ClaimConfidence(clustered, rs), ClaimConfidence(independent, rs))
+	assert.Less(t, ClaimConfidence(sameRegion, rs), ClaimConfidence(independent, rs))
+
+	t.Run("disputes still count against correlated endorsers", func(t *testing.T) {
+		for _, c := range []*Claim{clustered, independent} {
+			w, _ := GenerateWitness()
+			att, _ := w.Dispute(c)
+			require.NoError(t, c.AddAttestation(att))
+		}
+		assert.Less(t, ClaimConfidence(clustered, rs), ClaimConfidence(independent, rs))
+	})
+
+	t.Run("unprofiled witnesses count as independent", func(t *testing.T) {
+		c, _ := NewClaim(Statement{Subject: "match", Domain: "sports"}, nil, "")
+		for i := 0; i < 3; i++ {
+			w, _ := GenerateWitness()
+			att, _ := w.Attest(c)
+			require.NoError(t, c.AddAttestation(att))
+		}
+		assert.InDelta(t, 3.0, WitnessDiversity(ids(c), rs), 1e-9)
+		w, _ := GenerateWitness()
+		att, _ := w.Dispute(c)
+		require.NoError(t, c.AddAttestation(att))
+		assert.InDelta(t, ClaimConfidence(independent, rs), ClaimConfidence(c, rs), 1e-9)
+	})
+}
+
+func TestWitnessProfile(t *testing.T) {
+	w, _ := GenerateWitness()
+	p, err := w.SignProfile("acme", 64500, "eu-west")
+	require.NoError(t, err)
+	assert.NoError(t, VerifyProfile(p))
+
+	forged := *p
+	forged.Operator = "globex"
+	assert.Error(t, VerifyProfile(&forged))
+
+	rs := NewReputationStore()
+	require.NoError(t, rs.SetProfile(p))
+	assert.Error(t, rs.SetProfile(&forged))
+	assert.Error(t, rs.SetProfile(p), "same profile is not newer")
+
+	got, ok := rs.Profile(w.ID)
+	require.True(t, ok)
+	assert.Equal(t, "acme", got.Operator)
+}
+
+func TestAbstentionPolicy(t *testing.T) {
+	rs := NewReputationStore()
+
+	c, err := NewClaim(Statement{Subject: "test", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+
+	var endorsements []Attestation
+	for i := 0; i < 2; i++ {
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		endorsements = append(endorsements, *att)
+	}
+	for i := 0; i < 2; i++ {
+		w, _ := GenerateWitness()
+		att, err := w.Abstain(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+	}
+	require.NoError(t, c.VerifyAllAttestations())
+
+	endorsedOnly := *c
+	endorsedOnly.Witnesses = endorsements
+	baseline := ClaimConfidence(&endorsedOnly, rs)
+
+	t.Run("ignored by default", func(t *testing.T) {
+		assert.Equal(t, baseline, ClaimConfidence(c, rs))
+	})
+
+	t.Run("counted as uncertainty", func(t *testing.T) {
+		counting := NewReputationStore()
+		counting.SetAbstentionPolicy(CountAbstentions)
+
+		counted := ClaimConfidence(c, counting)
+		assert.InDelta(t, baseline/2, counted, 1e-9)
+		assert.Equal(t, baseline, ClaimConfidence(&endorsedOnly, counting))
+	})
+
+	t.Run("abstentions alone give no confidence", func(t *testing.T) {
+		abstained := *c
+		abstained.Witnesses = c.Witnesses[2:]
+		assert.Zero(t, ClaimConfidence(&abstained, rs))
+	})
+
+	t.Run("stance round-trips", func(t *testing.T) {
+		text, err := StanceAbstain.MarshalText()
+		require.NoError(t, err)
+		var s Stance
+		require.NoError(t, s.UnmarshalText(text))
+		assert.Equal(t, StanceAbstain, s)
+	})
+}
+
+func TestReputationOnChange(t *testing.T) {
+	rs := NewReputationStore()
+
+	var changed []string
+	unsubscribe := rs.OnChange(func(witnessID string) {
+		// Listeners run without the store locked
+		rs.GetRecord(witnessID)
+		changed = append(changed, witnessID)
+	})
+
+	rs.RecordAttestation("alice", "sports")
+	rs.RecordAgreement("alice", "sports")
+	rs.RecordDispute("bob", "sports")
+	rs.SetAbstentionPolicy(CountAbstentions)
+	assert.Equal(t, []string{"alice", "alice", "bob", ""}, changed)
+
+	t.Run("failed changes not notified", func(t *testing.T) {
+		changed = nil
+		assert.Error(t, rs.RestoreRecord(ExportedReputation{}))
+		assert.Error(t, rs.ApproveReset("alice", nil))
+		assert.Empty(t, changed)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		changed = nil
+		unsubscribe()
+		rs.RecordAttestation("alice", "sports")
+		assert.Empty(t, changed)
+	})
+}
+
+func TestReputationClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rs := NewReputationStore()
+	rs.SetClock(FixedClock(start))
+
+	w := DeterministicWitness("alice")
+	for i := 0; i < 100; i++ {
+		rs.RecordAttestation(w.ID, "sports")
+		if i < 80 {
+			rs.RecordAgreement(w.ID, "sports")
+		}
+	}
+
+	record, ok := rs.GetRecord(w.ID)
+	require.True(t, ok)
+	assert.Equal(t, start, record.FirstSeen)
+	assert.Equal(t, start, record.LastSeen)
+	assert.Equal(t, 0.8, record.Score()) // No longevity bonus yet
+
+	t.Run("longevity bonus grows with the clock", func(t *testing.T) {
+		// 0.05 years of history
+		rs.SetClock(FixedClock(start.Add(438 * time.Hour)))
+		record, _ := rs.GetRecord(w.ID)
+		assert.InDelta(t, 0.85, record.Score(), 1e-12)
+		assert.InDelta(t, 0.85, record.DomainScore("markets"), 1e-12) // Falls back to Score
+
+		// The bonus is capped
+		rs.SetClock(FixedClock(start.Add(5 * 365 * 24 * time.Hour)))
+		record, _ = rs.GetRecord(w.ID)
+		assert.InDelta(t, 0.9, record.Score(), 1e-12)
+	})
+
+	t.Run("scores are deterministic", func(t *testing.T) {
+		c, err := NewClaim(Statement{Subject: "match-1", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+
+		first := ClaimConfidence(c, rs)
+		time.Sleep(time.Millisecond)
+		assert.Equal(t, first, ClaimConfidence(c, rs))
+	})
+}
@@ -0,0 +1,226 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// ResetRequest is a witness's signed request to start a fresh reputation
+// record. A request on its own has no effect; it must be approved by a
+// reset admin before the witness's history is archived.
+type ResetRequest struct {
+	// WitnessID is the witness asking for a reset
+	WitnessID string
+
+	// Reason is a free-text justification for the reset
+	Reason string
+
+	// Timestamp is when the request was made
+	Timestamp time.Time
+
+	// Signature is the witness's signature over the request
+	Signature []byte
+}
+
+// ArchivedReputation is a reputation record retired by an approved reset.
+// It is kept so the witness's prior history remains auditable.
+type ArchivedReputation struct {
+	// Record is the reputation record as it was at reset time
+	Record *ReputationRecord
+
+	// Request is the witness's signed reset request
+	Request ResetRequest
+
+	// ApprovedBy is the hex-encoded public key of the approving admin
+	ApprovedBy string
+
+	// Approval is the admin's signature over the reset request
+	Approval []byte
+
+	// ArchivedAt is when the reset was approved
+	ArchivedAt time.Time
+}
+
+// RequestReset creates a signed reset request for this witness
+func (w *Witness) RequestReset(reason string) (*ResetRequest, error) {
+	if !w.CanSign() {
+		return nil, fmt.Errorf("witness has no private key")
+	}
+
+	req := &ResetRequest{
+		WitnessID: w.ID,
+		Reason:    reason,
+		Timestamp: time.Now().UTC(),
+	}
+
+	payload, err := resetPayload(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Signature, err = w.signPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// VerifyResetRequest checks that a reset request was signed by its witness
+func VerifyResetRequest(req *ResetRequest) error {
+	if req == nil {
+		return fmt.Errorf("reset request cannot be nil")
+	}
+
+	w, err := WitnessFromID(req.WitnessID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := resetPayload(req)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(w.PublicKey, payload, req.Signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+func resetPayload(req *ResetRequest) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := writeString(&buf, "claim-graph/reputation-reset"); err != nil {
+		return nil, err
+	}
+	if err := writeString(&buf, req.WitnessID); err != nil {
+		return nil, err
+	}
+	if err := writeString(&buf, req.Reason); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&buf, binary.BigEndian, req.Timestamp.UnixNano()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// AddResetAdmin authorizes a public key to approve reputation resets
+func (rs *ReputationStore) AddResetAdmin(pubKey ed25519.PublicKey) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.admins[hex.EncodeToString(pubKey)] = true
+}
+
+// SubmitResetRequest records a verified reset request as pending.
+// The witness's reputation is untouched until the request is approved.
+// A request must be newer than the last one accepted from its witness, so
+// a captured request cannot be replayed to reset the witness again.
+func (rs *ReputationStore) SubmitResetRequest(req *ResetRequest) error {
+	if err := VerifyResetRequest(req); err != nil {
+		return fmt.Errorf("invalid reset request: %w", err)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if last, exists := rs.resets[req.WitnessID]; exists && !req.Timestamp.After(last) {
+		return fmt.Errorf("reset request from witness %s is not newer than the last one accepted", req.WitnessID)
+	}
+
+	reqCopy := *req
+	rs.pending[req.WitnessID] = &reqCopy
+	rs.resets[req.WitnessID] = req.Timestamp
+	return nil
+}
+
+// PendingReset returns the pending reset request for a witness, if any
+func (rs *ReputationStore) PendingReset(witnessID string) (*ResetRequest, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	req, exists := rs.pending[witnessID]
+	if !exists {
+		return nil, false
+	}
+	reqCopy := *req
+	return &reqCopy, true
+}
+
+// ApproveReset approves a witness's pending reset request. The current
+// record is archived and replaced with a fresh record, so the witness
+// starts again as a newcomer. adminKey must belong to a registered admin.
+func (rs *ReputationStore) ApproveReset(witnessID string, adminKey ed25519.PrivateKey) (err error) {
+	if len(adminKey) != ed25519.PrivateKeySize {
+		return fmt.Errorf("invalid admin key length")
+	}
+	adminID := hex.EncodeToString(adminKey.Public().(ed25519.PublicKey))
+
+	defer func() {
+		if err == nil {
+			rs.notify(witnessID)
+		}
+	}()
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if !rs.admins[adminID] {
+		return fmt.Errorf("key %s is not a reset admin", adminID)
+	}
+
+	req, exists := rs.pending[witnessID]
+	if !exists {
+		return fmt.Errorf("no pending reset request for witness %s", witnessID)
+	}
+
+	payload, err := resetPayload(req)
+	if err != nil {
+		return err
+	}
+
+	now := rs.now().UTC()
+	shard := rs.shard(witnessID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if record, exists := shard.records[witnessID]; exists {
+		rs.archive[witnessID] = append(rs.archive[witnessID], &ArchivedReputation{
+			Record:     record.clone(),
+			Request:    *req,
+			ApprovedBy: adminID,
+			Approval:   ed25519.Sign(adminKey, payload),
+			ArchivedAt: now,
+		})
+	}
+
+	shard.records[witnessID] = &ReputationRecord{
+		WitnessID: witnessID,
+		Domains:   make(map[string]*DomainReputation),
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+	delete(rs.pending, witnessID)
+
+	return nil
+}
+
+// ArchivedRecords returns the records retired by approved resets, oldest first
+func (rs *ReputationStore) ArchivedRecords(witnessID string) []*ArchivedReputation {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	archived := rs.archive[witnessID]
+	result := make([]*ArchivedReputation, len(archived))
+	for i, a := range archived {
+		aCopy := *a
+		aCopy.Record = a.Record.clone()
+		result[i] = &aCopy
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package claim
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReputationReset(t *testing.T) {
+	w, err := GenerateWitness()
+	require.NoError(t, err)
+
+	adminPub, adminKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	newStore := func() *ReputationStore {
+		rs := NewReputationStore()
+		rs.AddResetAdmin(adminPub)
+		for i := 0; i < 10; i++ {
+			rs.RecordAttestation(w.ID, "sports")
+			rs.RecordDispute(w.ID, "sports")
+		}
+		return rs
+	}
+
+	t.Run("request without approval is a no-op", func(t *testing.T) {
+		rs := newStore()
+		before, _ := rs.GetRecord(w.ID)
+
+		req, err := w.RequestReset("new operator")
+		require.NoError(t, err)
+		require.NoError(t, rs.SubmitResetRequest(req))
+
+		after, _ := rs.GetRecord(w.ID)
+		assert.Equal(t, before.TotalClaims, after.TotalClaims)
+		assert.Equal(t, before.DisputedClaims, after.DisputedClaims)
+		assert.Empty(t, rs.ArchivedRecords(w.ID))
+
+		_, pending := rs.PendingReset(w.ID)
+		assert.True(t, pending)
+	})
+
+	t.Run("approved reset archives and starts fresh", func(t *testing.T) {
+		rs := newStore()
+		before, _ := rs.GetRecord(w.ID)
+
+		req, err := w.RequestReset("new operator")
+		require.NoError(t, err)
+		require.NoError(t, rs.SubmitResetRequest(req))
+		require.NoError(t, rs.ApproveReset(w.ID, adminKey))
+
+		after, exists := rs.GetRecord(w.ID)
+		require.True(t, exists)
+		assert.Zero(t, after.TotalClaims)
+		assert.Equal(t, 0.5, after.Score())
+
+		archived := rs.ArchivedRecords(w.ID)
+		require.Len(t, archived, 1)
+		assert.Equal(t, before.TotalClaims, archived[0].Record.TotalClaims)
+		assert.Equal(t, before.DisputedClaims, archived[0].Record.DisputedClaims)
+		assert.Equal(t, "new operator", archived[0].Request.Reason)
+		assert.True(t, ed25519.Verify(adminPub, mustResetPayload(t, &archived[0].Request), archived[0].Approval))
+
+		_, pending := rs.PendingReset(w.ID)
+		assert.False(t, pending)
+	})
+
+	t.Run("approval without request fails", func(t *testing.T) {
+		rs := newStore()
+		assert.Error(t, rs.ApproveReset(w.ID, adminKey))
+	})
+
+	t.Run("non-admin cannot approve", func(t *testing.T) {
+		rs := newStore()
+		req, _ := w.RequestReset("")
+		require.NoError(t, rs.SubmitResetRequest(req))
+
+		_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+		assert.Error(t, rs.ApproveReset(w.ID, otherKey))
+
+		record, _ := rs.GetRecord(w.ID)
+		assert.Equal(t, int64(10), record.TotalClaims)
+	})
+
+	t.Run("replayed request rejected", func(t *testing.T) {
+		rs := newStore()
+		req, err := w.RequestReset("new operator")
+		require.NoError(t, err)
+		require.NoError(t, rs.SubmitResetRequest(req))
+		require.NoError(t, rs.ApproveReset(w.ID, adminKey))
+
+		// The witness earns a fresh history after the reset
+		rs.RecordAttestation(w.ID, "sports")
+
+		assert.Error(t, rs.SubmitResetRequest(req))
+		_, pending := rs.PendingReset(w.ID)
+		assert.False(t, pending)
+		assert.Error(t, rs.ApproveReset(w.ID, adminKey))
+
+		record, _ := rs.GetRecord(w.ID)
+		assert.Equal(t, int64(1), record.TotalClaims)
+
+		t.Run("older request rejected", func(t *testing.T) {
+			older := signedResetRequest(t, w, req.Timestamp.Add(-time.Hour))
+			assert.Error(t, rs.SubmitResetRequest(older))
+		})
+
+		t.Run("newer request accepted", func(t *testing.T) {
+			newer := signedResetRequest(t, w, req.Timestamp.Add(time.Second))
+			assert.NoError(t, rs.SubmitResetRequest(newer))
+		})
+	})
+
+	t.Run("forged request rejected", func(t *testing.T) {
+		rs := newStore()
+		req, _ := w.RequestReset("")
+		req.Reason = "tampered"
+		assert.Error(t, rs.SubmitResetRequest(req))
+	})
+}
+
+// signedResetRequest returns a reset request from w signed at the given time
+func signedResetRequest(t *testing.T, w *Witness, at time.Time) *ResetRequest {
+	t.Helper()
+	req := &ResetRequest{WitnessID: w.ID, Timestamp: at}
+	sig, err := w.signPayload(mustResetPayload(t, req))
+	require.NoError(t, err)
+	req.Signature = sig
+	return req
+}
+
+func mustResetPayload(t *testing.T, req *ResetRequest) []byte {
+	t.Helper()
+	payload, err := resetPayload(req)
+	require.NoError(t, err)
+	return payload
+}
@@ -0,0 +1,100 @@
+package claim
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change is a difference in one part of a claim between two revisions
+type Change struct {
+	// Field names what changed: a statement part ("object"), "evidence",
+	// "time-event", "quantity", "objects", "expires-at" or "field:<name>"
+	Field string
+
+	// Old and New are the values before and after (empty when absent)
+	Old string
+	New string
+}
+
+// RevisionChange lists the changes from one revision of a claim to the
+// revision that supersedes it
+type RevisionChange struct {
+	From    string
+	To      string
+	Changes []Change
+}
+
+// Diff returns the content changes from old to new, in a fixed order.
+// Attestations and other annotations are not compared.
+func Diff(old, new *Claim) []Change {
+	var changes []Change
+	add := func(field, before, after string) {
+		if before != after {
+			changes = append(changes, Change{Field: field, Old: before, New: after})
+		}
+	}
+
+	add("subject", old.Statement.Subject, new.Statement.Subject)
+	add("predicate", old.Statement.Predicate, new.Statement.Predicate)
+	add("object", old.Statement.Object, new.Statement.Object)
+	add("domain", old.Statement.Domain, new.Statement.Domain)
+	add("evidence", strings.Join(old.Evidence, ","), strings.Join(new.Evidence, ","))
+	add("time-event", old.TimeEvent, new.TimeEvent)
+	add("quantity", quantityString(old.Quantity), quantityString(new.Quantity))
+	add("objects", objectsString(old.Objects), objectsString(new.Objects))
+	add("expires-at", timeString(old.ExpiresAt), timeString(new.ExpiresAt))
+	add("namespace", old.Namespace, new.Namespace)
+	add("cid-fields", old.CIDFields.String(), new.CIDFields.String())
+
+	names := sortedKeys(old.Fields)
+	for _, name := range sortedKeys(new.Fields) {
+		if _, exists := old.Fields[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add("field:"+name, old.Fields[name], new.Fields[name])
+	}
+
+	return changes
+}
+
+// Changelog diffs each consecutive pair of revisions, given oldest first
+func Changelog(revisions []*Claim) []RevisionChange {
+	var log []RevisionChange
+	for i := 1; i < len(revisions); i++ {
+		log = append(log, RevisionChange{
+			From:    revisions[i-1].ID,
+			To:      revisions[i].ID,
+			Changes: Diff(revisions[i-1], revisions[i]),
+		})
+	}
+	return log
+}
+
+func quantityString(q *Quantity) string {
+	if q == nil {
+		return ""
+	}
+	return q.String()
+}
+
+// objectsString writes an object set as {a,b} and a sequence as [a,b]
+func objectsString(l *ObjectList) string {
+	if l == nil {
+		return ""
+	}
+	if l.Ordered {
+		return "[" + strings.Join(l.Values, ",") + "]"
+	}
+	return "{" + strings.Join(l.Canonical(), ",") + "}"
+}
+
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
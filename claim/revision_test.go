@@ -0,0 +1,43 @@
+package claim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangelog(t *testing.T) {
+	v1, err := NewClaim(Statement{Subject: "match-42", Predicate: "final-score", Object: "2-1", Domain: "sports"}, []string{"bafkreireport"}, "",
+		WithFields(map[string]string{"venue": "home"}))
+	require.NoError(t, err)
+
+	v2, err := NewClaim(Statement{Subject: "match-42", Predicate: "final-score", Object: "2-2", Domain: "sports"}, []string{"bafkreireport"}, "",
+		WithFields(map[string]string{"venue": "home"}), WithSupersedes(v1.ID))
+	require.NoError(t, err)
+	assert.NotEqual(t, v1.ID, v2.ID)
+
+	v3, err := NewClaim(Statement{Subject: "match-42", Predicate: "final-score", Object: "3-2", Domain: "sports"}, []string{"bafkreireport", "bafkreireplay"}, "",
+		WithFields(map[string]string{"attendance": "40000"}), WithSupersedes(v2.ID))
+	require.NoError(t, err)
+
+	log := Changelog([]*Claim{v1, v2, v3})
+	require.Len(t, log, 2)
+
+	assert.Equal(t, v1.ID, log[0].From)
+	assert.Equal(t, v2.ID, log[0].To)
+	assert.Equal(t, []Change{{Field: "object", Old: "2-1", New: "2-2"}}, log[0].Changes)
+
+	assert.Equal(t, []Change{
+		{Field: "object", Old: "2-2", New: "3-2"},
+		{Field: "evidence", Old: "bafkreireport", New: "bafkreireport,bafkreireplay"},
+		{Field: "field:attendance", Old: "", New: "40000"},
+		{Field: "field:venue", Old: "home", New: ""},
+	}, log[1].Changes)
+
+	t.Run("supersedes is part of identity", func(t *testing.T) {
+		tampered := *v2
+		tampered.Supersedes = ""
+		assert.Error(t, VerifyCID(&tampered))
+	})
+}
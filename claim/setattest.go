@@ -0,0 +1,147 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// SetAttestation is a witness's signature over the Merkle root of a set of
+// claim CIDs. Signing one root instead of every claim keeps attesting to a
+// large set cheap; each claim is then shown to be in the set by its
+// SetProof. The root, not any per-claim attestation, is the signed artifact.
+type SetAttestation struct {
+	WitnessID string
+
+	// Root is the Merkle tree hash of the claims' set leaves, in order
+	Root []byte
+
+	// Signature is the witness's signature over the root
+	Signature []byte
+
+	// Proofs holds each claim's inclusion proof, in the order signed
+	Proofs []*SetProof
+}
+
+// SetProof shows that a claim's CID is entry LeafIndex of a signed set
+type SetProof struct {
+	// LeafIndex is the claim's position in the set
+	LeafIndex uint64
+
+	// Size is the number of claims in the set
+	Size uint64
+
+	// Hashes is the audit path from the claim's leaf to the root
+	Hashes [][]byte
+}
+
+// AttestSet signs the Merkle root of the claims' CIDs and returns it with
+// an inclusion proof for each claim
+func (w *Witness) AttestSet(claims []*Claim) (*SetAttestation, error) {
+	if !w.CanSign() {
+		return nil, fmt.Errorf("witness has no private key")
+	}
+	if len(claims) == 0 {
+		return nil, fmt.Errorf("claim set cannot be empty")
+	}
+
+	leaves := make([][]byte, len(claims))
+	for i, c := range claims {
+		if c == nil || c.ID == "" {
+			return nil, fmt.Errorf("claim %d has no CID", i)
+		}
+		leaves[i] = SetLeafHash(c.ID)
+	}
+
+	tree := newMerkleTree(leaves)
+	root := tree.hash(0, tree.size())
+	payload, err := setRootPayload(root)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := w.signPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &SetAttestation{
+		WitnessID: w.ID,
+		Root:      root,
+		Signature: sig,
+		Proofs:    make([]*SetProof, len(claims)),
+	}
+	for i := range claims {
+		set.Proofs[i] = &SetProof{
+			LeafIndex: uint64(i),
+			Size:      uint64(len(leaves)),
+			Hashes:    tree.inclusionPath(uint64(i), 0, tree.size()),
+		}
+	}
+	return set, nil
+}
+
+// VerifySetAttestation checks that a witness signed root and that proof
+// shows the claim's CID is in the set the root commits to. The claim's
+// content is checked against its CID as well.
+func VerifySetAttestation(c *Claim, root []byte, proof *SetProof, signature []byte, witnessID string) error {
+	if c == nil {
+		return fmt.Errorf("claim cannot be nil")
+	}
+	if proof == nil {
+		return fmt.Errorf("proof cannot be nil")
+	}
+	if err := VerifyCID(c); err != nil {
+		return err
+	}
+
+	pubBytes, err := hex.DecodeString(witnessID)
+	if err != nil {
+		return fmt.Errorf("invalid witness ID: %w", err)
+	}
+	if len(pubBytes) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key length")
+	}
+
+	payload, err := setRootPayload(root)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pubBytes), payload, signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	if !includes(SetLeafHash(c.ID), proof.LeafIndex, proof.Size, proof.Hashes, root) {
+		return fmt.Errorf("claim %s is not in the signed set", c.ID)
+	}
+	return nil
+}
+
+// SetLeafHash returns the Merkle leaf hash of a claim CID in a signed set
+func SetLeafHash(cid string) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(0x00)
+	_ = writeString(&buf, "claim-graph/set-leaf")
+	_ = writeString(&buf, cid)
+
+	sum := sha256.Sum256(buf.Bytes())
+	return sum[:]
+}
+
+// setRootPayload is the byte string a witness signs for a set
+func setRootPayload(root []byte) ([]byte, error) {
+	if len(root) != sha256.Size {
+		return nil, fmt.Errorf("invalid root length: got %d, want %d", len(root), sha256.Size)
+	}
+
+	var buf bytes.Buffer
+	if err := writeString(&buf, "claim-graph/set-attestation"); err != nil {
+		return nil, err
+	}
+	if err := writeString(&buf, string(root)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
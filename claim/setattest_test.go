@@ -0,0 +1,66 @@
+package claim
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAttestation(t *testing.T) {
+	w := DeterministicWitness("alice")
+
+	var claims []*Claim
+	for i := 0; i < 7; i++ {
+		c, err := NewClaim(Statement{Subject: fmt.Sprintf("match-%d", i), Predicate: "result", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		claims = append(claims, c)
+	}
+
+	set, err := w.AttestSet(claims)
+	require.NoError(t, err)
+	require.Len(t, set.Proofs, len(claims))
+	assert.Equal(t, w.ID, set.WitnessID)
+
+	t.Run("members verify", func(t *testing.T) {
+		for i, c := range claims {
+			assert.NoError(t, VerifySetAttestation(c, set.Root, set.Proofs[i], set.Signature, w.ID), "claim %d", i)
+		}
+	})
+
+	t.Run("non-member rejected", func(t *testing.T) {
+		outsider, err := NewClaim(Statement{Subject: "match-99", Predicate: "result", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		for _, proof := range set.Proofs {
+			assert.Error(t, VerifySetAttestation(outsider, set.Root, proof, set.Signature, w.ID))
+		}
+	})
+
+	t.Run("wrong proof rejected", func(t *testing.T) {
+		assert.Error(t, VerifySetAttestation(claims[0], set.Root, set.Proofs[1], set.Signature, w.ID))
+	})
+
+	t.Run("tampered claim rejected", func(t *testing.T) {
+		tampered := *claims[2]
+		tampered.Statement.Object = "changed"
+		assert.Error(t, VerifySetAttestation(&tampered, set.Root, set.Proofs[2], set.Signature, w.ID))
+	})
+
+	t.Run("other witness rejected", func(t *testing.T) {
+		other := DeterministicWitness("bob")
+		assert.Error(t, VerifySetAttestation(claims[0], set.Root, set.Proofs[0], set.Signature, other.ID))
+	})
+
+	t.Run("single claim set", func(t *testing.T) {
+		single, err := w.AttestSet(claims[:1])
+		require.NoError(t, err)
+		assert.Equal(t, SetLeafHash(claims[0].ID), single.Root)
+		assert.NoError(t, VerifySetAttestation(claims[0], single.Root, single.Proofs[0], single.Signature, w.ID))
+	})
+
+	t.Run("empty set rejected", func(t *testing.T) {
+		_, err := w.AttestSet(nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,56 @@
+package claim
+
+import (
+	"sync"
+	"time"
+)
+
+// reputationShards is the number of independently locked partitions a
+// ReputationStore spreads witness records over, so updates for different
+// witnesses rarely contend
+const reputationShards = 64
+
+// recordShard holds the records of the witnesses whose IDs hash to it
+type recordShard struct {
+	mu      sync.RWMutex
+	records map[string]*ReputationRecord
+}
+
+func newRecordShards(n int) []*recordShard {
+	shards := make([]*recordShard, n)
+	for i := range shards {
+		shards[i] = &recordShard{records: make(map[string]*ReputationRecord)}
+	}
+	return shards
+}
+
+// shard returns the shard holding a witness's record. A shard's lock is
+// always taken after rs.mu, never before.
+func (rs *ReputationStore) shard(witnessID string) *recordShard {
+	// FNV-1a, inlined to avoid allocating a hash.Hash per lookup
+	h := uint32(2166136261)
+	for i := 0; i < len(witnessID); i++ {
+		h ^= uint32(witnessID[i])
+		h *= 16777619
+	}
+	return rs.shards[h%uint32(len(rs.shards))]
+}
+
+// eachRecord calls fn with every record, holding each shard's read lock in
+// turn. fn must not modify the record.
+func (rs *ReputationStore) eachRecord(fn func(record *ReputationRecord)) {
+	for _, shard := range rs.shards {
+		shard.mu.RLock()
+		for _, record := range shard.records {
+			fn(record)
+		}
+		shard.mu.RUnlock()
+	}
+}
+
+// currentTime reads the store's clock. Callers must not hold rs.mu.
+func (rs *ReputationStore) currentTime() time.Time {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.now()
+}
@@ -0,0 +1,82 @@
+package claim
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReputationSharding(t *testing.T) {
+	rs := NewReputationStore()
+
+	const (
+		witnesses = 200
+		workers   = 16
+		rounds    = 50
+	)
+
+	// Every worker updates every witness, so shards see concurrent writes
+	// to the same records as well as to different ones
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				for i := 0; i < witnesses; i++ {
+					id := fmt.Sprintf("witness-%d", i)
+					rs.RecordAttestation(id, "sports")
+					rs.RecordAgreement(id, "sports")
+					if r%10 == 0 {
+						rs.RecordDispute(id, "sports")
+					}
+				}
+				_ = rs.Records()
+			}
+		}()
+	}
+	wg.Wait()
+
+	records := rs.Records()
+	require.Len(t, records, witnesses)
+	for _, record := range records {
+		assert.Equal(t, int64(workers*rounds), record.TotalClaims, record.WitnessID)
+		assert.Equal(t, int64(workers*rounds), record.AgreedClaims, record.WitnessID)
+		assert.Equal(t, int64(workers*rounds/10), record.DisputedClaims, record.WitnessID)
+		assert.Equal(t, int64(workers*rounds), record.Domains["sports"].TotalClaims, record.WitnessID)
+	}
+
+	t.Run("witnesses spread over shards", func(t *testing.T) {
+		used := 0
+		for _, shard := range rs.shards {
+			if len(shard.records) > 0 {
+				used++
+			}
+		}
+		assert.Greater(t, used, reputationShards/2)
+	})
+}
+
+// BenchmarkRecordAttestationParallel compares concurrent updates to
+// different witnesses against a single shard, which behaves like one
+// store-wide lock. Run with -race to check the sharded path as well.
+func BenchmarkRecordAttestationParallel(b *testing.B) {
+	for _, shards := range []int{1, reputationShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			rs := NewReputationStore()
+			rs.shards = newRecordShards(shards)
+
+			var next int64
+			b.RunParallel(func(pb *testing.PB) {
+				id := fmt.Sprintf("witness-%d", atomic.AddInt64(&next, 1))
+				for pb.Next() {
+					rs.RecordAttestation(id, "sports")
+				}
+			})
+		})
+	}
+}
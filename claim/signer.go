@@ -0,0 +1,134 @@
+package claim
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+)
+
+// CanSign reports whether the witness can sign attestations, with its
+// private key or its Signer
+func (w *Witness) CanSign() bool {
+	return w.PrivateKey != nil || w.Signer != nil
+}
+
+// AttestationDigest returns the digest a prehash signer signs for an
+// attestation: the SHA-512 hash of its signing payload, as Ed25519ph
+// requires. Hardware signers that only accept a digest are given this
+// instead of the payload.
+func AttestationDigest(claim *Claim, att *Attestation) ([]byte, error) {
+	if claim == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+	if att == nil {
+		return nil, fmt.Errorf("attestation cannot be nil")
+	}
+
+	payload, err := signingPayload(claim, att)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha512.Sum512(payload)
+	return sum[:], nil
+}
+
+// signer returns the witness's Signer, or its private key if it has none.
+// A Signer for another key is refused, since its signatures would never
+// verify under the witness's ID.
+func (w *Witness) signer() (crypto.Signer, error) {
+	if w.Signer == nil {
+		if w.PrivateKey == nil {
+			return nil, fmt.Errorf("witness has no private key")
+		}
+		return w.PrivateKey, nil
+	}
+
+	pub, ok := w.Signer.Public().(ed25519.PublicKey)
+	if !ok || !pub.Equal(w.PublicKey) {
+		return nil, fmt.Errorf("signer's public key does not match the witness")
+	}
+	return w.Signer, nil
+}
+
+// signPayload signs a witness statement other than an attestation, such
+// as a profile or reset request. These verify as pure Ed25519, so a
+// signer that only signs digests cannot make them.
+func (w *Witness) signPayload(payload []byte) ([]byte, error) {
+	signer, err := w.signer()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	return sig, nil
+}
+
+// signAttestation signs the attestation's payload, or its digest when the
+// witness signs with Ed25519ph
+func (w *Witness) signAttestation(claim *Claim, att *Attestation) ([]byte, error) {
+	signer, err := w.signer()
+	if err != nil {
+		return nil, err
+	}
+
+	if att.Prehash {
+		digest, err := AttestationDigest(claim, att)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := signer.Sign(rand.Reader, digest, &ed25519.Options{Hash: crypto.SHA512})
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign attestation digest: %w", err)
+		}
+		return sig, nil
+	}
+
+	payload, err := signingPayload(claim, att)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign attestation: %w", err)
+	}
+	return sig, nil
+}
+
+// verifyAttestationSignature checks an attestation's signature as pure
+// Ed25519 or, when it is marked prehashed, as Ed25519ph
+func verifyAttestationSignature(pubKey ed25519.PublicKey, claim *Claim, att *Attestation) error {
+	payload, err := signingPayload(claim, att)
+	if err != nil {
+		return err
+	}
+
+	if !att.Prehash {
+		if ed25519.Verify(pubKey, payload, att.Signature) {
+			return nil
+		}
+		// Plain endorsements made before the domain was signed cover the
+		// bare claim ID. The CID already covers the domain, so accepting
+		// them cannot replay one onto a claim in another domain.
+		if isLegacyEndorsement(claim, att) && ed25519.Verify(pubKey, []byte(claim.ID), att.Signature) {
+			return nil
+		}
+		return fmt.Errorf("invalid signature")
+	}
+
+	digest := sha512.Sum512(payload)
+	if err := ed25519.VerifyWithOptions(pubKey, digest[:], att.Signature, &ed25519.Options{Hash: crypto.SHA512}); err != nil {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// isLegacyEndorsement reports whether an attestation is a plain endorsement
+// of a claim with a domain, which signed the bare claim ID before the
+// domain was added to the signing payload
+func isLegacyEndorsement(claim *Claim, att *Attestation) bool {
+	return !att.hasSignedFields() && claim.Statement.Domain != ""
+}
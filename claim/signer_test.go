@@ -0,0 +1,111 @@
+package claim
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// digestSigner stands in for a hardware key that only signs digests
+type digestSigner struct {
+	key     ed25519.PrivateKey
+	digests [][]byte
+}
+
+func (s *digestSigner) Public() crypto.PublicKey { return s.key.Public() }
+
+func (s *digestSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.SHA512 {
+		return nil, fmt.Errorf("only prehashed input is supported")
+	}
+	s.digests = append(s.digests, append([]byte(nil), digest...))
+	return s.key.Sign(rand, digest, opts)
+}
+
+func TestPrehashAttestation(t *testing.T) {
+	c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	key := DeterministicWitness("hsm")
+
+	t.Run("prehash", func(t *testing.T) {
+		signer := &digestSigner{key: key.PrivateKey}
+		w := WitnessFromPublicKey(key.PublicKey)
+		w.Signer = signer
+		w.Prehash = true
+		require.True(t, w.CanSign())
+
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		assert.True(t, att.Prehash)
+		require.NoError(t, VerifyAttestation(c, att))
+
+		// The signer saw only the digest
+		digest, err := AttestationDigest(c, att)
+		require.NoError(t, err)
+		require.Len(t, signer.digests, 1)
+		assert.Equal(t, digest, signer.digests[0])
+
+		// A prehash signature does not verify as a pure one
+		att.Prehash = false
+		assert.Error(t, VerifyAttestation(c, att))
+	})
+
+	t.Run("pure", func(t *testing.T) {
+		att, err := key.Attest(c)
+		require.NoError(t, err)
+		assert.False(t, att.Prehash)
+		require.NoError(t, VerifyAttestation(c, att))
+
+		att.Prehash = true
+		assert.Error(t, VerifyAttestation(c, att))
+	})
+
+	t.Run("digest-only signer refuses pure signing", func(t *testing.T) {
+		w := WitnessFromPublicKey(key.PublicKey)
+		w.Signer = &digestSigner{key: key.PrivateKey}
+		_, err := w.Attest(c)
+		assert.Error(t, err)
+	})
+}
+
+func TestSignerStatements(t *testing.T) {
+	key := DeterministicWitness("hsm")
+	c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+
+	t.Run("signer signs profiles, resets and sets", func(t *testing.T) {
+		w := WitnessFromPublicKey(key.PublicKey)
+		w.Signer = key.PrivateKey
+
+		profile, err := w.SignProfile("acme", 64500, "eu-west")
+		require.NoError(t, err)
+		assert.NoError(t, VerifyProfile(profile))
+
+		req, err := w.RequestReset("key rotation")
+		require.NoError(t, err)
+		assert.NoError(t, VerifyResetRequest(req))
+
+		set, err := w.AttestSet([]*Claim{c})
+		require.NoError(t, err)
+		assert.NoError(t, VerifySetAttestation(c, set.Root, set.Proofs[0], set.Signature, set.WitnessID))
+	})
+
+	t.Run("signer for another key is refused", func(t *testing.T) {
+		w := WitnessFromPublicKey(key.PublicKey)
+		w.Signer = DeterministicWitness("other").PrivateKey
+
+		_, err := w.Attest(c)
+		assert.ErrorContains(t, err, "does not match")
+		_, err = w.SignProfile("acme", 64500, "eu-west")
+		assert.ErrorContains(t, err, "does not match")
+		_, err = w.RequestReset("key rotation")
+		assert.ErrorContains(t, err, "does not match")
+		_, err = w.AttestSet([]*Claim{c})
+		assert.ErrorContains(t, err, "does not match")
+	})
+}
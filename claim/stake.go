@@ -0,0 +1,234 @@
+package claim
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StakeLedger tracks the stake witnesses have put up behind their
+// attestations. A witness that loses a dispute is slashed, and its
+// attestations then carry weight in proportion to the stake it has left.
+// Witnesses that never staked are unaffected. Each ReputationStore has
+// one (see ReputationStore.Stakes).
+type StakeLedger struct {
+	rs *ReputationStore // Time source
+
+	mu       sync.RWMutex
+	accounts map[string]*stakeAccount
+	slashes  []SlashRecord
+	settled  map[settledKey]bool // Witnesses slashed per disputed claim
+}
+
+// settledKey identifies a witness slashed over a claim's dispute
+type settledKey struct {
+	claimID   string
+	witnessID string
+}
+
+// stakeAccount is one witness's stake
+type stakeAccount struct {
+	deposited float64
+	balance   float64
+}
+
+// SlashRecord is the audit record of one slashing
+type SlashRecord struct {
+	WitnessID string    `json:"witness_id"`
+	ClaimID   string    `json:"claim_id,omitempty"` // Set when slashed by ResolveAndSlash
+	Amount    float64   `json:"amount"`             // Stake taken, at most what was left
+	Remaining float64   `json:"remaining"`
+	Reason    string    `json:"reason"`
+	Time      time.Time `json:"time"`
+}
+
+func newStakeLedger(rs *ReputationStore) *StakeLedger {
+	return &StakeLedger{
+		rs:       rs,
+		accounts: make(map[string]*stakeAccount),
+		settled:  make(map[settledKey]bool),
+	}
+}
+
+// Stakes returns the store's stake ledger
+func (rs *ReputationStore) Stakes() *StakeLedger {
+	return rs.stakes
+}
+
+// Deposit adds to a witness's stake
+func (l *StakeLedger) Deposit(witnessID string, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("deposit must be positive")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	account := l.accounts[witnessID]
+	if account == nil {
+		account = &stakeAccount{}
+		l.accounts[witnessID] = account
+	}
+	account.deposited += amount
+	account.balance += amount
+	return nil
+}
+
+// Stake returns a witness's remaining stake
+func (l *StakeLedger) Stake(witnessID string) float64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if account := l.accounts[witnessID]; account != nil {
+		return account.balance
+	}
+	return 0
+}
+
+// Weight returns the factor a witness's attestation weight is scaled by:
+// the share of its deposited stake it has left, or 1 if it never staked.
+// Staking is opt-in, so an unstaked witness counts as much as a fully
+// staked one and has nothing to lose; reputation, not stake, is what
+// holds it back. Applications that want stake to matter should only
+// count staked witnesses, or give unstaked ones low reputation.
+func (l *StakeLedger) Weight(witnessID string) float64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	account := l.accounts[witnessID]
+	if account == nil {
+		return 1
+	}
+	return account.balance / account.deposited
+}
+
+// Slash takes up to amount of a witness's stake and records why. A witness
+// cannot lose more than it has left.
+func (l *StakeLedger) Slash(witnessID string, amount float64, reason string) error {
+	return l.slash("", witnessID, amount, reason)
+}
+
+// slash takes stake as Slash does. With a claim ID, it refuses to slash a
+// witness over the same claim twice.
+func (l *StakeLedger) slash(claimID, witnessID string, amount float64, reason string) error {
+	if amount <= 0 {
+		return fmt.Errorf("slash amount must be positive")
+	}
+	if reason == "" {
+		return fmt.Errorf("slash reason is required")
+	}
+	now := l.rs.currentTime()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	account := l.accounts[witnessID]
+	if account == nil {
+		return fmt.Errorf("witness %s has no stake", witnessID)
+	}
+	key := settledKey{claimID: claimID, witnessID: witnessID}
+	if claimID != "" {
+		if l.settled[key] {
+			return fmt.Errorf("witness %s already slashed for claim %s", witnessID, claimID)
+		}
+		l.settled[key] = true
+	}
+	if amount > account.balance {
+		amount = account.balance
+	}
+	account.balance -= amount
+
+	l.slashes = append(l.slashes, SlashRecord{
+		WitnessID: witnessID,
+		ClaimID:   claimID,
+		Amount:    amount,
+		Remaining: account.balance,
+		Reason:    reason,
+		Time:      now.UTC(),
+	})
+	return nil
+}
+
+// Slashes returns the slashing records for a witness, or for every witness
+// if witnessID is empty, oldest first
+func (l *StakeLedger) Slashes(witnessID string) []SlashRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var records []SlashRecord
+	for _, r := range l.slashes {
+		if witnessID == "" || r.WitnessID == witnessID {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// slashedFor reports whether a witness was already slashed over a claim
+func (l *StakeLedger) slashedFor(claimID, witnessID string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.settled[settledKey{claimID: claimID, witnessID: witnessID}]
+}
+
+// ResolveAndSlash resolves a claim's dispute (see Resolve) and slashes
+// penalty from every staked witness whose latest valid attestation took
+// the losing side. Only the store's arbiters decide the outcome, and
+// "latest" is judged by signed time alone. Arbiters and unstaked witnesses
+// are not slashed. A witness is slashed at most once per claim: calling it
+// again slashes only losers not slashed before, and fails if there are
+// none. It returns the resolution and the IDs of the witnesses slashed,
+// sorted.
+func ResolveAndSlash(c *Claim, ledger *StakeLedger, penalty float64) (*Resolution, []string, error) {
+	if penalty <= 0 {
+		return nil, nil, fmt.Errorf("penalty must be positive")
+	}
+	resolution, err := Resolve(c, ledger.rs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	losing := StanceEndorse
+	if resolution.Outcome == StanceEndorse {
+		losing = StanceDispute
+	}
+
+	// Select each witness's latest among valid attestations only, so a
+	// forged one cannot displace the witness's real stance. Resolve has
+	// verified the CID.
+	var valid []Attestation
+	for i := range c.Witnesses {
+		att := &c.Witnesses[i]
+		if ledger.rs.revokedMember(att) || verifySignedAttestation(c, att) != nil {
+			continue
+		}
+		valid = append(valid, *att)
+	}
+
+	var slashed []string
+	repeated := false
+	for _, att := range latestAttestations(valid) {
+		if att.Stance != losing || ledger.rs.IsArbiter(att.WitnessID) {
+			continue
+		}
+		if ledger.Stake(att.WitnessID) <= 0 {
+			continue
+		}
+		if ledger.slashedFor(c.ID, att.WitnessID) {
+			repeated = true
+			continue
+		}
+		reason := fmt.Sprintf("lost dispute on claim %s", c.ID)
+		if err := ledger.slash(c.ID, att.WitnessID, penalty, reason); err != nil {
+			return resolution, slashed, err
+		}
+		slashed = append(slashed, att.WitnessID)
+	}
+	if repeated && len(slashed) == 0 {
+		return resolution, nil, fmt.Errorf("dispute on claim %s already slashed", c.ID)
+	}
+
+	sort.Strings(slashed)
+	return resolution, slashed, nil
+}
@@ -0,0 +1,130 @@
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStakeSlashing(t *testing.T) {
+	rs := NewReputationStore()
+	rs.SetClock(FixedClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	ledger := rs.Stakes()
+
+	alice := DeterministicWitness("alice")
+	bob := DeterministicWitness("bob")
+	carol := DeterministicWitness("carol")
+	judge := DeterministicWitness("judge")
+	require.NoError(t, ledger.Deposit(alice.ID, 100))
+	require.NoError(t, ledger.Deposit(bob.ID, 100))
+	require.NoError(t, ledger.Deposit(judge.ID, 100))
+
+	// Reputable witnesses, so the side a vote is on matters to confidence
+	for _, w := range []*Witness{alice, bob} {
+		for i := 0; i < 50; i++ {
+			rs.RecordAttestation(w.ID, "sports")
+			rs.RecordAgreement(w.ID, "sports")
+		}
+	}
+
+	c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	attest := func(w *Witness, stance Stance) {
+		att, err := w.AttestWithStance(c, stance)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+	}
+	attest(alice, StanceEndorse)
+	attest(bob, StanceDispute)
+	attest(carol, StanceDispute) // Unstaked
+
+	before := ExplainConfidence(c, rs)
+
+	require.NoError(t, rs.SetArbiters([]string{judge.ID}))
+	require.NoError(t, Escalate(c))
+	attest(judge, StanceEndorse)
+
+	t.Run("resolved dispute slashes the losers", func(t *testing.T) {
+		resolution, slashed, err := ResolveAndSlash(c, ledger, 40)
+		require.NoError(t, err)
+		assert.Equal(t, StanceEndorse, resolution.Outcome)
+		assert.Equal(t, []string{bob.ID}, slashed)
+
+		assert.Equal(t, 100.0, ledger.Stake(alice.ID))
+		assert.Equal(t, 60.0, ledger.Stake(bob.ID))
+		assert.Equal(t, 100.0, ledger.Stake(judge.ID))
+	})
+
+	t.Run("slash is recorded", func(t *testing.T) {
+		records := ledger.Slashes(bob.ID)
+		require.Len(t, records, 1)
+		assert.Equal(t, SlashRecord{
+			WitnessID: bob.ID,
+			ClaimID:   c.ID,
+			Amount:    40,
+			Remaining: 60,
+			Reason:    "lost dispute on claim " + c.ID,
+			Time:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, records[0])
+		assert.Empty(t, ledger.Slashes(alice.ID))
+		assert.Len(t, ledger.Slashes(""), 1)
+	})
+
+	t.Run("repeat resolution does not slash again", func(t *testing.T) {
+		_, slashed, err := ResolveAndSlash(c, ledger, 40)
+		assert.Error(t, err)
+		assert.Empty(t, slashed)
+		assert.Equal(t, 60.0, ledger.Stake(bob.ID))
+	})
+
+	t.Run("forged attestation does not get a witness slashed", func(t *testing.T) {
+		// A re-dated copy of alice's endorsement flipped to dispute fails
+		// verification, so her real, winning stance still counts
+		forged := c.Witnesses[0]
+		require.Equal(t, alice.ID, forged.WitnessID)
+		forged.Stance = StanceDispute
+		forged.Timestamp = forged.Timestamp.Add(time.Hour)
+		tampered := *c
+		tampered.Witnesses = append(append([]Attestation(nil), c.Witnesses...), forged)
+
+		_, _, err := ResolveAndSlash(&tampered, ledger, 40)
+		assert.Error(t, err)
+		assert.Equal(t, 100.0, ledger.Stake(alice.ID))
+	})
+
+	t.Run("slashed stake reduces attestation weight", func(t *testing.T) {
+		assert.Equal(t, 1.0, ledger.Weight(alice.ID))
+		assert.Equal(t, 0.6, ledger.Weight(bob.ID))
+		assert.Equal(t, 1.0, ledger.Weight(carol.ID))
+
+		// Scored on the unresolved tally, bob's dispute now counts for less
+		unresolved := *c
+		unresolved.Resolution = nil
+		after := ExplainConfidence(&unresolved, rs)
+		weights := func(e *ConfidenceExplanation) map[string]float64 {
+			m := make(map[string]float64)
+			for _, w := range e.Witnesses {
+				m[w.WitnessID] = w.Weight
+			}
+			return m
+		}
+		assert.InDelta(t, weights(before)[bob.ID]*0.6, weights(after)[bob.ID], 1e-9)
+		assert.Equal(t, weights(before)[alice.ID], weights(after)[alice.ID])
+		assert.Greater(t, after.Confidence, before.Confidence)
+	})
+
+	t.Run("slash is capped at the remaining stake", func(t *testing.T) {
+		require.NoError(t, ledger.Slash(bob.ID, 500, "equivocation"))
+		assert.Zero(t, ledger.Stake(bob.ID))
+		records := ledger.Slashes(bob.ID)
+		assert.Equal(t, 60.0, records[len(records)-1].Amount)
+	})
+
+	t.Run("invalid slashes", func(t *testing.T) {
+		assert.Error(t, ledger.Slash(carol.ID, 10, "unstaked"))
+		assert.Error(t, ledger.Slash(alice.ID, 0, "nothing"))
+		assert.Error(t, ledger.Slash(alice.ID, 10, ""))
+	})
+}
@@ -0,0 +1,48 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// StateHash returns a hex SHA-256 digest of everything verifying a claim
+// depends on: its content, its ID and each attestation's witness, signed
+// payload and signature. It changes whenever an attestation is added,
+// removed or altered, so it can key cached verification results.
+func StateHash(c *Claim) (string, error) {
+	if c == nil {
+		return "", fmt.Errorf("claim cannot be nil")
+	}
+
+	content, err := CanonicalBytes(c)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for _, s := range []string{"claim-graph/state", c.ID, string(content)} {
+		if err := writeString(&buf, s); err != nil {
+			return "", err
+		}
+	}
+
+	for i := range c.Witnesses {
+		att := &c.Witnesses[i]
+		// An attestation whose payload cannot be built fails verification
+		// the same way every time, so its error stands in for the payload
+		payload, err := signingPayload(c, att)
+		if err != nil {
+			payload = []byte("error:" + err.Error())
+		}
+		for _, s := range []string{att.WitnessID, string(payload), string(att.Signature)} {
+			if err := writeString(&buf, s); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
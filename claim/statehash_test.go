@@ -0,0 +1,44 @@
+package claim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateHash(t *testing.T) {
+	c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+
+	empty, err := StateHash(c)
+	require.NoError(t, err)
+
+	again, err := StateHash(c)
+	require.NoError(t, err)
+	assert.Equal(t, empty, again)
+
+	att, err := DeterministicWitness("alice").Attest(c)
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	attested, err := StateHash(c)
+	require.NoError(t, err)
+	assert.NotEqual(t, empty, attested)
+
+	t.Run("tampered signature", func(t *testing.T) {
+		tampered := *c
+		tampered.Witnesses = append([]Attestation(nil), c.Witnesses...)
+		tampered.Witnesses[0].Signature = append([]byte(nil), att.Signature...)
+		tampered.Witnesses[0].Signature[0] ^= 0xff
+
+		hash, err := StateHash(&tampered)
+		require.NoError(t, err)
+		assert.NotEqual(t, attested, hash)
+	})
+
+	t.Run("nil claim", func(t *testing.T) {
+		_, err := StateHash(nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,73 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// StatementKey returns the key of a claim's equivalence class: claims
+// whose statements differ only in incidental spelling share a key, even
+// when their timestamps and evidence differ. Subjects, objects and domains
+// are trimmed, predicates and domains are lowercased, and URL subjects are
+// normalized (see normalizeSubject). A quantity or object list is part of
+// the statement, in the canonical form the CID hashes, and so is the
+// namespace. The key is the hex SHA-256 of the normalized fields.
+func StatementKey(c *Claim) string {
+	if c == nil {
+		return ""
+	}
+
+	s := c.Statement
+	var buf bytes.Buffer
+	_ = writeField(&buf, "subject", normalizeSubject(s.Subject))
+	_ = writeField(&buf, "predicate", strings.ToLower(strings.TrimSpace(s.Predicate)))
+	_ = writeField(&buf, "object", strings.TrimSpace(s.Object))
+	_ = writeField(&buf, "domain", strings.ToLower(strings.TrimSpace(s.Domain)))
+	if c.Quantity != nil {
+		_ = writeField(&buf, "quantity", c.Quantity.String())
+	}
+	if c.Objects != nil {
+		if tag, value, err := c.Objects.canonicalField(); err == nil {
+			_ = writeField(&buf, tag, value)
+		}
+	}
+	if c.Namespace != "" {
+		_ = writeField(&buf, "namespace", c.Namespace)
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeSubject trims a subject and, for http and https URLs, lowercases
+// the scheme and host, drops a default port, the fragment and a trailing
+// slash, and sorts the query parameters. Other subjects are only trimmed.
+func normalizeSubject(subject string) string {
+	subject = strings.TrimSpace(subject)
+
+	u, err := url.Parse(subject)
+	if err != nil || u.Host == "" {
+		return subject
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return subject
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+
+	u.Scheme = scheme
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	u.RawQuery = u.Query().Encode() // Encode sorts by key
+	return u.String()
+}
@@ -0,0 +1,62 @@
+package claim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementKey(t *testing.T) {
+	keyOf := func(s Statement) string {
+		return StatementKey(&Claim{Statement: s})
+	}
+
+	base := Statement{Subject: "https://example.com/page?a=1&b=2", Predicate: "contains", Object: "hello", Domain: "web"}
+	key := keyOf(base)
+
+	same := []Statement{
+		{Subject: "HTTPS://Example.COM/page?a=1&b=2", Predicate: "contains", Object: "hello", Domain: "web"},
+		{Subject: "https://example.com:443/page/?b=2&a=1", Predicate: "contains", Object: "hello", Domain: "web"},
+		{Subject: "https://example.com/page?a=1&b=2#section", Predicate: "Contains", Object: "hello", Domain: "Web"},
+		{Subject: "  https://example.com/page?a=1&b=2 ", Predicate: " CONTAINS ", Object: " hello ", Domain: "web"},
+	}
+	for _, s := range same {
+		assert.Equal(t, key, keyOf(s), "%+v", s)
+	}
+
+	different := []Statement{
+		{Subject: "https://example.com/other?a=1&b=2", Predicate: "contains", Object: "hello", Domain: "web"},
+		{Subject: "https://example.com:8443/page?a=1&b=2", Predicate: "contains", Object: "hello", Domain: "web"},
+		{Subject: "https://example.com/page?a=1&b=2", Predicate: "contains", Object: "Hello", Domain: "web"},
+		{Subject: "https://example.com/page?a=1&b=2", Predicate: "contains", Object: "hello", Domain: "news"},
+	}
+	for _, s := range different {
+		assert.NotEqual(t, key, keyOf(s), "%+v", s)
+	}
+
+	t.Run("non-URL subjects are only trimmed", func(t *testing.T) {
+		a := keyOf(Statement{Subject: " match-1", Predicate: "result", Object: "2-1"})
+		assert.Equal(t, a, keyOf(Statement{Subject: "match-1", Predicate: "Result", Object: "2-1"}))
+		assert.NotEqual(t, a, keyOf(Statement{Subject: "Match-1", Predicate: "result", Object: "2-1"}))
+	})
+
+	t.Run("quantity, objects and namespace are part of the key", func(t *testing.T) {
+		s := Statement{Subject: "btc", Predicate: "price", Domain: "markets"}
+		usd := func(v float64) *Claim {
+			return &Claim{Statement: s, Quantity: &Quantity{Value: v, Unit: "USD"}}
+		}
+		assert.Equal(t, StatementKey(usd(100)), StatementKey(&Claim{Statement: s, Quantity: &Quantity{Value: 100, Unit: "usd"}}))
+		assert.NotEqual(t, StatementKey(usd(100)), StatementKey(usd(101)))
+		assert.NotEqual(t, StatementKey(usd(100)), keyOf(s))
+
+		set := func(values ...string) *Claim {
+			return &Claim{Statement: s, Objects: &ObjectList{Values: values}}
+		}
+		assert.Equal(t, StatementKey(set("a", "b")), StatementKey(set("b", "a")))
+		assert.NotEqual(t, StatementKey(set("a", "b")), StatementKey(set("a", "c")))
+
+		namespaced := usd(100)
+		namespaced.Namespace = "exchange-a"
+		assert.NotEqual(t, StatementKey(usd(100)), StatementKey(namespaced))
+	})
+}
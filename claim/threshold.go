@@ -0,0 +1,77 @@
+package claim
+
+// ThresholdEvent reports a claim's confidence crossing a threshold
+type ThresholdEvent struct {
+	// CID is the claim whose confidence changed
+	CID string
+
+	// Threshold is the threshold that was crossed
+	Threshold float64
+
+	// Before and After are the claim's confidence around the update
+	Before float64
+	After  float64
+
+	// Rising reports whether confidence rose to the threshold or above,
+	// rather than falling below it
+	Rising bool
+}
+
+// thresholdListener is a callback registered with OnThreshold
+type thresholdListener struct {
+	threshold float64
+	fn        func(ThresholdEvent)
+}
+
+// OnThreshold registers fn to be called whenever a claim updated through
+// AddAttestation crosses threshold, either rising to it or above, or
+// falling below it. It is called synchronously, without the store locked.
+// The returned function unregisters fn.
+func (rs *ReputationStore) OnThreshold(threshold float64, fn func(ThresholdEvent)) (unsubscribe func()) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.thresholds == nil {
+		rs.thresholds = make(map[int]thresholdListener)
+	}
+	id := rs.nextListener
+	rs.nextListener++
+	rs.thresholds[id] = thresholdListener{threshold: threshold, fn: fn}
+
+	return func() {
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+		delete(rs.thresholds, id)
+	}
+}
+
+// AddAttestation adds an attestation to a claim, as Claim.AddAttestation
+// does, and notifies OnThreshold listeners for every threshold the claim's
+// confidence crosses as a result
+func (rs *ReputationStore) AddAttestation(c *Claim, att *Attestation) error {
+	before := ClaimConfidence(c, rs)
+	if err := c.AddAttestation(att); err != nil {
+		return err
+	}
+	after := ClaimConfidence(c, rs)
+
+	rs.mu.RLock()
+	var fire []thresholdListener
+	for _, l := range rs.thresholds {
+		if (before >= l.threshold) != (after >= l.threshold) {
+			fire = append(fire, l)
+		}
+	}
+	rs.mu.RUnlock()
+
+	for _, l := range fire {
+		l.fn(ThresholdEvent{
+			CID:       c.ID,
+			Threshold: l.threshold,
+			Before:    before,
+			After:     after,
+			Rising:    after >= l.threshold,
+		})
+	}
+	return nil
+}
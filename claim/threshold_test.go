@@ -0,0 +1,74 @@
+package claim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnThreshold(t *testing.T) {
+	rs := NewReputationStore()
+	c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+
+	var events []ThresholdEvent
+	unsubscribe := rs.OnThreshold(0.6, func(e ThresholdEvent) {
+		events = append(events, e)
+	})
+
+	add := func(stance Stance) {
+		w, _ := GenerateWitness()
+		att, err := w.AttestWithStance(c, stance)
+		require.NoError(t, err)
+		require.NoError(t, rs.AddAttestation(c, att))
+	}
+
+	t.Run("rising crossing fires once", func(t *testing.T) {
+		// Unknown witnesses score 0.5, so the first endorser crosses
+		// only with the witness-count bonus: 0.7
+		add(StanceEndorse)
+		require.Len(t, events, 1)
+		assert.True(t, events[0].Rising)
+		assert.Equal(t, c.ID, events[0].CID)
+		assert.Equal(t, 0.6, events[0].Threshold)
+		assert.Less(t, events[0].Before, 0.6)
+		assert.GreaterOrEqual(t, events[0].After, 0.6)
+
+		add(StanceEndorse)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("falling crossing fires once", func(t *testing.T) {
+		events = nil
+		add(StanceDispute)
+		assert.Empty(t, events)
+
+		add(StanceDispute)
+		require.Len(t, events, 1)
+		assert.False(t, events[0].Rising)
+		assert.Less(t, events[0].After, 0.6)
+
+		add(StanceDispute)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("invalid attestations fire nothing", func(t *testing.T) {
+		events = nil
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		att.Signature[0] ^= 0xFF
+		assert.Error(t, rs.AddAttestation(c, att))
+		assert.Empty(t, events)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		events = nil
+		unsubscribe()
+		for i := 0; i < 5; i++ {
+			add(StanceEndorse)
+		}
+		assert.Empty(t, events)
+	})
+}
@@ -0,0 +1,450 @@
+package claim
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// Object identifiers used by RFC 3161 timestamp tokens
+var (
+	oidSHA256         = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSignedData     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	maxTSAResponseLen = int64(1 << 20)
+)
+
+// TSAClient requests RFC 3161 timestamps from a Time Stamping Authority.
+// The timestamp covers the claim's CID and complements the dag-time
+// beacon anchor with a third-party, wall-clock attestation.
+type TSAClient struct {
+	// URL is the TSA endpoint
+	URL string
+
+	// HTTPClient is used for requests (default: http.DefaultClient)
+	HTTPClient *http.Client
+
+	// Roots are the trusted roots the TSA's certificate must chain to.
+	// Timestamp refuses tokens from a TSA they do not vouch for.
+	Roots *x509.CertPool
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+// issuerAndSerial identifies a signer's certificate by issuer and serial
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// tstInfo holds the TSTInfo fields needed for verification
+type tstInfo struct {
+	Imprint messageImprint
+	GenTime time.Time
+	Nonce   *big.Int
+}
+
+// Timestamp requests a timestamp over the claim's CID and stores the
+// token on the claim. The claim's CID is unaffected.
+func (t *TSAClient) Timestamp(ctx context.Context, c *Claim) error {
+	if c == nil {
+		return fmt.Errorf("claim cannot be nil")
+	}
+
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	digest := sha256.Sum256([]byte(c.ID))
+	reqDER, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			HashedMessage: digest[:],
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode timestamp request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.URL, bytes.NewReader(reqDER))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/timestamp-query")
+
+	client := t.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact TSA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("TSA returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTSAResponseLen))
+	if err != nil {
+		return fmt.Errorf("failed to read TSA response: %w", err)
+	}
+
+	token, err := parseTimeStampResp(body)
+	if err != nil {
+		return err
+	}
+
+	info, _, err := verifyTimestampToken(token, digest[:], t.Roots)
+	if err != nil {
+		return err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return fmt.Errorf("TSA response nonce mismatch")
+	}
+
+	c.TimestampToken = token
+	return nil
+}
+
+// VerifyTSA checks the claim's RFC 3161 timestamp token: that it covers
+// the claim's CID and is validly signed by a TSA certificate that chains
+// to roots and is issued for timestamping. It returns the time asserted
+// by the TSA.
+func VerifyTSA(c *Claim, roots *x509.CertPool) (time.Time, error) {
+	if c == nil {
+		return time.Time{}, fmt.Errorf("claim cannot be nil")
+	}
+	if len(c.TimestampToken) == 0 {
+		return time.Time{}, fmt.Errorf("claim has no timestamp token")
+	}
+
+	digest := sha256.Sum256([]byte(c.ID))
+	info, _, err := verifyTimestampToken(c.TimestampToken, digest[:], roots)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.GenTime, nil
+}
+
+// TSACertificate returns the certificate that signed the claim's
+// timestamp token, after verifying the token as VerifyTSA does
+func TSACertificate(c *Claim, roots *x509.CertPool) (*x509.Certificate, error) {
+	if c == nil || len(c.TimestampToken) == 0 {
+		return nil, fmt.Errorf("claim has no timestamp token")
+	}
+
+	digest := sha256.Sum256([]byte(c.ID))
+	_, cert, err := verifyTimestampToken(c.TimestampToken, digest[:], roots)
+	return cert, err
+}
+
+// parseTimeStampResp checks a TimeStampResp status and returns its token
+func parseTimeStampResp(der []byte) ([]byte, error) {
+	var resp asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, fmt.Errorf("invalid timestamp response: %w", err)
+	}
+
+	var statusInfo asn1.RawValue
+	rest, err := asn1.Unmarshal(resp.Bytes, &statusInfo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp status: %w", err)
+	}
+
+	var status int
+	if _, err := asn1.Unmarshal(statusInfo.Bytes, &status); err != nil {
+		return nil, fmt.Errorf("invalid timestamp status: %w", err)
+	}
+	// 0 = granted, 1 = grantedWithMods
+	if status != 0 && status != 1 {
+		return nil, fmt.Errorf("TSA rejected request with status %d", status)
+	}
+
+	var token asn1.RawValue
+	if _, err := asn1.Unmarshal(rest, &token); err != nil {
+		return nil, fmt.Errorf("timestamp response has no token: %w", err)
+	}
+	return token.FullBytes, nil
+}
+
+// verifyTimestampToken verifies a TimeStampToken against the expected
+// SHA-256 message imprint and trusted roots, and returns its TSTInfo and
+// signing certificate
+func verifyTimestampToken(token, expectedDigest []byte, roots *x509.CertPool) (*tstInfo, *x509.Certificate, error) {
+	if roots == nil {
+		return nil, nil, fmt.Errorf("no trusted TSA roots")
+	}
+
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(token, &ci); err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp token: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, nil, fmt.Errorf("timestamp token is not signed data")
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, nil, fmt.Errorf("invalid signed data: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, nil, fmt.Errorf("timestamp token does not contain TSTInfo")
+	}
+
+	info, err := parseTSTInfo(sd.EncapContentInfo.EContent)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.Imprint.HashAlgorithm.Algorithm.Equal(oidSHA256) {
+		return nil, nil, fmt.Errorf("unsupported imprint hash algorithm %v", info.Imprint.HashAlgorithm.Algorithm)
+	}
+	if !bytes.Equal(info.Imprint.HashedMessage, expectedDigest) {
+		return nil, nil, fmt.Errorf("timestamp does not cover this claim")
+	}
+
+	if len(sd.SignerInfos) != 1 {
+		return nil, nil, fmt.Errorf("expected one signer, got %d", len(sd.SignerInfos))
+	}
+	si := sd.SignerInfos[0]
+	if !si.DigestAlgorithm.Algorithm.Equal(oidSHA256) {
+		return nil, nil, fmt.Errorf("unsupported digest algorithm %v", si.DigestAlgorithm.Algorithm)
+	}
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp token certificates: %w", err)
+	}
+	cert, err := signerCertificate(si.SID, certs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The signature covers the signed attributes, which in turn bind the
+	// TSTInfo through the messageDigest attribute
+	if len(si.SignedAttrs.Bytes) == 0 {
+		return nil, nil, fmt.Errorf("timestamp token has no signed attributes")
+	}
+	contentDigest := sha256.Sum256(sd.EncapContentInfo.EContent)
+	if err := checkMessageDigest(si.SignedAttrs.Bytes, contentDigest[:]); err != nil {
+		return nil, nil, err
+	}
+
+	// Signed attributes are signed as a DER SET, not with the implicit tag
+	attrsDER, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: si.SignedAttrs.Bytes})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sigAlg x509.SignatureAlgorithm
+	switch cert.PublicKeyAlgorithm {
+	case x509.ECDSA:
+		sigAlg = x509.ECDSAWithSHA256
+	case x509.RSA:
+		sigAlg = x509.SHA256WithRSA
+	case x509.Ed25519:
+		sigAlg = x509.PureEd25519
+	default:
+		return nil, nil, fmt.Errorf("unsupported TSA key algorithm %v", cert.PublicKeyAlgorithm)
+	}
+
+	if err := cert.CheckSignature(sigAlg, attrsDER, si.Signature); err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp signature: %w", err)
+	}
+
+	if err := verifyTSACertificate(cert, certs, roots, info.GenTime); err != nil {
+		return nil, nil, err
+	}
+
+	return info, cert, nil
+}
+
+// signerCertificate returns the certificate a SignerInfo's SID names,
+// either by issuer and serial number or by subject key identifier
+func signerCertificate(sid asn1.RawValue, certs []*x509.Certificate) (*x509.Certificate, error) {
+	switch {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		var ias issuerAndSerial
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil || ias.Serial == nil {
+			return nil, fmt.Errorf("invalid timestamp signer identifier")
+		}
+		for _, cert := range certs {
+			if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.Serial) == 0 {
+				return cert, nil
+			}
+		}
+
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		for _, cert := range certs {
+			if len(cert.SubjectKeyId) > 0 && bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid timestamp signer identifier")
+	}
+
+	return nil, fmt.Errorf("timestamp token has no signing certificate")
+}
+
+// verifyTSACertificate checks that a TSA certificate chains to roots at
+// the asserted time, using the token's other certificates as
+// intermediates, and that it is issued solely for timestamping (RFC 3161,
+// section 2.3)
+func verifyTSACertificate(cert *x509.Certificate, certs []*x509.Certificate, roots *x509.CertPool, at time.Time) error {
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageTimeStamping || len(cert.UnknownExtKeyUsage) != 0 {
+		return fmt.Errorf("TSA certificate is not issued for timestamping")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, other := range certs {
+		if other != cert {
+			intermediates.AddCert(other)
+		}
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return fmt.Errorf("untrusted TSA certificate: %w", err)
+	}
+	return nil
+}
+
+// checkMessageDigest finds the messageDigest signed attribute and
+// compares it to the digest of the encapsulated content
+func checkMessageDigest(attrs []byte, digest []byte) error {
+	for len(attrs) > 0 {
+		var attr attribute
+		rest, err := asn1.Unmarshal(attrs, &attr)
+		if err != nil {
+			return fmt.Errorf("invalid signed attribute: %w", err)
+		}
+		attrs = rest
+
+		if !attr.Type.Equal(oidMessageDigest) || len(attr.Values) != 1 {
+			continue
+		}
+
+		var value []byte
+		if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &value); err != nil {
+			return fmt.Errorf("invalid message digest attribute: %w", err)
+		}
+		if !bytes.Equal(value, digest) {
+			return fmt.Errorf("timestamp content digest mismatch")
+		}
+		return nil
+	}
+	return fmt.Errorf("timestamp token has no message digest attribute")
+}
+
+// parseTSTInfo parses the TSTInfo fields up to genTime plus the nonce.
+// Fields are read one at a time because several are optional.
+func parseTSTInfo(der []byte) (*tstInfo, error) {
+	var seq asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &seq); err != nil {
+		return nil, fmt.Errorf("invalid TSTInfo: %w", err)
+	}
+
+	var (
+		version int
+		policy  asn1.ObjectIdentifier
+		serial  *big.Int
+		info    tstInfo
+	)
+
+	rest := seq.Bytes
+	for _, field := range []interface{}{&version, &policy, &info.Imprint, &serial} {
+		var err error
+		if rest, err = asn1.Unmarshal(rest, field); err != nil {
+			return nil, fmt.Errorf("invalid TSTInfo: %w", err)
+		}
+	}
+
+	var err error
+	if rest, err = asn1.UnmarshalWithParams(rest, &info.GenTime, "generalized"); err != nil {
+		return nil, fmt.Errorf("invalid TSTInfo genTime: %w", err)
+	}
+
+	// Optional trailing fields: accuracy, ordering, nonce, tsa, extensions
+	for len(rest) > 0 {
+		var field asn1.RawValue
+		if rest, err = asn1.Unmarshal(rest, &field); err != nil {
+			return nil, fmt.Errorf("invalid TSTInfo: %w", err)
+		}
+		if field.Class == asn1.ClassUniversal && field.Tag == asn1.TagInteger {
+			info.Nonce = new(big.Int)
+			if _, err := asn1.Unmarshal(field.FullBytes, &info.Nonce); err != nil {
+				return nil, fmt.Errorf("invalid TSTInfo nonce: %w", err)
+			}
+		}
+	}
+
+	return &info, nil
+}
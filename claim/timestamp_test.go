@@ -0,0 +1,334 @@
+package claim
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTSA is a minimal RFC 3161 Time Stamping Authority for tests. Its
+// certificate is issued by a test CA.
+type mockTSA struct {
+	key     *ecdsa.PrivateKey
+	cert    *x509.Certificate
+	ca      *x509.Certificate
+	genTime time.Time
+
+	// extraCerts are carried in tokens ahead of the TSA certificate
+	extraCerts [][]byte
+
+	// omitCert leaves the TSA certificate out of tokens
+	omitCert bool
+
+	// nonceOverride, when set, replaces the request nonce in responses
+	nonceOverride *big.Int
+}
+
+func newMockTSA(t *testing.T) *mockTSA {
+	return newMockTSAWithUsage(t, []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping})
+}
+
+// newMockTSAWithUsage creates a mock TSA whose certificate has the given
+// extended key usages
+func newMockTSAWithUsage(t *testing.T, usage []x509.ExtKeyUsage) *mockTSA {
+	t.Helper()
+
+	validity := func(tmpl *x509.Certificate) *x509.Certificate {
+		tmpl.NotBefore = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		tmpl.NotAfter = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		return tmpl
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := validity(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mock ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := validity(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "mock tsa"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  usage,
+	})
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+
+	return &mockTSA{key: key, cert: cert, ca: ca, genTime: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+// roots returns a pool trusting the mock TSA's CA
+func (m *mockTSA) roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(m.ca)
+	return pool
+}
+
+// token builds a signed TimeStampToken over the given imprint
+func (m *mockTSA) token(t *testing.T, imprint []byte, nonce *big.Int) []byte {
+	t.Helper()
+
+	tst, err := asn1.Marshal(struct {
+		Version        int
+		Policy         asn1.ObjectIdentifier
+		MessageImprint messageImprint
+		SerialNumber   *big.Int
+		GenTime        time.Time `asn1:"generalized"`
+		Nonce          *big.Int  `asn1:"optional"`
+	}{
+		Version: 1,
+		Policy:  asn1.ObjectIdentifier{1, 2, 3, 4},
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			HashedMessage: imprint,
+		},
+		SerialNumber: big.NewInt(42),
+		GenTime:      m.genTime,
+		Nonce:        nonce,
+	})
+	require.NoError(t, err)
+
+	contentTypeValue, err := asn1.Marshal(oidTSTInfo)
+	require.NoError(t, err)
+	digest := sha256.Sum256(tst)
+	digestValue, err := asn1.Marshal(digest[:])
+	require.NoError(t, err)
+
+	attrsDER, err := asn1.MarshalWithParams([]attribute{
+		{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: contentTypeValue}}},
+		{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: digestValue}}},
+	}, "set")
+	require.NoError(t, err)
+
+	var attrs asn1.RawValue
+	_, err = asn1.Unmarshal(attrsDER, &attrs)
+	require.NoError(t, err)
+
+	attrsDigest := sha256.Sum256(attrsDER)
+	sig, err := ecdsa.SignASN1(rand.Reader, m.key, attrsDigest[:])
+	require.NoError(t, err)
+
+	sid, err := asn1.Marshal(issuerAndSerial{
+		Issuer: asn1.RawValue{FullBytes: m.cert.RawIssuer},
+		Serial: m.cert.SerialNumber,
+	})
+	require.NoError(t, err)
+
+	var certs []byte
+	for _, der := range m.extraCerts {
+		certs = append(certs, der...)
+	}
+	if !m.omitCert {
+		certs = append(certs, m.cert.Raw...)
+	}
+
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: encapContentInfo{EContentType: oidTSTInfo, EContent: tst},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs.Bytes},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+			Signature:          sig,
+		}},
+	})
+	require.NoError(t, err)
+
+	token, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	require.NoError(t, err)
+
+	return token
+}
+
+func (m *mockTSA) server(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req timeStampReq
+		_, err = asn1.Unmarshal(body, &req)
+		require.NoError(t, err)
+
+		nonce := req.Nonce
+		if m.nonceOverride != nil {
+			nonce = m.nonceOverride
+		}
+
+		resp, err := asn1.Marshal(struct {
+			Status struct{ Status int }
+			Token  asn1.RawValue
+		}{
+			Token: asn1.RawValue{FullBytes: m.token(t, req.MessageImprint.HashedMessage, nonce)},
+		})
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestTimestampTSA(t *testing.T) {
+	tsa := newMockTSA(t)
+	srv := tsa.server(t)
+	client := &TSAClient{URL: srv.URL, Roots: tsa.roots()}
+
+	c, err := NewClaim(Statement{Subject: "test", Predicate: "is", Object: "timestamped"}, nil, "event-1")
+	require.NoError(t, err)
+	cid := c.ID
+
+	require.NoError(t, client.Timestamp(context.Background(), c))
+	require.NotEmpty(t, c.TimestampToken)
+	assert.Equal(t, cid, c.ID, "token must not change the CID")
+
+	genTime, err := VerifyTSA(c, tsa.roots())
+	require.NoError(t, err)
+	assert.True(t, genTime.Equal(tsa.genTime))
+
+	cert, err := TSACertificate(c, tsa.roots())
+	require.NoError(t, err)
+	assert.Equal(t, "mock tsa", cert.Subject.CommonName)
+
+	t.Run("tampered token fails", func(t *testing.T) {
+		tampered := *c
+		tampered.TimestampToken = append([]byte(nil), c.TimestampToken...)
+		// Flip a byte inside the signature at the end of the token
+		tampered.TimestampToken[len(tampered.TimestampToken)-1] ^= 0xff
+		_, err := VerifyTSA(&tampered, tsa.roots())
+		assert.Error(t, err)
+	})
+
+	t.Run("tampered genTime fails", func(t *testing.T) {
+		tampered := *c
+		tampered.TimestampToken = append([]byte(nil), c.TimestampToken...)
+		i := bytes.Index(tampered.TimestampToken, []byte("20240301"))
+		require.GreaterOrEqual(t, i, 0)
+		tampered.TimestampToken[i+3] = '5'
+		_, err := VerifyTSA(&tampered, tsa.roots())
+		assert.Error(t, err)
+	})
+
+	t.Run("token for another claim fails", func(t *testing.T) {
+		other, _ := NewClaim(Statement{Subject: "other"}, nil, "")
+		other.TimestampToken = c.TimestampToken
+		_, err := VerifyTSA(other, tsa.roots())
+		assert.Error(t, err)
+	})
+
+	t.Run("roots are required", func(t *testing.T) {
+		_, err := VerifyTSA(c, nil)
+		assert.ErrorContains(t, err, "no trusted TSA roots")
+	})
+
+	t.Run("untrusted TSA fails", func(t *testing.T) {
+		_, err := VerifyTSA(c, newMockTSA(t).roots())
+		assert.ErrorContains(t, err, "untrusted TSA certificate")
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		other, _ := NewClaim(Statement{Subject: "other"}, nil, "")
+		_, err := VerifyTSA(other, tsa.roots())
+		assert.Error(t, err)
+	})
+}
+
+func TestTimestampNonceMismatch(t *testing.T) {
+	tsa := newMockTSA(t)
+	tsa.nonceOverride = big.NewInt(7)
+	srv := tsa.server(t)
+
+	c, _ := NewClaim(Statement{Subject: "test"}, nil, "")
+	err := (&TSAClient{URL: srv.URL, Roots: tsa.roots()}).Timestamp(context.Background(), c)
+	assert.Error(t, err)
+	assert.Empty(t, c.TimestampToken)
+}
+
+func TestTimestampSignerCertificate(t *testing.T) {
+	c, err := NewClaim(Statement{Subject: "test"}, nil, "")
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(c.ID))
+
+	t.Run("signer found by SID, not position", func(t *testing.T) {
+		tsa := newMockTSA(t)
+		tsa.extraCerts = [][]byte{tsa.ca.Raw}
+		c.TimestampToken = tsa.token(t, digest[:], nil)
+
+		cert, err := TSACertificate(c, tsa.roots())
+		require.NoError(t, err)
+		assert.Equal(t, "mock tsa", cert.Subject.CommonName)
+	})
+
+	t.Run("signer certificate missing", func(t *testing.T) {
+		tsa := newMockTSA(t)
+		tsa.extraCerts = [][]byte{tsa.ca.Raw}
+		tsa.omitCert = true
+		c.TimestampToken = tsa.token(t, digest[:], nil)
+
+		_, err := VerifyTSA(c, tsa.roots())
+		assert.ErrorContains(t, err, "no signing certificate")
+	})
+
+	t.Run("certificate without the timestamping usage", func(t *testing.T) {
+		for _, usage := range [][]x509.ExtKeyUsage{
+			nil,
+			{x509.ExtKeyUsageServerAuth},
+			{x509.ExtKeyUsageTimeStamping, x509.ExtKeyUsageCodeSigning},
+		} {
+			tsa := newMockTSAWithUsage(t, usage)
+			c.TimestampToken = tsa.token(t, digest[:], nil)
+			_, err := VerifyTSA(c, tsa.roots())
+			assert.ErrorContains(t, err, "not issued for timestamping", "%v", usage)
+		}
+	})
+}
+
+func TestTimestampRequiresRoots(t *testing.T) {
+	tsa := newMockTSA(t)
+	srv := tsa.server(t)
+
+	c, _ := NewClaim(Statement{Subject: "test"}, nil, "")
+	err := (&TSAClient{URL: srv.URL}).Timestamp(context.Background(), c)
+	assert.ErrorContains(t, err, "no trusted TSA roots")
+	assert.Empty(t, c.TimestampToken)
+}
@@ -0,0 +1,124 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Tombstone is a signed record that a claim was deleted, kept after the
+// claim's content is erased so the deletion can be audited
+type Tombstone struct {
+	// CID is the deleted claim
+	CID string
+
+	// DeleterID is the hex-encoded public key of whoever deleted it
+	DeleterID string
+
+	// Reason is why the claim was deleted
+	Reason string
+
+	// DeletedAt is when the claim was deleted
+	DeletedAt time.Time
+
+	// Signature is the deleter's signature over the tombstone
+	Signature []byte
+}
+
+// IssueTombstone creates a tombstone for a claim, signed with the
+// deleter's key
+func IssueTombstone(deleterKey ed25519.PrivateKey, cid, reason string) (*Tombstone, error) {
+	if len(deleterKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid deleter key")
+	}
+	if cid == "" {
+		return nil, fmt.Errorf("CID cannot be empty")
+	}
+
+	t := &Tombstone{
+		CID:       cid,
+		DeleterID: hex.EncodeToString(deleterKey.Public().(ed25519.PublicKey)),
+		Reason:    reason,
+		DeletedAt: time.Now().UTC(),
+	}
+
+	payload, err := tombstonePayload(t)
+	if err != nil {
+		return nil, err
+	}
+	t.Signature = ed25519.Sign(deleterKey, payload)
+
+	return t, nil
+}
+
+// SignTombstone creates a tombstone for a claim the witness deleted,
+// signed with its key or Signer
+func (w *Witness) SignTombstone(cid, reason string) (*Tombstone, error) {
+	if !w.CanSign() {
+		return nil, fmt.Errorf("witness has no private key")
+	}
+	if cid == "" {
+		return nil, fmt.Errorf("CID cannot be empty")
+	}
+
+	t := &Tombstone{
+		CID:       cid,
+		DeleterID: w.ID,
+		Reason:    reason,
+		DeletedAt: w.now().UTC(),
+	}
+
+	payload, err := tombstonePayload(t)
+	if err != nil {
+		return nil, err
+	}
+	if t.Signature, err = w.signPayload(payload); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// VerifyTombstone checks that a tombstone was signed by its deleter
+func VerifyTombstone(t *Tombstone) error {
+	if t == nil {
+		return fmt.Errorf("tombstone cannot be nil")
+	}
+
+	pubBytes, err := hex.DecodeString(t.DeleterID)
+	if err != nil {
+		return fmt.Errorf("invalid deleter ID: %w", err)
+	}
+	if len(pubBytes) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key length")
+	}
+
+	payload, err := tombstonePayload(t)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(pubBytes), payload, t.Signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+func tombstonePayload(t *Tombstone) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, s := range []string{"claim-graph/tombstone", t.CID, t.DeleterID, t.Reason} {
+		if err := writeString(&buf, s); err != nil {
+			return nil, err
+		}
+	}
+	if err := binary.Write(&buf, binary.BigEndian, t.DeletedAt.UnixNano()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
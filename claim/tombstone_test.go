@@ -0,0 +1,42 @@
+package claim
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTombstone(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	tomb, err := IssueTombstone(key, "claim-cid", "erasure request")
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(pub), tomb.DeleterID)
+	assert.NoError(t, VerifyTombstone(tomb))
+
+	t.Run("tampered fields fail", func(t *testing.T) {
+		other, _ := GenerateWitness()
+		for name, tamper := range map[string]func(t *Tombstone){
+			"cid":       func(t *Tombstone) { t.CID = "other" },
+			"deleter":   func(t *Tombstone) { t.DeleterID = other.ID },
+			"reason":    func(t *Tombstone) { t.Reason = "" },
+			"deletedAt": func(t *Tombstone) { t.DeletedAt = t.DeletedAt.Add(time.Hour) },
+		} {
+			forged := *tomb
+			tamper(&forged)
+			assert.Error(t, VerifyTombstone(&forged), name)
+		}
+	})
+
+	t.Run("invalid input rejected", func(t *testing.T) {
+		_, err := IssueTombstone(nil, "claim-cid", "")
+		assert.Error(t, err)
+		_, err = IssueTombstone(key, "", "")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,592 @@
+package claim
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"sync"
+	"time"
+)
+
+// TransparencyLog is an append-only Merkle log of attestations in the
+// style of Certificate Transparency (RFC 6962). Once an attestation is
+// logged, its inclusion can be proven against a signed tree head, and
+// consistency proofs between tree heads show that no entry was later
+// removed, so a witness cannot quietly attest and deny it afterwards.
+type TransparencyLog struct {
+	mu  sync.RWMutex
+	key ed25519.PrivateKey
+
+	tree merkleTree
+
+	// index maps a leaf hash to its position, making Append idempotent
+	index map[string]uint64
+
+	// file persists the leaf hashes of a log opened from disk, or is nil
+	file *os.File
+}
+
+// SignedTreeHead is a log's signed commitment to its first Size entries
+type SignedTreeHead struct {
+	// Size is the number of entries covered
+	Size uint64
+
+	// RootHash is the Merkle tree hash of those entries
+	RootHash []byte
+
+	// Timestamp is when the head was signed
+	Timestamp time.Time
+
+	// Signature is the log's signature over the head
+	Signature []byte
+}
+
+// InclusionProof shows that an attestation is entry LeafIndex of the tree
+// committed to by Head
+type InclusionProof struct {
+	// LeafIndex is the entry's position in the log
+	LeafIndex uint64
+
+	// Hashes is the audit path from the leaf to the root
+	Hashes [][]byte
+
+	// Head is the signed tree head the proof is against
+	Head *SignedTreeHead
+}
+
+// NewTransparencyLog creates an empty log that signs tree heads with key
+func NewTransparencyLog(key ed25519.PrivateKey) (*TransparencyLog, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid log key")
+	}
+
+	return &TransparencyLog{
+		key:   key,
+		index: make(map[string]uint64),
+	}, nil
+}
+
+// OpenTransparencyLog opens (creating if needed) a log whose entries are
+// persisted to the file at path, signing tree heads with key. An entry is
+// written to the file before it is added to the tree, so every head the
+// log signs covers only entries that survive a restart.
+func OpenTransparencyLog(key ed25519.PrivateKey, path string) (*TransparencyLog, error) {
+	l, err := NewTransparencyLog(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transparency log: %w", err)
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read transparency log: %w", err)
+	}
+
+	// A torn final write leaves a partial leaf, which was never in a head
+	whole := len(content) - len(content)%sha256.Size
+	if whole != len(content) {
+		if err := file.Truncate(int64(whole)); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to truncate transparency log: %w", err)
+		}
+	}
+	if _, err := file.Seek(int64(whole), io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	for i := 0; i < whole; i += sha256.Size {
+		leaf := content[i : i+sha256.Size]
+		if _, exists := l.index[string(leaf)]; exists {
+			file.Close()
+			return nil, fmt.Errorf("corrupt transparency log: duplicate entry %d", i/sha256.Size)
+		}
+		l.addLeafLocked(leaf)
+	}
+	l.file = file
+
+	return l, nil
+}
+
+// Close closes the file backing a log opened with OpenTransparencyLog
+func (l *TransparencyLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// PublicKey returns the key that verifies the log's tree heads
+func (l *TransparencyLog) PublicKey() ed25519.PublicKey {
+	return l.key.Public().(ed25519.PublicKey)
+}
+
+// Size returns the number of logged entries
+func (l *TransparencyLog) Size() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.sizeLocked()
+}
+
+func (l *TransparencyLog) sizeLocked() uint64 {
+	return l.tree.size()
+}
+
+// Append logs a verified attestation on a claim and returns its inclusion
+// proof against a freshly signed tree head. Appending an attestation that
+// is already logged returns a proof for the existing entry.
+func (l *TransparencyLog) Append(c *Claim, att *Attestation) (*InclusionProof, error) {
+	if att == nil {
+		return nil, fmt.Errorf("attestation cannot be nil")
+	}
+	proofs, err := l.AppendAll(c, []Attestation{*att})
+	if err != nil {
+		return nil, err
+	}
+	return proofs[0], nil
+}
+
+// AppendAll logs every attestation on a claim as one batch, returning
+// their inclusion proofs against a single signed tree head. All of the
+// attestations are verified first, so either every one is logged or none
+// is. Attestations already logged keep their existing entries.
+func (l *TransparencyLog) AppendAll(c *Claim, atts []Attestation) ([]*InclusionProof, error) {
+	if err := VerifyCID(c); err != nil {
+		return nil, err
+	}
+
+	leaves := make([][]byte, len(atts))
+	for i := range atts {
+		if err := verifySignedAttestation(c, &atts[i]); err != nil {
+			return nil, fmt.Errorf("attestation from witness %s: %w", atts[i].WitnessID, err)
+		}
+		leaf, err := LeafHash(c, &atts[i])
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Persist the new leaves before adding any of them to the tree
+	var fresh []byte
+	batch := make(map[string]bool)
+	for _, leaf := range leaves {
+		if _, exists := l.index[string(leaf)]; !exists && !batch[string(leaf)] {
+			batch[string(leaf)] = true
+			fresh = append(fresh, leaf...)
+		}
+	}
+	if err := l.persistLocked(fresh); err != nil {
+		return nil, err
+	}
+	for _, leaf := range leaves {
+		if _, exists := l.index[string(leaf)]; !exists {
+			l.addLeafLocked(leaf)
+		}
+	}
+
+	head, err := l.signHeadLocked()
+	if err != nil {
+		return nil, err
+	}
+	proofs := make([]*InclusionProof, len(leaves))
+	for i, leaf := range leaves {
+		proofs[i] = l.proofLocked(l.index[string(leaf)], head)
+	}
+	return proofs, nil
+}
+
+// persistLocked writes leaf hashes to the log's file, if it has one
+func (l *TransparencyLog) persistLocked(leaves []byte) error {
+	if l.file == nil || len(leaves) == 0 {
+		return nil
+	}
+
+	if _, err := l.file.Write(leaves); err != nil {
+		return fmt.Errorf("failed to persist transparency log entries: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to persist transparency log entries: %w", err)
+	}
+	return nil
+}
+
+// addLeafLocked appends a leaf to the tree and the index
+func (l *TransparencyLog) addLeafLocked(leaf []byte) {
+	l.index[string(leaf)] = l.sizeLocked()
+	l.tree.add(leaf)
+}
+
+// Prove returns an inclusion proof for a logged attestation against the
+// current tree head
+func (l *TransparencyLog) Prove(c *Claim, att *Attestation) (*InclusionProof, error) {
+	leaf, err := LeafHash(c, att)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	i, exists := l.index[string(leaf)]
+	if !exists {
+		return nil, fmt.Errorf("attestation from witness %s is not logged", att.WitnessID)
+	}
+	return l.proveLocked(i)
+}
+
+func (l *TransparencyLog) proveLocked(i uint64) (*InclusionProof, error) {
+	head, err := l.signHeadLocked()
+	if err != nil {
+		return nil, err
+	}
+	return l.proofLocked(i, head), nil
+}
+
+// proofLocked returns the inclusion proof for entry i under head, which
+// must cover the whole log
+func (l *TransparencyLog) proofLocked(i uint64, head *SignedTreeHead) *InclusionProof {
+	return &InclusionProof{
+		LeafIndex: i,
+		Hashes:    l.tree.inclusionPath(i, 0, head.Size),
+		Head:      head,
+	}
+}
+
+// Head returns a signed tree head covering every entry logged so far
+func (l *TransparencyLog) Head() (*SignedTreeHead, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.signHeadLocked()
+}
+
+func (l *TransparencyLog) signHeadLocked() (*SignedTreeHead, error) {
+	head := &SignedTreeHead{
+		Size:      l.sizeLocked(),
+		RootHash:  l.tree.hash(0, l.sizeLocked()),
+		Timestamp: time.Now().UTC(),
+	}
+
+	payload, err := treeHeadPayload(head)
+	if err != nil {
+		return nil, err
+	}
+	head.Signature = ed25519.Sign(l.key, payload)
+
+	return head, nil
+}
+
+// ProveConsistency returns a proof that the tree of the first oldSize
+// entries is a prefix of the tree of the first newSize entries
+func (l *TransparencyLog) ProveConsistency(oldSize, newSize uint64) ([][]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if oldSize > newSize || newSize > l.sizeLocked() {
+		return nil, fmt.Errorf("invalid tree sizes %d and %d for log of %d entries", oldSize, newSize, l.sizeLocked())
+	}
+	if oldSize == 0 || oldSize == newSize {
+		return nil, nil
+	}
+	return l.tree.subproof(oldSize, 0, newSize, true), nil
+}
+
+// LeafHash returns the Merkle leaf hash of an attestation on a claim. The
+// leaf binds the claim CID, the witness, its signature (which covers any
+// stance and fields) and the attestation timestamp.
+func LeafHash(c *Claim, att *Attestation) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+	if att == nil {
+		return nil, fmt.Errorf("attestation cannot be nil")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(0x00)
+	for _, s := range []string{"claim-graph/transparency-leaf", c.ID, att.WitnessID, string(att.Signature)} {
+		if err := writeString(&buf, s); err != nil {
+			return nil, err
+		}
+	}
+	if err := binary.Write(&buf, binary.BigEndian, att.Timestamp.UnixNano()); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return sum[:], nil
+}
+
+// VerifyTreeHead checks that a tree head was signed by the log's key
+func VerifyTreeHead(head *SignedTreeHead, logPubKey ed25519.PublicKey) error {
+	if head == nil {
+		return fmt.Errorf("tree head cannot be nil")
+	}
+	if len(logPubKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key length: got %d, want %d", len(logPubKey), ed25519.PublicKeySize)
+	}
+
+	payload, err := treeHeadPayload(head)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(logPubKey, payload, head.Signature) {
+		return fmt.Errorf("invalid tree head signature")
+	}
+
+	return nil
+}
+
+// VerifyInclusion checks that an attestation on a claim is included in
+// the log under the proof's signed tree head
+func VerifyInclusion(c *Claim, att *Attestation, proof *InclusionProof, logPubKey ed25519.PublicKey) error {
+	if proof == nil {
+		return fmt.Errorf("proof cannot be nil")
+	}
+	if err := VerifyTreeHead(proof.Head, logPubKey); err != nil {
+		return err
+	}
+
+	leaf, err := LeafHash(c, att)
+	if err != nil {
+		return err
+	}
+
+	if !includes(leaf, proof.LeafIndex, proof.Head.Size, proof.Hashes, proof.Head.RootHash) {
+		return fmt.Errorf("attestation is not included in the tree")
+	}
+	return nil
+}
+
+// includes reports whether an audit path proves leaf is entry index of the
+// tree of the given size with the given root (RFC 9162, section 2.1.3.2)
+func includes(leaf []byte, index, size uint64, path [][]byte, root []byte) bool {
+	if index >= size {
+		return false
+	}
+
+	fn, sn := index, size-1
+	r := leaf
+	for _, p := range path {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	return sn == 0 && bytes.Equal(r, root)
+}
+
+// VerifyConsistency checks that newHead extends oldHead, i.e. that every
+// entry committed to by oldHead is still present. A log that dropped or
+// rewrote an entry cannot produce a valid proof.
+func VerifyConsistency(oldHead, newHead *SignedTreeHead, proof [][]byte, logPubKey ed25519.PublicKey) error {
+	if err := VerifyTreeHead(oldHead, logPubKey); err != nil {
+		return fmt.Errorf("old head: %w", err)
+	}
+	if err := VerifyTreeHead(newHead, logPubKey); err != nil {
+		return fmt.Errorf("new head: %w", err)
+	}
+
+	if oldHead.Size > newHead.Size {
+		return fmt.Errorf("old tree of size %d is larger than new tree of size %d", oldHead.Size, newHead.Size)
+	}
+	if oldHead.Size == 0 {
+		return nil
+	}
+	if oldHead.Size == newHead.Size {
+		if len(proof) != 0 || !bytes.Equal(oldHead.RootHash, newHead.RootHash) {
+			return fmt.Errorf("trees of equal size have different roots")
+		}
+		return nil
+	}
+
+	// RFC 9162, section 2.1.4.2
+	if bits.OnesCount64(oldHead.Size) == 1 {
+		proof = append([][]byte{oldHead.RootHash}, proof...)
+	}
+	if len(proof) == 0 {
+		return fmt.Errorf("empty consistency proof")
+	}
+
+	fn, sn := oldHead.Size-1, newHead.Size-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return fmt.Errorf("consistency proof too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = nodeHash(c, fr)
+			sr = nodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = nodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 || !bytes.Equal(fr, oldHead.RootHash) || !bytes.Equal(sr, newHead.RootHash) {
+		return fmt.Errorf("new tree is not consistent with old tree")
+	}
+	return nil
+}
+
+func treeHeadPayload(head *SignedTreeHead) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := writeString(&buf, "claim-graph/tree-head"); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&buf, binary.BigEndian, head.Size); err != nil {
+		return nil, err
+	}
+	if err := writeString(&buf, string(head.RootHash)); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&buf, binary.BigEndian, head.Timestamp.UnixNano()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// nodeHash is the RFC 6962 hash of an interior node
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// splitPoint returns the largest power of two smaller than n
+func splitPoint(n uint64) uint64 {
+	return 1 << (bits.Len64(n-1) - 1)
+}
+
+// merkleTree is an RFC 6962 Merkle tree that grows by appending leaves.
+// levels[h][i] is the hash of the complete subtree of 2^h leaves starting
+// at leaf i<<h, so levels[0] holds the leaves. Caching complete subtrees
+// keeps tree hashes and proofs logarithmic in the tree size.
+type merkleTree struct {
+	levels [][][]byte
+}
+
+// newMerkleTree returns a tree over a list of leaf hashes
+func newMerkleTree(leaves [][]byte) *merkleTree {
+	t := &merkleTree{}
+	for _, leaf := range leaves {
+		t.add(leaf)
+	}
+	return t
+}
+
+func (t *merkleTree) size() uint64 {
+	if len(t.levels) == 0 {
+		return 0
+	}
+	return uint64(len(t.levels[0]))
+}
+
+// add appends a leaf and the complete subtrees it finishes
+func (t *merkleTree) add(leaf []byte) {
+	if len(t.levels) == 0 {
+		t.levels = [][][]byte{nil}
+	}
+	t.levels[0] = append(t.levels[0], leaf)
+
+	for h := 0; len(t.levels[h])%2 == 0; h++ {
+		if h+1 == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+		n := len(t.levels[h])
+		t.levels[h+1] = append(t.levels[h+1], nodeHash(t.levels[h][n-2], t.levels[h][n-1]))
+	}
+}
+
+// hash is the Merkle tree hash of leaves [lo, hi). Every left
+// subtree RFC 6962 splits off is complete and aligned, so it comes from
+// the cache and only the right edge of the tree is hashed.
+func (t *merkleTree) hash(lo, hi uint64) []byte {
+	n := hi - lo
+	switch {
+	case n == 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case n&(n-1) == 0 && lo%n == 0:
+		h := bits.TrailingZeros64(n)
+		return t.levels[h][lo>>h]
+	}
+
+	k := splitPoint(n)
+	return nodeHash(t.hash(lo, lo+k), t.hash(lo+k, hi))
+}
+
+// inclusionPath is the audit path for entry m of the tree of entries
+// [lo, hi) (RFC 6962, section 2.1.1)
+func (t *merkleTree) inclusionPath(m, lo, hi uint64) [][]byte {
+	n := hi - lo
+	if n <= 1 {
+		return nil
+	}
+
+	k := splitPoint(n)
+	if m < k {
+		return append(t.inclusionPath(m, lo, lo+k), t.hash(lo+k, hi))
+	}
+	return append(t.inclusionPath(m-k, lo+k, hi), t.hash(lo, lo+k))
+}
+
+// subproof builds a consistency proof for the first m leaves of the tree
+// of leaves [lo, hi) (RFC 6962, section 2.1.2)
+func (t *merkleTree) subproof(m, lo, hi uint64, complete bool) [][]byte {
+	n := hi - lo
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][]byte{t.hash(lo, hi)}
+	}
+
+	k := splitPoint(n)
+	if m <= k {
+		return append(t.subproof(m, lo, lo+k, complete), t.hash(lo+k, hi))
+	}
+	return append(t.subproof(m-k, lo+k, hi, false), t.hash(lo, lo+k))
+}
@@ -0,0 +1,221 @@
+package claim
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransparencyLog(t *testing.T) {
+	_, logKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	log, err := NewTransparencyLog(logKey)
+	require.NoError(t, err)
+
+	c, err := NewClaim(Statement{Subject: "test", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+
+	// Seven entries exercise both balanced and unbalanced subtrees
+	var atts []*Attestation
+	var heads []*SignedTreeHead
+	for i := 0; i < 7; i++ {
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		atts = append(atts, att)
+
+		proof, err := log.Append(c, att)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i), proof.LeafIndex)
+		assert.NoError(t, VerifyInclusion(c, att, proof, log.PublicKey()))
+		heads = append(heads, proof.Head)
+	}
+	assert.Equal(t, uint64(7), log.Size())
+
+	t.Run("inclusion against the latest head", func(t *testing.T) {
+		for _, att := range atts {
+			proof, err := log.Prove(c, att)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(7), proof.Head.Size)
+			assert.NoError(t, VerifyInclusion(c, att, proof, log.PublicKey()))
+		}
+	})
+
+	t.Run("append is idempotent", func(t *testing.T) {
+		proof, err := log.Append(c, atts[2])
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), proof.LeafIndex)
+		assert.Equal(t, uint64(7), log.Size())
+	})
+
+	t.Run("invalid attestation rejected", func(t *testing.T) {
+		forged := *atts[0]
+		forged.Signature = atts[1].Signature
+		_, err := log.Append(c, &forged)
+		assert.Error(t, err)
+	})
+
+	t.Run("inclusion proof does not transfer", func(t *testing.T) {
+		proof, err := log.Prove(c, atts[0])
+		require.NoError(t, err)
+		assert.Error(t, VerifyInclusion(c, atts[1], proof, log.PublicKey()))
+
+		otherPub, _, _ := ed25519.GenerateKey(nil)
+		assert.Error(t, VerifyInclusion(c, atts[0], proof, otherPub))
+
+		forged := *proof
+		forged.Head = &SignedTreeHead{Size: proof.Head.Size, RootHash: make([]byte, 32), Timestamp: proof.Head.Timestamp, Signature: proof.Head.Signature}
+		assert.Error(t, VerifyInclusion(c, atts[0], &forged, log.PublicKey()))
+	})
+
+	t.Run("unlogged attestation has no proof", func(t *testing.T) {
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		_, err = log.Prove(c, att)
+		assert.Error(t, err)
+	})
+
+	t.Run("consistency between every pair of heads", func(t *testing.T) {
+		for _, old := range heads {
+			for _, next := range heads {
+				if old.Size > next.Size {
+					continue
+				}
+				proof, err := log.ProveConsistency(old.Size, next.Size)
+				require.NoError(t, err)
+				assert.NoError(t, VerifyConsistency(old, next, proof, log.PublicKey()), "%d -> %d", old.Size, next.Size)
+			}
+		}
+	})
+
+	t.Run("omitted entry detected", func(t *testing.T) {
+		// A log operator rebuilds the log without entry 1, hoping the
+		// witness can deny it
+		forked, err := NewTransparencyLog(logKey)
+		require.NoError(t, err)
+		for i, att := range atts {
+			if i == 1 {
+				continue
+			}
+			_, err := forked.Append(c, att)
+			require.NoError(t, err)
+		}
+
+		// A client holding the honest head of size 3 asks for a
+		// consistency proof to the forked log's newer head
+		old := heads[2]
+		head, err := forked.Head()
+		require.NoError(t, err)
+		proof, err := forked.ProveConsistency(old.Size, head.Size)
+		require.NoError(t, err)
+		assert.Error(t, VerifyConsistency(old, head, proof, log.PublicKey()))
+	})
+
+	t.Run("shrinking tree rejected", func(t *testing.T) {
+		assert.Error(t, VerifyConsistency(heads[4], heads[2], nil, log.PublicKey()))
+		_, err := log.ProveConsistency(5, 3)
+		assert.Error(t, err)
+	})
+}
+
+func TestTransparencyLogAppendAll(t *testing.T) {
+	_, logKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	log, err := NewTransparencyLog(logKey)
+	require.NoError(t, err)
+
+	c, err := NewClaim(Statement{Subject: "batch", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	var atts []Attestation
+	for i := 0; i < 3; i++ {
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		atts = append(atts, *att)
+	}
+
+	t.Run("an invalid attestation logs none", func(t *testing.T) {
+		forged := atts[2]
+		forged.Signature = atts[0].Signature
+		_, err := log.AppendAll(c, []Attestation{atts[0], atts[1], forged})
+		assert.Error(t, err)
+		assert.Zero(t, log.Size())
+	})
+
+	proofs, err := log.AppendAll(c, append(atts, atts[0]))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), log.Size())
+	require.Len(t, proofs, 4)
+	for i, proof := range proofs {
+		assert.Equal(t, uint64(i%3), proof.LeafIndex)
+		assert.NoError(t, VerifyInclusion(c, &atts[i%3], proof, log.PublicKey()))
+	}
+}
+
+func TestOpenTransparencyLog(t *testing.T) {
+	_, logKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "translog")
+
+	log, err := OpenTransparencyLog(logKey, path)
+	require.NoError(t, err)
+
+	c, err := NewClaim(Statement{Subject: "persisted", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	var atts []*Attestation
+	for i := 0; i < 5; i++ {
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		_, err = log.Append(c, att)
+		require.NoError(t, err)
+		atts = append(atts, att)
+	}
+	before, err := log.Head()
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	// A torn write leaves a partial entry, which is dropped on reopen
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0x01, 0x02, 0x03})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := OpenTransparencyLog(logKey, path)
+	require.NoError(t, err)
+	defer reopened.Close()
+	assert.Equal(t, uint64(5), reopened.Size())
+
+	after, err := reopened.Head()
+	require.NoError(t, err)
+	assert.Equal(t, before.RootHash, after.RootHash)
+
+	for i, att := range atts {
+		proof, err := reopened.Prove(c, att)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i), proof.LeafIndex)
+		assert.NoError(t, VerifyInclusion(c, att, proof, reopened.PublicKey()))
+	}
+
+	w, _ := GenerateWitness()
+	att, err := w.Attest(c)
+	require.NoError(t, err)
+	_, err = reopened.Append(c, att)
+	require.NoError(t, err)
+	newer, err := reopened.Head()
+	require.NoError(t, err)
+	consistency, err := reopened.ProveConsistency(before.Size, newer.Size)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyConsistency(before, newer, consistency, reopened.PublicKey()))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6*sha256.Size), info.Size())
+}
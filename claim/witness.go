@@ -1,10 +1,17 @@
 package claim
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -19,6 +26,20 @@ type Witness struct {
 	// PrivateKey is the ed25519 private key (only set for local witness)
 	PrivateKey ed25519.PrivateKey
 
+	// Signer, if set, signs attestations, profiles, reset requests and
+	// set attestations in place of PrivateKey, e.g. a hardware key. Its
+	// public key must be PublicKey, or signing fails.
+	Signer crypto.Signer
+
+	// Prehash makes the witness sign attestations with Ed25519ph, handing
+	// its signer the payload's SHA-512 digest instead of the payload, for
+	// signers that only accept a digest
+	Prehash bool
+
+	// Clock, if set, timestamps the witness's attestations (nil reads the
+	// system clock)
+	Clock Clock
+
 	// Metadata contains optional witness information
 	Metadata map[string]string
 }
@@ -38,6 +59,24 @@ func GenerateWitness() (*Witness, error) {
 	}, nil
 }
 
+// DeterministicWitness derives a witness from a seed string, so test
+// fixtures have the same witness IDs on every run.
+//
+// INSECURE: anyone who knows or guesses the seed has the private key. Use
+// it only in tests, never for real witness keys.
+func DeterministicWitness(seed string) *Witness {
+	sum := sha256.Sum256([]byte("claim-graph/insecure-test-witness:" + seed))
+	priv := ed25519.NewKeyFromSeed(sum[:])
+	pub := priv.Public().(ed25519.PublicKey)
+
+	return &Witness{
+		ID:         hex.EncodeToString(pub),
+		PublicKey:  pub,
+		PrivateKey: priv,
+		Metadata:   make(map[string]string),
+	}
+}
+
 // WitnessFromPublicKey creates a witness from an existing public key
 func WitnessFromPublicKey(pubKey ed25519.PublicKey) *Witness {
 	return &Witness{
@@ -65,9 +104,214 @@ func WitnessFromID(id string) (*Witness, error) {
 	}, nil
 }
 
-// Attest creates an attestation for a claim
+// Stance is a witness's position on a claim
+type Stance int
+
+const (
+	// StanceEndorse asserts the claim is true (the default)
+	StanceEndorse Stance = iota
+
+	// StanceDispute asserts the claim is false
+	StanceDispute
+
+	// StanceAbstain records that the witness examined the claim but could
+	// not decide. How it affects confidence is set by AbstentionPolicy.
+	StanceAbstain
+)
+
+// String returns the name of the stance
+func (s Stance) String() string {
+	switch s {
+	case StanceEndorse:
+		return "endorse"
+	case StanceDispute:
+		return "dispute"
+	case StanceAbstain:
+		return "abstain"
+	default:
+		return fmt.Sprintf("Stance(%d)", int(s))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Stance) MarshalText() ([]byte, error) {
+	switch s {
+	case StanceEndorse, StanceDispute, StanceAbstain:
+		return []byte(s.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown stance %d", int(s))
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Stance) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "endorse", "":
+		*s = StanceEndorse
+	case "dispute":
+		*s = StanceDispute
+	case "abstain":
+		*s = StanceAbstain
+	default:
+		return fmt.Errorf("unknown stance %q", string(text))
+	}
+	return nil
+}
+
+// Attest creates an attestation endorsing a claim
 func (w *Witness) Attest(claim *Claim) (*Attestation, error) {
-	if w.PrivateKey == nil {
+	return w.AttestWithStance(claim, StanceEndorse)
+}
+
+// Dispute creates an attestation disputing a claim
+func (w *Witness) Dispute(claim *Claim) (*Attestation, error) {
+	return w.AttestWithStance(claim, StanceDispute)
+}
+
+// Abstain creates an attestation recording that the witness could not
+// decide on a claim
+func (w *Witness) Abstain(claim *Claim) (*Attestation, error) {
+	return w.AttestWithStance(claim, StanceAbstain)
+}
+
+// AttestWithStance creates an attestation with the given stance
+func (w *Witness) AttestWithStance(claim *Claim, stance Stance) (*Attestation, error) {
+	return w.sign(claim, &Attestation{Stance: stance})
+}
+
+// AttestBatch endorses several claims, returning their attestations in
+// input order. It fails on the first claim that cannot be signed.
+func (w *Witness) AttestBatch(claims []*Claim) ([]*Attestation, error) {
+	atts := make([]*Attestation, len(claims))
+	for i, c := range claims {
+		att, err := w.Attest(c)
+		if err != nil {
+			return nil, fmt.Errorf("claim %d: %w", i, err)
+		}
+		atts[i] = att
+	}
+	return atts, nil
+}
+
+// AttestWithContext creates an endorsement that also signs how the witness
+// verified the claim
+func (w *Witness) AttestWithContext(claim *Claim, ac AttestationContext) (*Attestation, error) {
+	for _, cid := range ac.Evidence {
+		if cid == "" {
+			return nil, fmt.Errorf("context evidence CIDs cannot be empty")
+		}
+	}
+
+	ac.Evidence = append([]string(nil), ac.Evidence...)
+	return w.sign(claim, &Attestation{Context: &ac})
+}
+
+// AttestWithContextHash creates an endorsement bound to a hash of external
+// context the witness examined, such as a webpage snapshot too large to
+// store in the claim. VerifyAttestationWithContextHash checks it against
+// the context a verifier is presented with.
+func (w *Witness) AttestWithContextHash(claim *Claim, contextHash []byte) (*Attestation, error) {
+	if len(contextHash) == 0 {
+		return nil, fmt.Errorf("context hash cannot be empty")
+	}
+	return w.sign(claim, &Attestation{ContextHash: append([]byte(nil), contextHash...)})
+}
+
+// AttestUntil creates an endorsement that lapses at expiresAt, for
+// witnesses that only vouch for a claim for a limited time. Renew it to
+// keep it in force.
+func (w *Witness) AttestUntil(claim *Claim, expiresAt time.Time) (*Attestation, error) {
+	if !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+	return w.sign(claim, &Attestation{ExpiresAt: expiresAt.UTC()})
+}
+
+// Renew issues a fresh copy of one of the witness's expiring attestations
+// with a new timestamp and nonce, valid for as long again as the original
+// was. Add it with ReplaceLatest to replace the original.
+func (w *Witness) Renew(c *Claim, existing *Attestation) (*Attestation, error) {
+	if existing == nil {
+		return nil, fmt.Errorf("attestation cannot be nil")
+	}
+	if existing.WitnessID != w.ID {
+		return nil, fmt.Errorf("attestation belongs to witness %s", existing.WitnessID)
+	}
+	if existing.ExpiresAt.IsZero() {
+		return nil, fmt.Errorf("attestation does not expire")
+	}
+	if err := VerifyAttestation(c, existing); err != nil {
+		return nil, fmt.Errorf("cannot renew invalid attestation: %w", err)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	renewed := &Attestation{
+		Stance:      existing.Stance,
+		Fields:      append([]string(nil), existing.Fields...),
+		ContextHash: append([]byte(nil), existing.ContextHash...),
+		ExpiresAt:   time.Now().UTC().Add(existing.ExpiresAt.Sub(existing.Timestamp)),
+		Nonce:       nonce,
+	}
+	if len(renewed.Fields) == 0 {
+		renewed.Fields = nil
+	}
+	if len(renewed.ContextHash) == 0 {
+		renewed.ContextHash = nil
+	}
+	if ac := existing.Context; ac != nil {
+		copied := *ac
+		copied.Evidence = append([]string(nil), ac.Evidence...)
+		renewed.Context = &copied
+	}
+
+	return w.sign(c, renewed)
+}
+
+// AttestAtRound creates an endorsement bound to a dag-time beacon round,
+// normally the latest the witness has seen. A beacon's output cannot be
+// known before its round, so the attestation provably postdates the round;
+// VerifyAttestationFreshness bounds how long ago that was.
+func (w *Witness) AttestAtRound(claim *Claim, round uint64) (*Attestation, error) {
+	if round == 0 {
+		return nil, fmt.Errorf("beacon round must be positive")
+	}
+	return w.sign(claim, &Attestation{BeaconRound: round})
+}
+
+// VerifyAttestationFreshness checks that an attestation is bound to a
+// beacon round at most maxAge rounds before currentRound. It does not
+// check the signature, which covers the round; use VerifyAttestation too.
+func VerifyAttestationFreshness(att *Attestation, currentRound, maxAge uint64) error {
+	if att == nil {
+		return fmt.Errorf("attestation cannot be nil")
+	}
+	if att.BeaconRound == 0 {
+		return fmt.Errorf("attestation is not bound to a beacon round")
+	}
+	if att.BeaconRound > currentRound {
+		return fmt.Errorf("attestation is bound to future round %d (current round %d)", att.BeaconRound, currentRound)
+	}
+	if age := currentRound - att.BeaconRound; age > maxAge {
+		return fmt.Errorf("attestation is %d rounds old, more than %d", age, maxAge)
+	}
+	return nil
+}
+
+// AttestFields creates an endorsement covering only the named claim fields
+func (w *Witness) AttestFields(claim *Claim, fields ...string) (*Attestation, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("at least one field is required")
+	}
+	return w.sign(claim, &Attestation{Fields: append([]string(nil), fields...)})
+}
+
+// sign fills in the witness ID and timestamp and signs the attestation
+func (w *Witness) sign(claim *Claim, att *Attestation) (*Attestation, error) {
+	if !w.CanSign() {
 		return nil, fmt.Errorf("witness has no private key")
 	}
 
@@ -75,17 +319,175 @@ func (w *Witness) Attest(claim *Claim) (*Attestation, error) {
 		return nil, fmt.Errorf("claim cannot be nil")
 	}
 
-	// Sign the claim ID (which is its content hash)
-	signature := ed25519.Sign(w.PrivateKey, []byte(claim.ID))
+	att.WitnessID = w.ID
+	att.ClaimID = claim.ID
+	if att.Membership != nil {
+		att.WitnessID = att.Membership.GroupID
+	}
+	att.Timestamp = w.now().UTC()
+	att.TimestampSigned = true
+	att.Prehash = w.Prehash
+
+	sig, err := w.signAttestation(claim, att)
+	if err != nil {
+		return nil, err
+	}
+	att.Signature = sig
 
-	return &Attestation{
-		WitnessID: w.ID,
-		Signature: signature,
-		Timestamp: time.Now().UTC(),
-	}, nil
+	return att, nil
+}
+
+// now reads the witness's clock
+func (w *Witness) now() time.Time {
+	if w.Clock == nil {
+		return time.Now()
+	}
+	return w.Clock.Now()
 }
 
-// VerifyAttestation verifies that an attestation is valid for a claim
+// signingPayload returns the bytes a witness signs for an attestation.
+// Plain endorsements of claims without a domain sign the claim ID (which
+// is its content hash) directly; other attestations sign a tagged
+// encoding of the claim ID followed by the claim's domain and the
+// attestation's signed fields. Signing the domain keeps an attestation
+// from being replayed onto a claim in another domain.
+func signingPayload(claim *Claim, att *Attestation) ([]byte, error) {
+	if !att.hasSignedFields() && claim.Statement.Domain == "" {
+		return []byte(claim.ID), nil
+	}
+
+	var buf bytes.Buffer
+	if err := writeString(&buf, "claim-graph/attestation"); err != nil {
+		return nil, err
+	}
+	if err := writeString(&buf, claim.ID); err != nil {
+		return nil, err
+	}
+	if claim.Statement.Domain != "" {
+		if err := writeField(&buf, "domain", claim.Statement.Domain); err != nil {
+			return nil, err
+		}
+	}
+	if att.Stance != StanceEndorse {
+		if err := writeField(&buf, "stance", att.Stance.String()); err != nil {
+			return nil, err
+		}
+	}
+	if att.TimestampSigned {
+		if err := writeField(&buf, "timestamp", strconv.FormatInt(att.Timestamp.UnixNano(), 10)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Field-scoped attestations cover the named fields' values
+	fields := append([]string(nil), att.Fields...)
+	sort.Strings(fields)
+	for i, name := range fields {
+		if i > 0 && fields[i-1] == name {
+			return nil, fmt.Errorf("duplicate field %q", name)
+		}
+		value, exists := claim.Fields[name]
+		if !exists {
+			return nil, fmt.Errorf("claim has no field %q", name)
+		}
+		if err := writeField(&buf, "field:"+name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	// Context is signed as given; evidence order is the witness's own
+	if ac := att.Context; ac != nil {
+		if err := writeField(&buf, "context-method", ac.Method); err != nil {
+			return nil, err
+		}
+		for _, cid := range ac.Evidence {
+			if err := writeField(&buf, "context-evidence", cid); err != nil {
+				return nil, err
+			}
+		}
+		if !ac.VerifiedAt.IsZero() {
+			if err := writeField(&buf, "context-verified-at", strconv.FormatInt(ac.VerifiedAt.UnixNano(), 10)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(att.ContextHash) > 0 {
+		if err := writeField(&buf, "context-hash", hex.EncodeToString(att.ContextHash)); err != nil {
+			return nil, err
+		}
+	}
+	if !att.ExpiresAt.IsZero() {
+		if err := writeField(&buf, "expires-at", strconv.FormatInt(att.ExpiresAt.UnixNano(), 10)); err != nil {
+			return nil, err
+		}
+	}
+	if len(att.Nonce) > 0 {
+		if err := writeField(&buf, "nonce", hex.EncodeToString(att.Nonce)); err != nil {
+			return nil, err
+		}
+	}
+	if att.BeaconRound != 0 {
+		if err := writeField(&buf, "beacon-round", strconv.FormatUint(att.BeaconRound, 10)); err != nil {
+			return nil, err
+		}
+	}
+
+	// A member signs for a group by name, so its signature cannot be
+	// passed off as another group's
+	if m := att.Membership; m != nil {
+		if err := writeField(&buf, "group", m.GroupID); err != nil {
+			return nil, err
+		}
+		if err := writeField(&buf, "group-member", m.MemberID); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// hasSignedFields reports whether the attestation carries signed fields
+// beyond the claim ID
+func (a *Attestation) hasSignedFields() bool {
+	return a.Stance != StanceEndorse || a.TimestampSigned || len(a.Fields) > 0 || a.Context != nil || len(a.ContextHash) > 0 ||
+		!a.ExpiresAt.IsZero() || len(a.Nonce) > 0 || a.BeaconRound != 0 || a.Membership != nil
+}
+
+// NewerThan reports whether the attestation provably postdates other: its
+// timestamp is signed and either other's is not or it is later. An
+// attestation without a signed timestamp is never newer than another.
+func (a *Attestation) NewerThan(other *Attestation) bool {
+	if !a.TimestampSigned {
+		return false
+	}
+	return !other.TimestampSigned || a.Timestamp.After(other.Timestamp)
+}
+
+// IsExpired reports whether the attestation has an expiry at or before now
+func (a *Attestation) IsExpired(now time.Time) bool {
+	return !a.ExpiresAt.IsZero() && !a.ExpiresAt.After(now)
+}
+
+// Covers reports whether the attestation vouches for the named field.
+// Whole-claim attestations cover every field.
+func (a *Attestation) Covers(field string) bool {
+	if len(a.Fields) == 0 {
+		return true
+	}
+	for _, f := range a.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyAttestation verifies that an attestation is valid for a claim,
+// whether it was signed with pure Ed25519 or Ed25519ph. A group
+// attestation is checked against the key of the member its membership
+// certifies. Revocations are group state, so they are checked by
+// WitnessGroup.VerifyAttestation and ReputationStore.VerifyAttestation.
 func VerifyAttestation(claim *Claim, attestation *Attestation) error {
 	if claim == nil {
 		return fmt.Errorf("claim cannot be nil")
@@ -94,47 +496,263 @@ func VerifyAttestation(claim *Claim, attestation *Attestation) error {
 		return fmt.Errorf("attestation cannot be nil")
 	}
 
-	// Decode witness public key from ID
-	pubBytes, err := hex.DecodeString(attestation.WitnessID)
+	// The claim must be the one its CID names, or a revision could borrow
+	// the attestations of the claim it supersedes
+	if err := VerifyCID(claim); err != nil {
+		return err
+	}
+	return verifySignedAttestation(claim, attestation)
+}
+
+// verifySignedAttestation checks an attestation against a claim whose CID
+// has already been verified
+func verifySignedAttestation(claim *Claim, attestation *Attestation) error {
+	if attestation.ClaimID != "" && attestation.ClaimID != claim.ID {
+		return fmt.Errorf("attestation was signed for claim %s, not %s", attestation.ClaimID, claim.ID)
+	}
+
+	pubKey, err := attestationKey(attestation)
 	if err != nil {
-		return fmt.Errorf("invalid witness ID: %w", err)
+		return err
 	}
 
-	if len(pubBytes) != ed25519.PublicKeySize {
-		return fmt.Errorf("invalid public key length")
+	// Verify signature over claim ID and any signed attestation fields
+	return verifyAttestationSignature(pubKey, claim, attestation)
+}
+
+// VerifyAttestations verifies all of a claim's attestations in parallel,
+// returning the result for each in claim order (nil for a valid one)
+func VerifyAttestations(claim *Claim) []error {
+	errs := make([]error, len(claim.Witnesses))
+	if err := VerifyCID(claim); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
 	}
 
-	pubKey := ed25519.PublicKey(pubBytes)
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(claim.Witnesses) {
+		workers = len(claim.Witnesses)
+	}
 
-	// Verify signature over claim ID
-	if !ed25519.Verify(pubKey, []byte(claim.ID), attestation.Signature) {
-		return fmt.Errorf("invalid signature")
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+			for i := start; i < len(claim.Witnesses); i += workers {
+				errs[i] = verifySignedAttestation(claim, &claim.Witnesses[i])
+			}
+		}(w)
 	}
+	wg.Wait()
 
-	return nil
+	return errs
+}
+
+// VerifyAttestationWithContextHash verifies an attestation and that it was
+// made over the given external context hash
+func VerifyAttestationWithContextHash(claim *Claim, attestation *Attestation, contextHash []byte) error {
+	if attestation == nil {
+		return fmt.Errorf("attestation cannot be nil")
+	}
+	if len(attestation.ContextHash) == 0 {
+		return fmt.Errorf("attestation is not bound to a context hash")
+	}
+	if !bytes.Equal(attestation.ContextHash, contextHash) {
+		return fmt.Errorf("context hash does not match the one the witness signed")
+	}
+	return VerifyAttestation(claim, attestation)
+}
+
+// DuplicatePolicy controls how AddAttestationWith handles a second
+// attestation from a witness that has already attested to the claim
+type DuplicatePolicy int
+
+const (
+	// RejectDuplicate refuses the new attestation (the default)
+	RejectDuplicate DuplicatePolicy = iota
+
+	// ReplaceLatest replaces the witness's existing attestation when the
+	// new one is newer by signed timestamp (see NewerThan), e.g. a witness
+	// changing its stance
+	ReplaceLatest
+
+	// KeepBoth keeps every attestation. Confidence scoring only counts
+	// each witness's latest one.
+	KeepBoth
+)
+
+// OverflowPolicy controls how AddAttestationWith handles a new attestation
+// once a claim holds the maximum number
+type OverflowPolicy int
+
+const (
+	// RejectOverflow refuses the new attestation (the default)
+	RejectOverflow OverflowPolicy = iota
+
+	// ReplaceLowestReputation evicts the attestation whose witness has the
+	// lowest reputation in the claim's domain, provided the new witness's
+	// reputation is higher
+	ReplaceLowestReputation
+)
+
+// DefaultMaxAttestations bounds the attestations a claim accepts when
+// AttestOptions.MaxAttestations is unset
+const DefaultMaxAttestations = 1000
+
+// AttestOptions configures AddAttestationWith
+type AttestOptions struct {
+	// Duplicates handles repeat attestations from one witness
+	Duplicates DuplicatePolicy
+
+	// MaxAttestations caps the attestations on a claim
+	// (default DefaultMaxAttestations)
+	MaxAttestations int
+
+	// Overflow handles attestations beyond the cap
+	Overflow OverflowPolicy
+
+	// Reputation scores witnesses for ReplaceLowestReputation and, when
+	// set, verifies attestations with its VerifyAttestation, so revoked
+	// members of its registered groups are rejected
+	Reputation *ReputationStore
 }
 
-// AddAttestation adds a verified attestation to a claim
+// AddAttestation adds a verified attestation to a claim, rejecting
+// duplicates from the same witness and attestations beyond
+// DefaultMaxAttestations
 func (c *Claim) AddAttestation(attestation *Attestation) error {
-	if err := VerifyAttestation(c, attestation); err != nil {
+	return c.AddAttestationWith(attestation, AttestOptions{})
+}
+
+// AddAttestationWithPolicy adds a verified attestation to a claim, handling
+// an existing attestation from the same witness according to policy
+func (c *Claim) AddAttestationWithPolicy(attestation *Attestation, policy DuplicatePolicy) error {
+	return c.AddAttestationWith(attestation, AttestOptions{Duplicates: policy})
+}
+
+// AddAttestationWith adds a verified attestation to a claim according to
+// the given options
+func (c *Claim) AddAttestationWith(attestation *Attestation, opts AttestOptions) error {
+	verify := VerifyAttestation
+	if opts.Reputation != nil {
+		verify = opts.Reputation.VerifyAttestation
+	}
+	if err := verify(c, attestation); err != nil {
 		return err
 	}
 
-	// Check for duplicate
-	for _, existing := range c.Witnesses {
-		if existing.WitnessID == attestation.WitnessID {
-			return fmt.Errorf("witness %s already attested", attestation.WitnessID)
+	// Find the witness's most recent existing attestation
+	latest := -1
+	for i, existing := range c.Witnesses {
+		if existing.WitnessID != attestation.WitnessID {
+			continue
+		}
+		if bytes.Equal(existing.Signature, attestation.Signature) {
+			return fmt.Errorf("attestation from witness %s already added", attestation.WitnessID)
+		}
+		if latest < 0 || existing.NewerThan(&c.Witnesses[latest]) {
+			latest = i
+		}
+	}
+
+	if latest < 0 {
+		return c.appendAttestation(attestation, opts)
+	}
+
+	switch opts.Duplicates {
+	case ReplaceLatest:
+		if !attestation.NewerThan(&c.Witnesses[latest]) {
+			return fmt.Errorf("attestation from witness %s is not newer than the existing one", attestation.WitnessID)
+		}
+		c.Witnesses[latest] = *attestation
+	case KeepBoth:
+		return c.appendAttestation(attestation, opts)
+	default:
+		return fmt.Errorf("witness %s already attested", attestation.WitnessID)
+	}
+
+	return nil
+}
+
+// appendAttestation adds an attestation, applying the overflow policy if
+// the claim is at its cap
+func (c *Claim) appendAttestation(attestation *Attestation, opts AttestOptions) error {
+	limit := opts.MaxAttestations
+	if limit <= 0 {
+		limit = DefaultMaxAttestations
+	}
+
+	if len(c.Witnesses) < limit {
+		c.Witnesses = append(c.Witnesses, *attestation)
+		return nil
+	}
+
+	if opts.Overflow != ReplaceLowestReputation {
+		return fmt.Errorf("claim already has the maximum of %d attestations", limit)
+	}
+	if opts.Reputation == nil {
+		return fmt.Errorf("replacing attestations requires a reputation store")
+	}
+
+	lowest, lowestScore := -1, 0.0
+	for i, existing := range c.Witnesses {
+		score := witnessScore(c, existing.WitnessID, opts.Reputation)
+		if lowest < 0 || score < lowestScore {
+			lowest, lowestScore = i, score
 		}
 	}
 
-	c.Witnesses = append(c.Witnesses, *attestation)
+	if witnessScore(c, attestation.WitnessID, opts.Reputation) <= lowestScore {
+		return fmt.Errorf("claim already has the maximum of %d attestations from witnesses with higher reputation", limit)
+	}
+
+	c.Witnesses[lowest] = *attestation
 	return nil
 }
 
+// latestAttestations returns each witness's most recent attestation by
+// signed timestamp (see NewerThan), preserving the order in which
+// witnesses first attested
+func latestAttestations(attestations []Attestation) []Attestation {
+	position := make(map[string]int, len(attestations))
+	result := make([]Attestation, 0, len(attestations))
+
+	for _, att := range attestations {
+		i, seen := position[att.WitnessID]
+		if !seen {
+			position[att.WitnessID] = len(result)
+			result = append(result, att)
+			continue
+		}
+		if att.NewerThan(&result[i]) {
+			result[i] = att
+		}
+	}
+
+	return result
+}
+
+// unexpiredAttestations drops attestations that have lapsed by now
+func unexpiredAttestations(attestations []Attestation, now time.Time) []Attestation {
+	result := make([]Attestation, 0, len(attestations))
+	for _, att := range attestations {
+		if !att.IsExpired(now) {
+			result = append(result, att)
+		}
+	}
+	return result
+}
+
 // VerifyAllAttestations verifies all attestations on a claim
 func (c *Claim) VerifyAllAttestations() error {
+	if err := VerifyCID(c); err != nil {
+		return err
+	}
 	for i, att := range c.Witnesses {
-		if err := VerifyAttestation(c, &att); err != nil {
+		if err := verifySignedAttestation(c, &att); err != nil {
 			return fmt.Errorf("attestation %d invalid: %w", i, err)
 		}
 	}
@@ -1,7 +1,10 @@
 package claim
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,6 +21,21 @@ func TestGenerateWitness(t *testing.T) {
 	assert.Len(t, w.PrivateKey, 64)
 }
 
+func TestDeterministicWitness(t *testing.T) {
+	w := DeterministicWitness("alice")
+
+	// Pinned so a change to the derivation shows up as a failure
+	assert.Equal(t, "c5a634616cace223339a2f8386151f600c59bd7e1c918199e5fdfc9bad6724f8", w.ID)
+	assert.Equal(t, w.ID, DeterministicWitness("alice").ID)
+	assert.NotEqual(t, w.ID, DeterministicWitness("bob").ID)
+
+	c, err := NewClaim(Statement{Subject: "test"}, nil, "")
+	require.NoError(t, err)
+	att, err := w.Attest(c)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyAttestation(c, att))
+}
+
 func TestWitnessFromID(t *testing.T) {
 	w, err := GenerateWitness()
 	require.NoError(t, err)
@@ -67,6 +85,52 @@ func TestVerifyAttestation(t *testing.T) {
 	})
 }
 
+func TestAttestationDomainBinding(t *testing.T) {
+	w, err := GenerateWitness()
+	require.NoError(t, err)
+
+	finance, err := NewClaim(Statement{Subject: "ACME", Predicate: "closed-at", Object: "42.10", Domain: "finance"}, nil, "")
+	require.NoError(t, err)
+
+	for _, stance := range []Stance{StanceEndorse, StanceDispute} {
+		att, err := w.AttestWithStance(finance, stance)
+		require.NoError(t, err)
+		require.NoError(t, VerifyAttestation(finance, att))
+
+		t.Run(stance.String()+" is bound to the domain", func(t *testing.T) {
+			// A claim in another domain with a colliding CID
+			sports := *finance
+			sports.Statement.Domain = "sports"
+			assert.Error(t, VerifyAttestation(&sports, att))
+
+			undomained := *finance
+			undomained.Statement.Domain = ""
+			assert.Error(t, VerifyAttestation(&undomained, att))
+		})
+	}
+
+	t.Run("endorsements signed before domain binding still verify", func(t *testing.T) {
+		legacy := &Attestation{
+			WitnessID: w.ID,
+			Signature: ed25519.Sign(w.PrivateKey, []byte(finance.ID)),
+			Timestamp: time.Now().UTC(),
+		}
+		assert.NoError(t, VerifyAttestation(finance, legacy))
+
+		// Only plain endorsements were ever signed that way
+		disputed := *legacy
+		disputed.Stance = StanceDispute
+		assert.Error(t, VerifyAttestation(finance, &disputed))
+	})
+
+	t.Run("claims without a domain accept bare CID endorsements", func(t *testing.T) {
+		c, err := NewClaim(Statement{Subject: "test"}, nil, "")
+		require.NoError(t, err)
+		legacy := &Attestation{WitnessID: w.ID, Signature: ed25519.Sign(w.PrivateKey, []byte(c.ID))}
+		assert.NoError(t, VerifyAttestation(c, legacy))
+	})
+}
+
 func TestAddAttestation(t *testing.T) {
 	w, err := GenerateWitness()
 	require.NoError(t, err)
@@ -104,3 +168,439 @@ func TestVerifyAllAttestations(t *testing.T) {
 	err := c.VerifyAllAttestations()
 	assert.NoError(t, err)
 }
+
+func TestAttestFields(t *testing.T) {
+	w, _ := GenerateWitness()
+	c, err := NewClaim(Statement{Subject: "AAPL", Predicate: "quote", Domain: "finance"}, nil, "",
+		WithFields(map[string]string{"price": "189.20", "volume": "1000"}))
+	require.NoError(t, err)
+
+	att, err := w.AttestFields(c, "price")
+	require.NoError(t, err)
+	assert.NoError(t, VerifyAttestation(c, att))
+	assert.True(t, att.Covers("price"))
+	assert.False(t, att.Covers("volume"))
+
+	t.Run("scope is covered by signature", func(t *testing.T) {
+		forged := *att
+		forged.Fields = []string{"volume"}
+		assert.Error(t, VerifyAttestation(c, &forged))
+
+		forged.Fields = nil
+		assert.Error(t, VerifyAttestation(c, &forged))
+	})
+
+	t.Run("unknown field rejected", func(t *testing.T) {
+		_, err := w.AttestFields(c, "missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("fields are part of identity", func(t *testing.T) {
+		tampered := *c
+		tampered.Fields = map[string]string{"price": "1.00", "volume": "1000"}
+		assert.Error(t, VerifyCID(&tampered))
+	})
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	w, _ := GenerateWitness()
+
+	// reattest returns an endorsement and a later dispute from the same witness
+	reattest := func(t *testing.T) (*Claim, *Attestation, *Attestation) {
+		c, err := NewClaim(Statement{Subject: "test", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+
+		now := time.Now()
+		w.Clock = FixedClock(now)
+		first, err := w.Attest(c)
+		require.NoError(t, err)
+		w.Clock = FixedClock(now.Add(time.Second))
+		second, err := w.Dispute(c)
+		require.NoError(t, err)
+		w.Clock = nil
+
+		require.NoError(t, c.AddAttestation(first))
+		return c, first, second
+	}
+
+	t.Run("RejectDuplicate", func(t *testing.T) {
+		c, _, second := reattest(t)
+		assert.Error(t, c.AddAttestationWithPolicy(second, RejectDuplicate))
+		assert.Len(t, c.Witnesses, 1)
+		assert.Equal(t, StanceEndorse, c.Witnesses[0].Stance)
+	})
+
+	t.Run("ReplaceLatest", func(t *testing.T) {
+		c, first, second := reattest(t)
+		require.NoError(t, c.AddAttestationWithPolicy(second, ReplaceLatest))
+		require.Len(t, c.Witnesses, 1)
+		assert.Equal(t, StanceDispute, c.Witnesses[0].Stance)
+
+		// An older attestation cannot replace a newer one
+		assert.Error(t, c.AddAttestationWithPolicy(first, ReplaceLatest))
+		assert.Equal(t, StanceDispute, c.Witnesses[0].Stance)
+
+		// Invalid attestations never replace anything
+		forged := *second
+		forged.Timestamp = second.Timestamp.Add(time.Second)
+		forged.Signature = append([]byte(nil), first.Signature...)
+		assert.Error(t, c.AddAttestationWithPolicy(&forged, ReplaceLatest))
+		assert.Equal(t, StanceDispute, c.Witnesses[0].Stance)
+
+		// Nor can an old attestation be re-dated, since its time is signed
+		redated := *first
+		redated.Timestamp = second.Timestamp.Add(time.Hour)
+		assert.Error(t, c.AddAttestationWithPolicy(&redated, ReplaceLatest))
+		assert.Equal(t, StanceDispute, c.Witnesses[0].Stance)
+
+		// An attestation without a signed timestamp replaces nothing
+		legacy := &Attestation{WitnessID: w.ID, Signature: ed25519.Sign(w.PrivateKey, []byte(c.ID)), Timestamp: time.Now().Add(time.Hour)}
+		require.NoError(t, VerifyAttestation(c, legacy))
+		assert.ErrorContains(t, c.AddAttestationWithPolicy(legacy, ReplaceLatest), "not newer")
+		assert.Equal(t, StanceDispute, c.Witnesses[0].Stance)
+	})
+
+	t.Run("KeepBoth", func(t *testing.T) {
+		c, first, second := reattest(t)
+		require.NoError(t, c.AddAttestationWithPolicy(second, KeepBoth))
+		assert.Len(t, c.Witnesses, 2)
+		assert.NoError(t, c.VerifyAllAttestations())
+
+		// The identical attestation is still rejected
+		assert.Error(t, c.AddAttestationWithPolicy(first, KeepBoth))
+
+		// Only the latest (dispute) counts toward confidence
+		latestOnly := *c
+		latestOnly.Witnesses = []Attestation{*second}
+		rs := NewReputationStore()
+		assert.Equal(t, ClaimConfidence(&latestOnly, rs), ClaimConfidence(c, rs))
+	})
+}
+
+func TestMaxAttestations(t *testing.T) {
+	rs := NewReputationStore()
+
+	// track gives a witness a history of agreements or disputes in sports
+	track := func(agree bool) *Witness {
+		w, _ := GenerateWitness()
+		for i := 0; i < 50; i++ {
+			rs.RecordAttestation(w.ID, "sports")
+			if agree {
+				rs.RecordAgreement(w.ID, "sports")
+			} else {
+				rs.RecordDispute(w.ID, "sports")
+			}
+		}
+		return w
+	}
+	unreliable, trusted := track(false), track(true)
+	unknown, _ := GenerateWitness()
+	another, _ := GenerateWitness()
+
+	c, err := NewClaim(Statement{Subject: "test", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+
+	attest := func(w *Witness) *Attestation {
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		return att
+	}
+
+	opts := AttestOptions{MaxAttestations: 2}
+	require.NoError(t, c.AddAttestationWith(attest(unreliable), opts))
+	require.NoError(t, c.AddAttestationWith(attest(unknown), opts))
+
+	t.Run("cap reached", func(t *testing.T) {
+		assert.Error(t, c.AddAttestationWith(attest(trusted), opts))
+		assert.Len(t, c.Witnesses, 2)
+	})
+
+	t.Run("replacement needs a reputation store", func(t *testing.T) {
+		opts := AttestOptions{MaxAttestations: 2, Overflow: ReplaceLowestReputation}
+		assert.Error(t, c.AddAttestationWith(attest(trusted), opts))
+	})
+
+	t.Run("replace lowest reputation", func(t *testing.T) {
+		opts := AttestOptions{MaxAttestations: 2, Overflow: ReplaceLowestReputation, Reputation: rs}
+		require.NoError(t, c.AddAttestationWith(attest(trusted), opts))
+		require.Len(t, c.Witnesses, 2)
+		assert.Equal(t, unknown.ID, c.Witnesses[1].WitnessID)
+		assert.Equal(t, trusted.ID, c.Witnesses[0].WitnessID)
+
+		// A witness no more reputable than the lowest remaining is refused
+		assert.Error(t, c.AddAttestationWith(attest(another), opts))
+		assert.Equal(t, unknown.ID, c.Witnesses[1].WitnessID)
+	})
+
+	t.Run("default cap", func(t *testing.T) {
+		c, err := NewClaim(Statement{Subject: "busy", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		c.Witnesses = make([]Attestation, DefaultMaxAttestations)
+
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		assert.Error(t, c.AddAttestation(att))
+	})
+}
+
+func TestAttestWithContext(t *testing.T) {
+	w, _ := GenerateWitness()
+	c, err := NewClaim(Statement{Subject: "test", Domain: "sports"}, []string{"bafkreievidence"}, "")
+	require.NoError(t, err)
+
+	verifiedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	att, err := w.AttestWithContext(c, AttestationContext{
+		Method:     "manual-review",
+		Evidence:   []string{"bafkreievidence", "bafkreibroadcast"},
+		VerifiedAt: verifiedAt,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, VerifyAttestation(c, att))
+	assert.Equal(t, StanceEndorse, att.Stance)
+
+	t.Run("context is covered by the signature", func(t *testing.T) {
+		for name, tamper := range map[string]func(ac *AttestationContext){
+			"method":     func(ac *AttestationContext) { ac.Method = "automated" },
+			"evidence":   func(ac *AttestationContext) { ac.Evidence = ac.Evidence[:1] },
+			"order":      func(ac *AttestationContext) { ac.Evidence = []string{ac.Evidence[1], ac.Evidence[0]} },
+			"verifiedAt": func(ac *AttestationContext) { ac.VerifiedAt = ac.VerifiedAt.Add(time.Hour) },
+		} {
+			forged := *att
+			ac := *att.Context
+			tamper(&ac)
+			forged.Context = &ac
+			assert.Error(t, VerifyAttestation(c, &forged), name)
+		}
+
+		stripped := *att
+		stripped.Context = nil
+		assert.Error(t, VerifyAttestation(c, &stripped))
+	})
+
+	t.Run("empty evidence CID rejected", func(t *testing.T) {
+		_, err := w.AttestWithContext(c, AttestationContext{Method: "x", Evidence: []string{""}})
+		assert.Error(t, err)
+	})
+}
+
+func TestAttestWithContextHash(t *testing.T) {
+	w, _ := GenerateWitness()
+	c, err := NewClaim(Statement{Subject: "article", Predicate: "says", Object: "rates held"}, nil, "")
+	require.NoError(t, err)
+
+	snapshot := sha256.Sum256([]byte("<html>rates held at 5%</html>"))
+	att, err := w.AttestWithContextHash(c, snapshot[:])
+	require.NoError(t, err)
+	assert.NoError(t, VerifyAttestation(c, att))
+	assert.NoError(t, VerifyAttestationWithContextHash(c, att, snapshot[:]))
+
+	t.Run("different context rejected", func(t *testing.T) {
+		edited := sha256.Sum256([]byte("<html>rates cut to 4%</html>"))
+		assert.Error(t, VerifyAttestationWithContextHash(c, att, edited[:]))
+		assert.Error(t, VerifyAttestationWithContextHash(c, att, nil))
+
+		// Rewriting the recorded hash breaks the signature
+		forged := *att
+		forged.ContextHash = edited[:]
+		assert.Error(t, VerifyAttestation(c, &forged))
+		assert.Error(t, VerifyAttestationWithContextHash(c, &forged, edited[:]))
+
+		stripped := *att
+		stripped.ContextHash = nil
+		assert.Error(t, VerifyAttestation(c, &stripped))
+	})
+
+	t.Run("unbound attestation rejected", func(t *testing.T) {
+		plain, err := w.Attest(c)
+		require.NoError(t, err)
+		assert.Error(t, VerifyAttestationWithContextHash(c, plain, snapshot[:]))
+	})
+
+	t.Run("empty hash rejected", func(t *testing.T) {
+		_, err := w.AttestWithContextHash(c, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestRenew(t *testing.T) {
+	w, _ := GenerateWitness()
+	c, err := NewClaim(Statement{Subject: "license-7", Predicate: "valid", Domain: "registry"}, nil, "")
+	require.NoError(t, err)
+
+	// An attestation signed with an hour's validity that has since lapsed
+	expiring := &Attestation{ExpiresAt: time.Now().UTC().Add(-time.Minute)}
+	w.Clock = FixedClock(expiring.ExpiresAt.Add(-time.Hour))
+	_, err = w.sign(c, expiring)
+	require.NoError(t, err)
+	w.Clock = nil
+	require.NoError(t, VerifyAttestation(c, expiring))
+	require.NoError(t, c.AddAttestation(expiring))
+
+	rs := NewReputationStore()
+	assert.True(t, expiring.IsExpired(time.Now()))
+	assert.Zero(t, ClaimConfidence(c, rs), "lapsed attestations do not count")
+
+	renewed, err := w.Renew(c, expiring)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyAttestation(c, renewed))
+	assert.False(t, renewed.IsExpired(time.Now()))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), renewed.ExpiresAt, time.Minute)
+	assert.Len(t, renewed.Nonce, 16)
+
+	require.NoError(t, c.AddAttestationWithPolicy(renewed, ReplaceLatest))
+	require.Len(t, c.Witnesses, 1)
+	assert.Equal(t, renewed.ExpiresAt, c.Witnesses[0].ExpiresAt)
+	assert.Positive(t, ClaimConfidence(c, rs))
+
+	t.Run("expiry and nonce are covered by the signature", func(t *testing.T) {
+		extended := *renewed
+		extended.ExpiresAt = extended.ExpiresAt.Add(24 * time.Hour)
+		assert.Error(t, VerifyAttestation(c, &extended))
+
+		renonced := *renewed
+		renonced.Nonce = []byte("chosen")
+		assert.Error(t, VerifyAttestation(c, &renonced))
+	})
+
+	t.Run("renewals are distinct", func(t *testing.T) {
+		again, err := w.Renew(c, renewed)
+		require.NoError(t, err)
+		assert.NotEqual(t, renewed.Nonce, again.Nonce)
+		assert.NotEqual(t, renewed.Signature, again.Signature)
+	})
+
+	t.Run("scope and context carried over", func(t *testing.T) {
+		scoped, err := NewClaim(Statement{Subject: "license-8"}, nil, "", WithFields(map[string]string{"holder": "acme"}))
+		require.NoError(t, err)
+		original := &Attestation{
+			Stance:    StanceEndorse,
+			Fields:    []string{"holder"},
+			Context:   &AttestationContext{Method: "registry-lookup"},
+			ExpiresAt: time.Now().UTC().Add(time.Hour),
+		}
+		_, err = w.sign(scoped, original)
+		require.NoError(t, err)
+
+		renewed, err := w.Renew(scoped, original)
+		require.NoError(t, err)
+		assert.Equal(t, original.Fields, renewed.Fields)
+		assert.Equal(t, original.Context, renewed.Context)
+		assert.NoError(t, VerifyAttestation(scoped, renewed))
+	})
+
+	t.Run("invalid renewals rejected", func(t *testing.T) {
+		other, _ := GenerateWitness()
+		_, err := other.Renew(c, renewed)
+		assert.Error(t, err, "another witness's attestation")
+
+		plain, err := w.Attest(c)
+		require.NoError(t, err)
+		_, err = w.Renew(c, plain)
+		assert.Error(t, err, "attestation without expiry")
+
+		forged := *renewed
+		forged.Signature = []byte("forged")
+		_, err = w.Renew(c, &forged)
+		assert.Error(t, err)
+
+		_, err = w.AttestUntil(c, time.Now().Add(-time.Second))
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyAttestations(t *testing.T) {
+	c, err := NewClaim(Statement{Subject: "match-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		w, _ := GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+	}
+	c.Witnesses[3].Signature[0] ^= 0xFF
+
+	errs := VerifyAttestations(c)
+	require.Len(t, errs, 5)
+	for i, err := range errs {
+		if i == 3 {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestAttestationFreshness(t *testing.T) {
+	c, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	w := DeterministicWitness("alice")
+
+	att, err := w.AttestAtRound(c, 1000)
+	require.NoError(t, err)
+	require.NoError(t, VerifyAttestation(c, att))
+	assert.Equal(t, uint64(1000), att.BeaconRound)
+
+	t.Run("fresh", func(t *testing.T) {
+		assert.NoError(t, VerifyAttestationFreshness(att, 1000, 10))
+		assert.NoError(t, VerifyAttestationFreshness(att, 1010, 10))
+	})
+
+	t.Run("too old", func(t *testing.T) {
+		assert.ErrorContains(t, VerifyAttestationFreshness(att, 1011, 10), "11 rounds old")
+	})
+
+	t.Run("future round", func(t *testing.T) {
+		assert.Error(t, VerifyAttestationFreshness(att, 999, 10))
+	})
+
+	t.Run("unbound", func(t *testing.T) {
+		plain, err := w.Attest(c)
+		require.NoError(t, err)
+		assert.Error(t, VerifyAttestationFreshness(plain, 1000, 10))
+
+		_, err = w.AttestAtRound(c, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("round is signed", func(t *testing.T) {
+		forged := *att
+		forged.BeaconRound = 1009
+		assert.Error(t, VerifyAttestation(c, &forged))
+	})
+}
+
+func TestAttestationBoundToRevision(t *testing.T) {
+	original, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	revision, err := NewClaim(Statement{Subject: "match-1", Predicate: "result", Object: "2-2", Domain: "sports"}, nil, "", WithSupersedes(original.ID))
+	require.NoError(t, err)
+
+	w := DeterministicWitness("alice")
+	att, err := w.Attest(original)
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, att.ClaimID)
+	require.NoError(t, VerifyAttestation(original, att))
+
+	t.Run("moved to the revision", func(t *testing.T) {
+		assert.ErrorContains(t, VerifyAttestation(revision, att), "signed for claim")
+		assert.Error(t, revision.AddAttestation(att))
+	})
+
+	t.Run("revision posing under the original's CID", func(t *testing.T) {
+		forged := *revision
+		forged.ID = original.ID
+		assert.ErrorContains(t, VerifyAttestation(&forged, att), "CID mismatch")
+
+		forged.Witnesses = []Attestation{*att}
+		for _, err := range VerifyAttestations(&forged) {
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("relabeled claim ID", func(t *testing.T) {
+		relabeled := *att
+		relabeled.ClaimID = revision.ID
+		assert.ErrorContains(t, VerifyAttestation(revision, &relabeled), "invalid signature")
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+	"github.com/systemshift/claim-graph/store"
+)
+
+// batchReport is the outcome of attesting to a batch of claims
+type batchReport struct {
+	// Attested lists the claims attested and stored, in listing order
+	Attested []string
+
+	// Skipped lists claims the witness had already attested
+	Skipped []string
+
+	// Failed lists claims that could not be attested, with the reason
+	Failed []batchFailure
+}
+
+// batchFailure is a claim attest-batch could not attest
+type batchFailure struct {
+	CID string
+	Err error
+}
+
+// attestBatch attests, as w, to the claims in s matching filter, storing
+// each attested claim. limit caps the number of claims attested (0 for no
+// cap); claims the witness already attested are skipped and do not count
+// toward it. Failures on single claims are reported rather than stopping
+// the batch.
+func attestBatch(ctx context.Context, s store.Store, w *claim.Witness, filter *store.Filter, limit int) (*batchReport, error) {
+	cids, err := s.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list claims: %w", err)
+	}
+
+	report := &batchReport{}
+	for _, cid := range cids {
+		if limit > 0 && len(report.Attested) >= limit {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		c, err := s.Get(ctx, cid)
+		if err != nil {
+			report.Failed = append(report.Failed, batchFailure{CID: cid, Err: err})
+			continue
+		}
+		if attestedBy(c, w.ID) {
+			report.Skipped = append(report.Skipped, cid)
+			continue
+		}
+
+		// Attest a copy so a failed Put leaves the fetched claim untouched
+		updated := *c
+		updated.Witnesses = append([]claim.Attestation(nil), c.Witnesses...)
+		att, err := w.Attest(&updated)
+		if err == nil {
+			err = updated.AddAttestation(att)
+		}
+		if err == nil {
+			_, err = s.Put(ctx, &updated)
+		}
+		if err != nil {
+			report.Failed = append(report.Failed, batchFailure{CID: cid, Err: err})
+			continue
+		}
+		report.Attested = append(report.Attested, cid)
+	}
+
+	return report, nil
+}
+
+// attestedBy reports whether the witness has attested to the claim
+func attestedBy(c *claim.Claim, witnessID string) bool {
+	for _, att := range c.Witnesses {
+		if att.WitnessID == witnessID {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBatchReport prints a batch report for people to read
+func writeBatchReport(w io.Writer, r *batchReport) {
+	for _, cid := range r.Attested {
+		fmt.Fprintf(w, "  attested %s\n", cid)
+	}
+	for _, f := range r.Failed {
+		fmt.Fprintf(w, "  FAILED   %s: %v\n", f.CID, f.Err)
+	}
+	fmt.Fprintf(w, "Attested %d, skipped %d already attested, failed %d\n", len(r.Attested), len(r.Skipped), len(r.Failed))
+}
+
+// handleAttestBatch implements claimctl witness attest-batch
+func handleAttestBatch(args []string) {
+	batchCmd := flag.NewFlagSet("attest-batch", flag.ExitOnError)
+	domain := batchCmd.String("domain", "", "Only claims in this domain")
+	subject := batchCmd.String("subject", "", "Only claims about this subject")
+	state := batchCmd.String("state", "", "Only claims in this lifecycle state (e.g. published)")
+	query := batchCmd.String("query", "", "Only claims matching this query")
+	limit := batchCmd.Int("limit", 0, "Attest to at most this many claims (0 for all)")
+	ipfsURL := batchCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+	indexPath := batchCmd.String("index", defaultIndexPath(), "Local index log path")
+	_ = batchCmd.Parse(args)
+
+	filter := &store.Filter{Domain: *domain, Subject: *subject}
+	if *state != "" {
+		var st claim.State
+		if err := st.UnmarshalText([]byte(*state)); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --state: %v\n", err)
+			os.Exit(1)
+		}
+		filter.State = &st
+	}
+	if *query != "" {
+		pred, err := store.ParseQuery(*query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --query: %v\n", err)
+			os.Exit(1)
+		}
+		filter.Predicate = pred
+	}
+
+	witness, err := loadIdentity()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading witness: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openStore(*ipfsURL, *indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to IPFS: %v\n", err)
+		os.Exit(1)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report, err := attestBatch(ctx, s, witness, filter, *limit)
+	if report != nil {
+		writeBatchReport(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error attesting claims: %v\n", err)
+		os.Exit(1)
+	}
+	if len(report.Failed) > 0 {
+		os.Exit(1)
+	}
+}
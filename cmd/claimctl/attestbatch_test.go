@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+	"github.com/systemshift/claim-graph/store"
+)
+
+// batchStore is an in-memory store.Store that can refuse to store claims
+type batchStore struct {
+	claims map[string]*claim.Claim
+	refuse map[string]bool
+}
+
+func (s *batchStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
+	if s.refuse[c.ID] {
+		return "", fmt.Errorf("store unavailable")
+	}
+	s.claims[c.ID] = c
+	return c.ID, nil
+}
+
+func (s *batchStore) Get(ctx context.Context, cid string) (*claim.Claim, error) {
+	c, ok := s.claims[cid]
+	if !ok {
+		return nil, fmt.Errorf("claim %s not found", cid)
+	}
+	return c, nil
+}
+
+func (s *batchStore) Has(ctx context.Context, cid string) (bool, error) {
+	_, ok := s.claims[cid]
+	return ok, nil
+}
+
+func (s *batchStore) List(ctx context.Context, filter *store.Filter) ([]string, error) {
+	var cids []string
+	for cid, c := range s.claims {
+		if filter.Domain == "" || c.Statement.Domain == filter.Domain {
+			cids = append(cids, cid)
+		}
+	}
+	sort.Strings(cids)
+	return cids, nil
+}
+
+func (s *batchStore) Delete(ctx context.Context, cid string) error {
+	delete(s.claims, cid)
+	return nil
+}
+
+func (s *batchStore) Close() error { return nil }
+
+func TestAttestBatch(t *testing.T) {
+	ctx := context.Background()
+	oracle := claim.DeterministicWitness("oracle")
+	s := &batchStore{claims: make(map[string]*claim.Claim), refuse: make(map[string]bool)}
+
+	put := func(subject, domain string) *claim.Claim {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Predicate: "result", Object: "2-1", Domain: domain}, nil, "")
+		require.NoError(t, err)
+		s.claims[c.ID] = c
+		return c
+	}
+
+	var sports []*claim.Claim
+	for i := 0; i < 4; i++ {
+		sports = append(sports, put(fmt.Sprintf("match-%d", i), "sports"))
+	}
+	finance := put("ACME", "finance")
+
+	// One claim the oracle has already attested
+	att, err := oracle.Attest(sports[0])
+	require.NoError(t, err)
+	require.NoError(t, sports[0].AddAttestation(att))
+
+	// And one that cannot be stored
+	s.refuse[sports[1].ID] = true
+
+	report, err := attestBatch(ctx, s, oracle, &store.Filter{Domain: "sports"}, 0)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{sports[2].ID, sports[3].ID}, report.Attested)
+	assert.Equal(t, []string{sports[0].ID}, report.Skipped)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, sports[1].ID, report.Failed[0].CID)
+
+	t.Run("attested claims are stored", func(t *testing.T) {
+		for _, c := range sports[2:] {
+			stored := s.claims[c.ID]
+			require.Len(t, stored.Witnesses, 1)
+			assert.Equal(t, oracle.ID, stored.Witnesses[0].WitnessID)
+			assert.NoError(t, claim.VerifyAttestation(stored, &stored.Witnesses[0]))
+		}
+		assert.Len(t, sports[0].Witnesses, 1, "already attested claim is not attested twice")
+		assert.Empty(t, s.claims[sports[1].ID].Witnesses, "failed claim is left untouched")
+		assert.Empty(t, s.claims[finance.ID].Witnesses, "claims outside the filter are left alone")
+	})
+
+	t.Run("rerun skips what was attested", func(t *testing.T) {
+		delete(s.refuse, sports[1].ID)
+		report, err := attestBatch(ctx, s, oracle, &store.Filter{Domain: "sports"}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{sports[1].ID}, report.Attested)
+		assert.Len(t, report.Skipped, 3)
+		assert.Empty(t, report.Failed)
+	})
+
+	t.Run("limit counts attested claims", func(t *testing.T) {
+		for i := 4; i < 7; i++ {
+			put(fmt.Sprintf("match-%d", i), "sports")
+		}
+		report, err := attestBatch(ctx, s, oracle, &store.Filter{Domain: "sports"}, 2)
+		require.NoError(t, err)
+		assert.Len(t, report.Attested, 2)
+
+		var out bytes.Buffer
+		writeBatchReport(&out, report)
+		assert.Contains(t, out.String(), "Attested 2, skipped")
+	})
+}
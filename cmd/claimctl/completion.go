@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// completionCommand describes a command for shell completion
+type completionCommand struct {
+	name        string
+	description string
+	subcommands []completionCommand
+	flags       []string
+}
+
+// storeFlags are accepted by every command that opens the store
+var storeFlags = []string{"ipfs", "index"}
+
+// commandTree is the command tree offered by shell completion. Keep it in
+// step with the flag sets in main.go.
+var commandTree = []completionCommand{
+	{name: "identity", description: "Manage witness identity", subcommands: []completionCommand{
+		{name: "create", description: "Create new witness keypair"},
+		{name: "show", description: "Show current witness ID"},
+	}},
+	{name: "claim", description: "Create and manage claims", subcommands: []completionCommand{
+		{name: "create", description: "Create a new claim", flags: append([]string{
+			"subject", "predicate", "object", "objects", "ordered", "domain", "evidence", "time-event", "quantity", "cid-fields",
+		}, storeFlags...)},
+		{name: "get", description: "Get a claim by CID", flags: storeFlags},
+		{name: "verify", description: "Verify a claim", flags: append([]string{"output", "reputation", "tsa-roots"}, storeFlags...)},
+		{name: "import", description: "Import claims from JSON lines", flags: append([]string{"resume", "checkpoint"}, storeFlags...)},
+	}},
+	{name: "witness", description: "Attest to claims", subcommands: []completionCommand{
+		{name: "attest", description: "Attest to a claim", flags: storeFlags},
+		{name: "attest-batch", description: "Attest to every matching claim", flags: append([]string{
+			"domain", "subject", "state", "query", "limit",
+		}, storeFlags...)},
+		{name: "reputation", description: "Check witness reputation"},
+	}},
+	{name: "store", description: "Maintain the local store", subcommands: []completionCommand{
+		{name: "compact", description: "Drop superseded and deleted entries", flags: storeFlags},
+		{name: "check-refs", description: "Report evidence CIDs missing from the store", flags: storeFlags},
+	}},
+	{name: "backup", description: "Back up the store to an archive", flags: storeFlags},
+	{name: "restore", description: "Restore the store from an archive", flags: storeFlags},
+	{name: "serve", description: "Serve the REST API", flags: append([]string{"addr", "receipts", "drain"}, storeFlags...)},
+	{name: "completion", description: "Generate shell completion", subcommands: []completionCommand{
+		{name: "bash", description: "Bash completion script"},
+		{name: "zsh", description: "Zsh completion script"},
+		{name: "fish", description: "Fish completion script"},
+	}},
+	{name: "help", description: "Show this help"},
+}
+
+func handleCompletion(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: claimctl completion <bash|zsh|fish>")
+		return
+	}
+
+	if err := writeCompletion(os.Stdout, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeCompletion writes the completion script for a shell
+func writeCompletion(w io.Writer, shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		script = zshCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", shell)
+	}
+
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// hasSubcommandFlags reports whether any subcommand takes flags
+func hasSubcommandFlags(c completionCommand) bool {
+	for _, sub := range c.subcommands {
+		if len(sub.flags) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func commandNames(cmds []completionCommand) string {
+	names := make([]string, len(cmds))
+	for i, c := range cmds {
+		names[i] = c.name
+	}
+	return strings.Join(names, " ")
+}
+
+func flagNames(flags []string) string {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = "--" + f
+	}
+	return strings.Join(names, " ")
+}
+
+func bashCompletion() string {
+	var b strings.Builder
+
+	b.WriteString("# bash completion for claimctl\n")
+	b.WriteString("_claimctl() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    local cmd=\"${COMP_WORDS[1]}\" sub=\"${COMP_WORDS[2]}\"\n\n")
+	fmt.Fprintf(&b, "    if [ \"$COMP_CWORD\" -eq 1 ]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return\n    fi\n\n", commandNames(commandTree))
+
+	b.WriteString("    case \"$cmd\" in\n")
+	for _, c := range commandTree {
+		switch {
+		case len(c.subcommands) > 0:
+			fmt.Fprintf(&b, "    %s)\n        if [ \"$COMP_CWORD\" -eq 2 ]; then\n            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n            return\n        fi\n", c.name, commandNames(c.subcommands))
+			if hasSubcommandFlags(c) {
+				b.WriteString("        case \"$sub\" in\n")
+				for _, sub := range c.subcommands {
+					if len(sub.flags) > 0 {
+						fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", sub.name, flagNames(sub.flags))
+					}
+				}
+				b.WriteString("        esac\n")
+			}
+			b.WriteString("        ;;\n")
+		case len(c.flags) > 0:
+			fmt.Fprintf(&b, "    %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", c.name, flagNames(c.flags))
+		}
+	}
+	b.WriteString("    esac\n}\n\ncomplete -F _claimctl claimctl\n")
+
+	return b.String()
+}
+
+func zshCompletion() string {
+	var b strings.Builder
+
+	b.WriteString("#compdef claimctl\n\n")
+	b.WriteString("_claimctl() {\n")
+	b.WriteString("    local cmd=${words[2]} sub=${words[3]}\n\n")
+	fmt.Fprintf(&b, "    if (( CURRENT == 2 )); then\n        compadd -- %s\n        return\n    fi\n\n", commandNames(commandTree))
+
+	b.WriteString("    case $cmd in\n")
+	for _, c := range commandTree {
+		switch {
+		case len(c.subcommands) > 0:
+			fmt.Fprintf(&b, "    %s)\n        if (( CURRENT == 3 )); then\n            compadd -- %s\n            return\n        fi\n", c.name, commandNames(c.subcommands))
+			if hasSubcommandFlags(c) {
+				b.WriteString("        case $sub in\n")
+				for _, sub := range c.subcommands {
+					if len(sub.flags) > 0 {
+						fmt.Fprintf(&b, "        %s) compadd -- %s ;;\n", sub.name, flagNames(sub.flags))
+					}
+				}
+				b.WriteString("        esac\n")
+			}
+			b.WriteString("        ;;\n")
+		case len(c.flags) > 0:
+			fmt.Fprintf(&b, "    %s) compadd -- %s ;;\n", c.name, flagNames(c.flags))
+		}
+	}
+	b.WriteString("    esac\n}\n\ncompdef _claimctl claimctl\n")
+
+	return b.String()
+}
+
+func fishCompletion() string {
+	var b strings.Builder
+
+	b.WriteString("# fish completion for claimctl\n")
+	b.WriteString("complete -c claimctl -f\n")
+	for _, c := range commandTree {
+		fmt.Fprintf(&b, "complete -c claimctl -n __fish_use_subcommand -a %s -d %q\n", c.name, c.description)
+	}
+
+	for _, c := range commandTree {
+		for _, sub := range c.subcommands {
+			fmt.Fprintf(&b, "complete -c claimctl -n \"__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s\" -a %s -d %q\n",
+				c.name, commandNames(c.subcommands), sub.name, sub.description)
+			for _, f := range sub.flags {
+				fmt.Fprintf(&b, "complete -c claimctl -n \"__fish_seen_subcommand_from %s; and __fish_seen_subcommand_from %s\" -l %s\n", c.name, sub.name, f)
+			}
+		}
+		for _, f := range c.flags {
+			fmt.Fprintf(&b, "complete -c claimctl -n \"__fish_seen_subcommand_from %s\" -l %s\n", c.name, f)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeCompletion(&buf, shell))
+
+			script := buf.String()
+			require.NotEmpty(t, script)
+			for _, cmd := range commandTree {
+				assert.Contains(t, script, cmd.name)
+			}
+			assert.Contains(t, script, "reputation")
+			assert.Contains(t, script, "subject")
+			assert.Contains(t, script, "receipts")
+		})
+	}
+
+	t.Run("unknown shell", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Error(t, writeCompletion(&buf, "powershell"))
+	})
+}
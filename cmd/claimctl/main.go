@@ -3,13 +3,22 @@ package main
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/systemshift/claim-graph/claim"
+	"github.com/systemshift/claim-graph/server"
 	"github.com/systemshift/claim-graph/store"
 )
 
@@ -29,6 +38,16 @@ func main() {
 		handleClaim(args)
 	case "witness":
 		handleWitness(args)
+	case "store":
+		handleStore(args)
+	case "backup":
+		handleBackup(args)
+	case "restore":
+		handleRestore(args)
+	case "serve":
+		handleServe(args)
+	case "completion":
+		handleCompletion(args)
 	case "help", "-h", "--help":
 		printUsage()
 	default:
@@ -48,6 +67,11 @@ Commands:
   identity    Manage witness identity
   claim       Create and manage claims
   witness     Attest to claims
+  store       Maintain the local store
+  backup      Back up the store to an archive
+  restore     Restore the store from an archive
+  serve       Serve the REST API
+  completion  Generate shell completion
   help        Show this help
 
 Identity Commands:
@@ -56,13 +80,40 @@ Identity Commands:
 
 Claim Commands:
   claimctl claim create                 Create a new claim
-  claimctl claim get <cid>              Get a claim by CID
+  claimctl claim get <cid>              Get a claim by CID (or unambiguous prefix)
   claimctl claim verify <cid>           Verify a claim
+  claimctl claim verify <cid> --output json
+                                        Print the verification report as JSON
+  claimctl claim import <file>          Import claims from JSON lines
+  claimctl claim import <file> --resume Continue an interrupted import
 
 Witness Commands:
   claimctl witness attest <cid>         Attest to a claim
+  claimctl witness attest-batch --domain <d> [--limit <n>]
+                                        Attest to every matching claim not yet attested
   claimctl witness reputation <id>      Check witness reputation
 
+Store Commands:
+  claimctl store compact                Drop superseded and deleted entries
+  claimctl store check-refs             Report evidence CIDs missing from the store
+
+Backup Commands:
+  claimctl backup <file>                Write a backup archive with a manifest
+  claimctl restore <file>               Verify a backup archive and load it
+
+Server Commands:
+  claimctl serve [--addr :8080]         Serve the REST API
+  claimctl serve --receipts             Also sign attestation receipts
+  claimctl serve --drain 30s            Time allowed for in-flight requests on shutdown
+
+Completion Commands:
+  claimctl completion <bash|zsh|fish>   Print a shell completion script
+
+Options:
+  --ipfs <url>     IPFS API URL, or comma-separated URLs to fail over between
+                   (default: http://localhost:5001)
+  --index <path>   Local index log (default: ~/.claimctl/index.log)
+
 Examples:
   claimctl identity create
   claimctl claim create --subject "https://example.com" --predicate "contains" --object "text" --domain "web"
@@ -133,7 +184,7 @@ func handleIdentity(args []string) {
 
 func handleClaim(args []string) {
 	if len(args) == 0 {
-		fmt.Println("Usage: claimctl claim <create|get|verify>")
+		fmt.Println("Usage: claimctl claim <create|get|verify|import>")
 		return
 	}
 
@@ -146,14 +197,19 @@ func handleClaim(args []string) {
 		domain := createCmd.String("domain", "", "Domain category")
 		evidence := createCmd.String("evidence", "", "Evidence CIDs (comma-separated)")
 		timeEvent := createCmd.String("time-event", "", "dag-time event ID")
+		quantity := createCmd.String("quantity", "", "Numeric object with unit (e.g. \"100 USD\")")
+		objects := createCmd.String("objects", "", "Object with several values, in place of --object (comma-separated)")
+		ordered := createCmd.Bool("ordered", false, "Keep --objects in order rather than as a set")
+		cidFields := createCmd.String("cid-fields", "full", "Parts of the claim its CID covers: full, statement or statement+evidence")
 		ipfsURL := createCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+		indexPath := createCmd.String("index", defaultIndexPath(), "Local index log path")
 
 		if err := createCmd.Parse(args[1:]); err != nil {
 			os.Exit(1)
 		}
 
-		if *subject == "" || *predicate == "" || *object == "" {
-			fmt.Println("Error: subject, predicate, and object are required")
+		if *subject == "" || *predicate == "" || (*object == "") == (*objects == "") {
+			fmt.Println("Error: subject, predicate, and one of object or objects are required")
 			createCmd.Usage()
 			os.Exit(1)
 		}
@@ -171,19 +227,48 @@ func handleClaim(args []string) {
 			evidenceList = []string{*evidence}
 		}
 
-		c, err := claim.NewClaim(statement, evidenceList, *timeEvent)
+		var fields claim.CIDFields
+		if err := fields.UnmarshalText([]byte(*cidFields)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts := []claim.ClaimOption{claim.WithCIDFields(fields)}
+		if *quantity != "" {
+			q, err := claim.ParseQuantity(*quantity)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			opts = append(opts, claim.WithQuantity(q))
+		}
+		if *objects != "" {
+			values := strings.Split(*objects, ",")
+			newList := claim.NewObjectSet
+			if *ordered {
+				newList = claim.NewObjectSequence
+			}
+			l, err := newList(values...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			opts = append(opts, claim.WithObjects(l))
+		}
+
+		c, err := claim.NewClaim(statement, evidenceList, *timeEvent, opts...)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating claim: %v\n", err)
 			os.Exit(1)
 		}
 
 		// Store in IPFS if available
-		s, err := store.NewIPFSStore(store.IPFSConfig{APIURL: *ipfsURL})
+		s, err := openStore(*ipfsURL, *indexPath)
 		if err != nil {
 			fmt.Printf("Warning: IPFS not available, claim not stored\n")
 			fmt.Printf("Claim CID: %s\n", c.ID)
 			return
 		}
+		defer s.Close()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -199,24 +284,33 @@ func handleClaim(args []string) {
 
 	case "get":
 		if len(args) < 2 {
-			fmt.Println("Usage: claimctl claim get <cid>")
+			fmt.Println("Usage: claimctl claim get <cid or prefix>")
 			os.Exit(1)
 		}
 
 		cid := args[1]
 		getCmd := flag.NewFlagSet("get", flag.ExitOnError)
 		ipfsURL := getCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+		indexPath := getCmd.String("index", defaultIndexPath(), "Local index log path")
 		_ = getCmd.Parse(args[2:])
 
-		s, err := store.NewIPFSStore(store.IPFSConfig{APIURL: *ipfsURL})
+		s, err := openStore(*ipfsURL, *indexPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error connecting to IPFS: %v\n", err)
 			os.Exit(1)
 		}
+		defer s.Close()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
+		// Accept an unambiguous prefix in place of the full CID
+		cid, err = s.ResolveCID(ctx, cid)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		c, err := s.Get(ctx, cid)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting claim: %v\n", err)
@@ -228,69 +322,141 @@ func handleClaim(args []string) {
 
 	case "verify":
 		if len(args) < 2 {
-			fmt.Println("Usage: claimctl claim verify <cid>")
+			fmt.Println("Usage: claimctl claim verify <cid or prefix> [--output json] [--reputation <file>] [--tsa-roots <file>]")
 			os.Exit(1)
 		}
 
 		cid := args[1]
 		verifyCmd := flag.NewFlagSet("verify", flag.ExitOnError)
 		ipfsURL := verifyCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+		indexPath := verifyCmd.String("index", defaultIndexPath(), "Local index log path")
+		output := verifyCmd.String("output", "text", "Output format (text or json)")
+		reputation := verifyCmd.String("reputation", "", "Reputation records (JSON lines) for a confidence score")
+		tsaRootsPath := verifyCmd.String("tsa-roots", "", "Trusted TSA root certificates (PEM) for checking RFC 3161 timestamps")
 		_ = verifyCmd.Parse(args[2:])
 
-		s, err := store.NewIPFSStore(store.IPFSConfig{APIURL: *ipfsURL})
+		if *output != "text" && *output != "json" {
+			fmt.Fprintf(os.Stderr, "Unknown output format %q (want text or json)\n", *output)
+			os.Exit(1)
+		}
+
+		var rs *claim.ReputationStore
+		if *reputation != "" {
+			var err error
+			if rs, err = loadReputation(*reputation); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading reputation: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		var tsaRoots *x509.CertPool
+		if *tsaRootsPath != "" {
+			var err error
+			if tsaRoots, err = loadTSARoots(*tsaRootsPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading TSA roots: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		s, err := openStore(*ipfsURL, *indexPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error connecting to IPFS: %v\n", err)
 			os.Exit(1)
 		}
+		defer s.Close()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
+		// Accept an unambiguous prefix in place of the full CID
+		cid, err = s.ResolveCID(ctx, cid)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		c, err := s.Get(ctx, cid)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting claim: %v\n", err)
 			os.Exit(1)
 		}
 
-		// Verify CID
-		if err := claim.VerifyCID(c); err != nil {
-			fmt.Printf("CID verification: FAILED (%v)\n", err)
+		report := buildVerifyReport(c, rs, tsaRoots)
+		if *output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(report)
 		} else {
-			fmt.Printf("CID verification: OK\n")
+			writeVerifyReport(os.Stdout, report)
 		}
 
-		// Verify attestations
-		if len(c.Witnesses) == 0 {
-			fmt.Printf("Attestations: none\n")
-		} else {
-			if err := c.VerifyAllAttestations(); err != nil {
-				fmt.Printf("Attestations: INVALID (%v)\n", err)
-			} else {
-				fmt.Printf("Attestations: %d valid\n", len(c.Witnesses))
+	case "import":
+		if len(args) < 2 {
+			fmt.Println("Usage: claimctl claim import <file> [--resume]")
+			os.Exit(1)
+		}
+
+		path := args[1]
+		importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+		resume := importCmd.Bool("resume", false, "Resume from the last checkpoint")
+		checkpoint := importCmd.String("checkpoint", path+".checkpoint", "Checkpoint file path")
+		ipfsURL := importCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+		indexPath := importCmd.String("index", defaultIndexPath(), "Local index log path")
+		_ = importCmd.Parse(args[2:])
+
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening import file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+
+		s, err := openStore(*ipfsURL, *indexPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error connecting to IPFS: %v\n", err)
+			os.Exit(1)
+		}
+		defer s.Close()
+
+		report, err := store.Import(context.Background(), s, file, store.ImportOptions{
+			CheckpointPath: *checkpoint,
+			Resume:         *resume,
+		})
+		if report != nil {
+			fmt.Printf("Imported: %d\n", report.Imported)
+			fmt.Printf("Already stored: %d\n", report.Duplicates)
+			if *resume {
+				fmt.Printf("Skipped via checkpoint: %d\n", report.Resumed)
 			}
 		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error importing claims: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Re-run with --resume to continue from the last checkpoint\n")
+			os.Exit(1)
+		}
 
 	default:
-		fmt.Println("Usage: claimctl claim <create|get|verify>")
+		fmt.Println("Usage: claimctl claim <create|get|verify|import>")
 	}
 }
 
 func handleWitness(args []string) {
 	if len(args) == 0 {
-		fmt.Println("Usage: claimctl witness <attest|reputation>")
+		fmt.Println("Usage: claimctl witness <attest|attest-batch|reputation>")
 		return
 	}
 
 	switch args[0] {
 	case "attest":
 		if len(args) < 2 {
-			fmt.Println("Usage: claimctl witness attest <cid>")
+			fmt.Println("Usage: claimctl witness attest <cid or prefix>")
 			os.Exit(1)
 		}
 
 		cid := args[1]
 		attestCmd := flag.NewFlagSet("attest", flag.ExitOnError)
 		ipfsURL := attestCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+		indexPath := attestCmd.String("index", defaultIndexPath(), "Local index log path")
 		_ = attestCmd.Parse(args[2:])
 
 		// Load identity
@@ -315,15 +481,23 @@ func handleWitness(args []string) {
 		}
 
 		// Get claim
-		s, err := store.NewIPFSStore(store.IPFSConfig{APIURL: *ipfsURL})
+		s, err := openStore(*ipfsURL, *indexPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error connecting to IPFS: %v\n", err)
 			os.Exit(1)
 		}
+		defer s.Close()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
+		// Accept an unambiguous prefix in place of the full CID
+		cid, err = s.ResolveCID(ctx, cid)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		c, err := s.Get(ctx, cid)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting claim: %v\n", err)
@@ -353,6 +527,9 @@ func handleWitness(args []string) {
 		fmt.Printf("Attestation added to claim %s\n", cid)
 		fmt.Printf("  Witness: %s\n", witness.ID[:32]+"...")
 
+	case "attest-batch":
+		handleAttestBatch(args[1:])
+
 	case "reputation":
 		if len(args) < 2 {
 			fmt.Println("Usage: claimctl witness reputation <witness-id>")
@@ -364,8 +541,272 @@ func handleWitness(args []string) {
 		fmt.Println("(Reputation tracking not yet implemented)")
 
 	default:
-		fmt.Println("Usage: claimctl witness <attest|reputation>")
+		fmt.Println("Usage: claimctl witness <attest|attest-batch|reputation>")
+	}
+}
+
+func handleStore(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: claimctl store <compact|check-refs>")
+		return
+	}
+
+	switch args[0] {
+	case "compact":
+		compactCmd := flag.NewFlagSet("compact", flag.ExitOnError)
+		ipfsURL := compactCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+		indexPath := compactCmd.String("index", defaultIndexPath(), "Local index log path")
+		_ = compactCmd.Parse(args[1:])
+
+		s, err := openStore(*ipfsURL, *indexPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening store: %v\n", err)
+			os.Exit(1)
+		}
+		defer s.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		report, err := s.Compact(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error compacting store: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Compaction complete:\n")
+		fmt.Printf("  Live claims: %d\n", report.LiveClaims)
+		fmt.Printf("  Dropped index entries: %d\n", report.DroppedEntries)
+		fmt.Printf("  Unpinned envelopes: %d\n", report.Unpinned)
+		fmt.Printf("  Reclaimed: %d bytes\n", report.ReclaimedBytes)
+
+	case "check-refs":
+		checkCmd := flag.NewFlagSet("check-refs", flag.ExitOnError)
+		ipfsURL := checkCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+		indexPath := checkCmd.String("index", defaultIndexPath(), "Local index log path")
+		_ = checkCmd.Parse(args[1:])
+
+		s, err := openStore(*ipfsURL, *indexPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening store: %v\n", err)
+			os.Exit(1)
+		}
+		defer s.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		dangling, err := store.DanglingReferences(ctx, s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking references: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(dangling) == 0 {
+			fmt.Println("All references resolve")
+			return
+		}
+
+		cids := make([]string, 0, len(dangling))
+		for cid := range dangling {
+			cids = append(cids, cid)
+		}
+		sort.Strings(cids)
+
+		fmt.Printf("%d claims have dangling references:\n", len(cids))
+		for _, cid := range cids {
+			fmt.Printf("  %s\n", cid)
+			for _, ref := range dangling[cid] {
+				fmt.Printf("    -> %s\n", ref)
+			}
+		}
+		s.Close()
+		os.Exit(1)
+
+	default:
+		fmt.Println("Usage: claimctl store <compact|check-refs>")
+	}
+}
+
+func handleBackup(args []string) {
+	backupCmd := flag.NewFlagSet("backup", flag.ExitOnError)
+	ipfsURL := backupCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+	indexPath := backupCmd.String("index", defaultIndexPath(), "Local index log path")
+	_ = backupCmd.Parse(args)
+
+	if backupCmd.NArg() == 0 {
+		fmt.Println("Usage: claimctl backup <file>")
+		return
+	}
+
+	s, err := openStore(*ipfsURL, *indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening store: %v\n", err)
+		os.Exit(1)
+	}
+	defer s.Close()
+
+	file, err := os.OpenFile(backupCmd.Arg(0), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating backup: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	// claimctl keeps no reputation or profiles yet, so those sections are empty
+	manifest, err := store.ExportAll(ctx, file, s, claim.NewReputationStore())
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(backupCmd.Arg(0))
+		fmt.Fprintf(os.Stderr, "Error writing backup: %v\n", err)
+		os.Exit(1)
+	}
+
+	printManifest("Backup written", manifest)
+}
+
+func handleRestore(args []string) {
+	restoreCmd := flag.NewFlagSet("restore", flag.ExitOnError)
+	ipfsURL := restoreCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+	indexPath := restoreCmd.String("index", defaultIndexPath(), "Local index log path")
+	_ = restoreCmd.Parse(args)
+
+	if restoreCmd.NArg() == 0 {
+		fmt.Println("Usage: claimctl restore <file>")
+		return
+	}
+
+	file, err := os.Open(restoreCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening backup: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	s, err := openStore(*ipfsURL, *indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening store: %v\n", err)
+		os.Exit(1)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	// claimctl keeps no reputation or profiles yet, so those sections are
+	// verified but not kept
+	manifest, err := store.ImportAll(ctx, file, s, claim.NewReputationStore())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error restoring backup: %v\n", err)
+		s.Close()
+		os.Exit(1)
+	}
+
+	printManifest("Backup restored", manifest)
+}
+
+// printManifest summarizes a backup archive
+func printManifest(title string, m *store.BackupManifest) {
+	fmt.Printf("%s (%s, taken %s):\n", title, m.Schema, m.Created.Format(time.RFC3339))
+	fmt.Printf("  Claims: %d\n", m.Sections["claims.jsonl"].Count)
+	fmt.Printf("  Reputation records: %d\n", m.Sections["reputation.jsonl"].Count)
+	fmt.Printf("  Witness profiles: %d\n", m.Sections["profiles.jsonl"].Count)
+}
+
+func handleServe(args []string) {
+	serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := serveCmd.String("addr", ":8080", "Listen address")
+	ipfsURL := serveCmd.String("ipfs", "http://localhost:5001", "IPFS API URL")
+	indexPath := serveCmd.String("index", defaultIndexPath(), "Local index log path")
+	receipts := serveCmd.Bool("receipts", false, "Sign attestation receipts with the local identity")
+	drain := serveCmd.Duration("drain", 30*time.Second, "How long to wait for in-flight requests on shutdown")
+	_ = serveCmd.Parse(args)
+
+	var opts []server.Option
+	if *receipts {
+		node, err := loadIdentity()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading identity: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, server.WithNodeKey(node.PrivateKey))
+		fmt.Printf("Signing receipts as node %s\n", node.ID)
 	}
+
+	s, err := openStore(*ipfsURL, *indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to IPFS: %v\n", err)
+		os.Exit(1)
+	}
+
+	api := server.New(s, opts...)
+	httpServer := &http.Server{Addr: *addr, Handler: api}
+
+	// On SIGINT or SIGTERM, let in-flight requests finish and close the
+	// store before exiting
+	stopped := make(chan error, 1)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		fmt.Println("Shutting down...")
+
+		ctx, cancel := context.WithTimeout(context.Background(), *drain)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			stopped <- err
+			return
+		}
+		stopped <- api.Shutdown(ctx)
+	}()
+
+	fmt.Printf("Serving claim-graph API on %s\n", *addr)
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		s.Close()
+		os.Exit(1)
+	}
+	if err := <-stopped; err != nil {
+		fmt.Fprintf(os.Stderr, "Error shutting down: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// defaultIndexPath is where the CLI persists its local claim index
+func defaultIndexPath() string {
+	return os.ExpandEnv("$HOME/.claimctl/index.log")
+}
+
+// openStore connects to IPFS using the given local index log. ipfsURL may
+// list several comma-separated API URLs to fail over between.
+func openStore(ipfsURL, indexPath string) (*store.IPFSStore, error) {
+	if indexPath != "" {
+		if err := os.MkdirAll(filepath.Dir(indexPath), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create index dir: %w", err)
+		}
+	}
+	urls := strings.Split(ipfsURL, ",")
+	return store.NewIPFSStore(store.IPFSConfig{APIURL: urls[0], FallbackURLs: urls[1:], IndexPath: indexPath})
+}
+
+// loadIdentity reads the local witness identity
+func loadIdentity() (*claim.Witness, error) {
+	data, err := os.ReadFile(os.ExpandEnv("$HOME/.claimctl/identity.json"))
+	if err != nil {
+		return nil, fmt.Errorf("no identity found, run 'claimctl identity create' first")
+	}
+
+	var identity map[string]string
+	if err := json.Unmarshal(data, &identity); err != nil {
+		return nil, err
+	}
+
+	return witnessFromStoredKey(identity["private_key"])
 }
 
 func witnessFromStoredKey(hexKey string) (*claim.Witness, error) {
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// verifyReport is the full verification report printed by claim verify.
+// Claims carry no author signature of their own, so every signature it
+// reports is a witness attestation.
+type verifyReport struct {
+	// CID is the claim identifier that was verified
+	CID string `json:"cid"`
+
+	// OK reports whether every check that could be made passed
+	OK bool `json:"ok"`
+
+	// CIDValid reports whether the claim content matches its CID
+	CIDValid bool `json:"cid_valid"`
+
+	// CIDError explains a CID mismatch
+	CIDError string `json:"cid_error,omitempty"`
+
+	// Attestations reports each witness attestation in claim order
+	Attestations []attestationReport `json:"attestations"`
+
+	// Anchor reports how the claim is anchored in time
+	Anchor anchorReport `json:"anchor"`
+
+	// Confidence is the claim's confidence score, when reputation records
+	// were supplied
+	Confidence *float64 `json:"confidence,omitempty"`
+}
+
+// attestationReport is one witness attestation in a verifyReport
+type attestationReport struct {
+	WitnessID string    `json:"witness_id"`
+	Stance    string    `json:"stance"`
+	Timestamp time.Time `json:"timestamp"`
+	Valid     bool      `json:"valid"`
+	Error     string    `json:"error,omitempty"`
+
+	// Fields lists the fields a field-scoped attestation covers
+	Fields []string `json:"fields,omitempty"`
+
+	// Method, Evidence and VerifiedAt are the witness's signed account of
+	// how it verified the claim
+	Method     string     `json:"method,omitempty"`
+	Evidence   []string   `json:"evidence,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+
+	// ContextHash is the hex external context hash the witness signed
+	ContextHash string `json:"context_hash,omitempty"`
+
+	// ExpiresAt is when an expiring attestation lapses
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// anchorReport is the temporal anchor section of a verifyReport
+type anchorReport struct {
+	// TimeEvent is the dag-time event the claim cites. It is part of the
+	// CID but cannot be checked without a dag-time node.
+	TimeEvent string `json:"time_event,omitempty"`
+
+	// Timestamped reports whether the claim carries an RFC 3161 token
+	Timestamped bool `json:"timestamped"`
+
+	// TSAChecked reports whether the token was checked against trusted
+	// TSA roots. Without roots a token is reported but not verified.
+	TSAChecked bool `json:"tsa_checked"`
+
+	// TSATime is the time asserted by a valid timestamp token
+	TSATime *time.Time `json:"tsa_time,omitempty"`
+
+	// TSAError explains why a timestamp token failed verification
+	TSAError string `json:"tsa_error,omitempty"`
+}
+
+// buildVerifyReport verifies a claim. rs may be nil when no reputation is
+// available, in which case the report has no confidence score. tsaRoots
+// may be nil when no TSA is trusted, in which case a timestamp token is
+// left unchecked.
+func buildVerifyReport(c *claim.Claim, rs *claim.ReputationStore, tsaRoots *x509.CertPool) *verifyReport {
+	r := &verifyReport{CID: c.ID, Attestations: []attestationReport{}}
+
+	if err := claim.VerifyCID(c); err != nil {
+		r.CIDError = err.Error()
+	} else {
+		r.CIDValid = true
+	}
+	r.OK = r.CIDValid
+
+	for i := range c.Witnesses {
+		att := &c.Witnesses[i]
+		ar := attestationReport{
+			WitnessID: att.WitnessID,
+			Stance:    att.Stance.String(),
+			Timestamp: att.Timestamp,
+			Fields:    att.Fields,
+		}
+		if err := claim.VerifyAttestation(c, att); err != nil {
+			ar.Error = err.Error()
+			r.OK = false
+		} else {
+			ar.Valid = true
+		}
+		if ac := att.Context; ac != nil {
+			ar.Method = ac.Method
+			ar.Evidence = ac.Evidence
+			if !ac.VerifiedAt.IsZero() {
+				verifiedAt := ac.VerifiedAt
+				ar.VerifiedAt = &verifiedAt
+			}
+		}
+		if len(att.ContextHash) > 0 {
+			ar.ContextHash = hex.EncodeToString(att.ContextHash)
+		}
+		if !att.ExpiresAt.IsZero() {
+			expiresAt := att.ExpiresAt
+			ar.ExpiresAt = &expiresAt
+		}
+		r.Attestations = append(r.Attestations, ar)
+	}
+
+	r.Anchor.TimeEvent = c.TimeEvent
+	if len(c.TimestampToken) > 0 && tsaRoots != nil {
+		r.Anchor.Timestamped = true
+		r.Anchor.TSAChecked = true
+		if at, err := claim.VerifyTSA(c, tsaRoots); err != nil {
+			r.Anchor.TSAError = err.Error()
+			r.OK = false
+		} else {
+			r.Anchor.TSATime = &at
+		}
+	} else if len(c.TimestampToken) > 0 {
+		r.Anchor.Timestamped = true
+	}
+
+	if rs != nil {
+		confidence := claim.ClaimConfidence(c, rs)
+		r.Confidence = &confidence
+	}
+
+	return r
+}
+
+// writeVerifyReport prints a report for people to read
+func writeVerifyReport(w io.Writer, r *verifyReport) {
+	fmt.Fprintf(w, "Claim: %s\n", r.CID)
+
+	if r.CIDValid {
+		fmt.Fprintf(w, "CID integrity: OK\n")
+	} else {
+		fmt.Fprintf(w, "CID integrity: FAILED (%s)\n", r.CIDError)
+	}
+
+	valid := 0
+	for _, ar := range r.Attestations {
+		if ar.Valid {
+			valid++
+		}
+	}
+	switch {
+	case len(r.Attestations) == 0:
+		fmt.Fprintf(w, "Attestations: none\n")
+	case valid == len(r.Attestations):
+		fmt.Fprintf(w, "Attestations: %d valid\n", valid)
+	default:
+		fmt.Fprintf(w, "Attestations: %d valid, %d INVALID\n", valid, len(r.Attestations)-valid)
+	}
+	for _, ar := range r.Attestations {
+		status := "valid"
+		if !ar.Valid {
+			status = "INVALID: " + ar.Error
+		}
+		fmt.Fprintf(w, "  %s %s at %s (%s)\n", ar.WitnessID, ar.Stance, ar.Timestamp.Format(time.RFC3339), status)
+		if len(ar.Fields) > 0 {
+			fmt.Fprintf(w, "    fields: %s\n", strings.Join(ar.Fields, ", "))
+		}
+		if ar.Method != "" || len(ar.Evidence) > 0 {
+			fmt.Fprintf(w, "    verified by %q", ar.Method)
+			if ar.VerifiedAt != nil {
+				fmt.Fprintf(w, " at %s", ar.VerifiedAt.Format(time.RFC3339))
+			}
+			fmt.Fprintln(w)
+			for _, ev := range ar.Evidence {
+				fmt.Fprintf(w, "      using %s\n", ev)
+			}
+		}
+		if ar.ContextHash != "" {
+			fmt.Fprintf(w, "    bound to context %s\n", ar.ContextHash)
+		}
+		if ar.ExpiresAt != nil {
+			verb := "expires"
+			if !ar.ExpiresAt.After(time.Now()) {
+				verb = "EXPIRED"
+			}
+			fmt.Fprintf(w, "    %s %s\n", verb, ar.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+
+	fmt.Fprintf(w, "Temporal anchor:\n")
+	if r.Anchor.TimeEvent != "" {
+		fmt.Fprintf(w, "  dag-time event: %s (not checked)\n", r.Anchor.TimeEvent)
+	} else {
+		fmt.Fprintf(w, "  dag-time event: none\n")
+	}
+	switch {
+	case !r.Anchor.Timestamped:
+		fmt.Fprintf(w, "  RFC 3161 timestamp: none\n")
+	case !r.Anchor.TSAChecked:
+		fmt.Fprintf(w, "  RFC 3161 timestamp: present (not checked; no --tsa-roots)\n")
+	case r.Anchor.TSATime != nil:
+		fmt.Fprintf(w, "  RFC 3161 timestamp: OK (%s)\n", r.Anchor.TSATime.Format(time.RFC3339))
+	default:
+		fmt.Fprintf(w, "  RFC 3161 timestamp: FAILED (%s)\n", r.Anchor.TSAError)
+	}
+
+	if r.Confidence != nil {
+		fmt.Fprintf(w, "Confidence: %.2f\n", *r.Confidence)
+	} else {
+		fmt.Fprintf(w, "Confidence: unavailable (no --reputation records)\n")
+	}
+
+	if r.OK {
+		fmt.Fprintf(w, "Result: OK\n")
+	} else {
+		fmt.Fprintf(w, "Result: FAILED\n")
+	}
+}
+
+// loadTSARoots reads the PEM certificates of trusted TSA roots
+func loadTSARoots(path string) (*x509.CertPool, error) {
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no PEM certificates in %s", path)
+	}
+	return roots, nil
+}
+
+// loadReputation reads reputation records written as JSON lines, such as
+// the reputation section of a backup
+func loadReputation(path string) (*claim.ReputationStore, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rs := claim.NewReputationStore()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var export claim.ExportedReputation
+		if err := json.Unmarshal(scanner.Bytes(), &export); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := rs.RestoreRecord(export); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
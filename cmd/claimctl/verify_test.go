@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestVerifyReport(t *testing.T) {
+	c, err := claim.NewClaim(claim.Statement{Subject: "match-7", Predicate: "won-by", Object: "home", Domain: "sports"},
+		[]string{"bafkreiscore"}, "dag-event-42", claim.WithFields(map[string]string{"score": "2-1"}))
+	require.NoError(t, err)
+
+	reviewer := claim.DeterministicWitness("reviewer")
+	verifiedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	att, err := reviewer.AttestWithContext(c, claim.AttestationContext{
+		Method: "manual-review", Evidence: []string{"bafkreiscore"}, VerifiedAt: verifiedAt,
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	scorer := claim.DeterministicWitness("scorer")
+	att, err = scorer.AttestFields(c, "score")
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	snapshot := []byte{0xde, 0xad, 0xbe, 0xef}
+	archivist := claim.DeterministicWitness("archivist")
+	att, err = archivist.AttestWithContextHash(c, snapshot)
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	skeptic := claim.DeterministicWitness("skeptic")
+	att, err = skeptic.Dispute(c)
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	rs := claim.NewReputationStore()
+	for _, w := range []*claim.Witness{reviewer, scorer, archivist, skeptic} {
+		rs.RecordAttestation(w.ID, "sports")
+	}
+
+	report := buildVerifyReport(c, rs, nil)
+	assert.True(t, report.OK)
+	assert.True(t, report.CIDValid)
+	require.Len(t, report.Attestations, 4)
+	for _, ar := range report.Attestations {
+		assert.True(t, ar.Valid, ar.WitnessID)
+	}
+	assert.Equal(t, "manual-review", report.Attestations[0].Method)
+	assert.Equal(t, []string{"bafkreiscore"}, report.Attestations[0].Evidence)
+	assert.Equal(t, verifiedAt, *report.Attestations[0].VerifiedAt)
+	assert.Equal(t, []string{"score"}, report.Attestations[1].Fields)
+	assert.Equal(t, "deadbeef", report.Attestations[2].ContextHash)
+	assert.Equal(t, "dispute", report.Attestations[3].Stance)
+	assert.Equal(t, anchorReport{TimeEvent: "dag-event-42"}, report.Anchor)
+	require.NotNil(t, report.Confidence)
+	assert.InDelta(t, claim.ClaimConfidence(c, rs), *report.Confidence, 1e-9)
+
+	t.Run("json", func(t *testing.T) {
+		data, err := json.Marshal(report)
+		require.NoError(t, err)
+
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, true, decoded["ok"])
+		assert.Equal(t, c.ID, decoded["cid"])
+		assert.Len(t, decoded["attestations"], 4)
+		assert.Equal(t, "dag-event-42", decoded["anchor"].(map[string]interface{})["time_event"])
+		assert.Contains(t, decoded, "confidence")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeVerifyReport(&buf, report)
+		out := buf.String()
+
+		assert.Contains(t, out, "CID integrity: OK")
+		assert.Contains(t, out, "Attestations: 4 valid")
+		assert.Contains(t, out, skeptic.ID+" dispute")
+		assert.Contains(t, out, `verified by "manual-review" at 2024-05-01T12:00:00Z`)
+		assert.Contains(t, out, "fields: score")
+		assert.Contains(t, out, "bound to context deadbeef")
+		assert.Contains(t, out, "dag-time event: dag-event-42")
+		assert.Contains(t, out, "RFC 3161 timestamp: none")
+		assert.Contains(t, out, "Confidence: ")
+		assert.Contains(t, out, "Result: OK")
+	})
+
+	t.Run("failures reported", func(t *testing.T) {
+		broken := *c
+		broken.Witnesses = append([]claim.Attestation(nil), c.Witnesses...)
+		broken.Witnesses[1].Signature = []byte("forged")
+		broken.TimestampToken = []byte("not a token")
+
+		report := buildVerifyReport(&broken, nil, x509.NewCertPool())
+		assert.False(t, report.OK)
+		assert.True(t, report.CIDValid)
+		assert.False(t, report.Attestations[1].Valid)
+		assert.NotEmpty(t, report.Attestations[1].Error)
+		assert.True(t, report.Anchor.Timestamped)
+		assert.True(t, report.Anchor.TSAChecked)
+		assert.NotEmpty(t, report.Anchor.TSAError)
+		assert.Nil(t, report.Confidence)
+
+		broken.Statement.Object = "away"
+		report = buildVerifyReport(&broken, nil, x509.NewCertPool())
+		assert.False(t, report.CIDValid)
+
+		var buf bytes.Buffer
+		writeVerifyReport(&buf, report)
+		assert.Contains(t, buf.String(), "CID integrity: FAILED")
+		assert.Contains(t, buf.String(), "INVALID")
+		assert.Contains(t, buf.String(), "RFC 3161 timestamp: FAILED")
+		assert.Contains(t, buf.String(), "Confidence: unavailable")
+		assert.Contains(t, buf.String(), "Result: FAILED")
+	})
+
+	t.Run("timestamp unchecked without TSA roots", func(t *testing.T) {
+		stamped := *c
+		stamped.TimestampToken = []byte("not a token")
+
+		report := buildVerifyReport(&stamped, nil, nil)
+		assert.True(t, report.OK)
+		assert.True(t, report.Anchor.Timestamped)
+		assert.False(t, report.Anchor.TSAChecked)
+		assert.Empty(t, report.Anchor.TSAError)
+		assert.Nil(t, report.Anchor.TSATime)
+
+		var buf bytes.Buffer
+		writeVerifyReport(&buf, report)
+		assert.Contains(t, buf.String(), "RFC 3161 timestamp: present (not checked; no --tsa-roots)")
+	})
+
+	t.Run("reputation records", func(t *testing.T) {
+		var lines bytes.Buffer
+		for _, record := range rs.Records() {
+			require.NoError(t, json.NewEncoder(&lines).Encode(record))
+		}
+		path := filepath.Join(t.TempDir(), "reputation.jsonl")
+		require.NoError(t, os.WriteFile(path, lines.Bytes(), 0600))
+
+		loaded, err := loadReputation(path)
+		require.NoError(t, err)
+		assert.Len(t, loaded.Records(), 4)
+
+		require.NoError(t, os.WriteFile(path, []byte("{not json\n"), 0600))
+		_, err = loadReputation(path)
+		assert.Error(t, err)
+	})
+}
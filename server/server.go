@@ -0,0 +1,316 @@
+// Package server exposes a claim store over HTTP
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/systemshift/claim-graph/claim"
+	"github.com/systemshift/claim-graph/store"
+)
+
+// maxBatchSize bounds the number of CIDs accepted in one verify request
+const maxBatchSize = 1000
+
+// Server serves the claim-graph REST API backed by a store
+type Server struct {
+	store store.Store
+	mux   *http.ServeMux
+
+	// nodeKey signs attestation receipts (nil disables receipts)
+	nodeKey ed25519.PrivateKey
+
+	// writeMu serializes read-modify-write updates to stored claims
+	writeMu sync.Mutex
+
+	// verified caches each claim's latest verification by state hash
+	verifiedMu sync.Mutex
+	verified   map[string]cachedReport
+
+	// inflight counts requests being served, so Shutdown can drain them
+	stateMu   sync.Mutex
+	closing   bool
+	inflight  sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// ErrShuttingDown is returned for requests that arrive after Shutdown
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// cachedReport is a verification result and the claim state it was
+// computed for
+type cachedReport struct {
+	stateHash string
+	report    *store.VerifyReport
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithNodeKey makes the server issue signed receipts for accepted
+// attestations, so witnesses can prove the node received them
+func WithNodeKey(key ed25519.PrivateKey) Option {
+	return func(s *Server) {
+		s.nodeKey = key
+	}
+}
+
+// New creates a new server for the given store
+func New(s store.Store, opts ...Option) *Server {
+	srv := &Server{
+		store:    s,
+		mux:      http.NewServeMux(),
+		verified: make(map[string]cachedReport),
+	}
+
+	for _, opt := range opts {
+		opt(srv)
+	}
+
+	srv.mux.HandleFunc("GET /claims", srv.handleList)
+	srv.mux.HandleFunc("POST /verify", srv.handleVerify)
+	srv.mux.HandleFunc("GET /claims/{cid}/verify", srv.handleVerifyClaim)
+	srv.mux.HandleFunc("POST /claims/{cid}/attestations", srv.handleAttest)
+
+	return srv
+}
+
+// ServeHTTP implements http.Handler. Once Shutdown has been called, new
+// requests are rejected with 503 Service Unavailable.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.stateMu.Lock()
+	if s.closing {
+		s.stateMu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, ErrShuttingDown)
+		return
+	}
+	s.inflight.Add(1)
+	s.stateMu.Unlock()
+	defer s.inflight.Done()
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// Shutdown stops accepting requests, waits for those in flight to finish
+// and then closes the store, so no store operation is cut off midway. If
+// ctx ends first, Shutdown returns its error and leaves the store open,
+// since requests are still using it; calling Shutdown again resumes the
+// wait. Stop the HTTP listener (http.Server.Shutdown) before calling it.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stateMu.Lock()
+	s.closing = true
+	s.stateMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.closeOnce.Do(func() {
+		s.closeErr = s.store.Close()
+	})
+	return s.closeErr
+}
+
+// ListResponse is the response of GET /claims
+type ListResponse struct {
+	CIDs []string `json:"cids"`
+}
+
+// handleList lists stored claims, filtered by the query in the q
+// parameter (see store.ParseQuery)
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	var filter *store.Filter
+	if q := r.URL.Query().Get("q"); q != "" {
+		pred, err := store.ParseQuery(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		filter = &store.Filter{Predicate: pred}
+	}
+
+	cids, err := s.store.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if cids == nil {
+		cids = []string{}
+	}
+	sort.Strings(cids)
+	writeJSON(w, http.StatusOK, ListResponse{CIDs: cids})
+}
+
+// VerifyRequest is the body of POST /verify
+type VerifyRequest struct {
+	CIDs []string `json:"cids"`
+}
+
+// VerifyResponse is the response of POST /verify
+type VerifyResponse struct {
+	Results map[string]store.VerifyResult `json:"results"`
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	var req VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if len(req.CIDs) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("cids cannot be empty"))
+		return
+	}
+	if len(req.CIDs) > maxBatchSize {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("too many cids: got %d, max %d", len(req.CIDs), maxBatchSize))
+		return
+	}
+
+	results := store.VerifyMany(r.Context(), s.store, req.CIDs)
+	writeJSON(w, http.StatusOK, VerifyResponse{Results: results})
+}
+
+// ClaimVerifyResponse is the response of GET /claims/{cid}/verify
+type ClaimVerifyResponse struct {
+	CID    string              `json:"cid"`
+	OK     bool                `json:"ok"`
+	Report *store.VerifyReport `json:"report"`
+}
+
+// handleVerifyClaim verifies a single claim. The response's ETag is the
+// claim's state hash, so a client polling with If-None-Match gets 304 Not
+// Modified until an attestation changes, and results are cached per state.
+func (s *Server) handleVerifyClaim(w http.ResponseWriter, r *http.Request) {
+	cid := r.PathValue("cid")
+
+	c, err := s.store.Get(r.Context(), cid)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	stateHash, err := claim.StateHash(c)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	etag := `"` + stateHash + `"`
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	s.verifiedMu.Lock()
+	cached, ok := s.verified[cid]
+	s.verifiedMu.Unlock()
+
+	report := cached.report
+	if !ok || cached.stateHash != stateHash {
+		report = store.VerifyClaim(c, cid)
+		s.verifiedMu.Lock()
+		s.verified[cid] = cachedReport{stateHash: stateHash, report: report}
+		s.verifiedMu.Unlock()
+	}
+
+	writeJSON(w, http.StatusOK, ClaimVerifyResponse{CID: cid, OK: report.OK(), Report: report})
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag.
+// Weak validators match too, as If-None-Match uses weak comparison.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// AttestResponse is the response of POST /claims/{cid}/attestations
+type AttestResponse struct {
+	CID     string         `json:"cid"`
+	Receipt *claim.Receipt `json:"receipt,omitempty"`
+}
+
+func (s *Server) handleAttest(w http.ResponseWriter, r *http.Request) {
+	cid := r.PathValue("cid")
+
+	var att claim.Attestation
+	if err := json.NewDecoder(r.Body).Decode(&att); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	storeCID, err := store.AttachAttestation(r.Context(), s.store, cid, &att)
+	if err != nil {
+		writeError(w, attachErrorStatus(err), err)
+		return
+	}
+
+	resp := AttestResponse{CID: storeCID}
+	if s.nodeKey != nil {
+		updated, err := s.store.Get(r.Context(), storeCID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		receipt, err := claim.IssueReceipt(s.nodeKey, updated, att.WitnessID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		resp.Receipt = receipt
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// attachErrorStatus maps an error from store.AttachAttestation to an HTTP
+// status
+func attachErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrDeleted):
+		return http.StatusGone
+	case errors.Is(err, store.ErrWrongClaim), errors.Is(err, store.ErrInvalidAttestation):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrVersionConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
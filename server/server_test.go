@@ -0,0 +1,462 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+	"github.com/systemshift/claim-graph/store"
+)
+
+// memStore is a minimal in-memory store.Store for handler tests
+type memStore struct {
+	mu     sync.Mutex
+	claims map[string]*claim.Claim
+}
+
+func newMemStore() *memStore {
+	return &memStore{claims: make(map[string]*claim.Claim)}
+}
+
+func (m *memStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.claims[c.ID] = c
+	return c.ID, nil
+}
+
+func (m *memStore) Get(ctx context.Context, cid string) (*claim.Claim, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.claims[cid]
+	if !ok {
+		return nil, fmt.Errorf("claim %s: %w", cid, store.ErrNotFound)
+	}
+	return c, nil
+}
+
+func (m *memStore) Has(ctx context.Context, cid string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.claims[cid]
+	return ok, nil
+}
+
+func (m *memStore) List(ctx context.Context, filter *store.Filter) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var cids []string
+	for cid, c := range m.claims {
+		if filter != nil && filter.Predicate != nil && !filter.Predicate(c) {
+			continue
+		}
+		cids = append(cids, cid)
+	}
+	return cids, nil
+}
+
+func (m *memStore) Delete(ctx context.Context, cid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.claims[cid]; !ok {
+		return fmt.Errorf("claim %s: %w", cid, store.ErrNotFound)
+	}
+	delete(m.claims, cid)
+	return nil
+}
+
+func (m *memStore) Close() error {
+	return nil
+}
+
+func TestListEndpoint(t *testing.T) {
+	s := newMemStore()
+	ctx := context.Background()
+
+	var finance []string
+	for _, subject := range []string{"rates", "earnings"} {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: "finance"}, nil, "")
+		require.NoError(t, err)
+		_, _ = s.Put(ctx, c)
+		finance = append(finance, c.ID)
+	}
+	sports, err := claim.NewClaim(claim.Statement{Subject: "match", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	_, _ = s.Put(ctx, sports)
+
+	srv := New(s)
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/claims?q="+url.QueryEscape(query), nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("query", func(t *testing.T) {
+		rec := list("domain:finance AND NOT subject:nothing")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp ListResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.ElementsMatch(t, finance, resp.CIDs)
+	})
+
+	t.Run("no query lists everything", func(t *testing.T) {
+		rec := list("")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp ListResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Len(t, resp.CIDs, 3)
+	})
+
+	t.Run("malformed query rejected", func(t *testing.T) {
+		rec := list("domain:finance AND (")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "query")
+	})
+}
+
+func TestVerifyEndpoint(t *testing.T) {
+	s := newMemStore()
+	ctx := context.Background()
+
+	valid, err := claim.NewClaim(claim.Statement{Subject: "valid"}, nil, "")
+	require.NoError(t, err)
+	_, _ = s.Put(ctx, valid)
+
+	tampered, err := claim.NewClaim(claim.Statement{Subject: "tampered"}, nil, "")
+	require.NoError(t, err)
+	_, _ = s.Put(ctx, tampered)
+	tampered.Statement.Subject = "changed"
+
+	srv := New(s)
+
+	t.Run("batch verification", func(t *testing.T) {
+		body, _ := json.Marshal(VerifyRequest{CIDs: []string{valid.ID, tampered.ID, "missing"}})
+		req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp VerifyResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.Results, 3)
+
+		assert.True(t, resp.Results[valid.ID].OK())
+		assert.False(t, resp.Results[tampered.ID].CIDValid)
+		assert.False(t, resp.Results["missing"].Found)
+	})
+
+	t.Run("empty batch rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader([]byte(`{"cids":[]}`)))
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("wrong method rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestVerifyClaimEndpoint(t *testing.T) {
+	s := newMemStore()
+	ctx := context.Background()
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "match", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	w, _ := claim.GenerateWitness()
+	att, err := w.Attest(c)
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+	_, _ = s.Put(ctx, c)
+
+	srv := New(s)
+
+	verify := func(cid, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/claims/"+cid+"/verify", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := verify(c.ID, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	var resp ClaimVerifyResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.True(t, resp.OK)
+	assert.Len(t, resp.Report.Attestations, 1)
+
+	t.Run("unchanged state is not modified", func(t *testing.T) {
+		rec := verify(c.ID, etag)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+
+		assert.Equal(t, http.StatusNotModified, verify(c.ID, `"other", W/`+etag).Code)
+	})
+
+	t.Run("unchanged state is served from cache", func(t *testing.T) {
+		cached := srv.verified[c.ID].report
+		require.NotNil(t, cached)
+
+		rec := verify(c.ID, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+		assert.Same(t, cached, srv.verified[c.ID].report)
+	})
+
+	t.Run("changed state is verified afresh", func(t *testing.T) {
+		other, _ := claim.GenerateWitness()
+		otherAtt, err := other.AttestWithStance(c, claim.StanceDispute)
+		require.NoError(t, err)
+		body, _ := json.Marshal(otherAtt)
+		req := httptest.NewRequest(http.MethodPost, "/claims/"+c.ID+"/attestations", bytes.NewReader(body))
+		attRec := httptest.NewRecorder()
+		srv.ServeHTTP(attRec, req)
+		require.Equal(t, http.StatusOK, attRec.Code, attRec.Body.String())
+
+		rec := verify(c.ID, etag)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+		var resp ClaimVerifyResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.True(t, resp.OK)
+		assert.Len(t, resp.Report.Attestations, 2)
+	})
+
+	t.Run("unknown claim", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, verify("missing", "").Code)
+	})
+}
+
+func TestAttestEndpoint(t *testing.T) {
+	s := newMemStore()
+	ctx := context.Background()
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "match", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	_, _ = s.Put(ctx, c)
+
+	nodePub, nodeKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	srv := New(s, WithNodeKey(nodeKey))
+
+	attest := func(srv *Server, cid string, att *claim.Attestation) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(att)
+		req := httptest.NewRequest(http.MethodPost, "/claims/"+cid+"/attestations", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	w, _ := claim.GenerateWitness()
+	att, err := w.Attest(c)
+	require.NoError(t, err)
+
+	rec := attest(srv, c.ID, att)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp AttestResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.NotNil(t, resp.Receipt)
+	assert.Equal(t, c.ID, resp.Receipt.ClaimCID)
+	assert.Equal(t, w.ID, resp.Receipt.WitnessID)
+	assert.NoError(t, claim.VerifyReceipt(resp.Receipt, nodePub))
+
+	stored, err := s.Get(ctx, c.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Witnesses, 1)
+	stateHash, err := claim.StateHash(stored)
+	require.NoError(t, err)
+	assert.Equal(t, stateHash, resp.Receipt.StateHash)
+
+	t.Run("duplicate rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, attest(srv, c.ID, att).Code)
+	})
+
+	t.Run("unknown claim", func(t *testing.T) {
+		unstored, err := claim.NewClaim(claim.Statement{Subject: "unstored", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		unstoredAtt, err := w.Attest(unstored)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, attest(srv, unstored.ID, unstoredAtt).Code)
+	})
+
+	t.Run("attestation for another claim", func(t *testing.T) {
+		other, err := claim.NewClaim(claim.Statement{Subject: "other", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		_, _ = s.Put(ctx, other)
+
+		w2, _ := claim.GenerateWitness()
+		otherAtt, err := w2.Attest(other)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, attest(srv, c.ID, otherAtt).Code)
+	})
+
+	t.Run("store failures are not reported as missing claims", func(t *testing.T) {
+		w2, _ := claim.GenerateWitness()
+		att2, err := w2.Attest(c)
+		require.NoError(t, err)
+
+		rec := attest(New(failingStore{s}), c.ID, att2)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+	})
+
+	t.Run("no receipt without node key", func(t *testing.T) {
+		other, _ := claim.GenerateWitness()
+		otherAtt, _ := other.Attest(c)
+
+		rec := attest(New(s), c.ID, otherAtt)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp AttestResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Nil(t, resp.Receipt)
+	})
+}
+
+// failingStore fails every Get as an unreachable backend would
+type failingStore struct {
+	*memStore
+}
+
+func (f failingStore) Get(ctx context.Context, cid string) (*claim.Claim, error) {
+	return nil, errors.New("backend unavailable")
+}
+
+// blockingStore holds Puts until released and records being closed
+type blockingStore struct {
+	*memStore
+	started chan struct{}
+	release chan struct{}
+
+	closeMu sync.Mutex
+	closed  bool
+}
+
+func (b *blockingStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return b.memStore.Put(ctx, c)
+}
+
+func (b *blockingStore) Close() error {
+	b.closeMu.Lock()
+	defer b.closeMu.Unlock()
+	b.closed = true
+	return nil
+}
+
+func (b *blockingStore) isClosed() bool {
+	b.closeMu.Lock()
+	defer b.closeMu.Unlock()
+	return b.closed
+}
+
+func TestShutdown(t *testing.T) {
+	ctx := context.Background()
+
+	newServer := func(t *testing.T) (*Server, *blockingStore, string, []byte) {
+		s := &blockingStore{memStore: newMemStore(), started: make(chan struct{}), release: make(chan struct{})}
+		c, err := claim.NewClaim(claim.Statement{Subject: "match", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		_, _ = s.memStore.Put(ctx, c)
+
+		w, _ := claim.GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		body, _ := json.Marshal(att)
+		return New(s), s, c.ID, body
+	}
+
+	serve := func(srv *Server, method, target string, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(method, target, bytes.NewReader(body)))
+		return rec
+	}
+
+	t.Run("waits for in-flight put", func(t *testing.T) {
+		srv, s, cid, body := newServer(t)
+
+		attested := make(chan *httptest.ResponseRecorder)
+		go func() {
+			attested <- serve(srv, http.MethodPost, "/claims/"+cid+"/attestations", body)
+		}()
+		<-s.started
+
+		shutdown := make(chan error)
+		go func() {
+			shutdown <- srv.Shutdown(ctx)
+		}()
+
+		select {
+		case <-shutdown:
+			t.Fatal("Shutdown returned while a Put was in flight")
+		case <-time.After(50 * time.Millisecond):
+		}
+		assert.False(t, s.isClosed())
+
+		// New requests are turned away while draining
+		rec := serve(srv, http.MethodGet, "/claims", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+		close(s.release)
+		assert.Equal(t, http.StatusOK, (<-attested).Code)
+		require.NoError(t, <-shutdown)
+		assert.True(t, s.isClosed())
+
+		stored, err := s.Get(ctx, cid)
+		require.NoError(t, err)
+		assert.Len(t, stored.Witnesses, 1)
+
+		rec = serve(srv, http.MethodGet, "/claims", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("context expiry leaves store open", func(t *testing.T) {
+		srv, s, cid, body := newServer(t)
+
+		attested := make(chan *httptest.ResponseRecorder)
+		go func() {
+			attested <- serve(srv, http.MethodPost, "/claims/"+cid+"/attestations", body)
+		}()
+		<-s.started
+
+		short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, srv.Shutdown(short), context.DeadlineExceeded)
+		assert.False(t, s.isClosed())
+
+		close(s.release)
+		<-attested
+		require.NoError(t, srv.Shutdown(ctx))
+		assert.True(t, s.isClosed())
+	})
+}
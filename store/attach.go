@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// ErrWrongClaim is returned when an attestation is attached to a claim
+// other than the one it was signed for
+var ErrWrongClaim = errors.New("attestation was signed for another claim")
+
+// ErrInvalidAttestation is returned when an attestation cannot be added to
+// the stored claim, e.g. because its signature does not verify or the
+// witness already attested
+var ErrInvalidAttestation = errors.New("invalid attestation")
+
+// AttachAttestation verifies an attestation against the stored claim cid
+// and stores the claim with it added, returning the stored CID. An
+// attestation signed over any other CID, such as that of a revision the
+// claim supersedes or is superseded by, is rejected with ErrWrongClaim;
+// one the claim does not accept is rejected with ErrInvalidAttestation.
+func AttachAttestation(ctx context.Context, s Store, cid string, att *claim.Attestation) (string, error) {
+	return AttachAttestationWith(ctx, s, cid, att, claim.AttestOptions{})
+}
+
+// AttachAttestationWith is AttachAttestation adding the attestation with
+// the given options, e.g. a Reputation store whose registered groups'
+// revocations are honored. On a store that supports PutIfVersion, a
+// concurrent write to the claim is retried rather than overwritten, so no
+// attestation is lost.
+func AttachAttestationWith(ctx context.Context, s Store, cid string, att *claim.Attestation, opts claim.AttestOptions) (string, error) {
+	if att == nil {
+		return "", fmt.Errorf("%w: attestation cannot be nil", ErrInvalidAttestation)
+	}
+	if att.ClaimID != "" && att.ClaimID != cid {
+		return "", fmt.Errorf("%w: signed %s, attaching to %s", ErrWrongClaim, att.ClaimID, cid)
+	}
+
+	vs, versioned := s.(versionedStore)
+	for attempt := 0; ; attempt++ {
+		// Read the version first, so a write after it fails the Put below
+		var version string
+		if versioned {
+			version = vs.Version(cid)
+		}
+
+		c, err := s.Get(ctx, cid)
+		if err != nil {
+			return "", err
+		}
+		if c.ID != cid {
+			return "", fmt.Errorf("stored claim ID does not match requested CID")
+		}
+
+		// Work on a copy so a failed Put leaves the stored claim untouched
+		updated := *c
+		updated.Witnesses = append([]claim.Attestation(nil), c.Witnesses...)
+		if err := updated.AddAttestationWith(att, opts); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+		}
+
+		if !versioned {
+			return s.Put(ctx, &updated)
+		}
+		stored, err := vs.PutIfVersion(ctx, &updated, version)
+		if errors.Is(err, ErrVersionConflict) && attempt < maxAttachRetries {
+			continue
+		}
+		return stored, err
+	}
+}
+
+// maxAttachRetries bounds how often AttachAttestationWith re-reads a claim
+// that was written concurrently
+const maxAttachRetries = 8
+
+// versionedStore is a Store that can write conditionally on the version
+// a claim was read at
+type versionedStore interface {
+	Store
+	Version(cid string) string
+	PutIfVersion(ctx context.Context, c *claim.Claim, expectedVersion string) (string, error)
+}
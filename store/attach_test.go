@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestAttachAttestation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	original, err := claim.NewClaim(claim.Statement{Subject: "match-1", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	originalCID, err := s.Put(ctx, original)
+	require.NoError(t, err)
+
+	revision, err := claim.NewClaim(claim.Statement{Subject: "match-1", Object: "2-2", Domain: "sports"}, nil, "", claim.WithSupersedes(originalCID))
+	require.NoError(t, err)
+	revisionCID, err := s.Put(ctx, revision)
+	require.NoError(t, err)
+
+	att, err := claim.DeterministicWitness("alice").Attest(original)
+	require.NoError(t, err)
+
+	t.Run("onto the revision", func(t *testing.T) {
+		_, err := AttachAttestation(ctx, s, revisionCID, att)
+		assert.ErrorIs(t, err, ErrWrongClaim)
+
+		stored, err := s.Get(ctx, revisionCID)
+		require.NoError(t, err)
+		assert.Empty(t, stored.Witnesses)
+	})
+
+	t.Run("onto the claim it signed", func(t *testing.T) {
+		_, err := AttachAttestation(ctx, s, originalCID, att)
+		require.NoError(t, err)
+
+		stored, err := s.Get(ctx, originalCID)
+		require.NoError(t, err)
+		require.Len(t, stored.Witnesses, 1)
+		assert.NoError(t, claim.VerifyAttestation(stored, &stored.Witnesses[0]))
+	})
+
+	t.Run("legacy attestation without a claim ID", func(t *testing.T) {
+		legacy := *att
+		legacy.ClaimID = ""
+		_, err := AttachAttestation(ctx, s, revisionCID, &legacy)
+		assert.ErrorIs(t, err, ErrInvalidAttestation)
+		assert.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("onto a claim the store does not have", func(t *testing.T) {
+		unstored, err := claim.NewClaim(claim.Statement{Subject: "match-2", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		att, err := claim.DeterministicWitness("alice").Attest(unstored)
+		require.NoError(t, err)
+
+		_, err = AttachAttestation(ctx, s, unstored.ID, att)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("concurrent attestations are all kept", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				att, err := claim.DeterministicWitness(fmt.Sprintf("w%d", i)).Attest(revision)
+				if err == nil {
+					_, err = AttachAttestation(ctx, s, revisionCID, att)
+				}
+				errs[i] = err
+			}(i)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+
+		stored, err := s.Get(ctx, revisionCID)
+		require.NoError(t, err)
+		assert.Len(t, stored.Witnesses, len(errs))
+	})
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// Defaults for AttestQueueConfig
+const (
+	defaultAttestBatchSize     = 100
+	defaultAttestFlushInterval = time.Second
+)
+
+// AttestQueueConfig configures an AttestQueue
+type AttestQueueConfig struct {
+	// BatchSize flushes the queue once this many claims are waiting
+	// (default 100)
+	BatchSize int
+
+	// FlushInterval is the longest a claim waits before being flushed
+	// (default 1s)
+	FlushInterval time.Duration
+
+	// OnError receives errors from timer-triggered flushes (optional)
+	OnError func(error)
+}
+
+// AttestQueue buffers claims for a witness to attest and stores them in
+// batches, flushing when the batch is full, when the oldest queued claim
+// has waited FlushInterval, or on Close. The queue adds attestations to
+// the claims it is given. Claims that fail to store stay queued for the
+// next flush.
+type AttestQueue struct {
+	witness *claim.Witness
+	store   Store
+	config  AttestQueueConfig
+
+	mu      sync.Mutex
+	pending []*claim.Claim
+	timer   *time.Timer
+	closed  bool
+
+	// flushMu serializes flushes so batches are stored in queue order
+	flushMu sync.Mutex
+}
+
+// NewAttestQueue creates a queue that attests with w and stores to s
+func NewAttestQueue(w *claim.Witness, s Store, config AttestQueueConfig) (*AttestQueue, error) {
+	if w == nil || !w.CanSign() {
+		return nil, fmt.Errorf("queue witness must have a private key")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultAttestBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultAttestFlushInterval
+	}
+
+	return &AttestQueue{witness: w, store: s, config: config}, nil
+}
+
+// Enqueue adds a claim to the queue, flushing if the batch is full
+func (q *AttestQueue) Enqueue(ctx context.Context, c *claim.Claim) error {
+	if c == nil {
+		return fmt.Errorf("claim cannot be nil")
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return fmt.Errorf("attest queue is closed")
+	}
+	q.pending = append(q.pending, c)
+	if q.timer == nil {
+		q.timer = time.AfterFunc(q.config.FlushInterval, q.flushOnTimer)
+	}
+	full := len(q.pending) >= q.config.BatchSize
+	q.mu.Unlock()
+
+	if full {
+		return q.Flush(ctx)
+	}
+	return nil
+}
+
+// Len returns the number of claims waiting to be flushed
+func (q *AttestQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Flush attests and stores every queued claim. If storing fails, the
+// claims not yet stored are put back at the front of the queue.
+func (q *AttestQueue) Flush(ctx context.Context) error {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	q.mu.Lock()
+	batch := q.pending
+	q.pending = nil
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	q.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// Claims requeued after a failed store already carry our attestation
+	var unsigned []*claim.Claim
+	for _, c := range batch {
+		if !hasAttestationFrom(c, q.witness.ID) {
+			unsigned = append(unsigned, c)
+		}
+	}
+
+	var errs []error
+	atts, err := q.witness.AttestBatch(unsigned)
+	if err != nil {
+		q.requeue(batch)
+		return err
+	}
+	for i, c := range unsigned {
+		if err := c.AddAttestation(atts[i]); err != nil {
+			errs = append(errs, fmt.Errorf("claim %s: %w", c.ID, err))
+		}
+	}
+
+	for i, c := range batch {
+		if _, err := q.store.Put(ctx, c); err != nil {
+			q.requeue(batch[i:])
+			return errors.Join(append(errs, fmt.Errorf("claim %s: %w", c.ID, err))...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// requeue puts claims back at the front of the queue
+func (q *AttestQueue) requeue(claims []*claim.Claim) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = append(append([]*claim.Claim(nil), claims...), q.pending...)
+	if q.timer == nil && !q.closed {
+		q.timer = time.AfterFunc(q.config.FlushInterval, q.flushOnTimer)
+	}
+}
+
+func (q *AttestQueue) flushOnTimer() {
+	if err := q.Flush(context.Background()); err != nil && q.config.OnError != nil {
+		q.config.OnError(err)
+	}
+}
+
+// Close stops accepting claims and flushes those still queued
+func (q *AttestQueue) Close() error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	return q.Flush(context.Background())
+}
+
+func hasAttestationFrom(c *claim.Claim, witnessID string) bool {
+	for _, att := range c.Witnesses {
+		if att.WitnessID == witnessID {
+			return true
+		}
+	}
+	return false
+}
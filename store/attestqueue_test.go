@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestAttestQueue(t *testing.T) {
+	ctx := context.Background()
+	w, _ := claim.GenerateWitness()
+
+	newClaims := func(t *testing.T, n int) []*claim.Claim {
+		var claims []*claim.Claim
+		for i := 0; i < n; i++ {
+			c, err := claim.NewClaim(claim.Statement{Subject: fmt.Sprintf("%s-%d", t.Name(), i), Domain: "queue"}, nil, "")
+			require.NoError(t, err)
+			claims = append(claims, c)
+		}
+		return claims
+	}
+
+	// stored reports whether every claim is in s with our attestation
+	stored := func(s Store, claims []*claim.Claim) bool {
+		for _, c := range claims {
+			got, err := s.Get(ctx, c.ID)
+			if err != nil || len(got.Witnesses) != 1 || got.Witnesses[0].WitnessID != w.ID {
+				return false
+			}
+		}
+		return true
+	}
+
+	t.Run("flushes on batch size", func(t *testing.T) {
+		s := newTestStore(t)
+		q, err := NewAttestQueue(w, s, AttestQueueConfig{BatchSize: 3, FlushInterval: time.Hour})
+		require.NoError(t, err)
+		defer q.Close()
+
+		claims := newClaims(t, 3)
+		require.NoError(t, q.Enqueue(ctx, claims[0]))
+		require.NoError(t, q.Enqueue(ctx, claims[1]))
+		assert.Equal(t, 2, q.Len())
+		assert.False(t, stored(s, claims[:2]))
+
+		require.NoError(t, q.Enqueue(ctx, claims[2]))
+		assert.Zero(t, q.Len())
+		assert.True(t, stored(s, claims))
+	})
+
+	t.Run("flushes on timer", func(t *testing.T) {
+		s := newTestStore(t)
+		q, err := NewAttestQueue(w, s, AttestQueueConfig{BatchSize: 100, FlushInterval: 20 * time.Millisecond})
+		require.NoError(t, err)
+		defer q.Close()
+
+		claims := newClaims(t, 2)
+		for _, c := range claims {
+			require.NoError(t, q.Enqueue(ctx, c))
+		}
+		assert.Eventually(t, func() bool { return q.Len() == 0 && stored(s, claims) }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("flushes on close", func(t *testing.T) {
+		s := newTestStore(t)
+		q, err := NewAttestQueue(w, s, AttestQueueConfig{BatchSize: 100, FlushInterval: time.Hour})
+		require.NoError(t, err)
+
+		claims := newClaims(t, 5)
+		for _, c := range claims {
+			require.NoError(t, q.Enqueue(ctx, c))
+		}
+		require.NoError(t, q.Close())
+		assert.True(t, stored(s, claims))
+
+		assert.Error(t, q.Enqueue(ctx, newClaims(t, 1)[0]))
+	})
+
+	t.Run("failed store keeps claims queued", func(t *testing.T) {
+		s := &countingStore{Store: newTestStore(t), failAfter: 2}
+		q, err := NewAttestQueue(w, s, AttestQueueConfig{BatchSize: 100, FlushInterval: time.Hour})
+		require.NoError(t, err)
+
+		claims := newClaims(t, 3)
+		for _, c := range claims {
+			require.NoError(t, q.Enqueue(ctx, c))
+		}
+		assert.Error(t, q.Flush(ctx))
+		assert.Equal(t, 1, q.Len())
+
+		// The retry stores the remaining claim without attesting it twice
+		s.failAfter = 0
+		require.NoError(t, q.Close())
+		assert.True(t, stored(s, claims))
+	})
+
+	t.Run("witness needs a private key", func(t *testing.T) {
+		_, err := NewAttestQueue(&claim.Witness{ID: w.ID}, newTestStore(t), AttestQueueConfig{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,268 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// BackupSchema identifies the backup archive layout
+const BackupSchema = "claim-graph/backup/v1"
+
+// Backup archive sections, each a file of JSON lines in the archive
+const (
+	backupManifest   = "manifest.json"
+	backupClaims     = "claims.jsonl"
+	backupReputation = "reputation.jsonl"
+	backupProfiles   = "profiles.jsonl"
+)
+
+// backupSections lists the sections in archive order
+var backupSections = []string{backupClaims, backupReputation, backupProfiles}
+
+// BackupManifest describes a backup archive. It is the archive's first
+// entry, so an importer can check every section before loading any.
+type BackupManifest struct {
+	// Schema is the archive layout version (BackupSchema)
+	Schema string `json:"schema"`
+
+	// Created is when the backup was taken
+	Created time.Time `json:"created"`
+
+	// Sections describes each section by file name
+	Sections map[string]BackupSection `json:"sections"`
+}
+
+// BackupSection describes one section of a backup archive
+type BackupSection struct {
+	// Count is the number of records in the section
+	Count int `json:"count"`
+
+	// Size is the section's length in bytes
+	Size int64 `json:"size"`
+
+	// SHA256 is the hex SHA-256 of the section
+	SHA256 string `json:"sha256"`
+}
+
+// ExportAll writes a backup of every claim in s and every reputation
+// record and witness profile in rs to w, as a tar archive with a
+// manifest. Reset archives and trusted keys (reset admins, federation
+// roots) are configuration rather than data and are not included.
+func ExportAll(ctx context.Context, w io.Writer, s Store, rs *claim.ReputationStore) (*BackupManifest, error) {
+	sections := make(map[string]*bytes.Buffer, len(backupSections))
+	manifest := &BackupManifest{
+		Schema:   BackupSchema,
+		Created:  time.Now().UTC(),
+		Sections: make(map[string]BackupSection, len(backupSections)),
+	}
+
+	// Claims, ordered by CID so identical stores give identical sections
+	cids, err := s.List(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list claims: %w", err)
+	}
+	sort.Strings(cids)
+
+	var claims bytes.Buffer
+	for _, cid := range cids {
+		c, err := s.Get(ctx, cid)
+		if err != nil {
+			return nil, fmt.Errorf("claim %s: %w", cid, err)
+		}
+		line, err := ExportRecord(c)
+		if err != nil {
+			return nil, fmt.Errorf("claim %s: %w", cid, err)
+		}
+		claims.Write(append(line, '\n'))
+	}
+	sections[backupClaims] = &claims
+
+	records := rs.Records()
+	reputation, err := jsonLines(len(records), func(i int) interface{} { return records[i] })
+	if err != nil {
+		return nil, err
+	}
+	sections[backupReputation] = reputation
+
+	profiles := rs.Profiles()
+	profileLines, err := jsonLines(len(profiles), func(i int) interface{} { return profiles[i] })
+	if err != nil {
+		return nil, err
+	}
+	sections[backupProfiles] = profileLines
+
+	counts := map[string]int{backupClaims: len(cids), backupReputation: len(records), backupProfiles: len(profiles)}
+	for name, buf := range sections {
+		sum := sha256.Sum256(buf.Bytes())
+		manifest.Sections[name] = BackupSection{Count: counts[name], Size: int64(buf.Len()), SHA256: hex.EncodeToString(sum[:])}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeTarEntry(tw, backupManifest, manifestData, manifest.Created); err != nil {
+		return nil, err
+	}
+	for _, name := range backupSections {
+		if err := writeTarEntry(tw, name, sections[name].Bytes(), manifest.Created); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// jsonLines encodes n values as JSON lines
+func jsonLines(n int, value func(i int) interface{}) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := 0; i < n; i++ {
+		if err := enc.Encode(value(i)); err != nil {
+			return nil, err
+		}
+	}
+	return &buf, nil
+}
+
+func writeTarEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// ImportAll restores a backup written by ExportAll into s and rs. The
+// whole archive is checked against its manifest before anything is
+// loaded, so a corrupted or truncated archive is rejected untouched. Claims
+// already in s are skipped, reputation records replace those in rs, and
+// profiles older than ones rs already has are skipped.
+func ImportAll(ctx context.Context, r io.Reader, s Store, rs *claim.ReputationStore) (*BackupManifest, error) {
+	manifest, sections, err := readBackup(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := Import(ctx, s, bytes.NewReader(sections[backupClaims]), ImportOptions{}); err != nil {
+		return manifest, fmt.Errorf("failed to import claims: %w", err)
+	}
+
+	err = eachJSONLine(sections[backupReputation], func(line []byte) error {
+		var export claim.ExportedReputation
+		if err := json.Unmarshal(line, &export); err != nil {
+			return err
+		}
+		return rs.RestoreRecord(export)
+	})
+	if err != nil {
+		return manifest, fmt.Errorf("failed to restore reputation: %w", err)
+	}
+
+	err = eachJSONLine(sections[backupProfiles], func(line []byte) error {
+		var p claim.WitnessProfile
+		if err := json.Unmarshal(line, &p); err != nil {
+			return err
+		}
+		if existing, ok := rs.Profile(p.WitnessID); ok && !p.Timestamp.After(existing.Timestamp) {
+			return nil
+		}
+		return rs.SetProfile(&p)
+	})
+	if err != nil {
+		return manifest, fmt.Errorf("failed to restore profiles: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// readBackup reads an archive and checks every section against the
+// manifest
+func readBackup(r io.Reader) (*BackupManifest, map[string][]byte, error) {
+	tr := tar.NewReader(r)
+
+	header, err := tr.Next()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	if header.Name != backupManifest {
+		return nil, nil, fmt.Errorf("backup does not start with a manifest")
+	}
+	var manifest BackupManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, nil, fmt.Errorf("corrupt manifest: %w", err)
+	}
+	if manifest.Schema != BackupSchema {
+		return nil, nil, fmt.Errorf("unsupported backup schema %q (want %q)", manifest.Schema, BackupSchema)
+	}
+
+	sections := make(map[string][]byte, len(backupSections))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		if _, ok := manifest.Sections[header.Name]; !ok {
+			return nil, nil, fmt.Errorf("backup has unexpected entry %s", header.Name)
+		}
+		if _, dup := sections[header.Name]; dup {
+			return nil, nil, fmt.Errorf("backup has duplicate entry %s", header.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		sections[header.Name] = data
+	}
+
+	for _, name := range backupSections {
+		want, ok := manifest.Sections[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("manifest has no %s section", name)
+		}
+		data, ok := sections[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("backup is missing %s", name)
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != want.Size || hex.EncodeToString(sum[:]) != want.SHA256 {
+			return nil, nil, fmt.Errorf("%s does not match the manifest checksum", name)
+		}
+		if count := bytes.Count(data, []byte("\n")); count != want.Count {
+			return nil, nil, fmt.Errorf("%s has %d records, manifest says %d", name, count, want.Count)
+		}
+	}
+
+	return &manifest, sections, nil
+}
+
+// eachJSONLine calls fn for each non-empty line
+func eachJSONLine(data []byte, fn func(line []byte) error) error {
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestBackup(t *testing.T) {
+	ctx := context.Background()
+
+	// A populated node: attested claims, reputation and a profile
+	s := newTestStore(t)
+	rs := claim.NewReputationStore()
+	w, _ := claim.GenerateWitness()
+	for _, subject := range []string{"match-1", "match-2", "match-3"} {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+
+		rs.RecordAttestation(w.ID, "sports")
+		rs.RecordAgreement(w.ID, "sports")
+	}
+	profile, err := w.SignProfile("acme", 64500, "eu-west")
+	require.NoError(t, err)
+	require.NoError(t, rs.SetProfile(profile))
+
+	var archive bytes.Buffer
+	manifest, err := ExportAll(ctx, &archive, s, rs)
+	require.NoError(t, err)
+	assert.Equal(t, BackupSchema, manifest.Schema)
+	assert.Equal(t, 3, manifest.Sections[backupClaims].Count)
+	assert.Equal(t, 1, manifest.Sections[backupReputation].Count)
+	assert.Equal(t, 1, manifest.Sections[backupProfiles].Count)
+
+	t.Run("round trip", func(t *testing.T) {
+		restored := newTestStore(t)
+		restoredRep := claim.NewReputationStore()
+		got, err := ImportAll(ctx, bytes.NewReader(archive.Bytes()), restored, restoredRep)
+		require.NoError(t, err)
+		assert.Equal(t, manifest.Sections, got.Sections)
+
+		want, err := s.List(ctx, nil)
+		require.NoError(t, err)
+		have, err := restored.List(ctx, nil)
+		require.NoError(t, err)
+		sort.Strings(want)
+		sort.Strings(have)
+		assert.Equal(t, want, have)
+
+		for _, cid := range have {
+			c, err := restored.Get(ctx, cid)
+			require.NoError(t, err)
+			assert.NoError(t, claim.VerifyCID(c))
+			assert.NoError(t, c.VerifyAllAttestations())
+		}
+
+		// Scores include a longevity bonus that grows as the test runs
+		withoutScores := func(records []claim.ExportedReputation) []claim.ExportedReputation {
+			for i := range records {
+				records[i].Score = 0
+				for domain, d := range records[i].Domains {
+					d.Score = 0
+					records[i].Domains[domain] = d
+				}
+			}
+			return records
+		}
+		assert.Equal(t, withoutScores(rs.Records()), withoutScores(restoredRep.Records()))
+		assert.Equal(t, rs.Profiles(), restoredRep.Profiles())
+
+		// Restoring again is harmless
+		_, err = ImportAll(ctx, bytes.NewReader(archive.Bytes()), restored, restoredRep)
+		assert.NoError(t, err)
+	})
+
+	t.Run("corrupted archive rejected before loading", func(t *testing.T) {
+		corrupt := bytes.Replace(archive.Bytes(), []byte("match-2"), []byte("match-9"), 1)
+		require.NotEqual(t, archive.Bytes(), corrupt)
+
+		restored := newTestStore(t)
+		restoredRep := claim.NewReputationStore()
+		_, err := ImportAll(ctx, bytes.NewReader(corrupt), restored, restoredRep)
+		assert.ErrorContains(t, err, "checksum")
+
+		cids, err := restored.List(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, cids)
+		assert.Empty(t, restoredRep.Records())
+	})
+
+	t.Run("truncated archive rejected", func(t *testing.T) {
+		truncated := archive.Bytes()[:archive.Len()/2]
+		_, err := ImportAll(ctx, bytes.NewReader(truncated), newTestStore(t), claim.NewReputationStore())
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown schema rejected", func(t *testing.T) {
+		other := bytes.Replace(archive.Bytes(), []byte(BackupSchema), []byte("claim-graph/backup/v9"), 1)
+		_, err := ImportAll(ctx, bytes.NewReader(other), newTestStore(t), claim.NewReputationStore())
+		assert.ErrorContains(t, err, "schema")
+	})
+}
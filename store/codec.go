@@ -0,0 +1,134 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// Codec serializes claims into the envelopes stored in IPFS. The envelopes
+// of each codec begin with a distinct magic byte, so a store can read
+// objects written with any mix of codecs. Unmarshal leaves the claim's ID
+// empty; the store fills it in from the CID it fetched.
+type Codec interface {
+	// Magic is the first byte of every envelope the codec writes
+	Magic() byte
+
+	// Marshal serializes a claim into an envelope
+	Marshal(c *claim.Claim) ([]byte, error)
+
+	// Unmarshal reconstructs a claim from an envelope
+	Unmarshal(data []byte) (*claim.Claim, error)
+}
+
+// JSONCodec stores claims as JSON objects, the format stores have always
+// written. Its magic byte is the object's opening brace, so envelopes
+// written before codecs existed still decode.
+type JSONCodec struct {
+	// DisallowUnknownFields rejects envelopes with fields a claim does
+	// not have, rather than ignoring them
+	DisallowUnknownFields bool
+}
+
+// Magic implements Codec
+func (JSONCodec) Magic() byte { return '{' }
+
+// Marshal implements Codec
+func (JSONCodec) Marshal(c *claim.Claim) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+	return json.Marshal(toClaimData(c))
+}
+
+// Unmarshal implements Codec
+func (j JSONCodec) Unmarshal(data []byte) (*claim.Claim, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if j.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	var cd claimData
+	if err := dec.Decode(&cd); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after claim")
+	}
+	return fromClaimData("", &cd), nil
+}
+
+// cborSelfDescribe is the self-described CBOR tag (RFC 8949 section
+// 3.4.6) that prefixes CBOR envelopes
+var cborSelfDescribe = []byte{0xd9, 0xd9, 0xf7}
+
+// cborEncMode writes canonical CBOR with nanosecond RFC 3339 times
+var cborEncMode = mustEncMode(cbor.EncOptions{Sort: cbor.SortCanonical, Time: cbor.TimeRFC3339Nano})
+
+// cborDecMode decodes CBOR envelopes within the default DecodeLimits; a
+// store checks envelopes against its own limits before decoding them
+var cborDecMode = DecodeLimits{}.cborDecMode()
+
+func mustEncMode(opts cbor.EncOptions) cbor.EncMode {
+	em, err := opts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return em
+}
+
+// CBORCodec stores claims as CBOR (RFC 8949), which is more compact than
+// JSON. Envelopes start with the self-described CBOR tag. Times are
+// written as RFC 3339 strings so they keep nanosecond precision.
+type CBORCodec struct{}
+
+// Magic implements Codec
+func (CBORCodec) Magic() byte { return cborSelfDescribe[0] }
+
+// Marshal implements Codec
+func (CBORCodec) Marshal(c *claim.Claim) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+	body, err := cborEncMode.Marshal(toClaimData(c))
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), cborSelfDescribe...), body...), nil
+}
+
+// Unmarshal implements Codec
+func (CBORCodec) Unmarshal(data []byte) (*claim.Claim, error) {
+	if !bytes.HasPrefix(data, cborSelfDescribe) {
+		return nil, fmt.Errorf("missing self-described CBOR tag")
+	}
+	var cd claimData
+	if err := cborDecMode.Unmarshal(data[len(cborSelfDescribe):], &cd); err != nil {
+		return nil, err
+	}
+	return fromClaimData("", &cd), nil
+}
+
+// builtinCodecs are the codecs every store can read
+var builtinCodecs = []Codec{JSONCodec{}, CBORCodec{}}
+
+// decodeEnvelope picks a decoder by the envelope's magic byte, trying the
+// store's configured codec before the built-in ones
+func decodeEnvelope(data []byte, configured Codec) (*claim.Claim, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty envelope")
+	}
+
+	codecs := builtinCodecs
+	if configured != nil {
+		codecs = append([]Codec{configured}, builtinCodecs...)
+	}
+	for _, codec := range codecs {
+		if codec.Magic() == data[0] {
+			return codec.Unmarshal(data)
+		}
+	}
+	return nil, fmt.Errorf("unknown envelope format (magic byte 0x%02x)", data[0])
+}
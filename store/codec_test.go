@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// codecTestClaim returns a claim that sets every stored field
+func codecTestClaim(t *testing.T, subject string) *claim.Claim {
+	t.Helper()
+
+	c, err := claim.NewClaim(
+		claim.Statement{Subject: subject, Predicate: "price", Object: "high", Domain: "markets"},
+		[]string{"bafyevidence1", "bafyevidence2"}, "event-1",
+		claim.WithQuantity(claim.Quantity{Value: 64250.5, Unit: "USD"}),
+		claim.WithFields(map[string]string{"source": "exchange"}),
+		claim.WithExpiry(time.Now().Add(time.Hour)),
+		claim.WithSupersedes("bafyprevious"),
+		claim.WithEvidenceOrdering(claim.EvidenceSequence),
+	)
+	require.NoError(t, err)
+	c.ID, err = claim.ComputeCID(c)
+	require.NoError(t, err)
+
+	endorser, _ := claim.GenerateWitness()
+	att, err := endorser.AttestWithContext(c, claim.AttestationContext{
+		Method:     "manual review",
+		Evidence:   []string{"bafyevidence1"},
+		VerifiedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	disputer, _ := claim.GenerateWitness()
+	att, err = disputer.Dispute(c)
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+
+	c.TimestampToken = []byte{0x30, 0x82, 0x01}
+	c.Metadata = map[string]string{"team": "research"}
+	c.State = claim.StateAttested
+	return c
+}
+
+func assertClaimsEqual(t *testing.T, want, got *claim.Claim) {
+	t.Helper()
+
+	assert.Equal(t, want.Statement, got.Statement)
+	assert.Equal(t, want.Quantity, got.Quantity)
+	assert.Equal(t, want.Fields, got.Fields)
+	assert.Equal(t, want.Evidence, got.Evidence)
+	assert.Equal(t, want.EvidenceOrdering, got.EvidenceOrdering)
+	assert.Equal(t, want.TimeEvent, got.TimeEvent)
+	assert.Equal(t, want.TimestampToken, got.TimestampToken)
+	assert.Equal(t, want.State, got.State)
+	assert.Equal(t, want.Supersedes, got.Supersedes)
+	assert.Equal(t, want.Metadata, got.Metadata)
+	assert.True(t, want.Created.Equal(got.Created))
+	assert.True(t, want.ExpiresAt.Equal(got.ExpiresAt))
+
+	require.Len(t, got.Witnesses, len(want.Witnesses))
+	for i, att := range got.Witnesses {
+		assert.Equal(t, want.Witnesses[i].Stance, att.Stance)
+		assert.True(t, want.Witnesses[i].Timestamp.Equal(att.Timestamp))
+	}
+
+	got.ID = want.ID
+	assert.NoError(t, claim.VerifyCID(got))
+	assert.NoError(t, got.VerifyAllAttestations())
+}
+
+func TestCodecs(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec{}, CBORCodec{}} {
+		c := codecTestClaim(t, "bitcoin")
+
+		envelope, err := codec.Marshal(c)
+		require.NoError(t, err)
+		assert.Equal(t, codec.Magic(), envelope[0])
+
+		got, err := codec.Unmarshal(envelope)
+		require.NoError(t, err)
+		assert.Empty(t, got.ID)
+		assertClaimsEqual(t, c, got)
+
+		got, err = decodeEnvelope(envelope, nil)
+		require.NoError(t, err)
+		assertClaimsEqual(t, c, got)
+	}
+
+	t.Run("CBOR is smaller than JSON", func(t *testing.T) {
+		c := codecTestClaim(t, "bitcoin")
+		jsonEnvelope, err := JSONCodec{}.Marshal(c)
+		require.NoError(t, err)
+		cborEnvelope, err := CBORCodec{}.Marshal(c)
+		require.NoError(t, err)
+		assert.Less(t, len(cborEnvelope), len(jsonEnvelope))
+	})
+
+	t.Run("unknown magic byte rejected", func(t *testing.T) {
+		_, err := decodeEnvelope([]byte{0x00, 0x01}, nil)
+		assert.Error(t, err)
+		_, err = decodeEnvelope(nil, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestMixedCodecs(t *testing.T) {
+	f := newFakeIPFS(t)
+	ctx := context.Background()
+
+	jsonStore, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL})
+	require.NoError(t, err)
+	cborStore, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, Codec: CBORCodec{}})
+	require.NoError(t, err)
+
+	fromJSON := codecTestClaim(t, "bitcoin")
+	_, err = jsonStore.Put(ctx, fromJSON)
+	require.NoError(t, err)
+	fromCBOR := codecTestClaim(t, "ethereum")
+	_, err = cborStore.Put(ctx, fromCBOR)
+	require.NoError(t, err)
+
+	// Serve each envelope under its claim CID, as a node would resolve it
+	f.mu.Lock()
+	f.objects[fromJSON.ID] = f.objects[jsonStore.versions[fromJSON.ID][0]]
+	f.objects[fromCBOR.ID] = f.objects[cborStore.versions[fromCBOR.ID][0]]
+	assert.Equal(t, byte('{'), f.objects[fromJSON.ID][0])
+	assert.Equal(t, CBORCodec{}.Magic(), f.objects[fromCBOR.ID][0])
+	f.mu.Unlock()
+
+	// Fresh stores of either codec read both envelopes
+	for _, codec := range []Codec{JSONCodec{}, CBORCodec{}} {
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, Codec: codec})
+		require.NoError(t, err)
+
+		got, err := s.Get(ctx, fromJSON.ID)
+		require.NoError(t, err)
+		assert.Equal(t, fromJSON.ID, got.ID)
+		assertClaimsEqual(t, fromJSON, got)
+
+		got, err = s.Get(ctx, fromCBOR.ID)
+		require.NoError(t, err)
+		assert.Equal(t, fromCBOR.ID, got.ID)
+		assertClaimsEqual(t, fromCBOR, got)
+	}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// ConfidenceCache memoizes claim.ClaimConfidence for claims in an
+// IPFSStore. A cached score is dropped when the claim is re-put, and when
+// the reputation of any witness that attested it changes, found through the
+// store's witness index.
+type ConfidenceCache struct {
+	s           *IPFSStore
+	rs          *claim.ReputationStore
+	unsubscribe func()
+
+	mu      sync.Mutex
+	entries map[string]confidenceEntry
+	gen     uint64 // Bumped by every invalidation
+}
+
+// confidenceEntry is a cached score and the claim version it was computed
+// for
+type confidenceEntry struct {
+	version    string
+	confidence float64
+}
+
+// NewConfidenceCache creates a cache of confidence scores for claims in s,
+// computed with rs. Close it to stop following rs.
+func NewConfidenceCache(s *IPFSStore, rs *claim.ReputationStore) *ConfidenceCache {
+	cc := &ConfidenceCache{
+		s:       s,
+		rs:      rs,
+		entries: make(map[string]confidenceEntry),
+	}
+	cc.unsubscribe = rs.OnChange(cc.invalidate)
+	return cc
+}
+
+// Confidence returns the claim's confidence score, from the cache when the
+// cached score is still current
+func (cc *ConfidenceCache) Confidence(ctx context.Context, cid string) (float64, error) {
+	version := cc.s.Version(cid)
+
+	cc.mu.Lock()
+	entry, ok := cc.entries[cid]
+	gen := cc.gen
+	cc.mu.Unlock()
+	if ok && entry.version == version {
+		return entry.confidence, nil
+	}
+
+	c, err := cc.s.Get(ctx, cid)
+	if err != nil {
+		return 0, err
+	}
+	confidence := claim.ClaimConfidence(c, cc.rs)
+
+	// A score computed across an invalidation may already be stale
+	cc.mu.Lock()
+	if cc.gen == gen {
+		cc.entries[cid] = confidenceEntry{version: version, confidence: confidence}
+	}
+	cc.mu.Unlock()
+
+	return confidence, nil
+}
+
+// invalidate drops the cached scores of claims the witness attested, or
+// every score when witnessID is empty
+func (cc *ConfidenceCache) invalidate(witnessID string) {
+	var cids []string
+	if witnessID != "" {
+		cc.s.mu.RLock()
+		cids = cc.s.idx.Query(IndexWitness, witnessID)
+		cc.s.mu.RUnlock()
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.gen++
+	if witnessID == "" {
+		cc.entries = make(map[string]confidenceEntry)
+		return
+	}
+	for _, cid := range cids {
+		delete(cc.entries, cid)
+	}
+}
+
+// Close stops the cache following reputation changes
+func (cc *ConfidenceCache) Close() {
+	cc.unsubscribe()
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestConfidenceCache(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	rs := claim.NewReputationStore()
+
+	// attested stores a claim endorsed by w, whose record is in good standing
+	attested := func(subject string, w *claim.Witness) string {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		cid, err := s.Put(ctx, c)
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			rs.RecordAttestation(w.ID, "sports")
+			rs.RecordAgreement(w.ID, "sports")
+		}
+		return cid
+	}
+
+	alice := claim.DeterministicWitness("alice")
+	bob := claim.DeterministicWitness("bob")
+	aliceClaim := attested("match-1", alice)
+	bobClaim := attested("match-2", bob)
+
+	cc := NewConfidenceCache(s, rs)
+	defer cc.Close()
+
+	before, err := cc.Confidence(ctx, aliceClaim)
+	require.NoError(t, err)
+	_, err = cc.Confidence(ctx, bobClaim)
+	require.NoError(t, err)
+	assert.Len(t, cc.entries, 2)
+
+	t.Run("dispute invalidates the witness's claims only", func(t *testing.T) {
+		rs.RecordDispute(alice.ID, "sports")
+		assert.NotContains(t, cc.entries, aliceClaim)
+		assert.Contains(t, cc.entries, bobClaim)
+
+		after, err := cc.Confidence(ctx, aliceClaim)
+		require.NoError(t, err)
+		assert.Less(t, after, before)
+
+		c, err := s.Get(ctx, aliceClaim)
+		require.NoError(t, err)
+		assert.InDelta(t, claim.ClaimConfidence(c, rs), after, 1e-6)
+	})
+
+	t.Run("re-put claim recomputed", func(t *testing.T) {
+		c, err := s.Get(ctx, bobClaim)
+		require.NoError(t, err)
+		cached, err := cc.Confidence(ctx, bobClaim)
+		require.NoError(t, err)
+
+		// A second, unknown witness changes the score without touching bob
+		updated := *c
+		updated.Witnesses = append([]claim.Attestation(nil), c.Witnesses...)
+		carol := claim.DeterministicWitness("carol")
+		att, err := carol.Dispute(&updated)
+		require.NoError(t, err)
+		require.NoError(t, updated.AddAttestation(att))
+		_, err = s.Put(ctx, &updated)
+		require.NoError(t, err)
+
+		recomputed, err := cc.Confidence(ctx, bobClaim)
+		require.NoError(t, err)
+		assert.NotEqual(t, cached, recomputed)
+	})
+
+	t.Run("policy change invalidates everything", func(t *testing.T) {
+		_, err := cc.Confidence(ctx, aliceClaim)
+		require.NoError(t, err)
+		rs.SetAbstentionPolicy(claim.CountAbstentions)
+		assert.Empty(t, cc.entries)
+	})
+
+	t.Run("closed cache stops following", func(t *testing.T) {
+		_, err := cc.Confidence(ctx, aliceClaim)
+		require.NoError(t, err)
+		cc.Close()
+		rs.RecordDispute(alice.ID, "sports")
+		assert.Contains(t, cc.entries, aliceClaim)
+	})
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// sealedMagic is the first byte of envelopes encrypted with
+// IPFSConfig.EncryptionKey. No codec's envelopes begin with it.
+const sealedMagic byte = 0xe5
+
+// newSealer returns the AEAD that encrypts a store's content, or nil when
+// encryption at rest is off
+func newSealer(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts content as the magic byte, a random nonce and the AES-GCM
+// ciphertext
+func (s *IPFSStore) seal(content []byte) ([]byte, error) {
+	nonce := make([]byte, s.sealer.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append([]byte{sealedMagic}, nonce...)
+	return s.sealer.Seal(sealed, nonce, content, []byte{sealedMagic}), nil
+}
+
+// open decrypts content written by seal
+func (s *IPFSStore) open(sealed []byte) ([]byte, error) {
+	if s.sealer == nil {
+		return nil, fmt.Errorf("content is encrypted and no encryption key is configured")
+	}
+	size := s.sealer.NonceSize()
+	if len(sealed) < 1+size || sealed[0] != sealedMagic {
+		return nil, fmt.Errorf("malformed encrypted content")
+	}
+
+	content, err := s.sealer.Open(nil, sealed[1:1+size], sealed[1+size:], []byte{sealedMagic})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt (wrong encryption key?): %w", err)
+	}
+	return content, nil
+}
+
+// decode decodes an envelope fetched from IPFS, decrypting it first if it
+// was sealed
+func (s *IPFSStore) decode(envelope []byte) (*claim.Claim, error) {
+	if len(envelope) > 0 && envelope[0] == sealedMagic {
+		var err error
+		if envelope, err = s.open(envelope); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.cfg.DecodeLimits.check(envelope); err != nil {
+		return nil, err
+	}
+	return decodeEnvelope(envelope, s.cfg.Codec)
+}
+
+// putEntry returns the index log entry recording a stored claim, with the
+// claim encrypted when encryption at rest is on
+func (s *IPFSStore) putEntry(cid, hash string, size int64, data claimData) (logEntry, error) {
+	entry := logEntry{Op: logOpPut, CID: cid, Hash: hash, Size: size}
+	if s.sealer == nil {
+		entry.Claim = &data
+		return entry, nil
+	}
+
+	plain, err := json.Marshal(data)
+	if err != nil {
+		return logEntry{}, err
+	}
+	if entry.Sealed, err = s.seal(plain); err != nil {
+		return logEntry{}, err
+	}
+	return entry, nil
+}
+
+// entryClaim returns the claim recorded by a put entry, decrypting it if
+// needed, or nil if the entry has none
+func (s *IPFSStore) entryClaim(entry logEntry) (*claimData, error) {
+	if entry.Sealed == nil {
+		return entry.Claim, nil
+	}
+
+	plain, err := s.open(entry.Sealed)
+	if err != nil {
+		return nil, fmt.Errorf("claim %s: %w", entry.CID, err)
+	}
+	var data claimData
+	if err := json.Unmarshal(plain, &data); err != nil {
+		return nil, fmt.Errorf("claim %s: %w", entry.CID, err)
+	}
+	return &data, nil
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestEncryptionAtRest(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeIPFS(t)
+	indexPath := filepath.Join(t.TempDir(), "index.log")
+	key := bytes.Repeat([]byte{0x42}, 32)
+	cfg := IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath, EncryptionKey: key}
+
+	s, err := NewIPFSStore(cfg)
+	require.NoError(t, err)
+
+	secret := "patient-7781"
+	c, err := claim.NewClaim(claim.Statement{Subject: secret, Predicate: "diagnosed-with", Object: "flu", Domain: "medical"}, nil, "")
+	require.NoError(t, err)
+	c.Metadata["source"] = "clinic-records"
+	cid, err := s.Put(ctx, c)
+	require.NoError(t, err)
+	hash := s.Version(cid)
+
+	t.Run("stored bytes are ciphertext", func(t *testing.T) {
+		f.mu.Lock()
+		envelope := f.objects[hash]
+		f.mu.Unlock()
+		require.NotEmpty(t, envelope)
+		assert.Equal(t, sealedMagic, envelope[0])
+		assert.NotContains(t, string(envelope), secret)
+		assert.NotContains(t, string(envelope), "clinic-records")
+
+		log, err := os.ReadFile(indexPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(log), cid, "CIDs stay in plaintext")
+		assert.NotContains(t, string(log), secret)
+	})
+
+	t.Run("reads decrypt transparently", func(t *testing.T) {
+		got, err := s.GetByIPFSHash(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, secret, got.Statement.Subject)
+		assert.Equal(t, "clinic-records", got.Metadata["source"])
+
+		// Compaction rewrites the log, still encrypted
+		_, err = s.Compact(ctx)
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+		log, err := os.ReadFile(indexPath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(log), secret)
+
+		reopened, err := NewIPFSStore(cfg)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		got, err = reopened.Get(ctx, cid)
+		require.NoError(t, err)
+		assert.Equal(t, secret, got.Statement.Subject)
+
+		cids, err := reopened.List(ctx, &Filter{Subject: secret})
+		require.NoError(t, err)
+		assert.Equal(t, []string{cid}, cids)
+	})
+
+	t.Run("wrong key fails", func(t *testing.T) {
+		wrong := cfg
+		wrong.EncryptionKey = bytes.Repeat([]byte{0x24}, 32)
+		_, err := NewIPFSStore(wrong)
+		assert.ErrorContains(t, err, "decrypt")
+
+		wrong.IndexPath = ""
+		other, err := NewIPFSStore(wrong)
+		require.NoError(t, err)
+		_, err = other.GetByIPFSHash(ctx, hash)
+		assert.ErrorContains(t, err, "decrypt")
+
+		plain, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL})
+		require.NoError(t, err)
+		_, err = plain.GetByIPFSHash(ctx, hash)
+		assert.ErrorContains(t, err, "encrypted")
+	})
+
+	t.Run("invalid key size", func(t *testing.T) {
+		_, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, EncryptionKey: []byte("short")})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultEndpointCooldown is how long a failed IPFS endpoint is passed over
+const defaultEndpointCooldown = 30 * time.Second
+
+// endpointPool tracks the health of the IPFS API endpoints a store can
+// use. Endpoints are tried in configured order; one that cannot be reached
+// is marked unhealthy and tried only after healthy ones until its cooldown
+// passes.
+type endpointPool struct {
+	mu        sync.Mutex
+	urls      []string
+	downUntil []time.Time
+	cooldown  time.Duration
+}
+
+func newEndpointPool(urls []string, cooldown time.Duration) *endpointPool {
+	if cooldown <= 0 {
+		cooldown = defaultEndpointCooldown
+	}
+	return &endpointPool{
+		urls:      urls,
+		downUntil: make([]time.Time, len(urls)),
+		cooldown:  cooldown,
+	}
+}
+
+// order returns endpoint indices to try: healthy endpoints in configured
+// order, then cooling-down ones, soonest to recover first, as a last resort
+func (p *endpointPool) order() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var healthy, cooling []int
+	for i := range p.urls {
+		if now.Before(p.downUntil[i]) {
+			cooling = append(cooling, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	sort.SliceStable(cooling, func(a, b int) bool {
+		return p.downUntil[cooling[a]].Before(p.downUntil[cooling[b]])
+	})
+	return append(healthy, cooling...)
+}
+
+func (p *endpointPool) markDown(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.downUntil[i] = time.Now().Add(p.cooldown)
+}
+
+func (p *endpointPool) markUp(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.downUntil[i] = time.Time{}
+}
+
+// healthy reports whether each endpoint is currently in use, by URL
+func (p *endpointPool) healthy() map[string]bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	health := make(map[string]bool, len(p.urls))
+	for i, u := range p.urls {
+		health[u] = !now.Before(p.downUntil[i])
+	}
+	return health
+}
+
+// Endpoints reports the health of each configured IPFS API endpoint by
+// URL. An endpoint is unhealthy while it cools down after a failed request.
+func (s *IPFSStore) Endpoints() map[string]bool {
+	return s.endpoints.healthy()
+}
+
+// post sends a POST request to the IPFS API, failing over between
+// endpoints. An endpoint that cannot be reached is marked unhealthy and the
+// next one is tried; an endpoint that answers, with any status, is used.
+func (s *IPFSStore) post(ctx context.Context, path string, body []byte, contentType string) (*http.Response, error) {
+	var lastErr error
+	for _, i := range s.endpoints.order() {
+		req, err := http.NewRequestWithContext(ctx, "POST", s.endpoints.urls[i]+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := s.client.Do(req)
+		if err == nil {
+			s.endpoints.markUp(i)
+			s.outcomes.record(false)
+			return resp, nil
+		}
+
+		// The caller gave up; that says nothing about the endpoint
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		s.endpoints.markDown(i)
+		s.outcomes.record(true)
+		lastErr = err
+	}
+
+	if len(s.endpoints.urls) == 1 {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("all %d IPFS endpoints failed: %w", len(s.endpoints.urls), lastErr)
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// flakyEndpoint proxies to a fake IPFS node, dropping connections while down
+type flakyEndpoint struct {
+	down     atomic.Bool
+	requests atomic.Int32
+	server   *httptest.Server
+}
+
+func newFlakyEndpoint(t *testing.T, target *fakeIPFS) *flakyEndpoint {
+	t.Helper()
+
+	u, err := url.Parse(target.server.URL)
+	require.NoError(t, err)
+	proxy := httputil.NewSingleHostReverseProxy(u)
+
+	e := &flakyEndpoint{}
+	e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.requests.Add(1)
+		if e.down.Load() {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(e.server.Close)
+	return e
+}
+
+func TestEndpointFailover(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeIPFS(t)
+	primary := newFlakyEndpoint(t, f)
+	primary.down.Store(true)
+
+	// A closed server stands in for a node that is gone entirely
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+
+	s, err := NewIPFSStore(IPFSConfig{
+		APIURL:           primary.server.URL,
+		FallbackURLs:     []string{gone.URL, f.server.URL},
+		EndpointCooldown: time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{primary.server.URL: false, gone.URL: false, f.server.URL: true}, s.Endpoints())
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "failover"}, nil, "")
+	require.NoError(t, err)
+	cid, err := s.Put(ctx, c)
+	require.NoError(t, err)
+
+	// Get fetches by claim CID
+	f.mu.Lock()
+	for _, data := range f.objects {
+		f.objects[cid] = data
+	}
+	f.mu.Unlock()
+	s.mu.Lock()
+	delete(s.index, cid)
+	s.mu.Unlock()
+
+	got, err := s.Get(ctx, cid)
+	require.NoError(t, err)
+	assert.Equal(t, c.Statement, got.Statement)
+
+	// Unhealthy endpoints are passed over during their cooldown
+	assert.Equal(t, int32(1), primary.requests.Load())
+
+	t.Run("endpoint retried after cooldown", func(t *testing.T) {
+		s.endpoints.cooldown = time.Millisecond
+		s.endpoints.markDown(0)
+		time.Sleep(5 * time.Millisecond)
+		primary.down.Store(false)
+
+		require.NoError(t, s.ping(ctx))
+		assert.True(t, s.Endpoints()[primary.server.URL])
+		assert.Equal(t, int32(2), primary.requests.Load())
+	})
+
+	t.Run("all endpoints down", func(t *testing.T) {
+		primary.down.Store(true)
+		f.server.Close()
+
+		err := s.ping(ctx)
+		assert.ErrorContains(t, err, "all 3 IPFS endpoints failed")
+	})
+}
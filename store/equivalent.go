@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+// ListEquivalent returns, sorted, the CIDs of the stored claims in the
+// equivalence class with the given key (see claim.StatementKey): claims
+// making the same statement, whatever their timestamps and evidence
+func (s *IPFSStore) ListEquivalent(ctx context.Context, key string) ([]string, error) {
+	s.mu.RLock()
+	var cids []string
+	for _, cid := range s.idx.Query(IndexStatementKey, key) {
+		// A persistent index may outlive claims this store no longer has
+		if _, exists := s.index[cid]; exists {
+			cids = append(cids, cid)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(cids)
+	return cids, nil
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestListEquivalent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	put := func(subject, predicate string, evidence []string) string {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Predicate: predicate, Object: "hello", Domain: "web"}, evidence, "")
+		require.NoError(t, err)
+		cid, err := s.Put(ctx, c)
+		require.NoError(t, err)
+		return cid
+	}
+
+	a := put("https://example.com/page", "contains", nil)
+	b := put("https://EXAMPLE.com/page/", "Contains", []string{"bafyevidence"})
+	other := put("https://example.com/other", "contains", nil)
+
+	key := claim.StatementKey(&claim.Claim{Statement: claim.Statement{Subject: "https://example.com/page", Predicate: "contains", Object: "hello", Domain: "web"}})
+	require.NotEqual(t, a, b)
+
+	cids, err := s.ListEquivalent(ctx, key)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{a, b}, cids)
+	assert.NotContains(t, cids, other)
+
+	require.NoError(t, s.Delete(ctx, a))
+	cids, err = s.ListEquivalent(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, []string{b}, cids)
+
+	cids, err = s.ListEquivalent(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, cids)
+}
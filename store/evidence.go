@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// storeEvidence resolves evidence CIDs to claims in a store
+type storeEvidence struct {
+	s Store
+}
+
+// EvidenceResolver returns a resolver that treats evidence as verified
+// when it names a claim in s whose content matches its CID
+func EvidenceResolver(s Store) claim.EvidenceResolver {
+	return storeEvidence{s: s}
+}
+
+// VerifyEvidence implements claim.EvidenceResolver
+func (e storeEvidence) VerifyEvidence(ctx context.Context, cid string) (bool, error) {
+	has, err := e.s.Has(ctx, cid)
+	if err != nil {
+		return false, err
+	}
+	if !has {
+		return false, nil
+	}
+
+	c, err := e.s.Get(ctx, cid)
+	if err != nil {
+		return false, err
+	}
+	return c.ID == cid && claim.VerifyCID(c) == nil, nil
+}
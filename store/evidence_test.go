@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestEvidenceResolver(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	rs := claim.NewReputationStore()
+	resolver := EvidenceResolver(s)
+
+	source, err := claim.NewClaim(claim.Statement{Subject: "source", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, source)
+	require.NoError(t, err)
+
+	attested := func(evidence string) *claim.Claim {
+		c, err := claim.NewClaim(claim.Statement{Subject: "derived", Domain: "sports"}, []string{evidence}, "")
+		require.NoError(t, err)
+		w, _ := claim.GenerateWitness()
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		return c
+	}
+
+	verified := attested(source.ID)
+	dangling := attested("bafkreimissingevidence")
+
+	assert.Equal(t, claim.EvidenceAssessment{Verified: 1}, claim.AssessEvidence(ctx, verified, resolver))
+	assert.Equal(t, claim.EvidenceAssessment{Dangling: 1}, claim.AssessEvidence(ctx, dangling, resolver))
+	assert.Greater(t,
+		claim.ClaimConfidenceWithEvidence(ctx, verified, rs, resolver),
+		claim.ClaimConfidenceWithEvidence(ctx, dangling, rs, resolver))
+
+}
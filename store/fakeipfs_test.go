@@ -0,0 +1,143 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIPFS is a minimal in-memory stand-in for the IPFS HTTP API,
+// supporting just the endpoints IPFSStore uses. Unpinned objects stay
+// readable, as they would until the node garbage collects them.
+type fakeIPFS struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	pinned  map[string]bool
+	server  *httptest.Server
+
+	// onUnpin, if set, is called before each unpin is served
+	onUnpin func(hash string)
+
+	// afterAdd, if set, is called after an object is added and pinned,
+	// before the add is answered
+	afterAdd func(hash string)
+}
+
+func newFakeIPFS(t *testing.T) *fakeIPFS {
+	t.Helper()
+
+	f := &fakeIPFS{objects: make(map[string][]byte), pinned: make(map[string]bool)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v0/id", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ID":"fake"}`))
+	})
+	mux.HandleFunc("/api/v0/add", func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		sum := sha256.Sum256(data)
+		hash := "Qm" + hex.EncodeToString(sum[:])
+
+		f.mu.Lock()
+		f.objects[hash] = data
+		f.pinned[hash] = true
+		f.mu.Unlock()
+
+		if f.afterAdd != nil {
+			f.afterAdd(hash)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"Hash": hash})
+	})
+	mux.HandleFunc("/api/v0/pin/rm", func(w http.ResponseWriter, r *http.Request) {
+		hash := r.URL.Query().Get("arg")
+		if f.onUnpin != nil {
+			f.onUnpin(hash)
+		}
+
+		f.mu.Lock()
+		ok := f.pinned[hash]
+		delete(f.pinned, hash)
+		f.mu.Unlock()
+
+		if !ok {
+			http.Error(w, "not pinned or pinned indirectly", http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]string{"Pins": {hash}})
+	})
+	mux.HandleFunc("/api/v0/pin/add", func(w http.ResponseWriter, r *http.Request) {
+		hash := r.URL.Query().Get("arg")
+
+		f.mu.Lock()
+		_, ok := f.objects[hash]
+		if ok {
+			f.pinned[hash] = true
+		}
+		f.mu.Unlock()
+
+		if !ok {
+			http.Error(w, "not found", http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]string{"Pins": {hash}})
+	})
+	mux.HandleFunc("/api/v0/cat", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		data, ok := f.objects[r.URL.Query().Get("arg")]
+		f.mu.Unlock()
+
+		if !ok {
+			http.Error(w, "not found", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(data)
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+// count returns the number of objects the node has pinned
+func (f *fakeIPFS) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pinned)
+}
+
+// isPinned reports whether the node has pinned an object
+func (f *fakeIPFS) isPinned(hash string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pinned[hash]
+}
+
+// newTestStore returns an IPFSStore backed by a fresh fake IPFS node.
+func newTestStore(t *testing.T) *IPFSStore {
+	t.Helper()
+
+	f := newFakeIPFS(t)
+	s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL})
+	require.NoError(t, err)
+
+	return s
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// evidenceHashPrefix prefixes the claim fields that record file evidence
+// content hashes
+const evidenceHashPrefix = "evidence-sha256:"
+
+// EvidenceHashField returns the name of the claim field that records the
+// SHA-256 of the file behind an evidence CID
+func EvidenceHashField(cid string) string {
+	return evidenceHashPrefix + cid
+}
+
+// NewClaimWithFiles uploads files to IPFS and creates a claim citing them
+// as evidence. A path naming a directory contributes the regular files
+// directly inside it, in name order. Each file's hex SHA-256 is recorded
+// in the claim field EvidenceHashField(cid), so it is covered by the
+// claim's CID and by whole-claim attestations, and FileEvidenceResolver
+// can check the uploaded content against it. The claim itself is not
+// stored.
+func NewClaimWithFiles(ctx context.Context, statement claim.Statement, timeEvent string, s *IPFSStore, paths []string, opts ...claim.ClaimOption) (*claim.Claim, error) {
+	files, err := evidenceFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no evidence files found")
+	}
+
+	var evidence []string
+	hashes := make(map[string]string, len(files))
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		cid, err := s.add(ctx, filepath.Base(path), bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", path, err)
+		}
+
+		// Identical files share a CID and are cited once
+		field := EvidenceHashField(cid)
+		if _, seen := hashes[field]; seen {
+			continue
+		}
+		sum := sha256.Sum256(content)
+		hashes[field] = hex.EncodeToString(sum[:])
+		evidence = append(evidence, cid)
+	}
+
+	recordHashes := func(c *claim.Claim) {
+		if c.Fields == nil {
+			c.Fields = make(map[string]string, len(hashes))
+		}
+		for field, hash := range hashes {
+			c.Fields[field] = hash
+		}
+	}
+
+	return claim.NewClaim(statement, evidence, timeEvent, append(opts, recordHashes)...)
+}
+
+// evidenceFiles expands directories into the regular files inside them
+func evidenceFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				names = append(names, entry.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	return files, nil
+}
+
+// fileEvidence checks file evidence against its recorded content hash
+type fileEvidence struct {
+	s      *IPFSStore
+	hashes map[string]string
+	claims claim.EvidenceResolver
+}
+
+// FileEvidenceResolver returns a resolver for a claim created with
+// NewClaimWithFiles. Evidence with a recorded content hash is verified by
+// fetching it from IPFS and comparing hashes; other evidence is resolved
+// as claims in s (see EvidenceResolver).
+func FileEvidenceResolver(s *IPFSStore, c *claim.Claim) claim.EvidenceResolver {
+	hashes := make(map[string]string)
+	for _, cid := range c.Evidence {
+		if hash, ok := c.Fields[EvidenceHashField(cid)]; ok {
+			hashes[cid] = hash
+		}
+	}
+	return fileEvidence{s: s, hashes: hashes, claims: EvidenceResolver(s)}
+}
+
+// VerifyEvidence implements claim.EvidenceResolver
+func (e fileEvidence) VerifyEvidence(ctx context.Context, cid string) (bool, error) {
+	want, ok := e.hashes[cid]
+	if !ok {
+		return e.claims.VerifyEvidence(ctx, cid)
+	}
+
+	content, err := e.s.cat(ctx, cid, 0)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]) == want, nil
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestNewClaimWithFiles(t *testing.T) {
+	t.Run("fake IPFS", func(t *testing.T) {
+		f := newFakeIPFS(t)
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL})
+		require.NoError(t, err)
+		testClaimWithFiles(t, s)
+
+		t.Run("altered upload fails verification", func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "scan.pdf")
+			require.NoError(t, os.WriteFile(path, []byte("original scan"), 0600))
+
+			c, err := NewClaimWithFiles(context.Background(), claim.Statement{Subject: "deed"}, "", s, []string{path})
+			require.NoError(t, err)
+
+			f.mu.Lock()
+			f.objects[c.Evidence[0]] = []byte("doctored scan")
+			f.mu.Unlock()
+
+			a := claim.AssessEvidence(context.Background(), c, FileEvidenceResolver(s, c))
+			assert.Equal(t, claim.EvidenceAssessment{Dangling: 1}, a)
+		})
+	})
+
+	t.Run("IPFS", func(t *testing.T) {
+		if !ipfsAvailable() {
+			t.Skip("IPFS not available, skipping IPFS tests")
+		}
+		s, err := NewIPFSStore(IPFSConfig{})
+		require.NoError(t, err)
+		testClaimWithFiles(t, s)
+	})
+}
+
+func testClaimWithFiles(t *testing.T, s *IPFSStore) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b-invoice.txt"), []byte("invoice 1042"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a-receipt.txt"), []byte("receipt 77"), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0700))
+	extra := filepath.Join(t.TempDir(), "contract.txt")
+	require.NoError(t, os.WriteFile(extra, []byte("signed contract"), 0600))
+
+	c, err := NewClaimWithFiles(ctx, claim.Statement{Subject: "order-1042", Predicate: "paid", Domain: "finance"}, "",
+		s, []string{dir, extra}, claim.WithFields(map[string]string{"amount": "120"}))
+	require.NoError(t, err)
+
+	// Directory files in name order, then the named file
+	require.Len(t, c.Evidence, 3)
+	assert.Equal(t, "120", c.Fields["amount"])
+	for i, content := range []string{"receipt 77", "invoice 1042", "signed contract"} {
+		sum := sha256.Sum256([]byte(content))
+		assert.Equal(t, hex.EncodeToString(sum[:]), c.Fields[EvidenceHashField(c.Evidence[i])])
+	}
+	assert.NoError(t, claim.VerifyCID(c))
+
+	a := claim.AssessEvidence(ctx, c, FileEvidenceResolver(s, c))
+	assert.Equal(t, claim.EvidenceAssessment{Verified: 3}, a)
+
+	t.Run("empty directory rejected", func(t *testing.T) {
+		_, err := NewClaimWithFiles(ctx, claim.Statement{Subject: "nothing"}, "", s, []string{t.TempDir()})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing file rejected", func(t *testing.T) {
+		_, err := NewClaimWithFiles(ctx, claim.Statement{Subject: "nothing"}, "", s, []string{filepath.Join(dir, "missing")})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// ResolveIPFSHash returns the claim stored in the envelope with the given
+// IPFS hash. Claim CIDs are computed over the claim's content and differ
+// from the hashes IPFS assigns to stored envelopes; this store records
+// which envelope holds which claim on every Put, and persists it in the
+// index. Every stored version of a live claim resolves until Compact
+// unpins it.
+func (s *IPFSStore) ResolveIPFSHash(ipfsHash string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cid, ok := s.byHash[ipfsHash]
+	return cid, ok
+}
+
+// GetByIPFSHash returns the claim stored in the envelope with the given
+// IPFS hash. Envelopes this store did not write are fetched from IPFS and
+// identified by computing their CID; they are not added to the index.
+func (s *IPFSStore) GetByIPFSHash(ctx context.Context, ipfsHash string) (*claim.Claim, error) {
+	if ipfsHash == "" {
+		return nil, fmt.Errorf("IPFS hash cannot be empty")
+	}
+	if cid, ok := s.ResolveIPFSHash(ipfsHash); ok {
+		return s.Get(ctx, cid)
+	}
+
+	envelope, err := s.cat(ctx, ipfsHash, s.cfg.DecodeLimits.MaxEnvelopeSize)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.decode(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode claim: %w", err)
+	}
+	if s.cfg.MetadataDigest {
+		if err := s.checkMetadataDigest(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.ID, err = claim.ComputeCID(c); err != nil {
+		return nil, fmt.Errorf("failed to compute CID: %w", err)
+	}
+	if t, deleted := s.WasDeleted(c.ID); deleted {
+		return nil, deletedError(t)
+	}
+	return c, nil
+}
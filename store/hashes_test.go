@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestResolveIPFSHash(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeIPFS(t)
+	cfg := IPFSConfig{APIURL: f.server.URL, IndexPath: filepath.Join(t.TempDir(), "index.log")}
+
+	s, err := NewIPFSStore(cfg)
+	require.NoError(t, err)
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	cid, err := s.Put(ctx, c)
+	require.NoError(t, err)
+
+	hash := s.Version(cid)
+	require.NotEmpty(t, hash)
+	assert.NotEqual(t, cid, hash)
+
+	t.Run("both directions resolve", func(t *testing.T) {
+		resolved, ok := s.ResolveIPFSHash(hash)
+		require.True(t, ok)
+		assert.Equal(t, cid, resolved)
+
+		got, err := s.GetByIPFSHash(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, cid, got.ID)
+
+		_, ok = s.ResolveIPFSHash("QmUnknown")
+		assert.False(t, ok)
+	})
+
+	t.Run("earlier versions resolve until compaction", func(t *testing.T) {
+		w := claim.DeterministicWitness("alice")
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+
+		latest := s.Version(cid)
+		require.NotEqual(t, hash, latest)
+		for _, h := range []string{hash, latest} {
+			resolved, ok := s.ResolveIPFSHash(h)
+			assert.True(t, ok)
+			assert.Equal(t, cid, resolved)
+		}
+
+		_, err = s.Compact(ctx)
+		require.NoError(t, err)
+		_, ok := s.ResolveIPFSHash(hash)
+		assert.False(t, ok)
+		hash = latest
+	})
+
+	t.Run("mapping persists in the index", func(t *testing.T) {
+		require.NoError(t, s.Close())
+		reopened, err := NewIPFSStore(cfg)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		resolved, ok := reopened.ResolveIPFSHash(hash)
+		require.True(t, ok)
+		assert.Equal(t, cid, resolved)
+
+		require.NoError(t, reopened.Delete(ctx, cid))
+		_, ok = reopened.ResolveIPFSHash(hash)
+		assert.False(t, ok)
+	})
+
+	t.Run("envelopes from other stores are identified", func(t *testing.T) {
+		other, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL})
+		require.NoError(t, err)
+
+		got, err := other.GetByIPFSHash(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, cid, got.ID)
+		assert.Len(t, got.Witnesses, 1)
+	})
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// heartbeatSkew is how far in the future a heartbeat may be dated, to
+// allow for clock differences between witnesses
+const heartbeatSkew = time.Minute
+
+// ActiveWitnesses returns, sorted, the witnesses that stored a heartbeat
+// claim (see claim.Witness.Heartbeat) dated within the given duration
+func (s *IPFSStore) ActiveWitnesses(within time.Duration) []string {
+	now := time.Now()
+	cutoff := now.Add(-within)
+
+	// Only the domain index is consulted under the lock; signatures are
+	// verified after it is released
+	s.mu.RLock()
+	var candidates []*claim.Claim
+	for _, cid := range s.idx.Query(IndexDomain, claim.HeartbeatDomain) {
+		if c, ok := s.index[cid]; ok {
+			candidates = append(candidates, c)
+		}
+	}
+	s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var active []string
+	for _, c := range candidates {
+		if seen[c.Statement.Subject] || !hasAttestationFrom(c, c.Statement.Subject) {
+			continue
+		}
+		id, at, err := claim.HeartbeatTime(c)
+		if err != nil || c.Statement.Subject != id {
+			continue
+		}
+		if !at.Before(cutoff) && !at.After(now.Add(heartbeatSkew)) {
+			seen[id] = true
+			active = append(active, id)
+		}
+	}
+
+	sort.Strings(active)
+	return active
+}
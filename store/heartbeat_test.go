@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestActiveWitnesses(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	alive := claim.DeterministicWitness("alice")
+	hb, err := alive.Heartbeat()
+	require.NoError(t, err)
+	_, err = s.Put(ctx, hb)
+	require.NoError(t, err)
+
+	// A heartbeat from two hours ago
+	stale := claim.DeterministicWitness("bob")
+	old, err := claim.NewClaim(claim.Statement{
+		Subject:   stale.ID,
+		Predicate: claim.HeartbeatPredicate,
+		Object:    time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano),
+		Domain:    claim.HeartbeatDomain,
+	}, nil, "")
+	require.NoError(t, err)
+	att, err := stale.Attest(old)
+	require.NoError(t, err)
+	require.NoError(t, old.AddAttestation(att))
+	_, err = s.Put(ctx, old)
+	require.NoError(t, err)
+
+	// A witness that only attests to other claims
+	busy := claim.DeterministicWitness("carol")
+	other, err := claim.NewClaim(claim.Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	att, err = busy.Attest(other)
+	require.NoError(t, err)
+	require.NoError(t, other.AddAttestation(att))
+	_, err = s.Put(ctx, other)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{alive.ID}, s.ActiveWitnesses(10*time.Minute))
+	assert.ElementsMatch(t, []string{alive.ID, stale.ID}, s.ActiveWitnesses(3*time.Hour))
+}
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// defaultCheckpointEvery is how many records are imported between
+// checkpoint writes when ImportOptions.CheckpointEvery is unset
+const defaultCheckpointEvery = 1000
+
+// importRecord is one line of an import file: a stored claim envelope
+// plus its CID, which is checked against the content (or computed, if
+// omitted)
+type importRecord struct {
+	CID string `json:"cid"`
+	claimData
+}
+
+// ImportOptions configures Import
+type ImportOptions struct {
+	// CheckpointPath records progress so an interrupted import can resume
+	// (empty disables checkpointing)
+	CheckpointPath string
+
+	// Resume skips records already covered by the checkpoint
+	Resume bool
+
+	// CheckpointEvery is how many records to import between checkpoint
+	// writes (default 1000). Records after the last checkpoint are
+	// re-read on resume but deduplicated, so nothing is stored twice.
+	CheckpointEvery int
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	// Imported is the number of claims stored
+	Imported int
+
+	// Duplicates is the number of claims skipped because the store
+	// already had them
+	Duplicates int
+
+	// Resumed is the number of records skipped via the checkpoint
+	Resumed int
+}
+
+// importCheckpoint is the persisted progress of an import
+type importCheckpoint struct {
+	// Offset is the number of records fully processed
+	Offset int `json:"offset"`
+
+	// CID is the CID of the last processed record, used to detect a
+	// checkpoint being applied to a different input
+	CID string `json:"cid"`
+}
+
+// Import reads claims as JSON lines from r and stores them in s. Claims
+// already in the store are skipped, so re-running an import is safe.
+func Import(ctx context.Context, s Store, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	every := opts.CheckpointEvery
+	if every <= 0 {
+		every = defaultCheckpointEvery
+	}
+
+	var resumeAt importCheckpoint
+	if opts.Resume && opts.CheckpointPath != "" {
+		cp, err := readCheckpoint(opts.CheckpointPath)
+		if err != nil {
+			return nil, err
+		}
+		resumeAt = cp
+	}
+
+	report := &ImportReport{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	offset, lastCID := 0, ""
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec importRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return report, fmt.Errorf("record %d: %w", offset+1, err)
+		}
+		offset++
+
+		if offset <= resumeAt.Offset {
+			report.Resumed++
+			if offset == resumeAt.Offset {
+				c, err := recordClaim(&rec)
+				if err != nil || c.ID != resumeAt.CID {
+					return report, fmt.Errorf("checkpoint does not match input at record %d", offset)
+				}
+				lastCID = c.ID
+			}
+			continue
+		}
+
+		c, err := recordClaim(&rec)
+		if err != nil {
+			return report, fmt.Errorf("record %d: %w", offset, err)
+		}
+
+		has, err := s.Has(ctx, c.ID)
+		if err != nil {
+			return report, fmt.Errorf("record %d: %w", offset, err)
+		}
+		if has {
+			report.Duplicates++
+		} else {
+			if _, err := s.Put(ctx, c); err != nil {
+				return report, fmt.Errorf("record %d: %w", offset, err)
+			}
+			report.Imported++
+		}
+		lastCID = c.ID
+
+		if opts.CheckpointPath != "" && offset%every == 0 {
+			if err := writeCheckpoint(opts.CheckpointPath, importCheckpoint{Offset: offset, CID: lastCID}); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+
+	if opts.Resume && offset < resumeAt.Offset {
+		return report, fmt.Errorf("input has %d records but checkpoint is at %d", offset, resumeAt.Offset)
+	}
+
+	if opts.CheckpointPath != "" {
+		if err := writeCheckpoint(opts.CheckpointPath, importCheckpoint{Offset: offset, CID: lastCID}); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// recordClaim rebuilds a record's claim, computing its CID if the record
+// has none and verifying it otherwise
+func recordClaim(rec *importRecord) (*claim.Claim, error) {
+	c := fromClaimData(rec.CID, &rec.claimData)
+	if c.ID == "" {
+		cid, err := claim.ComputeCID(c)
+		if err != nil {
+			return nil, err
+		}
+		c.ID = cid
+		return c, nil
+	}
+
+	if err := claim.VerifyCID(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ExportRecord encodes a claim as one line of the import format
+func ExportRecord(c *claim.Claim) ([]byte, error) {
+	return json.Marshal(importRecord{CID: c.ID, claimData: toClaimData(c)})
+}
+
+// readCheckpoint loads a checkpoint, treating a missing file as no progress
+func readCheckpoint(path string) (importCheckpoint, error) {
+	var cp importCheckpoint
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, fmt.Errorf("corrupt checkpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// writeCheckpoint atomically replaces the checkpoint file
+func writeCheckpoint(path string, cp importCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// countingStore counts Puts and fails once failAfter Puts have succeeded
+type countingStore struct {
+	Store
+	puts      []string
+	failAfter int
+}
+
+func (s *countingStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
+	if s.failAfter > 0 && len(s.puts) >= s.failAfter {
+		return "", fmt.Errorf("simulated crash")
+	}
+	s.puts = append(s.puts, c.ID)
+	return s.Store.Put(ctx, c)
+}
+
+func importInput(t *testing.T, n int) ([]byte, []string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var cids []string
+	for i := 0; i < n; i++ {
+		c, err := claim.NewClaim(claim.Statement{Subject: fmt.Sprintf("item-%d", i), Domain: "import"}, nil, "")
+		require.NoError(t, err)
+		line, err := ExportRecord(c)
+		require.NoError(t, err)
+		buf.Write(append(line, '\n'))
+		cids = append(cids, c.ID)
+	}
+	return buf.Bytes(), cids
+}
+
+func TestImportResume(t *testing.T) {
+	ctx := context.Background()
+	input, cids := importInput(t, 10)
+	checkpoint := filepath.Join(t.TempDir(), "import.checkpoint")
+	base := newTestStore(t)
+
+	// First run dies after committing 6 records
+	crashing := &countingStore{Store: base, failAfter: 6}
+	report, err := Import(ctx, crashing, bytes.NewReader(input), ImportOptions{CheckpointPath: checkpoint, CheckpointEvery: 2})
+	require.Error(t, err)
+	assert.Equal(t, 6, report.Imported)
+
+	// Resume picks up after the last checkpoint without re-putting
+	resumed := &countingStore{Store: base}
+	report, err = Import(ctx, resumed, bytes.NewReader(input), ImportOptions{CheckpointPath: checkpoint, CheckpointEvery: 2, Resume: true})
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Resumed)
+	assert.Equal(t, 4, report.Imported)
+	assert.Equal(t, cids[6:], resumed.puts)
+
+	all, err := base.List(ctx, &Filter{Domain: "import"})
+	require.NoError(t, err)
+	assert.Len(t, all, 10)
+
+	t.Run("records after last checkpoint are deduplicated", func(t *testing.T) {
+		store := newTestStore(t)
+		path := filepath.Join(t.TempDir(), "import.checkpoint")
+
+		// Crash after 5 records with checkpoints every 4: record 5 is
+		// committed but not checkpointed
+		_, err := Import(ctx, &countingStore{Store: store, failAfter: 5}, bytes.NewReader(input), ImportOptions{CheckpointPath: path, CheckpointEvery: 4})
+		require.Error(t, err)
+
+		resumed := &countingStore{Store: store}
+		report, err := Import(ctx, resumed, bytes.NewReader(input), ImportOptions{CheckpointPath: path, CheckpointEvery: 4, Resume: true})
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Resumed)
+		assert.Equal(t, 1, report.Duplicates)
+		assert.Equal(t, cids[5:], resumed.puts)
+	})
+
+	t.Run("re-import without checkpoint is safe", func(t *testing.T) {
+		again := &countingStore{Store: base}
+		report, err := Import(ctx, again, bytes.NewReader(input), ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 10, report.Duplicates)
+		assert.Empty(t, again.puts)
+	})
+
+	t.Run("checkpoint for different input rejected", func(t *testing.T) {
+		other, _ := importInput(t, 10)
+		_, err := Import(ctx, newTestStore(t), bytes.NewReader(other), ImportOptions{CheckpointPath: checkpoint, Resume: true})
+		assert.ErrorContains(t, err, "checkpoint does not match")
+	})
+
+	t.Run("tampered record rejected", func(t *testing.T) {
+		tampered := bytes.Replace(input, []byte(`"item-0"`), []byte(`"item-X"`), 1)
+		_, err := Import(ctx, newTestStore(t), bytes.NewReader(tampered), ImportOptions{})
+		assert.ErrorContains(t, err, "record 1")
+	})
+}
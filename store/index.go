@@ -0,0 +1,352 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// IndexField names a claim attribute that secondary indexes are keyed by
+type IndexField string
+
+const (
+	// IndexWitness keys claims by each witness that attested them
+	IndexWitness IndexField = "witness"
+
+	// IndexDomain keys claims by statement domain
+	IndexDomain IndexField = "domain"
+
+	// IndexSubject keys claims by statement subject
+	IndexSubject IndexField = "subject"
+
+	// IndexStatementKey keys claims by equivalence class (see
+	// claim.StatementKey)
+	IndexStatementKey IndexField = "statement-key"
+)
+
+// Index holds a store's secondary indexes, mapping field values to the
+// CIDs of the claims that have them. Backends share one implementation
+// rather than each keeping its own maps. Adding an entry that is already
+// present, or removing one that is not, does nothing.
+type Index interface {
+	// Add indexes the claim under each of its field values
+	Add(c *claim.Claim)
+
+	// Remove drops the claim from the entries it was added under. c must
+	// have the content it was added with.
+	Remove(c *claim.Claim)
+
+	// Query returns the CIDs indexed under the field value, in the order
+	// they were added
+	Query(field IndexField, value string) []string
+
+	// Rebuild replaces the index's contents with entries for the given
+	// claims, e.g. after a backend reloads them from its own storage
+	Rebuild(claims []*claim.Claim) error
+}
+
+// indexEntry is one value a claim is indexed under
+type indexEntry struct {
+	Field IndexField `json:"field"`
+	Value string     `json:"value"`
+	CID   string     `json:"cid"`
+}
+
+// indexEntries returns the entries a claim is indexed under: each witness
+// once even if it attested more than once, its domain and subject when
+// set, and its statement key
+func indexEntries(c *claim.Claim) []indexEntry {
+	var entries []indexEntry
+	seen := make(map[string]bool, len(c.Witnesses))
+	for _, w := range c.Witnesses {
+		if seen[w.WitnessID] {
+			continue
+		}
+		seen[w.WitnessID] = true
+		entries = append(entries, indexEntry{Field: IndexWitness, Value: w.WitnessID, CID: c.ID})
+	}
+	if c.Statement.Domain != "" {
+		entries = append(entries, indexEntry{Field: IndexDomain, Value: c.Statement.Domain, CID: c.ID})
+	}
+	if c.Statement.Subject != "" {
+		entries = append(entries, indexEntry{Field: IndexSubject, Value: c.Statement.Subject, CID: c.ID})
+	}
+	entries = append(entries, indexEntry{Field: IndexStatementKey, Value: claim.StatementKey(c), CID: c.ID})
+	return entries
+}
+
+// MemoryIndex is an Index held in memory
+type MemoryIndex struct {
+	mu      sync.RWMutex
+	entries map[IndexField]map[string][]string // Field -> value -> CIDs
+}
+
+// NewMemoryIndex creates an empty in-memory index
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{entries: make(map[IndexField]map[string][]string)}
+}
+
+// Add implements Index
+func (m *MemoryIndex) Add(c *claim.Claim) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range indexEntries(c) {
+		m.add(e)
+	}
+}
+
+// Remove implements Index
+func (m *MemoryIndex) Remove(c *claim.Claim) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range indexEntries(c) {
+		m.remove(e)
+	}
+}
+
+// Query implements Index
+func (m *MemoryIndex) Query(field IndexField, value string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.entries[field][value]...)
+}
+
+// Rebuild implements Index
+func (m *MemoryIndex) Rebuild(claims []*claim.Claim) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[IndexField]map[string][]string)
+	for _, c := range claims {
+		for _, e := range indexEntries(c) {
+			m.add(e)
+		}
+	}
+	return nil
+}
+
+// add records an entry, reporting whether it was new. Callers must hold
+// m.mu.
+func (m *MemoryIndex) add(e indexEntry) bool {
+	values := m.entries[e.Field]
+	if values == nil {
+		values = make(map[string][]string)
+		m.entries[e.Field] = values
+	}
+	for _, cid := range values[e.Value] {
+		if cid == e.CID {
+			return false
+		}
+	}
+	values[e.Value] = append(values[e.Value], e.CID)
+	return true
+}
+
+// remove drops an entry, reporting whether it was present. Callers must
+// hold m.mu.
+func (m *MemoryIndex) remove(e indexEntry) bool {
+	values := m.entries[e.Field]
+	for _, cid := range values[e.Value] {
+		if cid == e.CID {
+			removeFromIndex(values, e.Value, e.CID)
+			if len(values) == 0 {
+				delete(m.entries, e.Field)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot returns every entry. Callers must hold m.mu.
+func (m *MemoryIndex) snapshot() []indexEntry {
+	var entries []indexEntry
+	for field, values := range m.entries {
+		for value, cids := range values {
+			for _, cid := range cids {
+				entries = append(entries, indexEntry{Field: field, Value: value, CID: cid})
+			}
+		}
+	}
+	return entries
+}
+
+// Index file operations
+const (
+	indexOpAdd    = "add"
+	indexOpRemove = "remove"
+)
+
+// indexOp is one record in a FileIndex's file
+type indexOp struct {
+	Op string `json:"op"`
+	indexEntry
+}
+
+// FileIndex is an Index persisted to an append-only file of changes, so it
+// survives restarts without being rebuilt. Queries are served from memory.
+// Write failures are sticky: once one occurs, Err and Close report it.
+type FileIndex struct {
+	mem  *MemoryIndex
+	path string
+	file *os.File
+	err  error // First write failure
+}
+
+// OpenFileIndex opens (creating if needed) the index file at path and
+// loads its entries
+func OpenFileIndex(path string) (*FileIndex, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	mem := NewMemoryIndex()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var op indexOp
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("corrupt index file at line %d: %w", line, err)
+		}
+		switch op.Op {
+		case indexOpAdd:
+			mem.add(op.indexEntry)
+		case indexOpRemove:
+			mem.remove(op.indexEntry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &FileIndex{mem: mem, path: path, file: file}, nil
+}
+
+// Add implements Index
+func (f *FileIndex) Add(c *claim.Claim) {
+	f.mem.mu.Lock()
+	defer f.mem.mu.Unlock()
+	for _, e := range indexEntries(c) {
+		if f.mem.add(e) {
+			f.write(indexOp{Op: indexOpAdd, indexEntry: e})
+		}
+	}
+}
+
+// Remove implements Index
+func (f *FileIndex) Remove(c *claim.Claim) {
+	f.mem.mu.Lock()
+	defer f.mem.mu.Unlock()
+	for _, e := range indexEntries(c) {
+		if f.mem.remove(e) {
+			f.write(indexOp{Op: indexOpRemove, indexEntry: e})
+		}
+	}
+}
+
+// Query implements Index
+func (f *FileIndex) Query(field IndexField, value string) []string {
+	return f.mem.Query(field, value)
+}
+
+// Rebuild implements Index. The file is atomically replaced with the new
+// entries, which also compacts it.
+func (f *FileIndex) Rebuild(claims []*claim.Claim) error {
+	mem := NewMemoryIndex()
+	if err := mem.Rebuild(claims); err != nil {
+		return err
+	}
+
+	tmpPath := f.path + ".rebuild"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range mem.snapshot() {
+		if err := enc.Encode(indexOp{Op: indexOpAdd, indexEntry: e}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	f.mem.mu.Lock()
+	defer f.mem.mu.Unlock()
+
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	f.file.Close()
+	f.file = file
+	f.mem.entries = mem.entries
+	f.err = nil
+	return nil
+}
+
+// Err returns the first failure to persist a change, if any
+func (f *FileIndex) Err() error {
+	f.mem.mu.RLock()
+	defer f.mem.mu.RUnlock()
+	return f.err
+}
+
+// Close closes the index file, returning any earlier write failure.
+// Closing it again only returns the failure.
+func (f *FileIndex) Close() error {
+	f.mem.mu.Lock()
+	defer f.mem.mu.Unlock()
+
+	if f.file == nil {
+		return f.err
+	}
+	if err := f.file.Close(); err != nil && f.err == nil {
+		f.err = err
+	}
+	f.file = nil
+	return f.err
+}
+
+// write appends an operation to the file, keeping the first failure.
+// Callers must hold f.mem.mu.
+func (f *FileIndex) write(op indexOp) {
+	if f.err != nil {
+		return
+	}
+	if f.file == nil {
+		f.err = fmt.Errorf("index is closed")
+		return
+	}
+	line, err := json.Marshal(op)
+	if err != nil {
+		f.err = err
+		return
+	}
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		f.err = fmt.Errorf("failed to persist index: %w", err)
+	}
+}
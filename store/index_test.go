@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func indexTestClaims(t *testing.T) (attested, plain *claim.Claim) {
+	t.Helper()
+
+	attested, err := claim.NewClaim(claim.Statement{Subject: "match-1", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	w := claim.DeterministicWitness("alice")
+	for i := 0; i < 2; i++ {
+		att, err := w.AttestUntil(attested, attested.Created.AddDate(1, 0, i))
+		require.NoError(t, err)
+		require.NoError(t, attested.AddAttestationWithPolicy(att, claim.KeepBoth))
+	}
+
+	plain, err = claim.NewClaim(claim.Statement{Subject: "match-2", Object: "0-0", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	return attested, plain
+}
+
+// testIndexContract checks the behavior every Index implementation shares
+func testIndexContract(t *testing.T, newIndex func(t *testing.T) Index) {
+	alice := claim.DeterministicWitness("alice").ID
+
+	t.Run("add and query", func(t *testing.T) {
+		idx := newIndex(t)
+		attested, plain := indexTestClaims(t)
+		idx.Add(attested)
+		idx.Add(plain)
+
+		// A witness that attested twice is indexed once
+		assert.Equal(t, []string{attested.ID}, idx.Query(IndexWitness, alice))
+		assert.Equal(t, []string{attested.ID, plain.ID}, idx.Query(IndexDomain, "sports"))
+		assert.Equal(t, []string{plain.ID}, idx.Query(IndexSubject, "match-2"))
+		assert.Equal(t, []string{plain.ID}, idx.Query(IndexStatementKey, claim.StatementKey(plain)))
+		assert.Empty(t, idx.Query(IndexDomain, "finance"))
+	})
+
+	t.Run("add is idempotent", func(t *testing.T) {
+		idx := newIndex(t)
+		_, plain := indexTestClaims(t)
+		idx.Add(plain)
+		idx.Add(plain)
+		assert.Equal(t, []string{plain.ID}, idx.Query(IndexSubject, "match-2"))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		idx := newIndex(t)
+		attested, plain := indexTestClaims(t)
+		idx.Add(attested)
+		idx.Add(plain)
+		idx.Remove(attested)
+		idx.Remove(attested)
+
+		assert.Empty(t, idx.Query(IndexWitness, alice))
+		assert.Empty(t, idx.Query(IndexSubject, "match-1"))
+		assert.Equal(t, []string{plain.ID}, idx.Query(IndexDomain, "sports"))
+	})
+
+	t.Run("rebuild", func(t *testing.T) {
+		idx := newIndex(t)
+		attested, plain := indexTestClaims(t)
+		idx.Add(attested)
+		require.NoError(t, idx.Rebuild([]*claim.Claim{plain}))
+
+		assert.Empty(t, idx.Query(IndexWitness, alice))
+		assert.Equal(t, []string{plain.ID}, idx.Query(IndexDomain, "sports"))
+	})
+}
+
+func TestMemoryIndex(t *testing.T) {
+	testIndexContract(t, func(t *testing.T) Index {
+		return NewMemoryIndex()
+	})
+}
+
+func TestFileIndex(t *testing.T) {
+	open := func(t *testing.T, path string) *FileIndex {
+		idx, err := OpenFileIndex(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { idx.Close() })
+		return idx
+	}
+
+	testIndexContract(t, func(t *testing.T) Index {
+		return open(t, filepath.Join(t.TempDir(), "index"))
+	})
+
+	t.Run("survives reopening", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "index")
+		attested, plain := indexTestClaims(t)
+
+		idx := open(t, path)
+		idx.Add(attested)
+		idx.Add(plain)
+		idx.Remove(attested)
+		require.NoError(t, idx.Close())
+
+		reopened := open(t, path)
+		assert.Equal(t, []string{plain.ID}, reopened.Query(IndexDomain, "sports"))
+		assert.Empty(t, reopened.Query(IndexSubject, "match-1"))
+
+		require.NoError(t, reopened.Rebuild([]*claim.Claim{attested}))
+		require.NoError(t, reopened.Close())
+		assert.Equal(t, []string{attested.ID}, open(t, path).Query(IndexDomain, "sports"))
+	})
+
+	t.Run("backs a store", func(t *testing.T) {
+		ctx := context.Background()
+		f := newFakeIPFS(t)
+		idx := open(t, filepath.Join(t.TempDir(), "index"))
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, Index: idx})
+		require.NoError(t, err)
+
+		attested, _ := indexTestClaims(t)
+		cid, err := s.Put(ctx, attested)
+		require.NoError(t, err)
+
+		cids, err := s.List(ctx, &Filter{WitnessID: claim.DeterministicWitness("alice").ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{cid}, cids)
+		assert.Equal(t, []string{cid}, idx.Query(IndexSubject, "match-1"))
+		assert.NoError(t, idx.Err())
+
+		// The store owns the index once it is given one
+		require.NoError(t, s.Close())
+		idx.Remove(attested)
+		assert.Error(t, idx.Err(), "closed with the store")
+	})
+
+	t.Run("is rebuilt from the store's log", func(t *testing.T) {
+		ctx := context.Background()
+		f := newFakeIPFS(t)
+		dir := t.TempDir()
+		cfg := IPFSConfig{APIURL: f.server.URL, IndexPath: filepath.Join(dir, "log")}
+
+		cfg.Index = open(t, filepath.Join(dir, "index"))
+		s, err := NewIPFSStore(cfg)
+		require.NoError(t, err)
+		attested, plain := indexTestClaims(t)
+		_, err = s.Put(ctx, attested)
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+
+		// A fresh index misses the claim until the store rebuilds it
+		cfg.Index = open(t, filepath.Join(dir, "stale"))
+		cfg.Index.Add(plain)
+		reopened, err := NewIPFSStore(cfg)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		assert.Equal(t, []string{attested.ID}, cfg.Index.Query(IndexSubject, "match-1"))
+		assert.Empty(t, cfg.Index.Query(IndexStatementKey, claim.StatementKey(plain)))
+	})
+}
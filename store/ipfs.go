@@ -3,6 +3,8 @@ package store
 import (
 	"bytes"
 	"context"
+	"crypto/cipher"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,19 +20,114 @@ import (
 type IPFSConfig struct {
 	// APIURL is the IPFS HTTP API URL (default: http://localhost:5001)
 	APIURL string
+
+	// FallbackURLs are further IPFS API URLs to fail over to, in order,
+	// when APIURL cannot be reached
+	FallbackURLs []string
+
+	// EndpointCooldown is how long an unreachable endpoint is passed over
+	// before being tried again (default 30s)
+	EndpointCooldown time.Duration
+
+	// IndexPath is an optional append-only log that persists the local
+	// index across restarts (empty keeps the index in memory only)
+	IndexPath string
+
+	// Embedder optionally maps statements to vectors so claims can be
+	// found by similarity (see ListSimilar)
+	Embedder Embedder
+
+	// MetadataKeys are the claim metadata keys to index for
+	// Filter.Metadata. Other keys can still be filtered on, by scanning.
+	MetadataKeys []string
+
+	// Codec serializes claims for storage (default JSONCodec). Objects
+	// written with any built-in codec can still be read.
+	Codec Codec
+
+	// MetadataDigest stores a digest of each claim's metadata with it and
+	// checks the digest whenever the claim is read back from IPFS or the
+	// index log, so edits to metadata, which the CID does not cover, are
+	// detected. Once enabled, claims stored without a digest fail to load.
+	MetadataDigest bool
+
+	// MetadataDigestKey optionally keys the metadata digest (HMAC-SHA256)
+	// so that only holders of the key can produce a valid one
+	MetadataDigestKey []byte
+
+	// CheckReferenceCycles makes Put follow a claim's evidence and
+	// revision links through the store and reject claims that would close
+	// a cycle (see CheckReferenceCycles). Claims citing themselves are
+	// always rejected.
+	CheckReferenceCycles bool
+
+	// EncryptionKey turns on encryption at rest: claim envelopes stored in
+	// IPFS and claims in the index log are encrypted with AES-GCM under
+	// this 16, 24 or 32 byte key, and decrypted on read. CIDs and IPFS
+	// hashes stay in plaintext for lookup, and the secondary indexes are
+	// only ever held in memory.
+	EncryptionKey []byte
+
+	// Retention prunes claims by domain: GC removes a claim once it is
+	// older than its domain's retention period, counted from Created.
+	// Domains without an entry are kept until the claims expire.
+	Retention map[string]time.Duration
+
+	// TombstoneKey makes Delete a soft delete: the claim's content is
+	// scrubbed from the index log and unpinned, and a tombstone signed with
+	// this key records who deleted it, when and why (see WithDeleteReason,
+	// WithDeleter and WasDeleted). Tombstoned claims cannot be stored
+	// again. Nil deletes without a record unless the context names a
+	// deleter.
+	TombstoneKey ed25519.PrivateKey
+
+	// Index holds the secondary indexes by witness, domain, subject and
+	// statement key (default a MemoryIndex). A FileIndex keeps them on
+	// disk. With IndexPath it is rebuilt from the log on startup. The
+	// store closes it on Close if it is an io.Closer, reporting any change
+	// it failed to persist.
+	Index Index
+
+	// DecodeLimits bounds the claim envelopes read back from IPFS (see
+	// DecodeLimits for the defaults)
+	DecodeLimits DecodeLimits
 }
 
 // IPFSStore implements Store using IPFS
 type IPFSStore struct {
-	cfg    IPFSConfig
-	client *http.Client
+	cfg       IPFSConfig
+	client    *http.Client
+	endpoints *endpointPool
+	sealer    cipher.AEAD // Encrypts stored content (nil if off)
 
 	// Local index for filtering/listing
-	mu        sync.RWMutex
-	index     map[string]*claim.Claim // CID -> Claim
-	byWitness map[string][]string     // WitnessID -> CIDs
-	byDomain  map[string][]string     // Domain -> CIDs
-	bySubject map[string][]string     // Subject -> CIDs
+	mu      sync.RWMutex
+	index   map[string]*claim.Claim        // CID -> Claim
+	idx     Index                          // Witness, domain, subject and statement key -> CIDs
+	byState map[claim.State][]string       // Lifecycle state -> CIDs
+	states  map[string]claim.State         // CID -> state as indexed
+	byMeta  map[string]map[string][]string // Metadata key -> value -> CIDs
+	metas   map[string]map[string]string   // CID -> indexed metadata
+	ttl     ttlIndex                       // Expiring claims by expiry (see expiry)
+	vectors map[string][]float32           // CID -> statement embedding
+
+	// Stored envelope versions, for compaction
+	log      *indexLog           // Persisted index (nil if in-memory only)
+	versions map[string][]string // CID -> IPFS hashes, oldest first
+	sizes    map[string]int64    // IPFS hash -> envelope size
+	byHash   map[string]string   // IPFS hash -> CID
+	garbage  []string            // IPFS hashes of deleted claims
+
+	// Envelopes picked for unpinning (see reserveEnvelopes), and the
+	// number of Puts between upload and indexing
+	releasing map[string]*release
+	uploads   int
+
+	tombstones map[string]*claim.Tombstone // CID -> deletion record
+
+	// Pressure signals (see Pressure)
+	gets     cacheCounter
+	outcomes outcomeWindow
 }
 
 // NewIPFSStore creates a new IPFS-backed store
@@ -38,16 +135,38 @@ func NewIPFSStore(cfg IPFSConfig) (*IPFSStore, error) {
 	if cfg.APIURL == "" {
 		cfg.APIURL = "http://localhost:5001"
 	}
+	if cfg.Codec == nil {
+		cfg.Codec = JSONCodec{}
+	}
+	if cfg.Index == nil {
+		cfg.Index = NewMemoryIndex()
+	}
+	cfg.DecodeLimits = cfg.DecodeLimits.withDefaults()
+	sealer, err := newSealer(cfg.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
 
 	s := &IPFSStore{
 		cfg: cfg,
 		client: &http.Client{
 			Timeout: 30 * time.Second, // Prevent hanging on DHT lookups
 		},
+		endpoints: newEndpointPool(append([]string{cfg.APIURL}, cfg.FallbackURLs...), cfg.EndpointCooldown),
+		sealer:    sealer,
 		index:     make(map[string]*claim.Claim),
-		byWitness: make(map[string][]string),
-		byDomain:  make(map[string][]string),
-		bySubject: make(map[string][]string),
+		idx:       cfg.Index,
+		byState:   make(map[claim.State][]string),
+		states:    make(map[string]claim.State),
+		byMeta:    make(map[string]map[string][]string),
+		metas:     make(map[string]map[string]string),
+		vectors:   make(map[string][]float32),
+		versions:  make(map[string][]string),
+		sizes:     make(map[string]int64),
+		byHash:    make(map[string]string),
+		releasing: make(map[string]*release),
+
+		tombstones: make(map[string]*claim.Tombstone),
 	}
 
 	// Verify IPFS connection
@@ -55,16 +174,17 @@ func NewIPFSStore(cfg IPFSConfig) (*IPFSStore, error) {
 		return nil, fmt.Errorf("failed to connect to IPFS: %w", err)
 	}
 
+	if cfg.IndexPath != "" {
+		if err := s.loadIndex(cfg.IndexPath); err != nil {
+			return nil, fmt.Errorf("failed to load index: %w", err)
+		}
+	}
+
 	return s, nil
 }
 
 func (s *IPFSStore) ping(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, "POST", s.cfg.APIURL+"/api/v0/id", nil)
-	if err != nil {
-		return err
-	}
-
-	resp, err := s.client.Do(req)
+	resp, err := s.post(ctx, "/api/v0/id", nil, "")
 	if err != nil {
 		return err
 	}
@@ -77,14 +197,26 @@ func (s *IPFSStore) ping(ctx context.Context) error {
 	return nil
 }
 
-// claimData is the JSON structure stored in IPFS
+// claimData is the structure codecs store in IPFS
 type claimData struct {
-	Statement claim.Statement     `json:"statement"`
-	Evidence  []string            `json:"evidence"`
-	TimeEvent string              `json:"time_event"`
-	Witnesses []claim.Attestation `json:"witnesses"`
-	Created   int64               `json:"created"` // Unix nano
-	Metadata  map[string]string   `json:"metadata,omitempty"`
+	Statement        claim.Statement           `json:"statement"`
+	Quantity         *claim.Quantity           `json:"quantity,omitempty"`
+	Objects          *claim.ObjectList         `json:"objects,omitempty"`
+	Fields           map[string]string         `json:"fields,omitempty"`
+	Evidence         []string                  `json:"evidence"`
+	EvidenceOrdering claim.EvidenceOrdering    `json:"evidence_ordering,omitempty"`
+	External         []claim.ExternalSignature `json:"external_signatures,omitempty"`
+	CIDFields        claim.CIDFields           `json:"cid_fields,omitempty"`
+	TimeEvent        string                    `json:"time_event"`
+	TimestampToken   []byte                    `json:"timestamp_token,omitempty"` // RFC 3161 DER
+	Witnesses        []claim.Attestation       `json:"witnesses"`
+	Resolution       *claim.Resolution         `json:"resolution,omitempty"`
+	State            claim.State               `json:"state,omitempty"`
+	Created          int64                     `json:"created"`              // Unix nano
+	ExpiresAt        int64                     `json:"expires_at,omitempty"` // Unix nano
+	Supersedes       string                    `json:"supersedes,omitempty"`
+	Namespace        string                    `json:"namespace,omitempty"`
+	Metadata         map[string]string         `json:"metadata,omitempty"`
 }
 
 type ipfsAddResponse struct {
@@ -92,6 +224,12 @@ type ipfsAddResponse struct {
 }
 
 func (s *IPFSStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
+	return s.put(ctx, c, nil)
+}
+
+// put stores a claim. If expectedVersion is non-nil, the claim is only
+// stored while its current version matches.
+func (s *IPFSStore) put(ctx context.Context, c *claim.Claim, expectedVersion *string) (string, error) {
 	if c == nil {
 		return "", fmt.Errorf("claim cannot be nil")
 	}
@@ -105,81 +243,253 @@ func (s *IPFSStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
 		c.ID = cid
 	}
 
+	if s.cfg.CheckReferenceCycles {
+		if err := CheckReferenceCycles(ctx, s, c); err != nil {
+			return "", err
+		}
+	} else if err := checkSelfReference(c); err != nil {
+		return "", err
+	}
+
+	// Fail fast on a stale version or erased claim rather than uploading
+	// for nothing
+	if t, deleted := s.WasDeleted(c.ID); deleted {
+		return "", deletedError(t)
+	}
+	if expectedVersion != nil {
+		s.mu.RLock()
+		err := s.checkVersion(c.ID, *expectedVersion)
+		s.mu.RUnlock()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Embed before storing so a failing embedder leaves no partial state
+	var vector []float32
+	if s.cfg.Embedder != nil {
+		var err error
+		if vector, err = s.cfg.Embedder.Embed(c.Statement); err != nil {
+			return "", fmt.Errorf("failed to embed statement: %w", err)
+		}
+	}
+
 	// Serialize claim
-	data := claimData{
-		Statement: c.Statement,
-		Evidence:  c.Evidence,
-		TimeEvent: c.TimeEvent,
-		Witnesses: c.Witnesses,
-		Created:   c.Created.UnixNano(),
-		Metadata:  c.Metadata,
+	stored := c
+	if s.cfg.MetadataDigest {
+		var err error
+		if stored, err = s.withMetadataDigest(c); err != nil {
+			return "", err
+		}
 	}
+	data := toClaimData(stored)
 
-	jsonData, err := json.Marshal(data)
+	envelope, err := s.cfg.Codec.Marshal(stored)
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize claim: %w", err)
 	}
+	if s.sealer != nil {
+		if envelope, err = s.seal(envelope); err != nil {
+			return "", fmt.Errorf("failed to encrypt claim: %w", err)
+		}
+	}
 
 	// Upload to IPFS
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-	part, err := writer.CreateFormFile("file", "claim.json")
+	s.mu.Lock()
+	s.uploads++
+	s.mu.Unlock()
+	defer s.endUpload()
+
+	hash, err := s.add(ctx, "claim.json", bytes.NewReader(envelope))
 	if err != nil {
 		return "", err
 	}
-	if _, err := part.Write(jsonData); err != nil {
-		return "", err
+
+	repin, err := s.record(c, hash, int64(len(envelope)), data, vector, expectedVersion)
+	if repin {
+		if pinErr := s.pin(ctx, hash); pinErr != nil && err == nil {
+			err = fmt.Errorf("failed to re-pin %s: %w", hash, pinErr)
+		}
 	}
-	if err := writer.Close(); err != nil {
+	if err != nil {
 		return "", err
 	}
+	return c.ID, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", s.cfg.APIURL+"/api/v0/add", &buf)
-	if err != nil {
-		return "", err
+// record indexes a claim whose envelope was uploaded as hash. It reports
+// whether a concurrent Compact or Delete unpinned the envelope during the
+// upload, leaving the caller to pin it again.
+func (s *IPFSStore) record(c *claim.Claim, hash string, size int64, data claimData, vector []float32, expectedVersion *string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check again in case another writer stored or deleted the claim
+	// during upload
+	if t, deleted := s.tombstones[c.ID]; deleted {
+		return false, deletedError(t)
+	}
+	if expectedVersion != nil {
+		if err := s.checkVersion(c.ID, *expectedVersion); err != nil {
+			return false, err
+		}
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to add to IPFS: %w", err)
+	s.removeClaim(c.ID)
+	s.index[c.ID] = c
+	s.indexClaim(c)
+	s.addVersion(c.ID, hash, size)
+	if vector != nil {
+		s.vectors[c.ID] = vector
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("IPFS add failed: %s", string(body))
+	// An envelope being released is pinned again once its unpin is done:
+	// by the releaser if it is still running, otherwise by the caller
+	repin := false
+	if r := s.releasing[hash]; r != nil {
+		if r.done {
+			repin = true
+			delete(s.releasing, hash)
+		} else {
+			r.repin = true
+		}
 	}
 
-	var addResp ipfsAddResponse
-	if err := json.NewDecoder(resp.Body).Decode(&addResp); err != nil {
-		return "", fmt.Errorf("failed to decode IPFS response: %w", err)
+	if s.log != nil {
+		entry, err := s.putEntry(c.ID, hash, size, data)
+		if err == nil {
+			err = s.log.append(entry)
+		}
+		if err != nil {
+			return repin, fmt.Errorf("failed to persist index: %w", err)
+		}
 	}
+	return repin, nil
+}
 
-	// Update local index
-	s.mu.Lock()
-	s.index[c.ID] = c
-	s.indexClaim(c)
-	s.mu.Unlock()
+// toClaimData converts a claim to its stored envelope
+func toClaimData(c *claim.Claim) claimData {
+	data := claimData{
+		Statement:        c.Statement,
+		Quantity:         c.Quantity,
+		Objects:          c.Objects,
+		Fields:           c.Fields,
+		Evidence:         c.Evidence,
+		EvidenceOrdering: c.EvidenceOrdering,
+		External:         c.ExternalSignatures,
+		CIDFields:        c.CIDFields,
+		TimeEvent:        c.TimeEvent,
+		TimestampToken:   c.TimestampToken,
+		Witnesses:        c.Witnesses,
+		Resolution:       c.Resolution,
+		State:            c.State,
+		Created:          c.Created.UnixNano(),
+		Supersedes:       c.Supersedes,
+		Namespace:        c.Namespace,
+		Metadata:         c.Metadata,
+	}
+	if !c.ExpiresAt.IsZero() {
+		data.ExpiresAt = c.ExpiresAt.UnixNano()
+	}
+	return data
+}
 
-	return c.ID, nil
+// fromClaimData reconstructs a claim from its stored envelope
+func fromClaimData(cid string, data *claimData) *claim.Claim {
+	c := &claim.Claim{
+		ID:                 cid,
+		Statement:          data.Statement,
+		Quantity:           data.Quantity,
+		Objects:            data.Objects,
+		Fields:             data.Fields,
+		Evidence:           data.Evidence,
+		EvidenceOrdering:   data.EvidenceOrdering,
+		ExternalSignatures: data.External,
+		CIDFields:          data.CIDFields,
+		TimeEvent:          data.TimeEvent,
+		TimestampToken:     data.TimestampToken,
+		Witnesses:          data.Witnesses,
+		Resolution:         data.Resolution,
+		State:              data.State,
+		Created:            time.Unix(0, data.Created).UTC(),
+		Supersedes:         data.Supersedes,
+		Namespace:          data.Namespace,
+		Metadata:           data.Metadata,
+	}
+	if data.ExpiresAt != 0 {
+		c.ExpiresAt = time.Unix(0, data.ExpiresAt).UTC()
+	}
+	return c
 }
 
 func (s *IPFSStore) indexClaim(c *claim.Claim) {
-	// Index by witness
-	for _, w := range c.Witnesses {
-		s.byWitness[w.WitnessID] = append(s.byWitness[w.WitnessID], c.ID)
+	// Index by witness, domain, subject and equivalence class
+	s.idx.Add(c)
+
+	// Index by lifecycle state, remembering the state indexed since
+	// callers may transition a cached claim before re-putting it
+	s.byState[c.State] = append(s.byState[c.State], c.ID)
+	s.states[c.ID] = c.State
+
+	// Index configured metadata keys, remembering the values indexed
+	// since callers may edit a cached claim's metadata before re-putting it
+	for _, key := range s.cfg.MetadataKeys {
+		value, ok := c.Metadata[key]
+		if !ok {
+			continue
+		}
+		if s.byMeta[key] == nil {
+			s.byMeta[key] = make(map[string][]string)
+		}
+		s.byMeta[key][value] = append(s.byMeta[key][value], c.ID)
+		if s.metas[c.ID] == nil {
+			s.metas[c.ID] = make(map[string]string)
+		}
+		s.metas[c.ID][key] = value
 	}
 
-	// Index by domain
-	if c.Statement.Domain != "" {
-		s.byDomain[c.Statement.Domain] = append(s.byDomain[c.Statement.Domain], c.ID)
+	// Index by expiry, or by when its domain's retention runs out
+	if expiresAt := s.expiry(c); !expiresAt.IsZero() {
+		s.ttl.push(c.ID, expiresAt)
 	}
+}
 
-	// Index by subject
-	if c.Statement.Subject != "" {
-		s.bySubject[c.Statement.Subject] = append(s.bySubject[c.Statement.Subject], c.ID)
+// removeClaim drops a claim from the local index and secondary indexes.
+// Callers must hold s.mu.
+func (s *IPFSStore) removeClaim(cid string) {
+	c, exists := s.index[cid]
+	if !exists {
+		return
 	}
+	delete(s.index, cid)
+	delete(s.vectors, cid)
+	s.ttl.remove(cid)
+
+	s.idx.Remove(c)
+	removeFromIndex(s.byState, s.states[cid], cid)
+	delete(s.states, cid)
+	for key, value := range s.metas[cid] {
+		removeFromIndex(s.byMeta[key], value, cid)
+		if len(s.byMeta[key]) == 0 {
+			delete(s.byMeta, key)
+		}
+	}
+	delete(s.metas, cid)
+}
+
+func removeFromIndex[K comparable](idx map[K][]string, key K, cid string) {
+	cids := idx[key]
+	kept := cids[:0]
+	for _, existing := range cids {
+		if existing != cid {
+			kept = append(kept, existing)
+		}
+	}
+	if len(kept) == 0 {
+		delete(idx, key)
+		return
+	}
+	idx[key] = kept
 }
 
 func (s *IPFSStore) Get(ctx context.Context, cid string) (*claim.Claim, error) {
@@ -191,49 +501,103 @@ func (s *IPFSStore) Get(ctx context.Context, cid string) (*claim.Claim, error) {
 	s.mu.RLock()
 	if c, exists := s.index[cid]; exists {
 		s.mu.RUnlock()
+		s.gets.hits.Add(1)
 		return c, nil
 	}
+	if t, deleted := s.tombstones[cid]; deleted {
+		s.mu.RUnlock()
+		return nil, deletedError(t)
+	}
 	s.mu.RUnlock()
+	s.gets.misses.Add(1)
 
 	// Fetch from IPFS
-	req, err := http.NewRequestWithContext(ctx, "POST", s.cfg.APIURL+"/api/v0/cat?arg="+cid, nil)
+	envelope, err := s.cat(ctx, cid, s.cfg.DecodeLimits.MaxEnvelopeSize)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := s.client.Do(req)
+	c, err := s.decode(envelope)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch from IPFS: %w", err)
+		return nil, fmt.Errorf("failed to decode claim: %w", err)
+	}
+	c.ID = cid
+	if s.cfg.MetadataDigest {
+		if err := s.checkMetadataDigest(c); err != nil {
+			return nil, err
+		}
+	}
+	vector := s.embedBestEffort(c)
+
+	// Cache in local index
+	s.mu.Lock()
+	s.removeClaim(cid)
+	s.index[cid] = c
+	s.indexClaim(c)
+	if vector != nil {
+		s.vectors[cid] = vector
+	}
+	s.mu.Unlock()
+
+	return c, nil
+}
+
+// add uploads content to IPFS and returns its IPFS hash
+func (s *IPFSStore) add(ctx context.Context, name string, content io.Reader) (string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	resp, err := s.post(ctx, "/api/v0/add", buf.Bytes(), writer.FormDataContentType())
+	if err != nil {
+		return "", fmt.Errorf("failed to add to IPFS: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("IPFS cat failed: %s", string(body))
+		return "", fmt.Errorf("IPFS add failed: %s", string(body))
 	}
 
-	var data claimData
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode claim: %w", err)
+	var addResp ipfsAddResponse
+	if err := json.NewDecoder(resp.Body).Decode(&addResp); err != nil {
+		return "", fmt.Errorf("failed to decode IPFS response: %w", err)
 	}
+	return addResp.Hash, nil
+}
 
-	c := &claim.Claim{
-		ID:        cid,
-		Statement: data.Statement,
-		Evidence:  data.Evidence,
-		TimeEvent: data.TimeEvent,
-		Witnesses: data.Witnesses,
-		Created:   time.Unix(0, data.Created).UTC(),
-		Metadata:  data.Metadata,
+// cat fetches content from IPFS, failing with a *DecodeLimitError if it
+// is larger than limit bytes (0 for no limit)
+func (s *IPFSStore) cat(ctx context.Context, hash string, limit int64) ([]byte, error) {
+	resp, err := s.post(ctx, "/api/v0/cat?arg="+hash, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch from IPFS: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// Cache in local index
-	s.mu.Lock()
-	s.index[cid] = c
-	s.indexClaim(c)
-	s.mu.Unlock()
+	// IPFS reports content it cannot find as an error response
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%w: IPFS cat failed: %s", ErrNotFound, string(body))
+	}
 
-	return c, nil
+	if limit > 0 {
+		return readLimited(resp.Body, limit)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch from IPFS: %w", err)
+	}
+	return content, nil
 }
 
 func (s *IPFSStore) Has(ctx context.Context, cid string) (bool, error) {
@@ -243,11 +607,18 @@ func (s *IPFSStore) Has(ctx context.Context, cid string) (bool, error) {
 
 	// Only check local index - we cannot query IPFS by computed CID
 	// since the computed CID differs from the IPFS storage hash.
-	// The local index is the source of truth for this store instance.
+	// The local index is the source of truth for this store instance;
+	// use ResolveIPFSHash to go from a storage hash to its claim.
 	return exists, nil
 }
 
 func (s *IPFSStore) List(ctx context.Context, filter *Filter) ([]string, error) {
+	if filter != nil && filter.Quantity != nil {
+		if err := filter.Quantity.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid quantity filter: %w", err)
+		}
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -255,11 +626,15 @@ func (s *IPFSStore) List(ctx context.Context, filter *Filter) ([]string, error)
 
 	// Apply filters to narrow candidates
 	if filter != nil && filter.WitnessID != "" {
-		candidates = s.byWitness[filter.WitnessID]
+		candidates = s.idx.Query(IndexWitness, filter.WitnessID)
 	} else if filter != nil && filter.Domain != "" {
-		candidates = s.byDomain[filter.Domain]
+		candidates = s.idx.Query(IndexDomain, filter.Domain)
 	} else if filter != nil && filter.Subject != "" {
-		candidates = s.bySubject[filter.Subject]
+		candidates = s.idx.Query(IndexSubject, filter.Subject)
+	} else if filter != nil && filter.State != nil {
+		candidates = s.byState[*filter.State]
+	} else if cids, ok := s.metadataCandidates(filter); ok {
+		candidates = cids
 	} else {
 		// Return all
 		candidates = make([]string, 0, len(s.index))
@@ -278,12 +653,33 @@ func (s *IPFSStore) List(ctx context.Context, filter *Filter) ([]string, error)
 
 		// Check all filter criteria
 		if filter != nil {
+			if filter.Namespace != "" && c.Namespace != filter.Namespace {
+				continue
+			}
 			if filter.Domain != "" && c.Statement.Domain != filter.Domain {
 				continue
 			}
 			if filter.Subject != "" && c.Statement.Subject != filter.Subject {
 				continue
 			}
+			if filter.Object != "" && !c.HasObject(filter.Object) {
+				continue
+			}
+			if filter.State != nil && c.State != *filter.State {
+				continue
+			}
+			if !matchesMetadata(c, filter.Metadata) {
+				continue
+			}
+			if filter.Quantity != nil {
+				if c.Quantity == nil {
+					continue
+				}
+				// Claims in other units are not comparable and never match
+				if ok, err := filter.Quantity.Contains(*c.Quantity); err != nil || !ok {
+					continue
+				}
+			}
 			if filter.WitnessID != "" {
 				found := false
 				for _, w := range c.Witnesses {
@@ -296,6 +692,9 @@ func (s *IPFSStore) List(ctx context.Context, filter *Filter) ([]string, error)
 					continue
 				}
 			}
+			if filter.Predicate != nil && !filter.Predicate(c) {
+				continue
+			}
 		}
 
 		results = append(results, cid)
@@ -316,6 +715,61 @@ func (s *IPFSStore) List(ctx context.Context, filter *Filter) ([]string, error)
 	return results, nil
 }
 
+// metadataCandidates returns the smallest index entry among the filter's
+// indexed metadata keys, or false if none of its keys are indexed.
+// Callers must hold s.mu.
+func (s *IPFSStore) metadataCandidates(filter *Filter) ([]string, bool) {
+	if filter == nil {
+		return nil, false
+	}
+
+	var best []string
+	found := false
+	for _, key := range s.cfg.MetadataKeys {
+		value, ok := filter.Metadata[key]
+		if !ok {
+			continue
+		}
+		cids := s.byMeta[key][value]
+		if !found || len(cids) < len(best) {
+			best, found = cids, true
+		}
+	}
+	return best, found
+}
+
+// matchesMetadata reports whether a claim has every key/value in want
+func matchesMetadata(c *claim.Claim, want map[string]string) bool {
+	for key, value := range want {
+		if got, ok := c.Metadata[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *IPFSStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	if s.log != nil {
+		err = s.log.close()
+	}
+	if idxErr := s.closeIndex(); idxErr != nil && err == nil {
+		err = fmt.Errorf("failed to close index: %w", idxErr)
+	}
+	return err
+}
+
+// closeIndex closes the secondary index if it holds resources, or else
+// reports any change it failed to persist
+func (s *IPFSStore) closeIndex() error {
+	if closer, ok := s.idx.(io.Closer); ok {
+		return closer.Close()
+	}
+	if failing, ok := s.idx.(interface{ Err() error }); ok {
+		return failing.Err()
+	}
 	return nil
 }
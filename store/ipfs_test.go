@@ -171,3 +171,244 @@ func TestIPFSStoreConnectionFailure(t *testing.T) {
 	})
 	assert.Error(t, err)
 }
+
+func TestWitnessIndexWithReattestation(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	w, _ := claim.GenerateWitness()
+	c, err := claim.NewClaim(claim.Statement{Subject: "match", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+
+	first, _ := w.Attest(c)
+	require.NoError(t, c.AddAttestation(first))
+	_, err = s.Put(ctx, c)
+	require.NoError(t, err)
+
+	w.Clock = claim.FixedClock(first.Timestamp.Add(time.Second))
+	second, _ := w.Dispute(c)
+
+	for _, policy := range []claim.DuplicatePolicy{claim.ReplaceLatest, claim.KeepBoth} {
+		updated := *c
+		updated.Witnesses = append([]claim.Attestation(nil), c.Witnesses...)
+		require.NoError(t, updated.AddAttestationWithPolicy(second, policy))
+		_, err = s.Put(ctx, &updated)
+		require.NoError(t, err)
+
+		byWitness, err := s.List(ctx, &Filter{WitnessID: w.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{c.ID}, byWitness, "policy %d", policy)
+	}
+}
+
+func TestListQuantityRange(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	put := func(subject string, value float64, unit string) string {
+		q, err := claim.NewQuantity(value, unit)
+		require.NoError(t, err)
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Predicate: "price", Domain: "finance"}, nil, "", claim.WithQuantity(q))
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		return c.ID
+	}
+
+	inRange := put("AAPL", 100, "USD")
+	put("MSFT", 120, "USD")
+	put("SAP", 100, "EUR")
+	plain, err := claim.NewClaim(claim.Statement{Subject: "note", Domain: "finance"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, plain)
+	require.NoError(t, err)
+
+	cids, err := s.List(ctx, &Filter{Quantity: &claim.QuantityRange{Min: 90, Max: 110, Unit: "USD"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{inRange}, cids)
+
+	t.Run("combines with other filters", func(t *testing.T) {
+		cids, err := s.List(ctx, &Filter{Domain: "finance", Quantity: &claim.QuantityRange{Min: 90, Max: 110, Unit: "usd"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{inRange}, cids)
+	})
+
+	t.Run("invalid range rejected", func(t *testing.T) {
+		_, err := s.List(ctx, &Filter{Quantity: &claim.QuantityRange{Min: 110, Max: 90, Unit: "USD"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestListByState(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	published, err := claim.NewClaim(claim.Statement{Subject: "published"}, nil, "")
+	require.NoError(t, err)
+	require.NoError(t, claim.Transition(published, claim.StatePublished))
+	_, err = s.Put(ctx, published)
+	require.NoError(t, err)
+
+	draft, err := claim.NewClaim(claim.Statement{Subject: "draft"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, draft)
+	require.NoError(t, err)
+
+	byState := func(state claim.State) []string {
+		cids, err := s.List(ctx, &Filter{State: &state})
+		require.NoError(t, err)
+		return cids
+	}
+
+	assert.Equal(t, []string{published.ID}, byState(claim.StatePublished))
+	assert.Equal(t, []string{draft.ID}, byState(claim.StateDraft))
+
+	// Transition the cached claim in place and re-put it
+	cached, err := s.Get(ctx, published.ID)
+	require.NoError(t, err)
+	require.NoError(t, claim.Transition(cached, claim.StateAttested))
+	_, err = s.Put(ctx, cached)
+	require.NoError(t, err)
+
+	assert.Empty(t, byState(claim.StatePublished))
+	assert.Equal(t, []string{published.ID}, byState(claim.StateAttested))
+	assert.Empty(t, s.byState[claim.StatePublished], "no stale index entries")
+}
+
+func TestListByNamespace(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	statement := claim.Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}
+	cids := make(map[string]string)
+	for _, namespace := range []string{"", "app-a", "app-b"} {
+		c, err := claim.NewClaim(statement, nil, "", claim.WithNamespace(namespace))
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		cids[namespace] = c.ID
+	}
+	require.Len(t, map[string]bool{cids[""]: true, cids["app-a"]: true, cids["app-b"]: true}, 3)
+
+	list := func(filter *Filter) []string {
+		got, err := s.List(ctx, filter)
+		require.NoError(t, err)
+		return got
+	}
+
+	assert.Equal(t, []string{cids["app-a"]}, list(&Filter{Namespace: "app-a"}))
+	assert.Equal(t, []string{cids["app-b"]}, list(&Filter{Namespace: "app-b", Domain: "sports"}))
+	assert.Len(t, list(nil), 3)
+
+	pred, err := ParseQuery("namespace:app-a OR namespace:app-b")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{cids["app-a"], cids["app-b"]}, list(&Filter{Predicate: pred}))
+
+	t.Run("namespace survives storage", func(t *testing.T) {
+		data := toClaimData(&claim.Claim{Namespace: "app-a"})
+		assert.Equal(t, "app-a", fromClaimData("", &data).Namespace)
+	})
+
+	t.Run("CID fields mode survives storage", func(t *testing.T) {
+		data := toClaimData(&claim.Claim{CIDFields: claim.CIDFieldsStatement})
+		assert.Equal(t, claim.CIDFieldsStatement, fromClaimData("", &data).CIDFields)
+	})
+}
+
+func TestListByObject(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	put := func(statement claim.Statement, opts ...claim.ClaimOption) string {
+		c, err := claim.NewClaim(statement, nil, "", opts...)
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		return c.ID
+	}
+
+	teams, err := claim.NewObjectSet("Arsenal", "Chelsea")
+	require.NoError(t, err)
+	other, err := claim.NewObjectSequence("Chelsea", "Spurs")
+	require.NoError(t, err)
+
+	match1 := put(claim.Statement{Subject: "match-1", Predicate: "teams", Domain: "sports"}, claim.WithObjects(teams))
+	match2 := put(claim.Statement{Subject: "match-2", Predicate: "teams", Domain: "sports"}, claim.WithObjects(other))
+	scalar := put(claim.Statement{Subject: "match-3", Predicate: "winner", Object: "Chelsea", Domain: "sports"})
+
+	list := func(filter *Filter) []string {
+		got, err := s.List(ctx, filter)
+		require.NoError(t, err)
+		return got
+	}
+
+	assert.Equal(t, []string{match1}, list(&Filter{Object: "Arsenal"}))
+	assert.ElementsMatch(t, []string{match1, match2, scalar}, list(&Filter{Object: "Chelsea"}))
+	assert.Equal(t, []string{match2}, list(&Filter{Object: "Spurs", Domain: "sports"}))
+	assert.Empty(t, list(&Filter{Object: "Liverpool"}))
+
+	pred, err := ParseQuery("object:Arsenal OR object:Spurs")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{match1, match2}, list(&Filter{Predicate: pred}))
+
+	t.Run("object list survives storage", func(t *testing.T) {
+		got, err := s.Get(ctx, match2)
+		require.NoError(t, err)
+		require.NotNil(t, got.Objects)
+		assert.Equal(t, other, *got.Objects)
+		assert.NoError(t, claim.VerifyCID(got))
+	})
+}
+
+func TestListByMetadata(t *testing.T) {
+	f := newFakeIPFS(t)
+	s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, MetadataKeys: []string{"source"}})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	put := func(subject string, metadata map[string]string) *claim.Claim {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: "news"}, nil, "")
+		require.NoError(t, err)
+		c.Metadata = metadata
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		return c
+	}
+
+	reuters := put("a", map[string]string{"source": "reuters", "lang": "en"})
+	reutersDE := put("b", map[string]string{"source": "reuters", "lang": "de"})
+	ap := put("c", map[string]string{"source": "ap", "lang": "en"})
+	put("d", nil)
+
+	list := func(metadata map[string]string) []string {
+		cids, err := s.List(ctx, &Filter{Metadata: metadata})
+		require.NoError(t, err)
+		return cids
+	}
+
+	assert.ElementsMatch(t, []string{reuters.ID, reutersDE.ID}, list(map[string]string{"source": "reuters"}))
+	assert.Equal(t, []string{ap.ID}, list(map[string]string{"source": "ap"}))
+	assert.Empty(t, list(map[string]string{"source": "afp"}))
+
+	t.Run("unindexed keys are scanned", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{reuters.ID, ap.ID}, list(map[string]string{"lang": "en"}))
+		assert.Equal(t, []string{reutersDE.ID}, list(map[string]string{"source": "reuters", "lang": "de"}))
+	})
+
+	t.Run("only configured keys are indexed", func(t *testing.T) {
+		assert.Len(t, s.byMeta, 1)
+		assert.Contains(t, s.byMeta, "source")
+	})
+
+	t.Run("edited metadata is reindexed", func(t *testing.T) {
+		cached, err := s.Get(ctx, ap.ID)
+		require.NoError(t, err)
+		cached.Metadata = map[string]string{"source": "afp"}
+		_, err = s.Put(ctx, cached)
+		require.NoError(t, err)
+
+		assert.Empty(t, list(map[string]string{"source": "ap"}))
+		assert.Equal(t, []string{ap.ID}, list(map[string]string{"source": "afp"}))
+		assert.NotContains(t, s.byMeta["source"], "ap", "no stale index entries")
+	})
+}
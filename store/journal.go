@@ -0,0 +1,356 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// JournalOp is the kind of store mutation a journal entry records
+type JournalOp string
+
+const (
+	// JournalPut records a claim being stored, with a full snapshot
+	JournalPut JournalOp = "put"
+
+	// JournalAttest records an attestation first seen in a Put
+	JournalAttest JournalOp = "attest"
+
+	// JournalDelete records a claim being removed
+	JournalDelete JournalOp = "delete"
+
+	// JournalErase records a claim erased with a tombstone. Its earlier
+	// entries are scrubbed, so replay has nothing to undo and skips it.
+	JournalErase JournalOp = "erase"
+)
+
+// JournalEntry is one record in a store journal
+type JournalEntry struct {
+	Op        JournalOp `json:"op"`
+	CID       string    `json:"cid"`
+	Witness   string    `json:"witness,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Claim is the full claim as stored (put entries only)
+	Claim *claimData `json:"claim,omitempty"`
+
+	// Attestation is the newly added attestation (attest entries only)
+	Attestation *claim.Attestation `json:"attestation,omitempty"`
+}
+
+// Snapshot returns the claim recorded by a put entry, or nil
+func (e JournalEntry) Snapshot() *claim.Claim {
+	if e.Claim == nil {
+		return nil
+	}
+	return fromClaimData(e.CID, e.Claim)
+}
+
+// Journal is an append-only audit trail of store mutations. Unlike the
+// index log it is never compacted: every Put, Delete and attestation is
+// kept, so the journal doubles as a source for disaster recovery. The one
+// exception is a claim erased with a tombstone, whose entries are
+// scrubbed, leaving only an erase entry.
+type Journal struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+
+	// seen holds the attestation signatures already journaled per claim
+	seen map[string]map[string]bool
+}
+
+// OpenJournal opens (creating if needed) the journal at path
+func OpenJournal(path string) (*Journal, error) {
+	entries, err := ReadJournal(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	j := &Journal{path: path, file: file, seen: make(map[string]map[string]bool)}
+	for _, entry := range entries {
+		switch entry.Op {
+		case JournalPut:
+			if entry.Claim != nil {
+				j.markSeen(entry.CID, entry.Claim.Witnesses)
+			}
+		case JournalDelete, JournalErase:
+			delete(j.seen, entry.CID)
+		}
+	}
+
+	return j, nil
+}
+
+// ReadJournal returns all entries in the journal at path, oldest first
+func ReadJournal(path string) ([]JournalEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("corrupt journal at line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// recordPut journals a stored claim and any attestations not seen before
+func (j *Journal) recordPut(c *claim.Claim) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now().UTC()
+	data := toClaimData(c)
+	entries := []JournalEntry{{Op: JournalPut, CID: c.ID, Timestamp: now, Claim: &data}}
+
+	seen := j.seen[c.ID]
+	for i := range c.Witnesses {
+		att := c.Witnesses[i]
+		if seen[hex.EncodeToString(att.Signature)] {
+			continue
+		}
+		entries = append(entries, JournalEntry{
+			Op:          JournalAttest,
+			CID:         c.ID,
+			Witness:     att.WitnessID,
+			Timestamp:   now,
+			Attestation: &att,
+		})
+	}
+
+	if err := j.write(entries...); err != nil {
+		return err
+	}
+	j.markSeen(c.ID, c.Witnesses)
+	return nil
+}
+
+// recordDelete journals a removed claim
+func (j *Journal) recordDelete(cid string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.write(JournalEntry{Op: JournalDelete, CID: cid, Timestamp: time.Now().UTC()}); err != nil {
+		return err
+	}
+	delete(j.seen, cid)
+	return nil
+}
+
+// recordErasure journals a claim deleted with a tombstone and scrubs its
+// earlier entries, so its content is no longer on disk
+func (j *Journal) recordErasure(cid string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.write(JournalEntry{Op: JournalErase, CID: cid, Timestamp: time.Now().UTC()}); err != nil {
+		return err
+	}
+	delete(j.seen, cid)
+
+	entries, err := ReadJournal(j.path)
+	if err != nil {
+		return err
+	}
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.CID == cid && entry.Op != JournalErase {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return j.rewrite(kept)
+}
+
+// rewrite atomically replaces the journal with the given entries.
+// Callers must hold j.mu.
+func (j *Journal) rewrite(entries []JournalEntry) error {
+	tmpPath := j.path + ".scrub"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	j.file.Close()
+	j.file = file
+	return nil
+}
+
+// write appends entries and syncs them to disk. Callers must hold j.mu.
+func (j *Journal) write(entries ...JournalEntry) error {
+	var buf []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	if _, err := j.file.Write(buf); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return j.file.Sync()
+}
+
+// markSeen records attestation signatures as journaled.
+// Callers must hold j.mu.
+func (j *Journal) markSeen(cid string, attestations []claim.Attestation) {
+	seen := j.seen[cid]
+	if seen == nil {
+		seen = make(map[string]bool)
+		j.seen[cid] = seen
+	}
+	for _, att := range attestations {
+		seen[hex.EncodeToString(att.Signature)] = true
+	}
+}
+
+// JournaledStore wraps a Store, recording every successful mutation in a
+// Journal. Reads pass straight through to the wrapped store.
+type JournaledStore struct {
+	Store
+	journal *Journal
+}
+
+// NewJournaledStore returns a Store that journals mutations to j
+func NewJournaledStore(s Store, j *Journal) *JournaledStore {
+	return &JournaledStore{Store: s, journal: j}
+}
+
+// Put stores the claim, then journals it
+func (s *JournaledStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
+	cid, err := s.Store.Put(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.journal.recordPut(c); err != nil {
+		return cid, err
+	}
+	return cid, nil
+}
+
+// Delete removes the claim, then journals the deletion. If the wrapped
+// store erased the claim with a tombstone, the claim's snapshots are
+// scrubbed from the journal too. It returns ErrDeleteUnsupported if the
+// wrapped store does not implement Deleter.
+func (s *JournaledStore) Delete(ctx context.Context, cid string) error {
+	if err := deleteFrom(ctx, s.Store, cid); err != nil {
+		return err
+	}
+	if tombstoner, ok := s.Store.(interface {
+		WasDeleted(cid string) (*claim.Tombstone, bool)
+	}); ok {
+		if _, erased := tombstoner.WasDeleted(cid); erased {
+			return s.journal.recordErasure(cid)
+		}
+	}
+	return s.journal.recordDelete(cid)
+}
+
+// Close closes the wrapped store and the journal
+func (s *JournaledStore) Close() error {
+	err := s.Store.Close()
+	if jerr := s.journal.Close(); err == nil {
+		err = jerr
+	}
+	return err
+}
+
+// ReplayJournal rebuilds a store from the journal at path by applying its
+// puts and deletes, in order, to dst. Attest entries are audit records
+// only; the attestations they describe are part of the put snapshot
+// journaled alongside them. Erase entries are skipped, since the erased
+// claim's puts were scrubbed with it. A journal with deletes can only be
+// replayed into a store that implements Deleter.
+func ReplayJournal(ctx context.Context, path string, dst Store) error {
+	entries, err := ReadJournal(path)
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		switch entry.Op {
+		case JournalPut:
+			c := entry.Snapshot()
+			if c == nil {
+				return fmt.Errorf("journal entry %d: put without claim", i+1)
+			}
+			if _, err := dst.Put(ctx, c); err != nil {
+				return fmt.Errorf("journal entry %d: %w", i+1, err)
+			}
+		case JournalDelete:
+			if err := deleteFrom(ctx, dst, entry.CID); err != nil {
+				return fmt.Errorf("journal entry %d: %w", i+1, err)
+			}
+		}
+	}
+
+	return nil
+}
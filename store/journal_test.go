@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestJournalReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "journal.log")
+
+	j, err := OpenJournal(path)
+	require.NoError(t, err)
+	src := NewJournaledStore(newTestStore(t), j)
+
+	w, _ := claim.GenerateWitness()
+
+	kept, err := claim.NewClaim(claim.Statement{Subject: "kept", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	_, err = src.Put(ctx, kept)
+	require.NoError(t, err)
+
+	att, _ := w.Attest(kept)
+	require.NoError(t, kept.AddAttestation(att))
+	_, err = src.Put(ctx, kept)
+	require.NoError(t, err)
+
+	deleted, err := claim.NewClaim(claim.Statement{Subject: "deleted", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	_, err = src.Put(ctx, deleted)
+	require.NoError(t, err)
+	require.NoError(t, src.Delete(ctx, deleted.ID))
+
+	t.Run("records every mutation", func(t *testing.T) {
+		entries, err := ReadJournal(path)
+		require.NoError(t, err)
+
+		var ops []JournalOp
+		for _, entry := range entries {
+			ops = append(ops, entry.Op)
+		}
+		assert.Equal(t, []JournalOp{JournalPut, JournalPut, JournalAttest, JournalPut, JournalDelete}, ops)
+		assert.Equal(t, w.ID, entries[2].Witness)
+		assert.Equal(t, kept.ID, entries[2].CID)
+	})
+
+	t.Run("reopened journal does not repeat attestations", func(t *testing.T) {
+		require.NoError(t, j.Close())
+		j, err = OpenJournal(path)
+		require.NoError(t, err)
+		src = NewJournaledStore(src.Store, j)
+
+		_, err = src.Put(ctx, kept)
+		require.NoError(t, err)
+
+		entries, err := ReadJournal(path)
+		require.NoError(t, err)
+		assert.Equal(t, JournalPut, entries[len(entries)-1].Op)
+	})
+
+	t.Run("replay reconstructs state", func(t *testing.T) {
+		dst := newTestStore(t)
+		require.NoError(t, ReplayJournal(ctx, path, dst))
+
+		want, err := src.List(ctx, nil)
+		require.NoError(t, err)
+		got, err := dst.List(ctx, nil)
+		require.NoError(t, err)
+		sort.Strings(want)
+		sort.Strings(got)
+		assert.Equal(t, want, got)
+
+		restored, err := dst.Get(ctx, kept.ID)
+		require.NoError(t, err)
+		assert.NoError(t, claim.VerifyCID(restored))
+		assert.NoError(t, restored.VerifyAllAttestations())
+		assert.Len(t, restored.Witnesses, 1)
+
+		byWitness, err := dst.List(ctx, &Filter{WitnessID: w.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept.ID}, byWitness)
+
+		has, err := dst.Has(ctx, deleted.ID)
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("stores without Delete are rejected", func(t *testing.T) {
+		// Embedding the Store interface hides IPFSStore's Delete
+		readOnly := struct{ Store }{newTestStore(t)}
+
+		assert.ErrorIs(t, ReplayJournal(ctx, path, readOnly), ErrDeleteUnsupported)
+		assert.ErrorIs(t, NewJournaledStore(readOnly, j).Delete(ctx, kept.ID), ErrDeleteUnsupported)
+	})
+
+	require.NoError(t, src.Close())
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Defaults for DecodeLimits fields left at zero
+const (
+	defaultMaxEnvelopeSize = 1 << 20 // 1 MiB
+	defaultMaxDepth        = 32
+	defaultMaxElements     = 10000
+)
+
+// DecodeLimits bounds the claim envelopes a store will decode, so hostile
+// content fetched from IPFS cannot exhaust memory. Fields left at zero
+// take their defaults. Exceeding a limit fails the read with a
+// *DecodeLimitError.
+type DecodeLimits struct {
+	// MaxEnvelopeSize is the largest envelope read, in bytes (default
+	// 1 MiB). For an encrypted store it bounds the sealed envelope.
+	MaxEnvelopeSize int64
+
+	// MaxDepth is how deeply arrays and objects may nest (default 32).
+	// CBOR tags count as a level, and CBOR envelopes allow at least 4.
+	MaxDepth int
+
+	// MaxElements is the most elements any one array or object may have
+	// (default 10000). CBOR envelopes allow at least 16.
+	MaxElements int
+}
+
+// withDefaults fills in unset limits
+func (l DecodeLimits) withDefaults() DecodeLimits {
+	if l.MaxEnvelopeSize <= 0 {
+		l.MaxEnvelopeSize = defaultMaxEnvelopeSize
+	}
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = defaultMaxDepth
+	}
+	if l.MaxElements <= 0 {
+		l.MaxElements = defaultMaxElements
+	}
+	return l
+}
+
+// DecodeLimitError is returned when an envelope exceeds a DecodeLimits
+// bound
+type DecodeLimitError struct {
+	// Limit names the bound exceeded: "size", "depth" or "elements"
+	Limit string
+
+	// Max is the bound's value
+	Max int64
+}
+
+func (e *DecodeLimitError) Error() string {
+	return fmt.Sprintf("envelope exceeds %s limit of %d", e.Limit, e.Max)
+}
+
+// readLimited reads r to the end, failing once it has more than max bytes
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > max {
+		return nil, &DecodeLimitError{Limit: "size", Max: max}
+	}
+	return content, nil
+}
+
+// Smallest bounds the CBOR decoder accepts
+const (
+	minCBORDepth    = 4
+	minCBORElements = 16
+)
+
+// cborDecMode returns a CBOR decoder enforcing the limits. Bounds below
+// the decoder's minimums are raised to them.
+func (l DecodeLimits) cborDecMode() cbor.DecMode {
+	l = l.withDefaults()
+	depth, elements := l.MaxDepth, l.MaxElements
+	if depth < minCBORDepth {
+		depth = minCBORDepth
+	}
+	if elements < minCBORElements {
+		elements = minCBORElements
+	}
+	dm, err := cbor.DecOptions{
+		MaxNestedLevels:  depth,
+		MaxArrayElements: elements,
+		MaxMapPairs:      elements,
+	}.DecMode()
+	if err != nil {
+		panic(err) // The bounds are clamped into the decoder's range
+	}
+	return dm
+}
+
+// checkCBOR scans a CBOR envelope body against the limits. A malformed
+// body is left for the codec to report.
+func (l DecodeLimits) checkCBOR(body []byte) error {
+	var (
+		depthErr    *cbor.MaxNestedLevelError
+		arrayErr    *cbor.MaxArrayElementsError
+		mapPairsErr *cbor.MaxMapPairsError
+	)
+	err := l.cborDecMode().Wellformed(body)
+	switch {
+	case errors.As(err, &depthErr):
+		return &DecodeLimitError{Limit: "depth", Max: int64(l.MaxDepth)}
+	case errors.As(err, &arrayErr), errors.As(err, &mapPairsErr):
+		return &DecodeLimitError{Limit: "elements", Max: int64(l.MaxElements)}
+	}
+	return nil
+}
+
+// check scans a plaintext envelope against the limits before it is
+// decoded. JSON and CBOR envelopes are walked; a syntax error is left for
+// the codec to report.
+func (l DecodeLimits) check(envelope []byte) error {
+	l = l.withDefaults()
+	if int64(len(envelope)) > l.MaxEnvelopeSize {
+		return &DecodeLimitError{Limit: "size", Max: l.MaxEnvelopeSize}
+	}
+	if bytes.HasPrefix(envelope, cborSelfDescribe) {
+		return l.checkCBOR(envelope[len(cborSelfDescribe):])
+	}
+	if len(envelope) == 0 || envelope[0] != '{' {
+		return nil
+	}
+
+	// Each open container counts the tokens directly inside it; an object
+	// has a key token and a value token per element
+	type container struct {
+		object bool
+		tokens int
+	}
+	var stack []container
+
+	dec := json.NewDecoder(bytes.NewReader(envelope))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil // End of input, or a syntax error for the codec
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if n := len(stack); n > 0 {
+			top := &stack[n-1]
+			top.tokens++
+			elements := top.tokens
+			if top.object {
+				elements = (top.tokens + 1) / 2
+			}
+			if elements > l.MaxElements {
+				return &DecodeLimitError{Limit: "elements", Max: int64(l.MaxElements)}
+			}
+		}
+
+		if delim, ok := tok.(json.Delim); ok {
+			stack = append(stack, container{object: delim == '{'})
+			if len(stack) > l.MaxDepth {
+				return &DecodeLimitError{Limit: "depth", Max: int64(l.MaxDepth)}
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeLimits(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeIPFS(t)
+	s, err := NewIPFSStore(IPFSConfig{
+		APIURL:       f.server.URL,
+		DecodeLimits: DecodeLimits{MaxEnvelopeSize: 4096, MaxElements: 100},
+	})
+	require.NoError(t, err)
+
+	upload := func(envelope string) string {
+		hash, err := s.add(ctx, "claim.json", strings.NewReader(envelope))
+		require.NoError(t, err)
+		return hash
+	}
+	rejected := func(t *testing.T, envelope, limit string) {
+		_, err := s.GetByIPFSHash(ctx, upload(envelope))
+		var limitErr *DecodeLimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, limit, limitErr.Limit)
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		c, err := s.GetByIPFSHash(ctx, upload(`{"statement":{"Subject":"match-1","Domain":"sports"},"evidence":[],"time_event":"","witnesses":[],"created":0}`))
+		require.NoError(t, err)
+		assert.Equal(t, "match-1", c.Statement.Subject)
+	})
+
+	t.Run("oversized payload", func(t *testing.T) {
+		rejected(t, `{"time_event":"`+strings.Repeat("x", 5000)+`"}`, "size")
+	})
+
+	t.Run("deeply nested payload", func(t *testing.T) {
+		rejected(t, `{"metadata":`+strings.Repeat("[", 1000)+strings.Repeat("]", 1000)+`}`, "depth")
+	})
+
+	t.Run("too many elements", func(t *testing.T) {
+		rejected(t, `{"evidence":["a"`+strings.Repeat(`,"a"`, 100)+`]}`, "elements")
+	})
+
+	t.Run("CBOR envelopes", func(t *testing.T) {
+		cborEnvelope := func(field string, value []byte) string {
+			body := append([]byte{0xa1, 0x60 + byte(len(field))}, field...)
+			return string(append(append(append([]byte(nil), cborSelfDescribe...), body...), value...))
+		}
+
+		nested := append(bytes.Repeat([]byte{0x81}, 1000), 0x80)
+		rejected(t, cborEnvelope("metadata", nested), "depth")
+
+		elements := append([]byte{0x98, 101}, bytes.Repeat([]byte{0x61, 'a'}, 101)...)
+		rejected(t, cborEnvelope("evidence", elements), "elements")
+
+		within := append([]byte{0x98, 100}, bytes.Repeat([]byte{0x61, 'a'}, 100)...)
+		c, err := s.GetByIPFSHash(ctx, upload(cborEnvelope("evidence", within)))
+		require.NoError(t, err)
+		assert.Len(t, c.Evidence, 100)
+	})
+
+	t.Run("unknown fields", func(t *testing.T) {
+		envelope := `{"statement":{"Subject":"match-1"},"extra":true}`
+		_, err := s.GetByIPFSHash(ctx, upload(envelope))
+		require.NoError(t, err, "unknown fields are ignored by default")
+
+		_, err = decodeEnvelope([]byte(envelope), JSONCodec{DisallowUnknownFields: true})
+		assert.ErrorContains(t, err, "unknown field")
+	})
+}
@@ -0,0 +1,548 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// Index log operations
+const (
+	logOpPut    = "put"
+	logOpDelete = "delete"
+)
+
+// logEntry is one record in the append-only index log
+type logEntry struct {
+	Op    string     `json:"op"`
+	CID   string     `json:"cid"`
+	Hash  string     `json:"hash,omitempty"` // IPFS hash of the stored envelope
+	Size  int64      `json:"size,omitempty"` // Envelope size in bytes
+	Claim *claimData `json:"claim,omitempty"`
+
+	// Sealed is the claim encrypted with IPFSConfig.EncryptionKey, in
+	// place of Claim
+	Sealed []byte `json:"sealed,omitempty"`
+
+	Tombstone *claim.Tombstone `json:"tombstone,omitempty"`
+}
+
+// indexLog is an append-only file of index operations. Every Put and
+// Delete appends an entry, so the log grows until it is compacted.
+type indexLog struct {
+	path    string
+	file    *os.File
+	size    int64
+	entries int
+}
+
+// openIndexLog opens (creating if needed) the log and returns its entries
+func openIndexLog(path string) (*indexLog, []logEntry, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := readLogEntries(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return &indexLog{path: path, file: file, size: info.Size(), entries: len(entries)}, entries, nil
+}
+
+// readLogEntries reads every entry in an index log file
+func readLogEntries(file *os.File) ([]logEntry, error) {
+	var entries []logEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry logEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("corrupt index log at line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func (l *indexLog) append(entry logEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	l.entries++
+	return err
+}
+
+// rewrite atomically replaces the log with the given entries
+func (l *indexLog) rewrite(entries []logEntry) error {
+	tmpPath := l.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	var size int64
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		n, _ := w.Write(append(line, '\n'))
+		size += int64(n)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	l.file.Close()
+	l.file = file
+	l.size = size
+	l.entries = len(entries)
+
+	return nil
+}
+
+// scrub rewrites the log without the put entries of a claim, so its
+// content is no longer on disk
+func (l *indexLog) scrub(cid string) error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	entries, err := readLogEntries(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.Op == logOpPut && entry.CID == cid {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return l.rewrite(kept)
+}
+
+func (l *indexLog) close() error {
+	return l.file.Close()
+}
+
+// loadIndex replays the index log into the in-memory index
+func (s *IPFSStore) loadIndex(path string) error {
+	log, entries, err := openIndexLog(path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		switch entry.Op {
+		case logOpPut:
+			data, err := s.entryClaim(entry)
+			if err != nil {
+				log.close()
+				return err
+			}
+			if data == nil {
+				continue
+			}
+			c := fromClaimData(entry.CID, data)
+			if s.cfg.MetadataDigest {
+				if err := s.checkMetadataDigest(c); err != nil {
+					log.close()
+					return err
+				}
+			}
+			s.removeClaim(c.ID)
+			s.index[c.ID] = c
+			s.indexClaim(c)
+			s.addVersion(c.ID, entry.Hash, entry.Size)
+		case logOpDelete:
+			s.removeClaim(entry.CID)
+			s.dropVersions(entry.CID)
+		case logOpTombstone:
+			if entry.Tombstone == nil {
+				continue
+			}
+			s.removeClaim(entry.CID)
+			s.dropVersions(entry.CID)
+			s.tombstones[entry.CID] = entry.Tombstone
+		}
+	}
+
+	claims := make([]*claim.Claim, 0, len(s.index))
+	for cid, c := range s.index {
+		claims = append(claims, c)
+		if vector := s.embedBestEffort(c); vector != nil {
+			s.vectors[cid] = vector
+		}
+	}
+
+	// A persistent index may have missed changes, e.g. if it failed to
+	// write or was not in use, so the log is the source of truth
+	if err := s.idx.Rebuild(claims); err != nil {
+		log.close()
+		return fmt.Errorf("failed to rebuild index: %w", err)
+	}
+
+	s.log = log
+	return nil
+}
+
+// addVersion records a newly stored envelope for a claim.
+// Callers must hold s.mu.
+func (s *IPFSStore) addVersion(cid, hash string, size int64) {
+	if hash == "" {
+		return
+	}
+	s.sizes[hash] = size
+	s.byHash[hash] = cid
+
+	versions := s.versions[cid]
+	if len(versions) > 0 && versions[len(versions)-1] == hash {
+		return
+	}
+	s.versions[cid] = append(versions, hash)
+}
+
+// dropVersions marks all of a claim's envelopes as garbage.
+// Callers must hold s.mu.
+func (s *IPFSStore) dropVersions(cid string) {
+	for _, hash := range s.versions[cid] {
+		delete(s.byHash, hash)
+	}
+	s.garbage = append(s.garbage, s.versions[cid]...)
+	delete(s.versions, cid)
+}
+
+// deleteClaim removes a claim from the index and records the deletion.
+// Callers must hold s.mu.
+func (s *IPFSStore) deleteClaim(cid string) error {
+	s.removeClaim(cid)
+	s.dropVersions(cid)
+
+	if s.log != nil {
+		if err := s.log.append(logEntry{Op: logOpDelete, CID: cid}); err != nil {
+			return fmt.Errorf("failed to persist index: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delete removes a claim from the store. Its envelopes remain pinned in
+// IPFS until the next Compact. If IPFSConfig.TombstoneKey is set or the
+// context names a deleter (see WithDeleter), the delete is a soft delete:
+// a tombstone is recorded, the claim's content is scrubbed from the index
+// log, and its envelopes are unpinned at once. Unpinning only lets the
+// IPFS node garbage collect the content; copies other nodes fetched are
+// beyond the store's reach.
+func (s *IPFSStore) Delete(ctx context.Context, cid string) error {
+	s.mu.Lock()
+	if _, exists := s.index[cid]; !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("claim %s: %w", cid, ErrNotFound)
+	}
+	if s.cfg.TombstoneKey == nil && deleter(ctx) == nil {
+		defer s.mu.Unlock()
+		return s.deleteClaim(cid)
+	}
+
+	envelopes, err := s.tombstoneClaim(ctx, cid)
+	envelopes = s.reserveEnvelopes(envelopes)
+	s.mu.Unlock()
+
+	// Envelopes that cannot be unpinned now are left as garbage for Compact
+	_, _, _ = s.releaseEnvelopes(ctx, envelopes)
+	return err
+}
+
+// CompactReport describes the result of a compaction
+type CompactReport struct {
+	// LiveClaims is the number of claims retained
+	LiveClaims int
+
+	// DroppedEntries is the number of index log entries removed
+	DroppedEntries int
+
+	// Unpinned is the number of superseded or deleted envelopes unpinned
+	Unpinned int
+
+	// ReclaimedBytes is the total space freed in the log and in IPFS
+	ReclaimedBytes int64
+}
+
+// Compact drops deleted and superseded entries. Superseded envelopes
+// (older versions of a claim's attestation set) and envelopes of deleted
+// claims are unpinned from IPFS, and the index log is rewritten to hold
+// only the latest version of each live claim. The store stays readable and
+// writable while envelopes are unpinned.
+func (s *IPFSStore) Compact(ctx context.Context) (*CompactReport, error) {
+	// Collect envelopes no longer referenced by a live claim
+	s.mu.Lock()
+	stale := append([]string(nil), s.garbage...)
+	s.garbage = nil
+	for cid, versions := range s.versions {
+		if len(versions) > 1 {
+			stale = append(stale, versions[:len(versions)-1]...)
+			s.versions[cid] = versions[len(versions)-1:]
+		}
+	}
+	var unused []string
+	for _, hash := range stale {
+		if !s.isLive(hash) {
+			unused = append(unused, hash)
+		}
+	}
+	unused = s.reserveEnvelopes(unused)
+	s.mu.Unlock()
+
+	unpinned, reclaimed, err := s.releaseEnvelopes(ctx, unused)
+	report := &CompactReport{Unpinned: unpinned, ReclaimedBytes: reclaimed}
+	if err != nil {
+		s.mu.RLock()
+		report.LiveClaims = len(s.index)
+		s.mu.RUnlock()
+		return report, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report.LiveClaims = len(s.index)
+	if s.log == nil {
+		return report, nil
+	}
+
+	// Rewrite the log with one entry per live claim
+	cids := make([]string, 0, len(s.index))
+	for cid := range s.index {
+		cids = append(cids, cid)
+	}
+	sort.Strings(cids)
+
+	entries := make([]logEntry, 0, len(cids))
+	for _, cid := range cids {
+		stored := s.index[cid]
+		if s.cfg.MetadataDigest {
+			var err error
+			if stored, err = s.withMetadataDigest(stored); err != nil {
+				return report, err
+			}
+		}
+		var hash string
+		if versions := s.versions[cid]; len(versions) > 0 {
+			hash = versions[len(versions)-1]
+		}
+		entry, err := s.putEntry(cid, hash, s.sizes[hash], toClaimData(stored))
+		if err != nil {
+			return report, err
+		}
+		entries = append(entries, entry)
+	}
+
+	// Tombstones outlive compaction
+	deleted := make([]string, 0, len(s.tombstones))
+	for cid := range s.tombstones {
+		deleted = append(deleted, cid)
+	}
+	sort.Strings(deleted)
+	for _, cid := range deleted {
+		entries = append(entries, logEntry{Op: logOpTombstone, CID: cid, Tombstone: s.tombstones[cid]})
+	}
+
+	before, oldEntries := s.log.size, s.log.entries
+	if err := s.log.rewrite(entries); err != nil {
+		return report, fmt.Errorf("failed to rewrite index log: %w", err)
+	}
+
+	report.DroppedEntries = oldEntries - len(entries)
+	report.ReclaimedBytes += before - s.log.size
+
+	return report, nil
+}
+
+// release tracks an envelope picked for unpinning. A Put that indexes
+// the envelope meanwhile sets repin, so it is pinned again once the unpin
+// is done.
+type release struct {
+	done  bool // Unpinned; the record stays while uploads are in flight
+	repin bool
+}
+
+// reserveEnvelopes marks envelopes that are about to be unpinned and
+// returns those not already being released. Callers must hold s.mu.
+func (s *IPFSStore) reserveEnvelopes(hashes []string) []string {
+	reserved := hashes[:0:0]
+	for _, hash := range hashes {
+		if _, busy := s.releasing[hash]; !busy {
+			s.releasing[hash] = &release{}
+			reserved = append(reserved, hash)
+		}
+	}
+	return reserved
+}
+
+// releaseEnvelopes unpins reserved envelopes without holding s.mu, then
+// drops the unpinned ones from the store's records. An envelope that a
+// Put stored again during its unpin is pinned again instead, so live
+// content is never left unpinned. It stops at the first failure, leaving
+// the rest as garbage for the next Compact. It returns how many envelopes
+// were unpinned and their total size.
+func (s *IPFSStore) releaseEnvelopes(ctx context.Context, hashes []string) (int, int64, error) {
+	var err error
+	done := 0
+	for _, hash := range hashes {
+		if unpinErr := s.unpin(ctx, hash); unpinErr != nil {
+			err = fmt.Errorf("failed to unpin %s: %w", hash, unpinErr)
+			break
+		}
+		done++
+	}
+
+	s.mu.Lock()
+	s.garbage = append(s.garbage, hashes[done:]...)
+	for _, hash := range hashes[done:] {
+		delete(s.releasing, hash)
+	}
+
+	var repin []string
+	var reclaimed int64
+	for _, hash := range hashes[:done] {
+		r := s.releasing[hash]
+		r.done = true
+		if r.repin || s.isLive(hash) {
+			delete(s.releasing, hash)
+			repin = append(repin, hash)
+			continue
+		}
+		// Puts still uploading may yet index the envelope (see record)
+		if s.uploads == 0 {
+			delete(s.releasing, hash)
+		}
+		reclaimed += s.sizes[hash]
+		delete(s.sizes, hash)
+		delete(s.byHash, hash)
+	}
+	s.mu.Unlock()
+
+	for _, hash := range repin {
+		if pinErr := s.pin(ctx, hash); pinErr != nil && err == nil {
+			err = fmt.Errorf("failed to re-pin %s: %w", hash, pinErr)
+		}
+	}
+	return done - len(repin), reclaimed, err
+}
+
+// endUpload marks a Put's upload as indexed or abandoned. Once no uploads
+// are in flight, no Put can index an envelope that was already unpinned.
+func (s *IPFSStore) endUpload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.uploads--
+	if s.uploads > 0 {
+		return
+	}
+	for hash, r := range s.releasing {
+		if r.done {
+			delete(s.releasing, hash)
+		}
+	}
+}
+
+// isLive reports whether an envelope is the latest or an uncompacted
+// version of a stored claim. Callers must hold s.mu.
+func (s *IPFSStore) isLive(hash string) bool {
+	cid, ok := s.byHash[hash]
+	if !ok {
+		return false
+	}
+	for _, h := range s.versions[cid] {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// pin keeps an envelope from being garbage collected by the IPFS node
+func (s *IPFSStore) pin(ctx context.Context, hash string) error {
+	resp, err := s.post(ctx, "/api/v0/pin/add?arg="+url.QueryEscape(hash), nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("IPFS pin/add failed: %s", string(body))
+	}
+	return nil
+}
+
+// unpin releases an envelope so the IPFS node can garbage collect it
+func (s *IPFSStore) unpin(ctx context.Context, hash string) error {
+	resp, err := s.post(ctx, "/api/v0/pin/rm?arg="+url.QueryEscape(hash), nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		// Already unpinned content has nothing left to reclaim
+		if strings.Contains(string(body), "not pinned") {
+			return nil
+		}
+		return fmt.Errorf("IPFS pin/rm failed: %s", string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestIndexLogPersistence(t *testing.T) {
+	f := newFakeIPFS(t)
+	indexPath := filepath.Join(t.TempDir(), "index.log")
+	ctx := context.Background()
+
+	s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath})
+	require.NoError(t, err)
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "persisted", Domain: "test"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, c)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	reopened, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath})
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	got, err := reopened.Get(ctx, c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, c.Statement, got.Statement)
+	assert.NoError(t, claim.VerifyCID(got))
+
+	domain, err := reopened.List(ctx, &Filter{Domain: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{c.ID}, domain)
+}
+
+func TestCompact(t *testing.T) {
+	f := newFakeIPFS(t)
+	indexPath := filepath.Join(t.TempDir(), "index.log")
+	ctx := context.Background()
+
+	s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath})
+	require.NoError(t, err)
+
+	// A claim stored, then re-stored after each of three attestations
+	attested, err := claim.NewClaim(claim.Statement{Subject: "attested", Domain: "test"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, attested)
+	require.NoError(t, err)
+
+	var witnesses []string
+	for i := 0; i < 3; i++ {
+		w, _ := claim.GenerateWitness()
+		att, _ := w.Attest(attested)
+		require.NoError(t, attested.AddAttestation(att))
+		_, err = s.Put(ctx, attested)
+		require.NoError(t, err)
+		witnesses = append(witnesses, w.ID)
+	}
+
+	// A plain live claim, and one that is deleted
+	plain, _ := claim.NewClaim(claim.Statement{Subject: "plain", Domain: "test"}, nil, "")
+	_, err = s.Put(ctx, plain)
+	require.NoError(t, err)
+
+	deleted, _ := claim.NewClaim(claim.Statement{Subject: "deleted", Domain: "test"}, nil, "")
+	_, err = s.Put(ctx, deleted)
+	require.NoError(t, err)
+	require.NoError(t, s.Delete(ctx, deleted.ID))
+
+	infoBefore, err := os.Stat(indexPath)
+	require.NoError(t, err)
+	objectsBefore := f.count()
+
+	// Reads go on while envelopes are unpinned
+	var blockedReads int
+	f.onUnpin = func(string) {
+		done := make(chan struct{})
+		go func() {
+			_, _ = s.List(ctx, nil)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			blockedReads++
+		}
+	}
+	report, err := s.Compact(ctx)
+	require.NoError(t, err)
+	f.onUnpin = nil
+	assert.Zero(t, blockedReads)
+
+	t.Run("reports reclaimed space", func(t *testing.T) {
+		assert.Equal(t, 2, report.LiveClaims)
+		assert.Equal(t, 4, report.Unpinned) // 3 superseded versions + 1 deleted claim
+		assert.Equal(t, 5, report.DroppedEntries)
+		assert.Positive(t, report.ReclaimedBytes)
+		assert.Equal(t, objectsBefore-4, f.count())
+
+		infoAfter, err := os.Stat(indexPath)
+		require.NoError(t, err)
+		assert.Less(t, infoAfter.Size(), infoBefore.Size())
+	})
+
+	t.Run("live claims and attestations preserved", func(t *testing.T) {
+		require.NoError(t, s.Close())
+
+		reopened, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath})
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		all, err := reopened.List(ctx, nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{attested.ID, plain.ID}, all)
+
+		got, err := reopened.Get(ctx, attested.ID)
+		require.NoError(t, err)
+		require.Len(t, got.Witnesses, 3)
+		assert.NoError(t, got.VerifyAllAttestations())
+
+		for _, id := range witnesses {
+			byWitness, err := reopened.List(ctx, &Filter{WitnessID: id})
+			require.NoError(t, err)
+			assert.Equal(t, []string{attested.ID}, byWitness)
+		}
+
+		exists, _ := reopened.Has(ctx, deleted.ID)
+		assert.False(t, exists)
+	})
+
+	t.Run("compacting again reclaims nothing", func(t *testing.T) {
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath})
+		require.NoError(t, err)
+		defer s.Close()
+
+		report, err := s.Compact(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, report.Unpinned)
+		assert.Zero(t, report.DroppedEntries)
+	})
+}
+
+func TestCompactKeepsRestoredContentPinned(t *testing.T) {
+	ctx := context.Background()
+
+	// Stores a claim, deletes it and returns its envelope, which the next
+	// Compact unpins
+	deletedEnvelope := func(t *testing.T, s *IPFSStore, c *claim.Claim) string {
+		_, err := s.Put(ctx, c)
+		require.NoError(t, err)
+		hash := s.versions[c.ID][0]
+		require.NoError(t, s.Delete(ctx, c.ID))
+		return hash
+	}
+
+	t.Run("stored again while unpinning", func(t *testing.T) {
+		f := newFakeIPFS(t)
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL})
+		require.NoError(t, err)
+		c, err := claim.NewClaim(claim.Statement{Subject: "restored", Domain: "test"}, nil, "")
+		require.NoError(t, err)
+		hash := deletedEnvelope(t, s, c)
+
+		f.onUnpin = func(unpinned string) {
+			if unpinned == hash {
+				_, err := s.Put(ctx, c)
+				assert.NoError(t, err)
+			}
+		}
+		report, err := s.Compact(ctx)
+		require.NoError(t, err)
+		f.onUnpin = nil
+
+		assert.Zero(t, report.Unpinned)
+		assert.Equal(t, []string{hash}, s.versions[c.ID])
+		assert.True(t, f.isPinned(hash))
+	})
+
+	t.Run("uploaded before unpinning, indexed after", func(t *testing.T) {
+		f := newFakeIPFS(t)
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL})
+		require.NoError(t, err)
+		c, err := claim.NewClaim(claim.Statement{Subject: "restored", Domain: "test"}, nil, "")
+		require.NoError(t, err)
+		hash := deletedEnvelope(t, s, c)
+
+		// The Put's upload lands, then a whole Compact runs before the Put
+		// indexes the claim
+		f.afterAdd = func(added string) {
+			if added == hash {
+				f.afterAdd = nil
+				report, err := s.Compact(ctx)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, report.Unpinned)
+				assert.False(t, f.isPinned(hash))
+			}
+		}
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{hash}, s.versions[c.ID])
+		assert.True(t, f.isPinned(hash))
+		assert.Empty(t, s.releasing)
+	})
+}
+
+func TestAttestationContextPersistence(t *testing.T) {
+	f := newFakeIPFS(t)
+	indexPath := filepath.Join(t.TempDir(), "index.log")
+	ctx := context.Background()
+
+	s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath})
+	require.NoError(t, err)
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "contextual", Domain: "test"}, nil, "")
+	require.NoError(t, err)
+	w, _ := claim.GenerateWitness()
+	att, err := w.AttestWithContext(c, claim.AttestationContext{
+		Method:     "cross-check",
+		Evidence:   []string{"bafkreisource"},
+		VerifiedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+	_, err = s.Put(ctx, c)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	reopened, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath})
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	got, err := reopened.Get(ctx, c.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Witnesses, 1)
+	assert.Equal(t, att.Context, got.Witnesses[0].Context)
+	assert.NoError(t, got.VerifyAllAttestations())
+}
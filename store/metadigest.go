@@ -0,0 +1,88 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// metadataDigestKey is the reserved metadata key that carries the
+// metadata digest in stored envelopes
+const metadataDigestKey = "claim-graph:metadata-digest"
+
+// metadataDigest hashes a claim's metadata together with its CID, so a
+// digest cannot be moved to another claim. With a key it is an
+// HMAC-SHA256, which only holders of the key can produce.
+func metadataDigest(c *claim.Claim, key []byte) string {
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+
+	writeDigestString(h, c.ID)
+	keys := make([]string, 0, len(c.Metadata))
+	for k := range c.Metadata {
+		if k != metadataDigestKey {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeDigestString(h, k)
+		writeDigestString(h, c.Metadata[k])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeDigestString writes a length-prefixed string so that adjacent
+// strings cannot run together
+func writeDigestString(h hash.Hash, s string) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(s)))
+	h.Write(length[:])
+	h.Write([]byte(s))
+}
+
+// withMetadataDigest returns a copy of c whose metadata carries its
+// digest, for storing
+func (s *IPFSStore) withMetadataDigest(c *claim.Claim) (*claim.Claim, error) {
+	if _, reserved := c.Metadata[metadataDigestKey]; reserved {
+		return nil, fmt.Errorf("metadata key %q is reserved", metadataDigestKey)
+	}
+
+	stored := *c
+	stored.Metadata = make(map[string]string, len(c.Metadata)+1)
+	for k, v := range c.Metadata {
+		stored.Metadata[k] = v
+	}
+	stored.Metadata[metadataDigestKey] = metadataDigest(c, s.cfg.MetadataDigestKey)
+	return &stored, nil
+}
+
+// checkMetadataDigest verifies and removes the digest carried in a claim
+// read back from storage
+func (s *IPFSStore) checkMetadataDigest(c *claim.Claim) error {
+	digest, ok := c.Metadata[metadataDigestKey]
+	if !ok {
+		return fmt.Errorf("claim %s has no metadata digest", c.ID)
+	}
+
+	delete(c.Metadata, metadataDigestKey)
+	if len(c.Metadata) == 0 {
+		c.Metadata = nil
+	}
+
+	if !hmac.Equal([]byte(digest), []byte(metadataDigest(c, s.cfg.MetadataDigestKey))) {
+		return fmt.Errorf("claim %s: metadata does not match its digest", c.ID)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestMetadataDigest(t *testing.T) {
+	ctx := context.Background()
+
+	newClaim := func(t *testing.T) *claim.Claim {
+		c, err := claim.NewClaim(claim.Statement{Subject: "digested", Domain: "test"}, nil, "")
+		require.NoError(t, err)
+		c.Metadata = map[string]string{"source": "reuters"}
+		return c
+	}
+
+	t.Run("round trip through the index log", func(t *testing.T) {
+		f := newFakeIPFS(t)
+		cfg := IPFSConfig{APIURL: f.server.URL, IndexPath: filepath.Join(t.TempDir(), "index.log"), MetadataDigest: true}
+
+		s, err := NewIPFSStore(cfg)
+		require.NoError(t, err)
+		c := newClaim(t)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"source": "reuters"}, c.Metadata)
+		require.NoError(t, s.Close())
+
+		reopened, err := NewIPFSStore(cfg)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		got, err := reopened.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"source": "reuters"}, got.Metadata)
+	})
+
+	t.Run("tampered index log detected", func(t *testing.T) {
+		f := newFakeIPFS(t)
+		indexPath := filepath.Join(t.TempDir(), "index.log")
+		cfg := IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath, MetadataDigest: true}
+
+		s, err := NewIPFSStore(cfg)
+		require.NoError(t, err)
+		_, err = s.Put(ctx, newClaim(t))
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+
+		raw, err := os.ReadFile(indexPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(indexPath, bytes.ReplaceAll(raw, []byte("reuters"), []byte("forged!")), 0600))
+
+		_, err = NewIPFSStore(cfg)
+		assert.ErrorContains(t, err, "does not match its digest")
+
+		// Without the option the edit goes unnoticed
+		cfg.MetadataDigest = false
+		s, err = NewIPFSStore(cfg)
+		require.NoError(t, err)
+		s.Close()
+	})
+
+	t.Run("tampered envelope detected", func(t *testing.T) {
+		f := newFakeIPFS(t)
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, MetadataDigest: true})
+		require.NoError(t, err)
+		c := newClaim(t)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+
+		// Serve the envelope under the claim CID with edited metadata
+		f.mu.Lock()
+		envelope := f.objects[s.versions[c.ID][0]]
+		f.objects[c.ID] = bytes.ReplaceAll(envelope, []byte("reuters"), []byte("forged!"))
+		f.mu.Unlock()
+
+		fresh, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, MetadataDigest: true})
+		require.NoError(t, err)
+		_, err = fresh.Get(ctx, c.ID)
+		assert.ErrorContains(t, err, "does not match its digest")
+	})
+
+	t.Run("digest required once enabled", func(t *testing.T) {
+		f := newFakeIPFS(t)
+		plain, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL})
+		require.NoError(t, err)
+		c := newClaim(t)
+		_, err = plain.Put(ctx, c)
+		require.NoError(t, err)
+
+		f.mu.Lock()
+		f.objects[c.ID] = f.objects[plain.versions[c.ID][0]]
+		f.mu.Unlock()
+
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, MetadataDigest: true})
+		require.NoError(t, err)
+		_, err = s.Get(ctx, c.ID)
+		assert.ErrorContains(t, err, "no metadata digest")
+	})
+
+	t.Run("keyed digest cannot be recomputed without the key", func(t *testing.T) {
+		key := []byte("metadata-secret")
+		c := newClaim(t)
+		c.ID, _ = claim.ComputeCID(c)
+		c.Metadata["source"] = "forged"
+
+		// A forger recomputes the unkeyed digest over the edited metadata
+		forged := *c
+		forged.Metadata = map[string]string{"source": "forged", metadataDigestKey: metadataDigest(c, nil)}
+
+		s := &IPFSStore{cfg: IPFSConfig{MetadataDigest: true, MetadataDigestKey: key}}
+		assert.Error(t, s.checkMetadataDigest(&forged))
+
+		forged.Metadata = map[string]string{"source": "forged", metadataDigestKey: metadataDigest(c, key)}
+		assert.NoError(t, s.checkMetadataDigest(&forged))
+	})
+
+	t.Run("reserved key rejected", func(t *testing.T) {
+		f := newFakeIPFS(t)
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, MetadataDigest: true})
+		require.NoError(t, err)
+		c := newClaim(t)
+		c.Metadata[metadataDigestKey] = "00"
+		_, err = s.Put(ctx, c)
+		assert.Error(t, err)
+	})
+}
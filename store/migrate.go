@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// Migration records what happened to one claim in a MigrateClaims run
+type Migration struct {
+	// From is the claim that was migrated
+	From string `json:"from"`
+
+	// To is the superseding claim stored in its place (empty if the claim
+	// was left alone or failed)
+	To string `json:"to,omitempty"`
+
+	// Witnesses attested the old claim. Their attestations are bound to
+	// its CID, so they stay with it, reachable through the new claim's
+	// Supersedes link; these witnesses must attest again to vouch for
+	// the new claim.
+	Witnesses []string `json:"witnesses,omitempty"`
+
+	// Error is why the claim could not be migrated
+	Error string `json:"error,omitempty"`
+}
+
+// MigrationReport is the audit record of a MigrateClaims run, one entry
+// per claim considered, ordered by CID
+type MigrationReport struct {
+	Domain     string      `json:"domain"`
+	Migrations []Migration `json:"migrations"`
+}
+
+// Migrated returns the number of claims that were superseded
+func (r *MigrationReport) Migrated() int {
+	n := 0
+	for _, m := range r.Migrations {
+		if m.To != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the number of claims that could not be migrated
+func (r *MigrationReport) Failed() int {
+	n := 0
+	for _, m := range r.Migrations {
+		if m.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// MigrateClaims moves a domain's claims to a new schema. Since a claim's
+// content cannot change without changing its CID, each claim is replaced
+// by a revision that supersedes it: fn builds the new claim from the old,
+// or returns nil to leave the claim as it is. Only the latest revision of
+// each claim is migrated.
+//
+// fn is given a copy of each stored claim, so it may modify and return it.
+// The new claim is linked to the old through Supersedes, which is set if
+// fn left it empty (the CID is then recomputed, so fn must not attest the
+// claim itself). A claim fn fails on, or whose new claim cannot be made a
+// revision of it, is recorded in the report and skipped; an error is only
+// returned if the store fails, along with the report so far.
+func MigrateClaims(ctx context.Context, s Store, domain string, fn func(old *claim.Claim) (*claim.Claim, error)) (*MigrationReport, error) {
+	if domain == "" {
+		return nil, fmt.Errorf("domain cannot be empty")
+	}
+
+	cids, err := s.List(ctx, &Filter{Domain: domain})
+	if err != nil {
+		return nil, err
+	}
+	claims := make([]*claim.Claim, 0, len(cids))
+	superseded := make(map[string]bool)
+	for _, cid := range cids {
+		c, err := s.Get(ctx, cid)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, c)
+		if c.Supersedes != "" {
+			superseded[c.Supersedes] = true
+		}
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].ID < claims[j].ID })
+
+	report := &MigrationReport{Domain: domain, Migrations: []Migration{}}
+	for _, old := range claims {
+		if superseded[old.ID] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		m := Migration{From: old.ID, Witnesses: attestingWitnesses(old)}
+		updated, err := migrateClaim(old, fn)
+		if err != nil {
+			m.Error = err.Error()
+			report.Migrations = append(report.Migrations, m)
+			continue
+		}
+		if updated == nil {
+			report.Migrations = append(report.Migrations, m)
+			continue
+		}
+
+		if m.To, err = s.Put(ctx, updated); err != nil {
+			return report, fmt.Errorf("failed to store migration of %s: %w", old.ID, err)
+		}
+		report.Migrations = append(report.Migrations, m)
+	}
+	return report, nil
+}
+
+// migrateClaim applies fn to a copy of a claim and links the result to it
+func migrateClaim(old *claim.Claim, fn func(old *claim.Claim) (*claim.Claim, error)) (*claim.Claim, error) {
+	updated, err := fn(cloneClaim(old))
+	if err != nil || updated == nil {
+		return nil, err
+	}
+
+	switch updated.Supersedes {
+	case old.ID:
+	case "":
+		if len(updated.Witnesses) > 0 {
+			return nil, fmt.Errorf("migrated claim was attested before superseding %s", old.ID)
+		}
+		updated.Supersedes = old.ID
+		cid, err := claim.ComputeCID(updated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute CID: %w", err)
+		}
+		updated.ID = cid
+	default:
+		return nil, fmt.Errorf("migrated claim supersedes %s, not %s", updated.Supersedes, old.ID)
+	}
+
+	if updated.ID == old.ID {
+		return nil, fmt.Errorf("migrated claim is unchanged")
+	}
+	if err := claim.VerifyCID(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// attestingWitnesses returns, sorted, the witnesses that attested a claim
+func attestingWitnesses(c *claim.Claim) []string {
+	set := make(map[string]bool)
+	for _, att := range c.Witnesses {
+		set[att.WitnessID] = true
+	}
+	return sortedSet(set)
+}
+
+// cloneClaim returns a deep copy of a claim, so code handed a claim the
+// store caches cannot change the cached one
+func cloneClaim(c *claim.Claim) *claim.Claim {
+	cp := *c
+	if c.Quantity != nil {
+		q := *c.Quantity
+		cp.Quantity = &q
+	}
+	if c.Objects != nil {
+		cp.Objects = &claim.ObjectList{Values: cloneStrings(c.Objects.Values), Ordered: c.Objects.Ordered}
+	}
+	cp.Fields = cloneStringMap(c.Fields)
+	cp.Metadata = cloneStringMap(c.Metadata)
+	cp.Evidence = cloneStrings(c.Evidence)
+	cp.TimestampToken = cloneBytes(c.TimestampToken)
+
+	if c.ExternalSignatures != nil {
+		cp.ExternalSignatures = make([]claim.ExternalSignature, len(c.ExternalSignatures))
+		for i, sig := range c.ExternalSignatures {
+			sig.Signature = cloneBytes(sig.Signature)
+			sig.PayloadHash = cloneBytes(sig.PayloadHash)
+			cp.ExternalSignatures[i] = sig
+		}
+	}
+
+	if c.Witnesses != nil {
+		cp.Witnesses = make([]claim.Attestation, len(c.Witnesses))
+		for i, att := range c.Witnesses {
+			att.Signature = cloneBytes(att.Signature)
+			att.Fields = cloneStrings(att.Fields)
+			att.ContextHash = cloneBytes(att.ContextHash)
+			att.Nonce = cloneBytes(att.Nonce)
+			if att.Context != nil {
+				ctx := *att.Context
+				ctx.Evidence = cloneStrings(ctx.Evidence)
+				att.Context = &ctx
+			}
+			if att.Membership != nil {
+				m := *att.Membership
+				m.Signature = cloneBytes(m.Signature)
+				att.Membership = &m
+			}
+			cp.Witnesses[i] = att
+		}
+	}
+
+	if c.Resolution != nil {
+		r := *c.Resolution
+		cp.Resolution = &r
+	}
+	return &cp
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestMigrateClaims(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	put := func(st claim.Statement, opts ...claim.ClaimOption) *claim.Claim {
+		c, err := claim.NewClaim(st, nil, "", opts...)
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		return c
+	}
+
+	attested := put(claim.Statement{Subject: "match-1", Predicate: "score", Object: "2-1", Domain: "sports"})
+	att, err := claim.DeterministicWitness("alice").Attest(attested)
+	require.NoError(t, err)
+	_, err = AttachAttestation(ctx, s, attested.ID, att)
+	require.NoError(t, err)
+
+	original := put(claim.Statement{Subject: "match-2", Predicate: "score", Object: "0-0", Domain: "sports"})
+	revised := put(claim.Statement{Subject: "match-2", Predicate: "score", Object: "1-0", Domain: "sports"}, claim.WithSupersedes(original.ID))
+	current := put(claim.Statement{Subject: "match-3", Predicate: "final-score", Object: "3-3", Domain: "sports"})
+	broken := put(claim.Statement{Subject: "match-4", Predicate: "score", Object: "?", Domain: "sports"})
+	other := put(claim.Statement{Subject: "season", Predicate: "score", Object: "12", Domain: "league"})
+
+	// Rename the "score" predicate to "final-score"
+	report, err := MigrateClaims(ctx, s, "sports", func(old *claim.Claim) (*claim.Claim, error) {
+		if old.Statement.Predicate != "score" {
+			return nil, nil
+		}
+		if old.Statement.Object == "?" {
+			return nil, errors.New("unknown score")
+		}
+		st := old.Statement
+		st.Predicate = "final-score"
+		return claim.NewClaim(st, old.Evidence, old.TimeEvent)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sports", report.Domain)
+	assert.Equal(t, 2, report.Migrated())
+	assert.Equal(t, 1, report.Failed())
+
+	byFrom := make(map[string]Migration)
+	for _, m := range report.Migrations {
+		byFrom[m.From] = m
+	}
+	require.Len(t, byFrom, 4, "only the latest revision of each claim is migrated")
+	assert.NotContains(t, byFrom, original.ID)
+	assert.NotContains(t, byFrom, other.ID)
+	assert.Empty(t, byFrom[current.ID].To)
+	assert.Empty(t, byFrom[broken.ID].To)
+	assert.Equal(t, "unknown score", byFrom[broken.ID].Error)
+
+	t.Run("supersession links", func(t *testing.T) {
+		m := byFrom[revised.ID]
+		history, err := RevisionHistory(ctx, s, m.To)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, []string{original.ID, revised.ID, m.To}, []string{history[0].ID, history[1].ID, history[2].ID})
+		assert.Equal(t, "final-score", history[2].Statement.Predicate)
+		assert.Empty(t, m.Witnesses)
+	})
+
+	t.Run("attestations stay with the old claim", func(t *testing.T) {
+		m := byFrom[attested.ID]
+		assert.Equal(t, []string{att.WitnessID}, m.Witnesses)
+
+		migrated, err := s.Get(ctx, m.To)
+		require.NoError(t, err)
+		assert.Equal(t, attested.ID, migrated.Supersedes)
+		assert.Empty(t, migrated.Witnesses)
+
+		old, err := s.Get(ctx, migrated.Supersedes)
+		require.NoError(t, err)
+		require.Len(t, old.Witnesses, 1)
+		assert.NoError(t, claim.VerifyAttestation(old, &old.Witnesses[0]))
+	})
+
+	t.Run("rerun only migrates new revisions", func(t *testing.T) {
+		report, err := MigrateClaims(ctx, s, "sports", func(old *claim.Claim) (*claim.Claim, error) {
+			if old.Statement.Predicate != "score" || old.Statement.Object == "?" {
+				return nil, nil
+			}
+			return nil, errors.New("already migrated")
+		})
+		require.NoError(t, err)
+		assert.Zero(t, report.Migrated())
+		assert.Zero(t, report.Failed())
+	})
+
+	t.Run("result must supersede the old claim", func(t *testing.T) {
+		report, err := MigrateClaims(ctx, s, "league", func(old *claim.Claim) (*claim.Claim, error) {
+			return claim.NewClaim(old.Statement, nil, "", claim.WithSupersedes(current.ID))
+		})
+		require.NoError(t, err)
+		require.Len(t, report.Migrations, 1)
+		assert.Contains(t, report.Migrations[0].Error, "supersedes")
+	})
+
+	t.Run("fn may modify the claim it is given", func(t *testing.T) {
+		reading := put(claim.Statement{Subject: "station-1", Predicate: "temperature", Object: "21", Domain: "weather"},
+			claim.WithFields(map[string]string{"unit": "C"}))
+		att, err := claim.DeterministicWitness("alice").Attest(reading)
+		require.NoError(t, err)
+		_, err = AttachAttestation(ctx, s, reading.ID, att)
+		require.NoError(t, err)
+
+		report, err := MigrateClaims(ctx, s, "weather", func(old *claim.Claim) (*claim.Claim, error) {
+			old.Fields["unit"] = "celsius"
+			old.Witnesses = old.Witnesses[:0]
+			return old, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Migrated())
+
+		stored, err := s.Get(ctx, reading.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "C", stored.Fields["unit"])
+		require.Len(t, stored.Witnesses, 1)
+		assert.NoError(t, claim.VerifyAttestation(stored, &stored.Witnesses[0]))
+
+		migrated, err := s.Get(ctx, report.Migrations[0].To)
+		require.NoError(t, err)
+		assert.Equal(t, "celsius", migrated.Fields["unit"])
+	})
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrAmbiguousPrefix is returned when a CID prefix matches several claims
+var ErrAmbiguousPrefix = errors.New("ambiguous CID prefix")
+
+// maxListedCandidates bounds the candidates named in an ambiguity error
+const maxListedCandidates = 10
+
+// ResolvePrefix returns, sorted, the CIDs of the stored claims that begin
+// with prefix, like a git short hash. It scans the local index.
+func (s *IPFSStore) ResolvePrefix(ctx context.Context, prefix string) ([]string, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("CID prefix cannot be empty")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var cids []string
+	for cid := range s.index {
+		if strings.HasPrefix(cid, prefix) {
+			cids = append(cids, cid)
+		}
+	}
+	sort.Strings(cids)
+	return cids, ctx.Err()
+}
+
+// ResolveCID expands an unambiguous CID prefix to the stored claim's full
+// CID. A prefix matching several claims fails with ErrAmbiguousPrefix,
+// naming the candidates. A reference matching no stored claim is returned
+// as given, since it may be a full CID of a claim only in IPFS.
+func (s *IPFSStore) ResolveCID(ctx context.Context, ref string) (string, error) {
+	cids, err := s.ResolvePrefix(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(cids) {
+	case 0:
+		return ref, nil
+	case 1:
+		return cids[0], nil
+	}
+
+	// A full CID can also be a prefix of longer ones
+	for _, cid := range cids {
+		if cid == ref {
+			return cid, nil
+		}
+	}
+
+	listed := cids
+	if len(listed) > maxListedCandidates {
+		listed = listed[:maxListedCandidates]
+	}
+	msg := strings.Join(listed, ", ")
+	if more := len(cids) - len(listed); more > 0 {
+		msg += fmt.Sprintf(" and %d more", more)
+	}
+	return "", fmt.Errorf("%w %q matches %d claims: %s", ErrAmbiguousPrefix, ref, len(cids), msg)
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestResolvePrefix(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	var cids []string
+	for i := 0; i < 20; i++ {
+		c, err := claim.NewClaim(claim.Statement{Subject: fmt.Sprintf("match-%d", i), Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		cids = append(cids, c.ID)
+	}
+
+	// uniquePrefix returns the shortest prefix of cid no other claim shares
+	uniquePrefix := func(cid string) string {
+		for n := 1; n <= len(cid); n++ {
+			matches, err := s.ResolvePrefix(ctx, cid[:n])
+			require.NoError(t, err)
+			if len(matches) == 1 {
+				return cid[:n]
+			}
+		}
+		t.Fatalf("no unique prefix for %s", cid)
+		return ""
+	}
+
+	t.Run("unique prefix", func(t *testing.T) {
+		for _, cid := range cids {
+			prefix := uniquePrefix(cid)
+			assert.Less(t, len(prefix), len(cid))
+
+			resolved, err := s.ResolveCID(ctx, prefix)
+			require.NoError(t, err)
+			assert.Equal(t, cid, resolved)
+		}
+	})
+
+	t.Run("full CID", func(t *testing.T) {
+		resolved, err := s.ResolveCID(ctx, cids[3])
+		require.NoError(t, err)
+		assert.Equal(t, cids[3], resolved)
+	})
+
+	t.Run("ambiguous prefix", func(t *testing.T) {
+		// Every raw CIDv1 over SHA2-256 starts "bafkrei"
+		matches, err := s.ResolvePrefix(ctx, "bafkrei")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, cids, matches)
+
+		_, err = s.ResolveCID(ctx, "bafkrei")
+		assert.ErrorIs(t, err, ErrAmbiguousPrefix)
+		assert.ErrorContains(t, err, "matches 20 claims")
+		assert.ErrorContains(t, err, "and 10 more")
+		assert.ErrorContains(t, err, matches[0])
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		matches, err := s.ResolvePrefix(ctx, "bafkreinothere")
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+
+		resolved, err := s.ResolveCID(ctx, "bafkreinothere")
+		require.NoError(t, err)
+		assert.Equal(t, "bafkreinothere", resolved)
+
+		_, err = s.ResolvePrefix(ctx, "")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// pressureWindow is how many recent IPFS requests the error rate covers
+const pressureWindow = 100
+
+// PressureReport summarizes how hard an IPFSStore is working, for
+// orchestrators deciding when to scale. It complements raw metrics with
+// one structured view.
+type PressureReport struct {
+	// IndexSize is the number of claims held in the local index
+	IndexSize int
+
+	// CacheHitRatio is the share of Gets served from the local index
+	// rather than fetched from IPFS (1 before any Get)
+	CacheHitRatio float64
+
+	// ErrorRate is the share of recent IPFS requests that could not reach
+	// an endpoint (0 before any request)
+	ErrorRate float64
+
+	// PendingRetries is the number of endpoints cooling down after a
+	// failure, waiting to be tried again
+	PendingRetries int
+
+	// Level is the overall pressure between 0 and 1: the larger of the
+	// error rate and the share of endpoints cooling down
+	Level float64
+}
+
+// outcomeWindow records whether each of the last pressureWindow requests
+// failed
+type outcomeWindow struct {
+	mu       sync.Mutex
+	failed   [pressureWindow]bool
+	next     int
+	count    int
+	failures int
+}
+
+func (w *outcomeWindow) record(failed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.count == pressureWindow {
+		if w.failed[w.next] {
+			w.failures--
+		}
+	} else {
+		w.count++
+	}
+	w.failed[w.next] = failed
+	if failed {
+		w.failures++
+	}
+	w.next = (w.next + 1) % pressureWindow
+}
+
+func (w *outcomeWindow) rate() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.count == 0 {
+		return 0
+	}
+	return float64(w.failures) / float64(w.count)
+}
+
+// cacheCounter counts Gets served from the local index and from IPFS
+type cacheCounter struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func (c *cacheCounter) ratio() float64 {
+	hits, misses := c.hits.Load(), c.misses.Load()
+	if hits+misses == 0 {
+		return 1
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// Pressure reports the store's current load and backend health
+func (s *IPFSStore) Pressure() PressureReport {
+	s.mu.RLock()
+	size := len(s.index)
+	s.mu.RUnlock()
+
+	pending := 0
+	for _, up := range s.endpoints.healthy() {
+		if !up {
+			pending++
+		}
+	}
+
+	r := PressureReport{
+		IndexSize:      size,
+		CacheHitRatio:  s.gets.ratio(),
+		ErrorRate:      s.outcomes.rate(),
+		PendingRetries: pending,
+	}
+	r.Level = r.ErrorRate
+	if down := float64(pending) / float64(len(s.endpoints.urls)); down > r.Level {
+		r.Level = down
+	}
+	return r
+}
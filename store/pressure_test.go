@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestPressure(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeIPFS(t)
+	endpoint := newFlakyEndpoint(t, f)
+
+	s, err := NewIPFSStore(IPFSConfig{APIURL: endpoint.server.URL, EndpointCooldown: time.Hour})
+	require.NoError(t, err)
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "pressure"}, nil, "")
+	require.NoError(t, err)
+	cid, err := s.Put(ctx, c)
+	require.NoError(t, err)
+
+	t.Run("healthy", func(t *testing.T) {
+		_, err := s.Get(ctx, cid)
+		require.NoError(t, err)
+
+		report := s.Pressure()
+		assert.Equal(t, 1, report.IndexSize)
+		assert.Equal(t, 1.0, report.CacheHitRatio)
+		assert.Zero(t, report.ErrorRate)
+		assert.Zero(t, report.PendingRetries)
+		assert.Zero(t, report.Level)
+	})
+
+	t.Run("cache misses", func(t *testing.T) {
+		_, err := s.Get(ctx, "bafymissing")
+		require.Error(t, err)
+		assert.Equal(t, 0.5, s.Pressure().CacheHitRatio)
+	})
+
+	t.Run("failing endpoint", func(t *testing.T) {
+		endpoint.down.Store(true)
+		for i := 0; i < 3; i++ {
+			_, err := s.Get(ctx, "bafyunreachable")
+			require.Error(t, err)
+		}
+
+		report := s.Pressure()
+		assert.Greater(t, report.ErrorRate, 0.0)
+		assert.Equal(t, 1, report.PendingRetries)
+		assert.Equal(t, 1.0, report.Level)
+	})
+}
+
+func TestOutcomeWindow(t *testing.T) {
+	var w outcomeWindow
+	assert.Zero(t, w.rate())
+
+	for i := 0; i < pressureWindow; i++ {
+		w.record(true)
+	}
+	assert.Equal(t, 1.0, w.rate())
+
+	// Old failures age out of the window
+	for i := 0; i < pressureWindow/2; i++ {
+		w.record(false)
+	}
+	assert.Equal(t, 0.5, w.rate())
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// propagationWeight is the share of a claim's propagated confidence that
+// comes from the claims it cites as evidence. Each level of citation is
+// weighted by it again, so distant provenance counts for less.
+const propagationWeight = 0.3
+
+// PropagatedConfidence returns the claim's confidence with trust carried
+// through its provenance: its own claim.ClaimConfidence blended with the
+// mean propagated confidence of the evidence claims it cites, followed up
+// to depth levels deep. Evidence that is not a claim in s is ignored, as
+// is a citation back to a claim already on the path, so cycles end. A
+// claim nobody has endorsed stays at zero, and one that cannot be read
+// scores zero.
+func PropagatedConfidence(ctx context.Context, s Store, rs *claim.ReputationStore, cid string, depth int) float64 {
+	return propagate(ctx, s, rs, cid, depth, make(map[string]bool))
+}
+
+// propagate scores cid, with path holding the claims citing it
+func propagate(ctx context.Context, s Store, rs *claim.ReputationStore, cid string, depth int, path map[string]bool) float64 {
+	c, err := s.Get(ctx, cid)
+	if err != nil {
+		return 0
+	}
+	own := claim.ClaimConfidence(c, rs)
+	if own == 0 || depth <= 0 {
+		return own
+	}
+
+	path[cid] = true
+	defer delete(path, cid)
+
+	var total float64
+	cited := 0
+	for _, ev := range c.Evidence {
+		if path[ev] {
+			continue
+		}
+		if has, err := s.Has(ctx, ev); err != nil || !has {
+			continue
+		}
+		total += propagate(ctx, s, rs, ev, depth-1, path)
+		cited++
+	}
+	if cited == 0 {
+		return own
+	}
+	return (1-propagationWeight)*own + propagationWeight*total/float64(cited)
+}
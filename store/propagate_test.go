@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// citingStore makes one stored claim cite another, which content
+// addressing otherwise rules out for a cycle
+type citingStore struct {
+	Store
+	from, to string
+}
+
+func (s citingStore) Get(ctx context.Context, cid string) (*claim.Claim, error) {
+	c, err := s.Store.Get(ctx, cid)
+	if err != nil || cid != s.from {
+		return c, err
+	}
+	copied := *c
+	copied.Evidence = append(append([]string(nil), c.Evidence...), s.to)
+	return &copied, nil
+}
+
+func TestPropagatedConfidence(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	rs := claim.NewReputationStore()
+
+	put := func(subject string, evidence []string, endorse []string, dispute []string) string {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: "sports"}, evidence, "")
+		require.NoError(t, err)
+		for _, seed := range endorse {
+			att, err := claim.DeterministicWitness(seed).Attest(c)
+			require.NoError(t, err)
+			require.NoError(t, c.AddAttestation(att))
+		}
+		for _, seed := range dispute {
+			att, err := claim.DeterministicWitness(seed).Dispute(c)
+			require.NoError(t, err)
+			require.NoError(t, c.AddAttestation(att))
+		}
+		cid, err := s.Put(ctx, c)
+		require.NoError(t, err)
+		return cid
+	}
+
+	trusted := put("match-1", nil, []string{"alice", "bob", "carol"}, nil)
+	disputed := put("match-2", nil, []string{"alice"}, []string{"bob", "carol"})
+	citesTrusted := put("report-1", []string{trusted}, []string{"dave"}, nil)
+	citesDisputed := put("report-2", []string{disputed}, []string{"dave"}, nil)
+
+	t.Run("evidence confidence carries over", func(t *testing.T) {
+		high := PropagatedConfidence(ctx, s, rs, citesTrusted, 2)
+		low := PropagatedConfidence(ctx, s, rs, citesDisputed, 2)
+		assert.Greater(t, high, low)
+	})
+
+	t.Run("depth zero is the claim's own confidence", func(t *testing.T) {
+		c, err := s.Get(ctx, citesTrusted)
+		require.NoError(t, err)
+		assert.Equal(t, claim.ClaimConfidence(c, rs), PropagatedConfidence(ctx, s, rs, citesTrusted, 0))
+	})
+
+	t.Run("unendorsed claim stays at zero", func(t *testing.T) {
+		bare := put("report-3", []string{trusted}, nil, nil)
+		assert.Zero(t, PropagatedConfidence(ctx, s, rs, bare, 2))
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		cyclic := citingStore{Store: s, from: trusted, to: citesTrusted}
+		score := PropagatedConfidence(ctx, cyclic, rs, citesTrusted, 100)
+		assert.Greater(t, score, 0.0)
+		assert.LessOrEqual(t, score, 1.0)
+	})
+
+	t.Run("missing claim", func(t *testing.T) {
+		assert.Zero(t, PropagatedConfidence(ctx, s, rs, "bafymissing", 2))
+	})
+}
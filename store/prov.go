@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// PROV-JSON namespace prefixes used in exported documents
+const (
+	provNamespace    = "https://github.com/systemshift/claim-graph/ns#"
+	provCIDPrefix    = "ipfs://"
+	provWitnessURN   = "urn:claim-graph:witness:"
+	provTimeLayout   = time.RFC3339Nano
+	provResolveLimit = 30 * time.Second
+)
+
+// provDocument is a W3C PROV-JSON document
+type provDocument struct {
+	Prefix            map[string]string                 `json:"prefix"`
+	Entity            map[string]map[string]interface{} `json:"entity"`
+	Activity          map[string]map[string]interface{} `json:"activity,omitempty"`
+	Agent             map[string]map[string]interface{} `json:"agent,omitempty"`
+	Used              map[string]map[string]interface{} `json:"used,omitempty"`
+	WasAssociatedWith map[string]map[string]interface{} `json:"wasAssociatedWith,omitempty"`
+	WasDerivedFrom    map[string]map[string]interface{} `json:"wasDerivedFrom,omitempty"`
+}
+
+// ToPROV exports a claim's provenance as a W3C PROV-O document in the
+// PROV-JSON serialization. The claim is an Entity, each witness
+// attestation is an Activity associated with the witness as an Agent,
+// and evidence is linked with wasDerivedFrom. Evidence that resolves to
+// a claim in s is described with its statement; s may be nil.
+func ToPROV(c *claim.Claim, s Store) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("claim cannot be nil")
+	}
+
+	doc := provDocument{
+		Prefix: map[string]string{
+			"cg":      provNamespace,
+			"cid":     provCIDPrefix,
+			"witness": provWitnessURN,
+		},
+		Entity:            make(map[string]map[string]interface{}),
+		Activity:          make(map[string]map[string]interface{}),
+		Agent:             make(map[string]map[string]interface{}),
+		Used:              make(map[string]map[string]interface{}),
+		WasAssociatedWith: make(map[string]map[string]interface{}),
+		WasDerivedFrom:    make(map[string]map[string]interface{}),
+	}
+
+	claimID := "cid:" + c.ID
+	doc.Entity[claimID] = provClaimEntity(c)
+
+	// Evidence is what the claim was derived from
+	ctx, cancel := context.WithTimeout(context.Background(), provResolveLimit)
+	defer cancel()
+
+	for i, ev := range c.Evidence {
+		evID := "cid:" + ev
+		entity := map[string]interface{}{"prov:type": "cg:Evidence"}
+		if s != nil {
+			if exists, err := s.Has(ctx, ev); err == nil && exists {
+				if evClaim, err := s.Get(ctx, ev); err == nil {
+					entity = provClaimEntity(evClaim)
+				}
+			}
+		}
+		doc.Entity[evID] = entity
+		doc.WasDerivedFrom[fmt.Sprintf("_:derivation%d", i)] = map[string]interface{}{
+			"prov:generatedEntity": claimID,
+			"prov:usedEntity":      evID,
+		}
+	}
+
+	// Each attestation is an activity carried out by a witness
+	for i, att := range c.Witnesses {
+		agentID := "witness:" + att.WitnessID
+		activityID := fmt.Sprintf("cg:attestation/%s/%s", c.ID, att.WitnessID)
+		ts := att.Timestamp.UTC().Format(provTimeLayout)
+
+		activity := map[string]interface{}{
+			"prov:type":      "cg:Attestation",
+			"prov:startTime": ts,
+			"prov:endTime":   ts,
+			"cg:stance":      att.Stance.String(),
+		}
+		if c.TimeEvent != "" {
+			activity["cg:timeEvent"] = c.TimeEvent
+		}
+		if ac := att.Context; ac != nil {
+			activity["cg:method"] = ac.Method
+			if len(ac.Evidence) > 0 {
+				activity["cg:examined"] = ac.Evidence
+			}
+			if !ac.VerifiedAt.IsZero() {
+				activity["cg:verifiedAt"] = ac.VerifiedAt.UTC().Format(provTimeLayout)
+			}
+		}
+
+		doc.Agent[agentID] = map[string]interface{}{"prov:type": "prov:SoftwareAgent"}
+		doc.Activity[activityID] = activity
+		doc.Used[fmt.Sprintf("_:usage%d", i)] = map[string]interface{}{
+			"prov:activity": activityID,
+			"prov:entity":   claimID,
+			"prov:time":     ts,
+		}
+		doc.WasAssociatedWith[fmt.Sprintf("_:association%d", i)] = map[string]interface{}{
+			"prov:activity": activityID,
+			"prov:agent":    agentID,
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func provClaimEntity(c *claim.Claim) map[string]interface{} {
+	entity := map[string]interface{}{
+		"prov:type":            "cg:Claim",
+		"cg:subject":           c.Statement.Subject,
+		"cg:predicate":         c.Statement.Predicate,
+		"cg:object":            c.Statement.Object,
+		"prov:generatedAtTime": c.Created.UTC().Format(provTimeLayout),
+	}
+	if c.Statement.Domain != "" {
+		entity["cg:domain"] = c.Statement.Domain
+	}
+	if c.TimeEvent != "" {
+		entity["cg:timeEvent"] = c.TimeEvent
+	}
+	return entity
+}
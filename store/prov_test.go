@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// fixedWitness returns a witness with a key derived from a fixed seed byte
+func fixedWitness(seed byte) *claim.Witness {
+	seedBytes := make([]byte, ed25519.SeedSize)
+	for i := range seedBytes {
+		seedBytes[i] = seed
+	}
+	priv := ed25519.NewKeyFromSeed(seedBytes)
+	pub := priv.Public().(ed25519.PublicKey)
+
+	return &claim.Witness{
+		ID:         hex.EncodeToString(pub),
+		PublicKey:  pub,
+		PrivateKey: priv,
+		Metadata:   make(map[string]string),
+	}
+}
+
+func TestToPROV(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	evidence := &claim.Claim{
+		Statement: claim.Statement{Subject: "https://example.com/match-123", Predicate: "score", Object: "2-1", Domain: "sports"},
+		Created:   created.Add(-time.Hour),
+	}
+	evidenceCID, err := claim.ComputeCID(evidence)
+	require.NoError(t, err)
+	evidence.ID = evidenceCID
+	_, err = s.Put(ctx, evidence)
+	require.NoError(t, err)
+
+	c := &claim.Claim{
+		Statement: claim.Statement{Subject: "https://example.com/match-123", Predicate: "result", Object: "home win", Domain: "sports"},
+		Evidence:  []string{evidenceCID, "bafkreiexternalevidence"},
+		TimeEvent: "dag-time-event-42",
+		Created:   created,
+	}
+	c.ID, err = claim.ComputeCID(c)
+	require.NoError(t, err)
+
+	for i, w := range []*claim.Witness{fixedWitness(1), fixedWitness(2)} {
+		w.Clock = claim.FixedClock(created.Add(time.Duration(i+1) * time.Minute))
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+	}
+
+	out, err := ToPROV(c, s)
+	require.NoError(t, err)
+
+	golden := filepath.Join("testdata", "prov_claim.golden.json")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll("testdata", 0755))
+		require.NoError(t, os.WriteFile(golden, out, 0644))
+	}
+
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(out))
+
+	t.Run("structure", func(t *testing.T) {
+		var doc provDocument
+		require.NoError(t, json.Unmarshal(out, &doc))
+
+		assert.Len(t, doc.Entity, 3)
+		assert.Len(t, doc.Activity, 2)
+		assert.Len(t, doc.Agent, 2)
+		assert.Len(t, doc.WasDerivedFrom, 2)
+		assert.Equal(t, "cg:Claim", doc.Entity["cid:"+evidenceCID]["prov:type"])
+		assert.Equal(t, "cg:Evidence", doc.Entity["cid:bafkreiexternalevidence"]["prov:type"])
+	})
+
+	t.Run("nil store", func(t *testing.T) {
+		out, err := ToPROV(c, nil)
+		require.NoError(t, err)
+
+		var doc provDocument
+		require.NoError(t, json.Unmarshal(out, &doc))
+		assert.Equal(t, "cg:Evidence", doc.Entity["cid:"+evidenceCID]["prov:type"])
+	})
+}
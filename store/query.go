@@ -0,0 +1,409 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// Query limits keep parsing cheap for queries taken from untrusted input
+const (
+	maxQueryLength = 1024
+	maxQueryDepth  = 32
+)
+
+// Predicate reports whether a claim matches a filter condition
+type Predicate func(c *claim.Claim) bool
+
+// And matches claims that match every predicate
+func And(preds ...Predicate) Predicate {
+	return func(c *claim.Claim) bool {
+		for _, p := range preds {
+			if !p(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches claims that match any predicate
+func Or(preds ...Predicate) Predicate {
+	return func(c *claim.Claim) bool {
+		for _, p := range preds {
+			if p(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches claims that do not match p
+func Not(p Predicate) Predicate {
+	return func(c *claim.Claim) bool {
+		return !p(c)
+	}
+}
+
+// ParseQuery parses a query string into a predicate, for use as
+// Filter.Predicate. A query is a set of terms combined with AND, OR and NOT
+// (AND binds tighter than OR) and grouped with parentheses:
+//
+//	domain:finance AND (witness:ab12... OR witness:cd34...) AND created>2024-01-01
+//
+// Terms are field, operator and value with no spaces between them.
+// Values containing spaces or parentheses are double-quoted, with Go
+// escapes. The fields are:
+//
+//	domain, subject, predicate           exact match (:)
+//	object                               the object, or an element of the
+//	                                     object list (:)
+//	namespace                            application namespace (:)
+//	witness                              has an attestation from the witness (:)
+//	state                                lifecycle state name (:)
+//	meta.<key>                           metadata value (:)
+//	created                              creation time (<, <=, >, >=), as a
+//	                                     date (2006-01-02) or RFC 3339 time
+//
+// Queries are only ever matched against claims, never evaluated, and are
+// limited to 1024 bytes and 32 levels of nesting.
+func ParseQuery(s string) (Predicate, error) {
+	if len(s) > maxQueryLength {
+		return nil, fmt.Errorf("query is longer than %d bytes", maxQueryLength)
+	}
+
+	tokens, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("query is empty")
+	}
+
+	p := &queryParser{tokens: tokens}
+	pred, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %s", p.tokens[p.pos])
+	}
+	return pred, nil
+}
+
+// queryTokenKind classifies query tokens
+type queryTokenKind int
+
+const (
+	tokenTerm queryTokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+// queryToken is a lexed query token. Terms carry their parts.
+type queryToken struct {
+	kind   queryTokenKind
+	offset int
+	field  string
+	op     string
+	value  string
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	case tokenOpen:
+		return `"("`
+	case tokenClose:
+		return `")"`
+	}
+	return fmt.Sprintf("term %s%s%s", t.field, t.op, t.value)
+}
+
+// lexQuery splits a query into tokens
+func lexQuery(s string) ([]queryToken, error) {
+	var tokens []queryToken
+	i := 0
+	for i < len(s) {
+		switch ch := s[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '(':
+			tokens = append(tokens, queryToken{kind: tokenOpen, offset: i})
+			i++
+		case ch == ')':
+			tokens = append(tokens, queryToken{kind: tokenClose, offset: i})
+			i++
+		default:
+			tok, next, err := lexWord(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// lexWord reads a keyword or term starting at i
+func lexWord(s string, i int) (queryToken, int, error) {
+	start := i
+	for i < len(s) && isFieldChar(s[i]) {
+		i++
+	}
+	field := s[start:i]
+
+	// A bare word is a keyword
+	if i == len(s) || isQueryBreak(s[i]) {
+		switch field {
+		case "AND":
+			return queryToken{kind: tokenAnd, offset: start}, i, nil
+		case "OR":
+			return queryToken{kind: tokenOr, offset: start}, i, nil
+		case "NOT":
+			return queryToken{kind: tokenNot, offset: start}, i, nil
+		}
+		return queryToken{}, 0, fmt.Errorf("query: expected a term at offset %d, got %q", start, field)
+	}
+	if field == "" {
+		return queryToken{}, 0, fmt.Errorf("query: unexpected %q at offset %d", s[i], i)
+	}
+
+	var op string
+	for _, candidate := range []string{">=", "<=", ":", ">", "<"} {
+		if strings.HasPrefix(s[i:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return queryToken{}, 0, fmt.Errorf("query: unexpected %q at offset %d", s[i], i)
+	}
+	i += len(op)
+
+	var value string
+	if i < len(s) && s[i] == '"' {
+		quoted, err := strconv.QuotedPrefix(s[i:])
+		if err != nil {
+			return queryToken{}, 0, fmt.Errorf("query: unterminated string at offset %d", i)
+		}
+		value, _ = strconv.Unquote(quoted)
+		i += len(quoted)
+		if i < len(s) && !isQueryBreak(s[i]) {
+			return queryToken{}, 0, fmt.Errorf("query: unexpected %q at offset %d", s[i], i)
+		}
+	} else {
+		valueStart := i
+		for i < len(s) && !isQueryBreak(s[i]) {
+			if s[i] == '"' {
+				return queryToken{}, 0, fmt.Errorf("query: unexpected quote at offset %d", i)
+			}
+			i++
+		}
+		value = s[valueStart:i]
+	}
+	if value == "" {
+		return queryToken{}, 0, fmt.Errorf("query: term %s%s has no value", field, op)
+	}
+
+	return queryToken{kind: tokenTerm, offset: start, field: field, op: op, value: value}, i, nil
+}
+
+func isFieldChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+		ch == '_' || ch == '-' || ch == '.'
+}
+
+func isQueryBreak(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '(' || ch == ')'
+}
+
+// queryParser is a recursive descent parser over lexed tokens:
+//
+//	or   = and { "OR" and }
+//	and  = not { "AND" not }
+//	not  = "NOT" not | "(" or ")" | term
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	offset := -1
+	if p.pos < len(p.tokens) {
+		offset = p.tokens[p.pos].offset
+	}
+	if offset < 0 {
+		return fmt.Errorf("query: "+format+" at end of query", args...)
+	}
+	return fmt.Errorf("query: "+format+" at offset %d", append(args, offset)...)
+}
+
+func (p *queryParser) accept(kind queryTokenKind) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) parseOr(depth int) (Predicate, error) {
+	first, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	preds := []Predicate{first}
+	for p.accept(tokenOr) {
+		next, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, next)
+	}
+	if len(preds) == 1 {
+		return first, nil
+	}
+	return Or(preds...), nil
+}
+
+func (p *queryParser) parseAnd(depth int) (Predicate, error) {
+	first, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+	preds := []Predicate{first}
+	for p.accept(tokenAnd) {
+		next, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, next)
+	}
+	if len(preds) == 1 {
+		return first, nil
+	}
+	return And(preds...), nil
+}
+
+func (p *queryParser) parseNot(depth int) (Predicate, error) {
+	if depth >= maxQueryDepth {
+		return nil, p.errorf("query nested more than %d levels", maxQueryDepth)
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, p.errorf("expected a term")
+	}
+
+	switch tok := p.tokens[p.pos]; tok.kind {
+	case tokenNot:
+		p.pos++
+		inner, err := p.parseNot(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Not(inner), nil
+	case tokenOpen:
+		p.pos++
+		inner, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(tokenClose) {
+			return nil, p.errorf("expected \")\"")
+		}
+		return inner, nil
+	case tokenTerm:
+		pred, err := termPredicate(tok)
+		if err != nil {
+			return nil, fmt.Errorf("query: %w at offset %d", err, tok.offset)
+		}
+		p.pos++
+		return pred, nil
+	default:
+		return nil, p.errorf("unexpected %s", tok)
+	}
+}
+
+// termPredicate builds the predicate for a single term
+func termPredicate(tok queryToken) (Predicate, error) {
+	value := tok.value
+
+	if tok.field == "created" {
+		return createdPredicate(tok.op, value)
+	}
+	if tok.op != ":" {
+		return nil, fmt.Errorf("field %s only supports \":\"", tok.field)
+	}
+
+	switch tok.field {
+	case "namespace":
+		return func(c *claim.Claim) bool { return c.Namespace == value }, nil
+	case "domain":
+		return func(c *claim.Claim) bool { return c.Statement.Domain == value }, nil
+	case "subject":
+		return func(c *claim.Claim) bool { return c.Statement.Subject == value }, nil
+	case "predicate":
+		return func(c *claim.Claim) bool { return c.Statement.Predicate == value }, nil
+	case "object":
+		return func(c *claim.Claim) bool { return c.HasObject(value) }, nil
+	case "witness":
+		return func(c *claim.Claim) bool {
+			for _, att := range c.Witnesses {
+				if att.WitnessID == value {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "state":
+		var state claim.State
+		if err := state.UnmarshalText([]byte(value)); err != nil {
+			return nil, err
+		}
+		return func(c *claim.Claim) bool { return c.State == state }, nil
+	}
+
+	if key := strings.TrimPrefix(tok.field, "meta."); key != tok.field && key != "" {
+		return func(c *claim.Claim) bool {
+			got, ok := c.Metadata[key]
+			return ok && got == value
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown field %q", tok.field)
+}
+
+// createdPredicate compares claims' creation time with a date or RFC 3339
+// time
+func createdPredicate(op, value string) (Predicate, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", value); err != nil {
+			return nil, fmt.Errorf("invalid created time %q (want 2006-01-02 or RFC 3339)", value)
+		}
+	}
+
+	switch op {
+	case ">":
+		return func(c *claim.Claim) bool { return c.Created.After(t) }, nil
+	case ">=":
+		return func(c *claim.Claim) bool { return !c.Created.Before(t) }, nil
+	case "<":
+		return func(c *claim.Claim) bool { return c.Created.Before(t) }, nil
+	case "<=":
+		return func(c *claim.Claim) bool { return !c.Created.After(t) }, nil
+	}
+	return nil, fmt.Errorf("field created does not support %q", op)
+}
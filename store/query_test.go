@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestParseQuery(t *testing.T) {
+	x, _ := claim.GenerateWitness()
+	y, _ := claim.GenerateWitness()
+	z, _ := claim.GenerateWitness()
+
+	// newClaim builds a claim created at the given date, attested by w
+	newClaim := func(domain, subject, date string, w *claim.Witness) *claim.Claim {
+		created, err := time.Parse("2006-01-02", date)
+		require.NoError(t, err)
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Predicate: "reported", Domain: domain}, nil, "")
+		require.NoError(t, err)
+		c.Created = created
+		c.ID, err = claim.ComputeCID(c)
+		require.NoError(t, err)
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		c.Metadata = map[string]string{"source": "wire service"}
+		return c
+	}
+
+	earnings := newClaim("finance", "ACME earnings", "2024-03-01", x)
+	rates := newClaim("finance", "rates", "2024-06-01", y)
+	old := newClaim("finance", "rates", "2023-06-01", x)
+	other := newClaim("finance", "rates", "2024-06-01", z)
+	match := newClaim("sports", "match", "2024-06-01", x)
+	all := map[string]*claim.Claim{"earnings": earnings, "rates": rates, "old": old, "other": other, "match": match}
+
+	for query, want := range map[string][]string{
+		"domain:finance": {"earnings", "rates", "old", "other"},
+		"domain:finance AND (witness:" + x.ID + " OR witness:" + y.ID + ") AND created>2024-01-01": {"earnings", "rates"},
+		"witness:" + x.ID + " OR witness:" + y.ID + " AND domain:sports":                           {"earnings", "old", "match"},
+		`subject:"ACME earnings"`:                              {"earnings"},
+		"NOT domain:finance":                                   {"match"},
+		"NOT (domain:finance OR subject:match)":                {},
+		"created>=2024-06-01 AND created<2024-06-01T00:00:01Z": {"rates", "other", "match"},
+		"created<=2023-06-01":                                  {"old"},
+		`meta.source:"wire service" AND predicate:reported`:    {"earnings", "rates", "old", "other", "match"},
+		"((domain:sports))":                                    {"match"},
+		"domain:finance AND NOT NOT witness:" + z.ID:           {"other"},
+	} {
+		pred, err := ParseQuery(query)
+		require.NoError(t, err, query)
+
+		got := []string{}
+		for name, c := range all {
+			if pred(c) {
+				got = append(got, name)
+			}
+		}
+		assert.ElementsMatch(t, want, got, query)
+	}
+
+	t.Run("malformed queries rejected", func(t *testing.T) {
+		for _, query := range []string{
+			"",
+			"   ",
+			"finance",
+			"domain:",
+			`object:""`,
+			"domain:finance AND",
+			"AND domain:finance",
+			"domain:finance OR OR domain:sports",
+			"domain:finance domain:sports",
+			"(domain:finance",
+			"domain:finance)",
+			"()",
+			`subject:"unterminated`,
+			`subject:"quoted"trailing`,
+			`subject:bare"quote`,
+			"colour:red",
+			"meta.:x",
+			"domain>finance",
+			"created:2024-01-01",
+			"created>yesterday",
+			"state:pending",
+			"domain=finance",
+			"domain:finance; DROP TABLE claims",
+			strings.Repeat("(", maxQueryDepth+1) + "domain:x" + strings.Repeat(")", maxQueryDepth+1),
+			strings.Repeat("NOT ", maxQueryDepth+1) + "domain:x",
+			"domain:" + strings.Repeat("x", maxQueryLength),
+		} {
+			_, err := ParseQuery(query)
+			assert.Error(t, err, query)
+		}
+	})
+
+	t.Run("used by List", func(t *testing.T) {
+		ctx := context.Background()
+		s := newTestStore(t)
+		for _, c := range all {
+			_, err := s.Put(ctx, c)
+			require.NoError(t, err)
+		}
+
+		pred, err := ParseQuery("domain:finance AND witness:" + x.ID)
+		require.NoError(t, err)
+		cids, err := s.List(ctx, &Filter{Predicate: pred})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{earnings.ID, old.ID}, cids)
+
+		cids, err = s.List(ctx, &Filter{Domain: "sports", Predicate: pred})
+		require.NoError(t, err)
+		assert.Empty(t, cids)
+	})
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// ErrReferenceCycle is returned for a claim whose evidence or revision
+// links lead back to the claim itself
+var ErrReferenceCycle = errors.New("reference cycle")
+
+// DanglingReferences audits the store's cross-references, returning, for
+// each claim whose evidence or Supersedes link names CIDs missing from s,
+// those missing CIDs in evidence order, then the superseded revision.
+// Claims with no dangling references are omitted.
+// Evidence pointing at content outside the store (such as raw IPFS data)
+// is reported too, since the store cannot see it.
+func DanglingReferences(ctx context.Context, s Store) (map[string][]string, error) {
+	cids, err := s.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Many claims tend to cite the same evidence
+	present := make(map[string]bool)
+	dangling := make(map[string][]string)
+
+	for _, cid := range cids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		c, err := s.Get(ctx, cid)
+		if err != nil {
+			return nil, fmt.Errorf("claim %s: %w", cid, err)
+		}
+
+		for _, ref := range references(c) {
+			exists, checked := present[ref]
+			if !checked {
+				exists, err = s.Has(ctx, ref)
+				if err != nil {
+					return nil, fmt.Errorf("claim %s: %w", cid, err)
+				}
+				present[ref] = exists
+			}
+			if !exists {
+				dangling[cid] = append(dangling[cid], ref)
+			}
+		}
+	}
+
+	return dangling, nil
+}
+
+// references returns the CIDs a claim links to: its evidence and the
+// revision it supersedes
+func references(c *claim.Claim) []string {
+	refs := c.Evidence
+	if c.Supersedes != "" {
+		refs = append(append([]string(nil), refs...), c.Supersedes)
+	}
+	return refs
+}
+
+// checkSelfReference rejects a claim that cites itself. A CID covers the
+// claim's evidence, so this only happens to claims whose ID was set by
+// hand.
+func checkSelfReference(c *claim.Claim) error {
+	for _, ref := range references(c) {
+		if ref == c.ID {
+			return fmt.Errorf("claim %s: %w: cites itself", c.ID, ErrReferenceCycle)
+		}
+	}
+	return nil
+}
+
+// CheckReferenceCycles reports whether storing c would close a cycle of
+// references through the claims in s, so graph traversals stay finite.
+// References to CIDs missing from s are not followed.
+func CheckReferenceCycles(ctx context.Context, s Store, c *claim.Claim) error {
+	if err := checkSelfReference(c); err != nil {
+		return err
+	}
+
+	visited := make(map[string]bool)
+	var path []string
+
+	var visit func(cid string) error
+	visit = func(cid string) error {
+		if cid == c.ID {
+			cycle := append(append([]string{c.ID}, path...), c.ID)
+			return fmt.Errorf("claim %s: %w: %s", c.ID, ErrReferenceCycle, strings.Join(cycle, " -> "))
+		}
+		if visited[cid] {
+			return nil
+		}
+		visited[cid] = true
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		exists, err := s.Has(ctx, cid)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+		ref, err := s.Get(ctx, cid)
+		if err != nil {
+			return fmt.Errorf("claim %s: %w", cid, err)
+		}
+
+		path = append(path, cid)
+		for _, next := range references(ref) {
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		return nil
+	}
+
+	for _, ref := range references(c) {
+		if err := visit(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
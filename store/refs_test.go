@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestDanglingReferences(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	put := func(subject string, evidence ...string) *claim.Claim {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: "refs"}, evidence, "")
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		return c
+	}
+
+	source := put("source")
+	intact := put("intact", source.ID)
+	broken := put("broken", source.ID, "bafkreimissing1", "bafkreimissing2")
+	other := put("other", "bafkreimissing1")
+
+	dangling, err := DanglingReferences(ctx, s)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		broken.ID: {"bafkreimissing1", "bafkreimissing2"},
+		other.ID:  {"bafkreimissing1"},
+	}, dangling)
+	assert.NotContains(t, dangling, intact.ID)
+
+	t.Run("missing superseded revision", func(t *testing.T) {
+		revision, err := claim.NewClaim(claim.Statement{Subject: "revised", Domain: "refs"}, nil, "", claim.WithSupersedes("bafkreimissing3"))
+		require.NoError(t, err)
+		_, err = s.Put(ctx, revision)
+		require.NoError(t, err)
+
+		dangling, err := DanglingReferences(ctx, s)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bafkreimissing3"}, dangling[revision.ID])
+	})
+
+	t.Run("deleting a source breaks its references", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, source.ID))
+
+		dangling, err := DanglingReferences(ctx, s)
+		require.NoError(t, err)
+		assert.Equal(t, []string{source.ID}, dangling[intact.ID])
+		assert.Equal(t, []string{source.ID, "bafkreimissing1", "bafkreimissing2"}, dangling[broken.ID])
+	})
+}
+
+func TestReferenceCycles(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeIPFS(t)
+	s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, CheckReferenceCycles: true})
+	require.NoError(t, err)
+
+	// CIDs cover evidence, so cycles need claims whose IDs were set by hand
+	withID := func(id, subject string, evidence []string, opts ...claim.ClaimOption) *claim.Claim {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: "refs"}, evidence, "", opts...)
+		require.NoError(t, err)
+		c.ID = id
+		return c
+	}
+
+	t.Run("direct self-reference", func(t *testing.T) {
+		_, err := s.Put(ctx, withID("bafkreiself", "self", []string{"bafkreiself"}))
+		assert.ErrorIs(t, err, ErrReferenceCycle)
+
+		unchecked := newTestStore(t)
+		_, err = unchecked.Put(ctx, withID("bafkreiself", "self", []string{"bafkreiself"}))
+		assert.ErrorIs(t, err, ErrReferenceCycle)
+	})
+
+	t.Run("transitive cycle", func(t *testing.T) {
+		_, err := s.Put(ctx, withID("bafkreia", "a", []string{"bafkreib"}))
+		require.NoError(t, err)
+		_, err = s.Put(ctx, withID("bafkreib", "b", nil, claim.WithSupersedes("bafkreic")))
+		require.NoError(t, err)
+
+		c := withID("bafkreic", "c", []string{"bafkreia"})
+		_, err = s.Put(ctx, c)
+		require.ErrorIs(t, err, ErrReferenceCycle)
+		assert.Contains(t, err.Error(), "bafkreic -> bafkreia -> bafkreib -> bafkreic")
+
+		has, err := s.Has(ctx, "bafkreic")
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("shared references are not cycles", func(t *testing.T) {
+		source := withID("bafkreisource", "source", nil)
+		_, err := s.Put(ctx, source)
+		require.NoError(t, err)
+		_, err = s.Put(ctx, withID("bafkreileft", "left", []string{source.ID}))
+		require.NoError(t, err)
+		_, err = s.Put(ctx, withID("bafkreitop", "top", []string{"bafkreileft", source.ID, "bafkreimissing"}))
+		assert.NoError(t, err)
+	})
+}
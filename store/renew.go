@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// RenewAttestation renews the witness's latest attestation on a stored
+// claim and stores the claim with the renewal replacing it (see
+// claim.Witness.Renew). It returns the renewed attestation.
+func RenewAttestation(ctx context.Context, s Store, w *claim.Witness, cid string) (*claim.Attestation, error) {
+	c, err := s.Get(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing *claim.Attestation
+	for i := range c.Witnesses {
+		att := &c.Witnesses[i]
+		if att.WitnessID == w.ID && (existing == nil || att.NewerThan(existing)) {
+			existing = att
+		}
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("witness %s has not attested to claim %s", w.ID, cid)
+	}
+
+	renewed, err := w.Renew(c, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on a copy so a failed Put leaves the stored claim untouched
+	updated := *c
+	updated.Witnesses = append([]claim.Attestation(nil), c.Witnesses...)
+	if err := updated.AddAttestationWith(renewed, claim.AttestOptions{Duplicates: claim.ReplaceLatest}); err != nil {
+		return nil, err
+	}
+	if _, err := s.Put(ctx, &updated); err != nil {
+		return nil, err
+	}
+
+	return renewed, nil
+}
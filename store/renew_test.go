@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestRenewAttestation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	w := claim.DeterministicWitness("registrar")
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "license-7", Domain: "registry"}, nil, "")
+	require.NoError(t, err)
+	att, err := w.AttestUntil(c, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+	_, err = s.Put(ctx, c)
+	require.NoError(t, err)
+	before := s.Version(c.ID)
+
+	renewed, err := RenewAttestation(ctx, s, w, c.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, before, s.Version(c.ID))
+
+	stored, err := s.Get(ctx, c.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Witnesses, 1)
+	assert.Equal(t, renewed.Signature, stored.Witnesses[0].Signature)
+	assert.True(t, stored.Witnesses[0].ExpiresAt.After(att.ExpiresAt))
+	assert.NoError(t, stored.VerifyAllAttestations())
+
+	t.Run("witness without an attestation", func(t *testing.T) {
+		_, err := RenewAttestation(ctx, s, claim.DeterministicWitness("stranger"), c.ID)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// Consistency selects which store serves reads in a ReplicatedStore
+type Consistency int
+
+const (
+	// Strong reads from the primary, always seeing the latest writes
+	// (the default)
+	Strong Consistency = iota
+
+	// Eventual reads from any replica, which may lag the primary
+	Eventual
+)
+
+// String returns the consistency level name
+func (c Consistency) String() string {
+	switch c {
+	case Strong:
+		return "strong"
+	case Eventual:
+		return "eventual"
+	default:
+		return fmt.Sprintf("Consistency(%d)", int(c))
+	}
+}
+
+type consistencyKey struct{}
+
+// WithReadConsistency returns a context that overrides a ReplicatedStore's
+// default consistency for reads made with it
+func WithReadConsistency(ctx context.Context, c Consistency) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, c)
+}
+
+// ReplicatedOption configures a ReplicatedStore
+type ReplicatedOption func(*ReplicatedStore)
+
+// WithConsistency sets the default read consistency
+func WithConsistency(c Consistency) ReplicatedOption {
+	return func(s *ReplicatedStore) {
+		s.consistency = c
+	}
+}
+
+// WithFanout makes writes to the primary also go to every replica.
+// Without it, replicas are expected to sync from the primary themselves.
+func WithFanout() ReplicatedOption {
+	return func(s *ReplicatedStore) {
+		s.fanout = true
+	}
+}
+
+// ReplicatedStore sends writes to a primary store and spreads reads
+// across read replicas according to a consistency level.
+type ReplicatedStore struct {
+	primary     Store
+	replicas    []Store
+	consistency Consistency
+	fanout      bool
+	next        atomic.Uint64
+}
+
+// NewReplicatedStore creates a store over a primary and its read replicas
+func NewReplicatedStore(primary Store, replicas []Store, opts ...ReplicatedOption) *ReplicatedStore {
+	s := &ReplicatedStore{
+		primary:  primary,
+		replicas: replicas,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// reader picks the store to read from for the given context
+func (s *ReplicatedStore) reader(ctx context.Context) Store {
+	consistency := s.consistency
+	if c, ok := ctx.Value(consistencyKey{}).(Consistency); ok {
+		consistency = c
+	}
+
+	if consistency == Strong || len(s.replicas) == 0 {
+		return s.primary
+	}
+
+	// Round-robin across replicas
+	i := s.next.Add(1) - 1
+	return s.replicas[i%uint64(len(s.replicas))]
+}
+
+// Put writes to the primary, then to each replica if fanout is enabled
+func (s *ReplicatedStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
+	cid, err := s.primary.Put(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	if s.fanout {
+		var errs []error
+		for i, replica := range s.replicas {
+			if _, err := replica.Put(ctx, c); err != nil {
+				errs = append(errs, fmt.Errorf("replica %d: %w", i, err))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return cid, fmt.Errorf("stored on primary but fanout failed: %w", err)
+		}
+	}
+
+	return cid, nil
+}
+
+// Delete removes from the primary, then from each replica if fanout is
+// enabled. It returns ErrDeleteUnsupported if the primary does not
+// implement Deleter.
+func (s *ReplicatedStore) Delete(ctx context.Context, cid string) error {
+	if err := deleteFrom(ctx, s.primary, cid); err != nil {
+		return err
+	}
+
+	if s.fanout {
+		var errs []error
+		for i, replica := range s.replicas {
+			if err := deleteFrom(ctx, replica, cid); err != nil {
+				errs = append(errs, fmt.Errorf("replica %d: %w", i, err))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return fmt.Errorf("deleted on primary but fanout failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Get reads a claim at the context's consistency level. A replica that
+// fails (for instance, one that has not yet received the claim) falls
+// back to the primary.
+func (s *ReplicatedStore) Get(ctx context.Context, cid string) (*claim.Claim, error) {
+	reader := s.reader(ctx)
+	c, err := reader.Get(ctx, cid)
+	if err != nil && reader != s.primary {
+		return s.primary.Get(ctx, cid)
+	}
+	return c, err
+}
+
+// Has checks for a claim at the context's consistency level
+func (s *ReplicatedStore) Has(ctx context.Context, cid string) (bool, error) {
+	reader := s.reader(ctx)
+	has, err := reader.Has(ctx, cid)
+	if err != nil && reader != s.primary {
+		return s.primary.Has(ctx, cid)
+	}
+	return has, err
+}
+
+// List lists claims at the context's consistency level
+func (s *ReplicatedStore) List(ctx context.Context, filter *Filter) ([]string, error) {
+	reader := s.reader(ctx)
+	cids, err := reader.List(ctx, filter)
+	if err != nil && reader != s.primary {
+		return s.primary.List(ctx, filter)
+	}
+	return cids, err
+}
+
+// Close closes the primary and all replicas
+func (s *ReplicatedStore) Close() error {
+	errs := []error{s.primary.Close()}
+	for _, replica := range s.replicas {
+		errs = append(errs, replica.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestReplicatedStore(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := newTestStore(t), newTestStore(t)
+
+	// The replica holds a stale copy of the claim without the attestation
+	c, err := claim.NewClaim(claim.Statement{Subject: "match", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	stale := *c
+	_, err = replica.Put(ctx, &stale)
+	require.NoError(t, err)
+
+	w, _ := claim.GenerateWitness()
+	att, _ := w.Attest(c)
+	require.NoError(t, c.AddAttestation(att))
+	_, err = primary.Put(ctx, c)
+	require.NoError(t, err)
+
+	// Only the primary has this claim
+	fresh, err := claim.NewClaim(claim.Statement{Subject: "fresh", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	_, err = primary.Put(ctx, fresh)
+	require.NoError(t, err)
+
+	t.Run("strong reads see the primary", func(t *testing.T) {
+		s := NewReplicatedStore(primary, []Store{replica})
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Len(t, got.Witnesses, 1)
+
+		cids, err := s.List(ctx, &Filter{Domain: "sports"})
+		require.NoError(t, err)
+		assert.Len(t, cids, 2)
+	})
+
+	t.Run("eventual reads see the replica", func(t *testing.T) {
+		s := NewReplicatedStore(primary, []Store{replica}, WithConsistency(Eventual))
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Empty(t, got.Witnesses)
+
+		cids, err := s.List(ctx, &Filter{Domain: "sports"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{c.ID}, cids)
+
+		// A claim the replica lacks falls back to the primary
+		got, err = s.Get(ctx, fresh.ID)
+		require.NoError(t, err)
+		assert.Equal(t, fresh.ID, got.ID)
+	})
+
+	t.Run("context overrides default", func(t *testing.T) {
+		s := NewReplicatedStore(primary, []Store{replica}, WithConsistency(Eventual))
+
+		got, err := s.Get(WithReadConsistency(ctx, Strong), c.ID)
+		require.NoError(t, err)
+		assert.Len(t, got.Witnesses, 1)
+	})
+
+	t.Run("fanout writes to replicas", func(t *testing.T) {
+		p, r := newTestStore(t), newTestStore(t)
+		s := NewReplicatedStore(p, []Store{r}, WithFanout())
+
+		written, err := claim.NewClaim(claim.Statement{Subject: "written"}, nil, "")
+		require.NoError(t, err)
+		_, err = s.Put(ctx, written)
+		require.NoError(t, err)
+
+		has, err := r.Has(ctx, written.ID)
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		require.NoError(t, s.Delete(ctx, written.ID))
+		has, err = r.Has(ctx, written.ID)
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("writes skip replicas without fanout", func(t *testing.T) {
+		p, r := newTestStore(t), newTestStore(t)
+		s := NewReplicatedStore(p, []Store{r})
+
+		written, err := claim.NewClaim(claim.Statement{Subject: "written"}, nil, "")
+		require.NoError(t, err)
+		_, err = s.Put(ctx, written)
+		require.NoError(t, err)
+
+		has, err := r.Has(ctx, written.ID)
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+}
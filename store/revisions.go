@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// RevisionHistory follows a claim's Supersedes links back to the original
+// claim, returning the chain of revisions oldest first and ending with
+// the tip
+func RevisionHistory(ctx context.Context, s Store, tipCID string) ([]*claim.Claim, error) {
+	var chain []*claim.Claim
+	seen := make(map[string]bool)
+
+	for cid := tipCID; cid != ""; {
+		if seen[cid] {
+			return nil, fmt.Errorf("revision chain loops at %s", cid)
+		}
+		seen[cid] = true
+
+		c, err := s.Get(ctx, cid)
+		if err != nil {
+			return nil, fmt.Errorf("revision %s: %w", cid, err)
+		}
+		chain = append(chain, c)
+		cid = c.Supersedes
+	}
+
+	// Reverse into oldest-first order
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
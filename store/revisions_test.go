@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestRevisionHistory(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	var revisions []*claim.Claim
+	supersedes := ""
+	for _, object := range []string{"2-1", "2-2", "3-2"} {
+		var opts []claim.ClaimOption
+		if supersedes != "" {
+			opts = append(opts, claim.WithSupersedes(supersedes))
+		}
+		c, err := claim.NewClaim(claim.Statement{Subject: "match-42", Predicate: "final-score", Object: object, Domain: "sports"}, nil, "", opts...)
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+
+		revisions = append(revisions, c)
+		supersedes = c.ID
+	}
+
+	history, err := RevisionHistory(ctx, s, supersedes)
+	require.NoError(t, err)
+	assert.Equal(t, revisions, history)
+
+	log := claim.Changelog(history)
+	require.Len(t, log, 2)
+	assert.Equal(t, []claim.Change{{Field: "object", Old: "2-1", New: "2-2"}}, log[0].Changes)
+	assert.Equal(t, []claim.Change{{Field: "object", Old: "2-2", New: "3-2"}}, log[1].Changes)
+
+	t.Run("original claim is its own history", func(t *testing.T) {
+		history, err := RevisionHistory(ctx, s, revisions[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, revisions[:1], history)
+	})
+
+	t.Run("missing revision", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, revisions[1].ID))
+		_, err := RevisionHistory(ctx, s, revisions[2].ID)
+		assert.Error(t, err)
+	})
+}
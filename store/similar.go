@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// Embedder maps a statement to a vector for similarity search. It is
+// supplied by the caller, so the store carries no ML dependency.
+type Embedder interface {
+	Embed(statement claim.Statement) ([]float32, error)
+}
+
+// embedBestEffort embeds a claim's statement, returning nil if no
+// embedder is configured or embedding fails. Used when indexing claims
+// that were not stored through Put, where there is no caller to report to.
+func (s *IPFSStore) embedBestEffort(c *claim.Claim) []float32 {
+	if s.cfg.Embedder == nil {
+		return nil
+	}
+	vector, err := s.cfg.Embedder.Embed(c.Statement)
+	if err != nil {
+		return nil
+	}
+	return vector
+}
+
+// ListSimilar returns up to k claim CIDs whose statements are nearest to
+// the given statement by cosine similarity, most similar first. The index
+// is scanned in full, so cost grows linearly with the number of claims.
+func (s *IPFSStore) ListSimilar(ctx context.Context, statement claim.Statement, k int) ([]string, error) {
+	if s.cfg.Embedder == nil {
+		return nil, fmt.Errorf("store has no embedder configured")
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+
+	query, err := s.cfg.Embedder.Embed(statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed statement: %w", err)
+	}
+
+	type scored struct {
+		cid   string
+		score float64
+	}
+
+	s.mu.RLock()
+	results := make([]scored, 0, len(s.vectors))
+	for cid, vector := range s.vectors {
+		if len(vector) != len(query) {
+			continue
+		}
+		results = append(results, scored{cid: cid, score: cosineSimilarity(query, vector)})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].cid < results[j].cid
+	})
+
+	if len(results) > k {
+		results = results[:k]
+	}
+
+	cids := make([]string, len(results))
+	for i, r := range results {
+		cids[i] = r.cid
+	}
+	return cids, nil
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0
+// if either is a zero vector
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// fakeEmbedder returns fixed vectors keyed by statement subject
+type fakeEmbedder map[string][]float32
+
+func (f fakeEmbedder) Embed(statement claim.Statement) ([]float32, error) {
+	vector, ok := f[statement.Subject]
+	if !ok {
+		return nil, fmt.Errorf("no vector for %q", statement.Subject)
+	}
+	return vector, nil
+}
+
+func TestListSimilar(t *testing.T) {
+	embedder := fakeEmbedder{
+		"btc-price":   {1, 0, 0},
+		"eth-price":   {0.9, 0.1, 0},
+		"match-score": {0, 1, 0},
+		"weather":     {0, 0, 1},
+		"crypto":      {1, 0.05, 0},
+	}
+
+	f := newFakeIPFS(t)
+	s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, Embedder: embedder})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cids := make(map[string]string)
+	for _, subject := range []string{"btc-price", "eth-price", "match-score", "weather"} {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject}, nil, "")
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		cids[subject] = c.ID
+	}
+
+	similar, err := s.ListSimilar(ctx, claim.Statement{Subject: "crypto"}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{cids["btc-price"], cids["eth-price"]}, similar)
+
+	t.Run("k larger than store", func(t *testing.T) {
+		all, err := s.ListSimilar(ctx, claim.Statement{Subject: "weather"}, 10)
+		require.NoError(t, err)
+		require.Len(t, all, 4)
+		assert.Equal(t, cids["weather"], all[0])
+	})
+
+	t.Run("deleted claims are dropped", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, cids["btc-price"]))
+		similar, err := s.ListSimilar(ctx, claim.Statement{Subject: "crypto"}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{cids["eth-price"]}, similar)
+	})
+
+	t.Run("embedder failure fails Put", func(t *testing.T) {
+		c, _ := claim.NewClaim(claim.Statement{Subject: "unknown"}, nil, "")
+		_, err := s.Put(ctx, c)
+		assert.Error(t, err)
+
+		has, _ := s.Has(ctx, c.ID)
+		assert.False(t, has)
+	})
+
+	t.Run("requires an embedder", func(t *testing.T) {
+		_, err := newTestStore(t).ListSimilar(ctx, claim.Statement{Subject: "crypto"}, 1)
+		assert.Error(t, err)
+	})
+}
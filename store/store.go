@@ -3,6 +3,7 @@ package store
 
 import (
 	"context"
+	"errors"
 
 	"github.com/systemshift/claim-graph/claim"
 )
@@ -25,8 +26,35 @@ type Store interface {
 	Close() error
 }
 
+// ErrNotFound is returned when a store has no claim with the requested CID
+var ErrNotFound = errors.New("not found")
+
+// Deleter is implemented by stores that can remove claims
+type Deleter interface {
+	// Delete removes a claim
+	Delete(ctx context.Context, cid string) error
+}
+
+// ErrDeleteUnsupported is returned when deleting from a store that does not
+// implement Deleter
+var ErrDeleteUnsupported = errors.New("store does not support delete")
+
+// deleteFrom removes a claim from s, or returns ErrDeleteUnsupported if s
+// cannot remove claims
+func deleteFrom(ctx context.Context, s Store, cid string) error {
+	d, ok := s.(Deleter)
+	if !ok {
+		return ErrDeleteUnsupported
+	}
+	return d.Delete(ctx, cid)
+}
+
 // Filter specifies criteria for listing claims
 type Filter struct {
+	// Namespace filters by application namespace (empty matches claims in
+	// any namespace)
+	Namespace string
+
 	// Domain filters by statement domain
 	Domain string
 
@@ -36,6 +64,25 @@ type Filter struct {
 	// Subject filters by statement subject
 	Subject string
 
+	// Object filters by object: claims whose object is this value, or
+	// whose object list contains it
+	Object string
+
+	// State filters by lifecycle state (nil matches any state)
+	State *claim.State
+
+	// Quantity filters by numeric object range; claims without a quantity
+	// or in a different unit are excluded
+	Quantity *claim.QuantityRange
+
+	// Metadata filters by claim metadata; every key must be present with
+	// the given value. Keys in IPFSConfig.MetadataKeys are served from an
+	// index.
+	Metadata map[string]string
+
+	// Predicate, if set, must also match; see ParseQuery
+	Predicate Predicate
+
 	// Limit limits the number of results
 	Limit int
 
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// logOpTombstone is the index log operation recording a soft delete
+const logOpTombstone = "tombstone"
+
+// ErrDeleted is returned for claims that were deleted with a tombstone
+var ErrDeleted = errors.New("claim was deleted")
+
+type deleteReasonKey struct{}
+
+type deleterKey struct{}
+
+// WithDeleteReason returns a context that records why claims deleted with
+// it are being deleted, in their tombstones
+func WithDeleteReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, deleteReasonKey{}, reason)
+}
+
+// WithDeleter returns a context whose deletions are soft deletes signed by
+// the given witness, who the tombstone then records as the deleter in
+// place of the holder of IPFSConfig.TombstoneKey
+func WithDeleter(ctx context.Context, deleter *claim.Witness) context.Context {
+	return context.WithValue(ctx, deleterKey{}, deleter)
+}
+
+// deleter returns the witness a context's deletions are signed by, if any
+func deleter(ctx context.Context) *claim.Witness {
+	w, _ := ctx.Value(deleterKey{}).(*claim.Witness)
+	return w
+}
+
+// WasDeleted returns the tombstone of a claim deleted from the store
+func (s *IPFSStore) WasDeleted(cid string) (*claim.Tombstone, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.tombstones[cid]
+	if !ok {
+		return nil, false
+	}
+	copy := *t
+	return &copy, true
+}
+
+// deletedError reports an operation on a tombstoned claim
+func deletedError(t *claim.Tombstone) error {
+	return fmt.Errorf("claim %s: %w at %s", t.CID, ErrDeleted, t.DeletedAt.Format(time.RFC3339))
+}
+
+// tombstoneClaim deletes a claim, scrubs its content from the index log
+// and records a signed tombstone in its place. It returns the claim's
+// envelopes, for the caller to unpin once it has released s.mu.
+// Callers must hold s.mu.
+func (s *IPFSStore) tombstoneClaim(ctx context.Context, cid string) ([]string, error) {
+	reason, _ := ctx.Value(deleteReasonKey{}).(string)
+	var t *claim.Tombstone
+	var err error
+	if w := deleter(ctx); w != nil {
+		t, err = w.SignTombstone(cid, reason)
+	} else {
+		t, err = claim.IssueTombstone(s.cfg.TombstoneKey, cid, reason)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign tombstone: %w", err)
+	}
+
+	// The caller unpins the envelopes, rather than leaving them as garbage
+	envelopes := s.versions[cid]
+	s.removeClaim(cid)
+	delete(s.versions, cid)
+	s.tombstones[cid] = t
+
+	if s.log != nil {
+		if err := s.log.append(logEntry{Op: logOpTombstone, CID: cid, Tombstone: t}); err != nil {
+			return envelopes, fmt.Errorf("failed to persist index: %w", err)
+		}
+		if err := s.log.scrub(cid); err != nil {
+			return envelopes, fmt.Errorf("failed to scrub index log: %w", err)
+		}
+	}
+	return envelopes, nil
+}
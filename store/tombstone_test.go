@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeIPFS(t)
+	indexPath := filepath.Join(t.TempDir(), "index.log")
+	pub, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	cfg := IPFSConfig{APIURL: f.server.URL, IndexPath: indexPath, TombstoneKey: key}
+
+	s, err := NewIPFSStore(cfg)
+	require.NoError(t, err)
+
+	erased, err := claim.NewClaim(claim.Statement{Subject: "alice's address", Domain: "personal"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, erased)
+	require.NoError(t, err)
+	kept, err := claim.NewClaim(claim.Statement{Subject: "match-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, kept)
+	require.NoError(t, err)
+	require.Equal(t, 2, f.count())
+
+	_, deleted := s.WasDeleted(erased.ID)
+	assert.False(t, deleted)
+
+	require.NoError(t, s.Delete(WithDeleteReason(ctx, "GDPR erasure request #42"), erased.ID))
+
+	t.Run("content purged", func(t *testing.T) {
+		assert.Equal(t, 1, f.count())
+
+		log, err := os.ReadFile(indexPath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(log), "alice's address", "scrubbed from the index log")
+		assert.Contains(t, string(log), "match-1")
+
+		has, err := s.Has(ctx, erased.ID)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		_, err = s.Get(ctx, erased.ID)
+		assert.ErrorIs(t, err, ErrDeleted)
+
+		cids, err := s.List(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept.ID}, cids)
+		cids, err = s.List(ctx, &Filter{Domain: "personal"})
+		require.NoError(t, err)
+		assert.Empty(t, cids)
+	})
+
+	t.Run("tombstone retrieved", func(t *testing.T) {
+		tomb, ok := s.WasDeleted(erased.ID)
+		require.True(t, ok)
+		assert.Equal(t, erased.ID, tomb.CID)
+		assert.Equal(t, hex.EncodeToString(pub), tomb.DeleterID)
+		assert.Equal(t, "GDPR erasure request #42", tomb.Reason)
+		assert.False(t, tomb.DeletedAt.IsZero())
+		assert.NoError(t, claim.VerifyTombstone(tomb))
+	})
+
+	t.Run("deleted claim cannot be stored again", func(t *testing.T) {
+		_, err := s.Put(ctx, erased)
+		assert.ErrorIs(t, err, ErrDeleted)
+	})
+
+	t.Run("tombstones persist across restart and compaction", func(t *testing.T) {
+		_, err := s.Compact(ctx)
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+
+		reopened, err := NewIPFSStore(cfg)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		tomb, ok := reopened.WasDeleted(erased.ID)
+		require.True(t, ok)
+		assert.NoError(t, claim.VerifyTombstone(tomb))
+		assert.Equal(t, "GDPR erasure request #42", tomb.Reason)
+
+		cids, err := reopened.List(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept.ID}, cids)
+	})
+
+	t.Run("journal is scrubbed", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := NewIPFSStore(IPFSConfig{APIURL: f.server.URL, IndexPath: filepath.Join(dir, "index.log"), TombstoneKey: key})
+		require.NoError(t, err)
+		journalPath := filepath.Join(dir, "journal")
+		j, err := OpenJournal(journalPath)
+		require.NoError(t, err)
+		js := NewJournaledStore(s, j)
+		defer js.Close()
+
+		c, err := claim.NewClaim(claim.Statement{Subject: "bob's phone", Domain: "personal"}, nil, "")
+		require.NoError(t, err)
+		att, err := claim.DeterministicWitness("alice").Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+		_, err = js.Put(ctx, c)
+		require.NoError(t, err)
+		_, err = js.Put(ctx, kept)
+		require.NoError(t, err)
+		require.NoError(t, js.Delete(ctx, c.ID))
+
+		raw, err := os.ReadFile(journalPath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "bob's phone")
+
+		entries, err := ReadJournal(journalPath)
+		require.NoError(t, err)
+		var ops []JournalOp
+		for _, e := range entries {
+			if e.CID == c.ID {
+				ops = append(ops, e.Op)
+			}
+		}
+		assert.Equal(t, []JournalOp{JournalErase}, ops)
+
+		// The scrubbed journal still replays
+		recovered := newTestStore(t)
+		require.NoError(t, ReplayJournal(ctx, journalPath, recovered))
+		has, err := recovered.Has(ctx, kept.ID)
+		require.NoError(t, err)
+		assert.True(t, has)
+		has, err = recovered.Has(ctx, c.ID)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		// Appends continue after the rewrite
+		other, err := claim.NewClaim(claim.Statement{Subject: "match-2", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		_, err = js.Put(ctx, other)
+		require.NoError(t, err)
+		entries, err = ReadJournal(journalPath)
+		require.NoError(t, err)
+		assert.Equal(t, other.ID, entries[len(entries)-1].CID)
+	})
+
+	t.Run("deleter signs the tombstone", func(t *testing.T) {
+		plain := newTestStore(t)
+		c, err := claim.NewClaim(claim.Statement{Subject: "scratch"}, nil, "")
+		require.NoError(t, err)
+		_, err = plain.Put(ctx, c)
+		require.NoError(t, err)
+
+		operator := claim.DeterministicWitness("operator")
+		require.NoError(t, plain.Delete(WithDeleter(ctx, operator), c.ID))
+
+		tomb, deleted := plain.WasDeleted(c.ID)
+		require.True(t, deleted)
+		assert.Equal(t, operator.ID, tomb.DeleterID)
+		assert.NoError(t, claim.VerifyTombstone(tomb))
+	})
+
+	t.Run("without a key deletes leave no tombstone", func(t *testing.T) {
+		plain := newTestStore(t)
+		c, err := claim.NewClaim(claim.Statement{Subject: "scratch"}, nil, "")
+		require.NoError(t, err)
+		_, err = plain.Put(ctx, c)
+		require.NoError(t, err)
+		require.NoError(t, plain.Delete(ctx, c.ID))
+
+		_, deleted := plain.WasDeleted(c.ID)
+		assert.False(t, deleted)
+		_, err = plain.Put(ctx, c)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// TransparentStore wraps a Store, appending every attestation it stores to
+// a transparency log. Attestations are logged before the claim is stored,
+// so no stored attestation is missing from the log. Reads pass straight
+// through to the wrapped store. Open the log with
+// claim.OpenTransparencyLog to keep its entries across restarts.
+type TransparentStore struct {
+	Store
+	log *claim.TransparencyLog
+}
+
+// NewTransparentStore returns a Store that logs attestations to log
+func NewTransparentStore(s Store, log *claim.TransparencyLog) *TransparentStore {
+	return &TransparentStore{Store: s, log: log}
+}
+
+// Put logs the claim's attestations, then stores it. Every attestation is
+// verified before any is logged, so a claim with an invalid attestation
+// is neither logged nor stored. Attestations that are already logged keep
+// their original entries, so retrying a failed Put does not relog them.
+func (s *TransparentStore) Put(ctx context.Context, c *claim.Claim) (string, error) {
+	if c == nil {
+		return "", fmt.Errorf("claim cannot be nil")
+	}
+	if len(c.Witnesses) > 0 {
+		if _, err := s.log.AppendAll(c, c.Witnesses); err != nil {
+			return "", fmt.Errorf("failed to log attestations: %w", err)
+		}
+	}
+
+	return s.Store.Put(ctx, c)
+}
+
+// Log returns the transparency log attestations are appended to
+func (s *TransparentStore) Log() *claim.TransparencyLog {
+	return s.log
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestTransparentStore(t *testing.T) {
+	ctx := context.Background()
+
+	_, logKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	log, err := claim.NewTransparencyLog(logKey)
+	require.NoError(t, err)
+	s := NewTransparentStore(newTestStore(t), log)
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "logged", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, c)
+	require.NoError(t, err)
+	assert.Zero(t, log.Size())
+
+	w, _ := claim.GenerateWitness()
+	att, err := w.Attest(c)
+	require.NoError(t, err)
+	require.NoError(t, c.AddAttestation(att))
+	_, err = s.Put(ctx, c)
+	require.NoError(t, err)
+
+	proof, err := s.Log().Prove(c, att)
+	require.NoError(t, err)
+	assert.NoError(t, claim.VerifyInclusion(c, att, proof, log.PublicKey()))
+
+	t.Run("re-put does not relog", func(t *testing.T) {
+		_, err := s.Put(ctx, c)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), log.Size())
+	})
+
+	t.Run("one invalid attestation logs none", func(t *testing.T) {
+		other, err := claim.NewClaim(claim.Statement{Subject: "partly forged", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		valid, err := w.Attest(other)
+		require.NoError(t, err)
+		other.Witnesses = []claim.Attestation{*valid, *att}
+
+		_, err = s.Put(ctx, other)
+		assert.Error(t, err)
+		assert.Equal(t, uint64(1), log.Size())
+		_, err = log.Prove(other, valid)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid attestation is neither logged nor stored", func(t *testing.T) {
+		other, err := claim.NewClaim(claim.Statement{Subject: "forged", Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		other.Witnesses = []claim.Attestation{*att}
+
+		_, err = s.Put(ctx, other)
+		assert.Error(t, err)
+		assert.Equal(t, uint64(1), log.Size())
+
+		has, err := s.Has(ctx, other.ID)
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+}
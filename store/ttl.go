@@ -0,0 +1,119 @@
+package store
+
+import (
+	"container/heap"
+	"context"
+	"time"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// ttlEntry records when a stored claim expires
+type ttlEntry struct {
+	cid       string
+	expiresAt time.Time
+}
+
+// ttlIndex is a min-heap of claims ordered by expiry time, so expired
+// claims can be found without scanning the whole store. It holds at most
+// one entry per claim; pos tracks each claim's position in the heap.
+type ttlIndex struct {
+	entries []ttlEntry
+	pos     map[string]int
+}
+
+func (t *ttlIndex) Len() int           { return len(t.entries) }
+func (t *ttlIndex) Less(i, j int) bool { return t.entries[i].expiresAt.Before(t.entries[j].expiresAt) }
+
+func (t *ttlIndex) Swap(i, j int) {
+	t.entries[i], t.entries[j] = t.entries[j], t.entries[i]
+	t.pos[t.entries[i].cid] = i
+	t.pos[t.entries[j].cid] = j
+}
+
+func (t *ttlIndex) Push(x interface{}) {
+	entry := x.(ttlEntry)
+	t.pos[entry.cid] = len(t.entries)
+	t.entries = append(t.entries, entry)
+}
+
+func (t *ttlIndex) Pop() interface{} {
+	n := len(t.entries)
+	entry := t.entries[n-1]
+	t.entries = t.entries[:n-1]
+	delete(t.pos, entry.cid)
+	return entry
+}
+
+// push sets a claim's expiry in the index, replacing any earlier one
+func (t *ttlIndex) push(cid string, expiresAt time.Time) {
+	if t.pos == nil {
+		t.pos = make(map[string]int)
+	}
+	if i, ok := t.pos[cid]; ok {
+		t.entries[i].expiresAt = expiresAt
+		heap.Fix(t, i)
+		return
+	}
+	heap.Push(t, ttlEntry{cid: cid, expiresAt: expiresAt})
+}
+
+// remove drops a claim's expiry from the index, if it has one
+func (t *ttlIndex) remove(cid string) {
+	if i, ok := t.pos[cid]; ok {
+		heap.Remove(t, i)
+	}
+}
+
+// popExpired removes and returns all entries expiring at or before now.
+// Only expired entries are touched.
+func (t *ttlIndex) popExpired(now time.Time) []ttlEntry {
+	var expired []ttlEntry
+	for t.Len() > 0 && !t.entries[0].expiresAt.After(now) {
+		expired = append(expired, heap.Pop(t).(ttlEntry))
+	}
+	return expired
+}
+
+// expiry returns when GC should remove a claim: the earlier of its
+// ExpiresAt and the end of its domain's retention period, or the zero time
+// if neither applies
+func (s *IPFSStore) expiry(c *claim.Claim) time.Time {
+	expiresAt := c.ExpiresAt
+	if retention := s.cfg.Retention[c.Statement.Domain]; retention > 0 && !c.Created.IsZero() {
+		retainUntil := c.Created.Add(retention)
+		if expiresAt.IsZero() || retainUntil.Before(expiresAt) {
+			expiresAt = retainUntil
+		}
+	}
+	return expiresAt
+}
+
+// GC removes expired claims, and claims past their domain's retention
+// period, from the store's index and returns how many were removed. Its
+// cost is proportional to the number of expired claims, not the size of the
+// store.
+func (s *IPFSStore) GC(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+
+	for _, entry := range s.ttl.popExpired(now) {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+
+		if _, exists := s.index[entry.cid]; !exists {
+			continue
+		}
+
+		if err := s.deleteClaim(entry.cid); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestGC(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	var expired, live []string
+	for i := 0; i < 3; i++ {
+		c, err := claim.NewClaim(claim.Statement{Subject: fmt.Sprintf("expired-%d", i), Domain: "odds"}, nil, "", claim.WithExpiry(past))
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		expired = append(expired, c.ID)
+	}
+	for i := 0; i < 20; i++ {
+		c, err := claim.NewClaim(claim.Statement{Subject: fmt.Sprintf("live-%d", i), Domain: "odds"}, nil, "", claim.WithExpiry(future))
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		live = append(live, c.ID)
+	}
+	permanent, err := claim.NewClaim(claim.Statement{Subject: "permanent", Domain: "odds"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, permanent)
+	require.NoError(t, err)
+
+	removed, err := s.GC(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(expired), removed)
+
+	t.Run("expired claims removed", func(t *testing.T) {
+		for _, cid := range expired {
+			exists, _ := s.Has(ctx, cid)
+			assert.False(t, exists)
+		}
+
+		odds, err := s.List(ctx, &Filter{Domain: "odds"})
+		require.NoError(t, err)
+		assert.Len(t, odds, len(live)+1)
+	})
+
+	t.Run("unexpired entries are not touched", func(t *testing.T) {
+		// Only the expired entries were popped from the TTL index
+		assert.Equal(t, len(live), s.ttl.Len())
+		for _, cid := range append(live, permanent.ID) {
+			exists, _ := s.Has(ctx, cid)
+			assert.True(t, exists)
+		}
+	})
+
+	t.Run("second sweep is a no-op", func(t *testing.T) {
+		removed, err := s.GC(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, removed)
+	})
+}
+
+func TestTTLIndexOrdering(t *testing.T) {
+	var idx ttlIndex
+	now := time.Now()
+
+	idx.push("c", now.Add(3*time.Minute))
+	idx.push("a", now.Add(-2*time.Minute))
+	idx.push("d", now.Add(time.Hour))
+	idx.push("b", now.Add(-time.Minute))
+
+	expired := idx.popExpired(now)
+	require.Len(t, expired, 2)
+	assert.Equal(t, "a", expired[0].cid)
+	assert.Equal(t, "b", expired[1].cid)
+	assert.Equal(t, 2, idx.Len())
+}
+
+func TestTTLIndexOneEntryPerClaim(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "odds", Domain: "odds"}, nil, "", claim.WithExpiry(time.Now().Add(-time.Minute)))
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		w, _ := claim.GenerateWitness()
+		att, _ := w.Attest(c)
+		require.NoError(t, c.AddAttestation(att))
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, s.ttl.Len())
+
+	removed, err := s.GC(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Zero(t, s.ttl.Len())
+
+	t.Run("later expiry replaces the earlier one", func(t *testing.T) {
+		var idx ttlIndex
+		now := time.Now()
+		idx.push("a", now.Add(-time.Minute))
+		idx.push("b", now.Add(-time.Second))
+		idx.push("a", now.Add(time.Hour))
+
+		expired := idx.popExpired(now)
+		require.Len(t, expired, 1)
+		assert.Equal(t, "b", expired[0].cid)
+		assert.Equal(t, 1, idx.Len())
+
+		idx.remove("a")
+		assert.Zero(t, idx.Len())
+	})
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeIPFS(t)
+	s, err := NewIPFSStore(IPFSConfig{
+		APIURL:    f.server.URL,
+		Retention: map[string]time.Duration{"live-odds": 24 * time.Hour},
+	})
+	require.NoError(t, err)
+
+	// put stores a claim created the given time ago
+	put := func(domain, subject string, age time.Duration, opts ...claim.ClaimOption) string {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: domain}, nil, "", opts...)
+		require.NoError(t, err)
+		c.Created = time.Now().Add(-age).UTC()
+		c.ID, err = claim.ComputeCID(c)
+		require.NoError(t, err)
+		_, err = s.Put(ctx, c)
+		require.NoError(t, err)
+		return c.ID
+	}
+
+	staleOdds := put("live-odds", "match-1", 48*time.Hour)
+	freshOdds := put("live-odds", "match-2", time.Hour)
+	oldLegal := put("legal", "ruling", 10*365*24*time.Hour)
+	expiredLegal := put("legal", "injunction", time.Hour, claim.WithExpiry(time.Now().Add(-time.Minute)))
+
+	removed, err := s.GC(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	for cid, kept := range map[string]bool{staleOdds: false, freshOdds: true, oldLegal: true, expiredLegal: false} {
+		exists, err := s.Has(ctx, cid)
+		require.NoError(t, err)
+		assert.Equal(t, kept, exists, cid)
+	}
+
+	t.Run("earlier expiry wins", func(t *testing.T) {
+		soon := time.Now().Add(time.Minute)
+		c, err := claim.NewClaim(claim.Statement{Subject: "match-3", Domain: "live-odds"}, nil, "", claim.WithExpiry(soon))
+		require.NoError(t, err)
+		assert.True(t, s.expiry(c).Equal(c.ExpiresAt))
+
+		c.ExpiresAt = c.Created.Add(48 * time.Hour)
+		assert.True(t, s.expiry(c).Equal(c.Created.Add(24*time.Hour)))
+	})
+}
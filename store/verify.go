@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// maxVerifyWorkers bounds the number of concurrent fetches in VerifyMany
+const maxVerifyWorkers = 8
+
+// VerifyResult is the outcome of verifying a single stored claim
+type VerifyResult struct {
+	// CID is the claim identifier that was requested
+	CID string `json:"cid"`
+
+	// Found reports whether the claim could be fetched from the store
+	Found bool `json:"found"`
+
+	// CIDValid reports whether the claim content matches its CID
+	CIDValid bool `json:"cid_valid"`
+
+	// ValidAttestations is the number of attestations with valid signatures
+	ValidAttestations int `json:"valid_attestations"`
+
+	// InvalidAttestations is the number of attestations that failed verification
+	InvalidAttestations int `json:"invalid_attestations"`
+
+	// Error describes the first problem encountered, if any
+	Error string `json:"error,omitempty"`
+}
+
+// OK reports whether the claim was found and fully verified
+func (r VerifyResult) OK() bool {
+	return r.Found && r.CIDValid && r.InvalidAttestations == 0
+}
+
+// VerifyReport is the verification of a claim fetched by GetVerified
+type VerifyReport struct {
+	// CIDValid reports whether the claim content matches the requested CID
+	CIDValid bool `json:"cid_valid"`
+
+	// CIDError explains a CID mismatch
+	CIDError string `json:"cid_error,omitempty"`
+
+	// Attestations holds the outcome for each attestation, in claim order
+	Attestations []AttestationCheck `json:"attestations"`
+}
+
+// AttestationCheck is the outcome of verifying one attestation
+type AttestationCheck struct {
+	WitnessID string `json:"witness_id"`
+	Valid     bool   `json:"valid"`
+	Error     string `json:"error,omitempty"`
+}
+
+// OK reports whether the CID and every attestation verified
+func (r *VerifyReport) OK() bool {
+	return r.CIDValid && r.InvalidAttestations() == 0
+}
+
+// InvalidAttestations returns the number of attestations that failed
+func (r *VerifyReport) InvalidAttestations() int {
+	n := 0
+	for _, a := range r.Attestations {
+		if !a.Valid {
+			n++
+		}
+	}
+	return n
+}
+
+// GetVerified fetches a claim and verifies its CID and attestations, the
+// latter in parallel, so callers need not verify it again. Only a failure
+// to fetch the claim is an error; verification failures are in the report.
+func GetVerified(ctx context.Context, s Store, cid string) (*claim.Claim, *VerifyReport, error) {
+	c, err := s.Get(ctx, cid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, VerifyClaim(c, cid), nil
+}
+
+// VerifyClaim verifies an already fetched claim's CID, against the CID it
+// was requested by, and its attestations
+func VerifyClaim(c *claim.Claim, cid string) *VerifyReport {
+	report := &VerifyReport{Attestations: make([]AttestationCheck, len(c.Witnesses))}
+	if err := claim.VerifyCID(c); err != nil {
+		report.CIDError = err.Error()
+	} else if c.ID != cid {
+		report.CIDError = "stored claim ID does not match requested CID"
+	} else {
+		report.CIDValid = true
+	}
+
+	for i, err := range claim.VerifyAttestations(c) {
+		check := AttestationCheck{WitnessID: c.Witnesses[i].WitnessID, Valid: err == nil}
+		if err != nil {
+			check.Error = err.Error()
+		}
+		report.Attestations[i] = check
+	}
+
+	return report
+}
+
+// Verify fetches a single claim and checks its CID and attestations
+func Verify(ctx context.Context, s Store, cid string) VerifyResult {
+	result := VerifyResult{CID: cid}
+
+	_, report, err := GetVerified(ctx, s, cid)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Found = true
+	result.CIDValid = report.CIDValid
+	result.Error = report.CIDError
+
+	for _, check := range report.Attestations {
+		if !check.Valid {
+			result.InvalidAttestations++
+			if result.Error == "" {
+				result.Error = check.Error
+			}
+			continue
+		}
+		result.ValidAttestations++
+	}
+
+	return result
+}
+
+// VerifyMany concurrently fetches and verifies each claim, returning
+// results keyed by CID. Missing claims are reported rather than failing
+// the whole batch.
+func VerifyMany(ctx context.Context, s Store, cids []string) map[string]VerifyResult {
+	results := make(map[string]VerifyResult, len(cids))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxVerifyWorkers)
+
+	for _, cid := range cids {
+		mu.Lock()
+		_, seen := results[cid]
+		if !seen {
+			results[cid] = VerifyResult{CID: cid}
+		}
+		mu.Unlock()
+		if seen {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(cid string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := Verify(ctx, s, cid)
+
+			mu.Lock()
+			results[cid] = result
+			mu.Unlock()
+		}(cid)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestVerifyMany(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	witness, err := claim.GenerateWitness()
+	require.NoError(t, err)
+
+	// Valid claim with one attestation
+	valid, err := claim.NewClaim(claim.Statement{Subject: "valid", Domain: "test"}, nil, "")
+	require.NoError(t, err)
+	att, err := witness.Attest(valid)
+	require.NoError(t, err)
+	require.NoError(t, valid.AddAttestation(att))
+	_, err = s.Put(ctx, valid)
+	require.NoError(t, err)
+
+	// Claim whose content is modified after storage
+	tampered, err := claim.NewClaim(claim.Statement{Subject: "tampered"}, nil, "")
+	require.NoError(t, err)
+	_, err = s.Put(ctx, tampered)
+	require.NoError(t, err)
+	tampered.Statement.Object = "changed"
+
+	// Claim with a corrupted attestation signature
+	badSig, err := claim.NewClaim(claim.Statement{Subject: "bad-signature"}, nil, "")
+	require.NoError(t, err)
+	att, err = witness.Attest(badSig)
+	require.NoError(t, err)
+	require.NoError(t, badSig.AddAttestation(att))
+	badSig.Witnesses[0].Signature[0] ^= 0xFF
+	_, err = s.Put(ctx, badSig)
+	require.NoError(t, err)
+
+	missing := "bafkreimissingclaim"
+
+	results := VerifyMany(ctx, s, []string{valid.ID, tampered.ID, badSig.ID, missing, valid.ID})
+	require.Len(t, results, 4)
+
+	t.Run("valid claim passes", func(t *testing.T) {
+		r := results[valid.ID]
+		assert.True(t, r.OK())
+		assert.Equal(t, 1, r.ValidAttestations)
+		assert.Empty(t, r.Error)
+	})
+
+	t.Run("tampered claim fails CID check", func(t *testing.T) {
+		r := results[tampered.ID]
+		assert.True(t, r.Found)
+		assert.False(t, r.CIDValid)
+		assert.False(t, r.OK())
+	})
+
+	t.Run("corrupted attestation is reported", func(t *testing.T) {
+		r := results[badSig.ID]
+		assert.True(t, r.CIDValid)
+		assert.Equal(t, 1, r.InvalidAttestations)
+		assert.False(t, r.OK())
+	})
+
+	t.Run("missing claim is reported", func(t *testing.T) {
+		r := results[missing]
+		assert.False(t, r.Found)
+		assert.NotEmpty(t, r.Error)
+	})
+}
+
+func TestGetVerified(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	alice := claim.DeterministicWitness("alice")
+	bob := claim.DeterministicWitness("bob")
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "match-1", Predicate: "result", Object: "2-1", Domain: "sports"}, nil, "")
+	require.NoError(t, err)
+	for _, w := range []*claim.Witness{alice, bob} {
+		att, err := w.Attest(c)
+		require.NoError(t, err)
+		require.NoError(t, c.AddAttestation(att))
+	}
+	_, err = s.Put(ctx, c)
+	require.NoError(t, err)
+
+	t.Run("intact claim verifies", func(t *testing.T) {
+		got, report, err := GetVerified(ctx, s, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, c.ID, got.ID)
+		assert.True(t, report.OK())
+		require.Len(t, report.Attestations, 2)
+		assert.Equal(t, alice.ID, report.Attestations[0].WitnessID)
+		assert.True(t, report.Attestations[1].Valid)
+	})
+
+	t.Run("corrupted attestation is flagged", func(t *testing.T) {
+		c.Witnesses[1].Signature[0] ^= 0xFF
+		_, err := s.Put(ctx, c)
+		require.NoError(t, err)
+
+		_, report, err := GetVerified(ctx, s, c.ID)
+		require.NoError(t, err)
+		assert.False(t, report.OK())
+		assert.True(t, report.CIDValid)
+		assert.Equal(t, 1, report.InvalidAttestations())
+		assert.True(t, report.Attestations[0].Valid)
+		assert.False(t, report.Attestations[1].Valid)
+		assert.NotEmpty(t, report.Attestations[1].Error)
+	})
+
+	t.Run("missing claim is an error", func(t *testing.T) {
+		_, _, err := GetVerified(ctx, s, "bafkreimissingclaim")
+		assert.Error(t, err)
+	})
+}
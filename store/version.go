@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// ErrVersionConflict is returned by PutIfVersion when the claim was stored
+// by another writer since the caller read it
+var ErrVersionConflict = errors.New("version conflict")
+
+// Version returns the stored version of a claim, an opaque tag that
+// changes whenever a different envelope is stored for it. It is empty if
+// this store has not stored the claim.
+func (s *IPFSStore) Version(cid string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version(cid)
+}
+
+// PutIfVersion stores a claim only if its stored version is still
+// expectedVersion, as returned by Version when the caller read the claim.
+// An empty expectedVersion requires that the claim has not been stored.
+// On a mismatch it returns an error wrapping ErrVersionConflict, and the
+// caller should re-read the claim, merge its changes and try again.
+//
+// Claims returned by Get are shared with the store's index, so writers
+// that update concurrently should modify copies.
+func (s *IPFSStore) PutIfVersion(ctx context.Context, c *claim.Claim, expectedVersion string) (string, error) {
+	return s.put(ctx, c, &expectedVersion)
+}
+
+// version returns the hash of a claim's latest envelope.
+// Callers must hold s.mu.
+func (s *IPFSStore) version(cid string) string {
+	versions := s.versions[cid]
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[len(versions)-1]
+}
+
+// checkVersion fails if a claim's stored version is not expected.
+// Callers must hold s.mu.
+func (s *IPFSStore) checkVersion(cid, expected string) error {
+	if current := s.version(cid); current != expected {
+		return fmt.Errorf("claim %s: %w (expected %q, stored %q)", cid, ErrVersionConflict, expected, current)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+// attestCopy returns a copy of c with an attestation from w added, leaving
+// the store's cached claim untouched
+func attestCopy(t *testing.T, c *claim.Claim, w *claim.Witness) *claim.Claim {
+	t.Helper()
+
+	updated := *c
+	updated.Witnesses = append([]claim.Attestation(nil), c.Witnesses...)
+	att, err := w.Attest(&updated)
+	require.NoError(t, err)
+	require.NoError(t, updated.AddAttestation(att))
+	return &updated
+}
+
+func TestPutIfVersion(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	c, err := claim.NewClaim(claim.Statement{Subject: "contested", Domain: "test"}, nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, s.Version(c.ID))
+
+	_, err = s.PutIfVersion(ctx, c, "")
+	require.NoError(t, err)
+	v1 := s.Version(c.ID)
+	assert.NotEmpty(t, v1)
+
+	t.Run("create fails once stored", func(t *testing.T) {
+		_, err := s.PutIfVersion(ctx, c, "")
+		assert.ErrorIs(t, err, ErrVersionConflict)
+	})
+
+	t.Run("concurrent attestations", func(t *testing.T) {
+		alice, _ := claim.GenerateWitness()
+		bob, _ := claim.GenerateWitness()
+
+		// Both writers read the same version
+		stored, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		version := s.Version(c.ID)
+		fromAlice := attestCopy(t, stored, alice)
+		fromBob := attestCopy(t, stored, bob)
+
+		_, err = s.PutIfVersion(ctx, fromAlice, version)
+		require.NoError(t, err)
+		assert.NotEqual(t, version, s.Version(c.ID))
+
+		// Bob's write would drop Alice's attestation
+		_, err = s.PutIfVersion(ctx, fromBob, version)
+		require.ErrorIs(t, err, ErrVersionConflict)
+
+		// Bob re-reads, merges and retries
+		stored, err = s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		_, err = s.PutIfVersion(ctx, attestCopy(t, stored, bob), s.Version(c.ID))
+		require.NoError(t, err)
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.True(t, hasAttestationFrom(got, alice.ID))
+		assert.True(t, hasAttestationFrom(got, bob.ID))
+	})
+
+	t.Run("racing writers lose nothing", func(t *testing.T) {
+		const writers = 8
+		witnesses := make([]*claim.Witness, writers)
+		for i := range witnesses {
+			witnesses[i], _ = claim.GenerateWitness()
+		}
+
+		var wg sync.WaitGroup
+		for _, w := range witnesses {
+			wg.Add(1)
+			go func(w *claim.Witness) {
+				defer wg.Done()
+				for {
+					version := s.Version(c.ID)
+					stored, err := s.Get(ctx, c.ID)
+					if !assert.NoError(t, err) {
+						return
+					}
+					_, err = s.PutIfVersion(ctx, attestCopy(t, stored, w), version)
+					if !errors.Is(err, ErrVersionConflict) {
+						assert.NoError(t, err)
+						return
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		for _, w := range witnesses {
+			assert.True(t, hasAttestationFrom(got, w.ID))
+		}
+	})
+}
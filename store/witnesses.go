@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// WitnessesOf returns, sorted, every witness that attested to any of the
+// claims (the union of their witness sets). Attestations of every stance
+// count.
+func WitnessesOf(ctx context.Context, s Store, cids []string) ([]string, error) {
+	union := make(map[string]bool)
+	for _, cid := range cids {
+		set, err := witnessSet(ctx, s, cid)
+		if err != nil {
+			return nil, err
+		}
+		for id := range set {
+			union[id] = true
+		}
+	}
+	return sortedSet(union), nil
+}
+
+// CommonWitnesses returns, sorted, the witnesses that attested to both
+// claims
+func CommonWitnesses(ctx context.Context, s Store, cidA, cidB string) ([]string, error) {
+	a, err := witnessSet(ctx, s, cidA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := witnessSet(ctx, s, cidB)
+	if err != nil {
+		return nil, err
+	}
+
+	common := make(map[string]bool)
+	for id := range a {
+		if b[id] {
+			common[id] = true
+		}
+	}
+	return sortedSet(common), nil
+}
+
+// UniqueWitnesses returns, sorted, the witnesses that attested to the claim
+// but to none of the others
+func UniqueWitnesses(ctx context.Context, s Store, cid string, others []string) ([]string, error) {
+	unique, err := witnessSet(ctx, s, cid)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		set, err := witnessSet(ctx, s, other)
+		if err != nil {
+			return nil, err
+		}
+		for id := range set {
+			delete(unique, id)
+		}
+	}
+	return sortedSet(unique), nil
+}
+
+// witnessSet returns the witnesses that attested to a stored claim
+func witnessSet(ctx context.Context, s Store, cid string) (map[string]bool, error) {
+	c, err := s.Get(ctx, cid)
+	if err != nil {
+		return nil, fmt.Errorf("claim %s: %w", cid, err)
+	}
+
+	set := make(map[string]bool, len(c.Witnesses))
+	for _, att := range c.Witnesses {
+		set[att.WitnessID] = true
+	}
+	return set, nil
+}
+
+func sortedSet(set map[string]bool) []string {
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/systemshift/claim-graph/claim"
+)
+
+func TestWitnessSets(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	alice := claim.DeterministicWitness("alice").ID
+	bob := claim.DeterministicWitness("bob").ID
+	carol := claim.DeterministicWitness("carol").ID
+	dave := claim.DeterministicWitness("dave").ID
+
+	put := func(subject string, witnesses ...string) string {
+		c, err := claim.NewClaim(claim.Statement{Subject: subject, Domain: "sports"}, nil, "")
+		require.NoError(t, err)
+		for _, seed := range witnesses {
+			att, err := claim.DeterministicWitness(seed).Attest(c)
+			require.NoError(t, err)
+			require.NoError(t, c.AddAttestation(att))
+		}
+		cid, err := s.Put(ctx, c)
+		require.NoError(t, err)
+		return cid
+	}
+
+	a := put("match-1", "alice", "bob", "carol")
+	b := put("match-2", "bob", "carol", "dave")
+	c := put("match-3", "carol")
+	none := put("match-4")
+
+	sorted := func(ids ...string) []string {
+		sort.Strings(ids)
+		return ids
+	}
+
+	t.Run("union", func(t *testing.T) {
+		union, err := WitnessesOf(ctx, s, []string{a, b, c})
+		require.NoError(t, err)
+		assert.Equal(t, sorted(alice, bob, carol, dave), union)
+
+		union, err = WitnessesOf(ctx, s, []string{none})
+		require.NoError(t, err)
+		assert.Empty(t, union)
+	})
+
+	t.Run("intersection", func(t *testing.T) {
+		common, err := CommonWitnesses(ctx, s, a, b)
+		require.NoError(t, err)
+		assert.Equal(t, sorted(bob, carol), common)
+
+		common, err = CommonWitnesses(ctx, s, a, none)
+		require.NoError(t, err)
+		assert.Empty(t, common)
+	})
+
+	t.Run("difference", func(t *testing.T) {
+		unique, err := UniqueWitnesses(ctx, s, a, []string{b})
+		require.NoError(t, err)
+		assert.Equal(t, []string{alice}, unique)
+
+		unique, err = UniqueWitnesses(ctx, s, b, []string{a, c})
+		require.NoError(t, err)
+		assert.Equal(t, []string{dave}, unique)
+	})
+
+	t.Run("missing claim", func(t *testing.T) {
+		_, err := CommonWitnesses(ctx, s, a, "bafkreimissing")
+		assert.Error(t, err)
+	})
+}